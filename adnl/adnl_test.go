@@ -0,0 +1,247 @@
+package adnl
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func startTestGateways(t *testing.T) (*Gateway, *Gateway, *Peer) {
+	_, srvKey, _ := ed25519.GenerateKey(nil)
+	_, cliKey, _ := ed25519.GenerateKey(nil)
+
+	srv := NewGateway(srvKey)
+	if err := srv.StartServer("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	cli := NewGateway(cliKey)
+	if err := cli.StartClient(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cli.Close()
+	})
+
+	peer, err := cli.RegisterClient(srv.Addr().String(), srvKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return srv, cli, peer
+}
+
+func TestADNL_Query(t *testing.T) {
+	srv, cli, peer := startTestGateways(t)
+
+	big := bytes.Repeat([]byte{0xAA}, 5000)
+
+	srv.SetConnectionHandler(func(client *Peer) error {
+		client.SetQueryHandler(func(msg *MessageQuery) error {
+			if bytes.Equal(msg.Data, []byte("big")) {
+				return client.Answer(msg.ID, big)
+			}
+			return client.Answer(msg.ID, append([]byte("re:"), msg.Data...))
+		})
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		resp, err := peer.Query(ctx, []byte("ping"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(resp) != "re:ping" {
+			t.Fatal("wrong answer", string(resp))
+		}
+	}
+
+	peer.mx.Lock()
+	ready := peer.channel != nil && peer.channel.ready
+	peer.mx.Unlock()
+
+	if !ready {
+		t.Fatal("channel should be established")
+	}
+
+	// big answer is split into parts, big query too
+	resp, err := peer.Query(ctx, []byte("big"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(resp, big) {
+		t.Fatal("wrong big answer", len(resp))
+	}
+
+	resp, err = peer.Query(ctx, big)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp) != len(big)+3 {
+		t.Fatal("wrong answer to big query", len(resp))
+	}
+
+	// server should know address of the client and its key
+	srv.mx.RLock()
+	client := srv.peers[hex.EncodeToString(cli.ID())]
+	srv.mx.RUnlock()
+
+	cliAddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: cli.Addr().(*net.UDPAddr).Port}
+	if client == nil || client.RemoteAddr() != cliAddr.String() || !bytes.Equal(client.PeerKey(), cli.key.Public().(ed25519.PublicKey)) {
+		t.Fatal("client is not registered on server")
+	}
+
+	list := peer.AddressList()
+	if list == nil || len(list.Addresses) != 1 || list.Addresses[0].Port != int32(srv.Addr().(*net.UDPAddr).Port) {
+		t.Fatal("server address list should be received")
+	}
+}
+
+func TestADNL_CustomMessage(t *testing.T) {
+	srv, _, peer := startTestGateways(t)
+
+	got := make(chan []byte, 1)
+	srv.SetConnectionHandler(func(client *Peer) error {
+		client.SetCustomMessageHandler(func(msg *MessageCustom) error {
+			got <- msg.Data
+			// reply in the opposite direction
+			return client.SendCustomMessage([]byte("pong"))
+		})
+		return nil
+	})
+
+	reply := make(chan []byte, 1)
+	peer.SetCustomMessageHandler(func(msg *MessageCustom) error {
+		reply <- msg.Data
+		return nil
+	})
+
+	if err := peer.SendCustomMessage([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case data := <-got:
+		if string(data) != "hello" {
+			t.Fatal("wrong message", string(data))
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("message was not received")
+	}
+
+	select {
+	case data := <-reply:
+		if string(data) != "pong" {
+			t.Fatal("wrong reply", string(data))
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("reply was not received")
+	}
+}
+
+func TestADNL_Reject(t *testing.T) {
+	srv, _, peer := startTestGateways(t)
+
+	srv.SetConnectionHandler(func(client *Peer) error {
+		return errors.New("not allowed")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	if _, err := peer.Query(ctx, []byte("ping")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("query should time out", err)
+	}
+
+	closed := make(chan bool, 1)
+	peer.SetDisconnectHandler(func(addr string, key ed25519.PublicKey) {
+		closed <- true
+	})
+	peer.Close()
+
+	if !<-closed {
+		t.Fatal("disconnect handler should be called")
+	}
+
+	if err := peer.SendCustomMessage(nil); !errors.Is(err, ErrPeerClosed) {
+		t.Fatal("peer should be closed", err)
+	}
+}
+
+func TestPeer_CheckSeqno(t *testing.T) {
+	p := &Peer{}
+
+	for _, s := range []int64{1, 3, 2, 10} {
+		if !p.checkSeqno(s) {
+			t.Fatal("seqno should be accepted", s)
+		}
+	}
+
+	for _, s := range []int64{1, 3, 10} {
+		if p.checkSeqno(s) {
+			t.Fatal("duplicate seqno should be dropped", s)
+		}
+	}
+
+	if !p.checkSeqno(100) || p.checkSeqno(30) || !p.checkSeqno(99) {
+		t.Fatal("wrong window")
+	}
+}
+
+func TestPeer_PartsLimit(t *testing.T) {
+	p := &Peer{parts: map[string]*partialMessage{}}
+
+	var last []byte
+	for i := 0; i < _MaxPartialMessages*2; i++ {
+		last = bytes.Repeat([]byte{byte(i)}, 32)
+
+		full, err := p.addPart(MessagePart{Hash: last, TotalSize: _MaxMessageSize, Offset: 0, Data: []byte("part")})
+		if err != nil || full != nil {
+			t.Fatal("part should be accepted", err)
+		}
+	}
+
+	if len(p.parts) != _MaxPartialMessages {
+		t.Fatal("partial messages are not limited", len(p.parts))
+	}
+
+	if p.parts[hex.EncodeToString(last)] == nil {
+		t.Fatal("the newest message should be kept")
+	}
+}
+
+func TestGateway_LeastActiveIncomingPeer(t *testing.T) {
+	registered := &Peer{peerID: []byte{1}, lastActive: 1}
+	old := &Peer{peerID: []byte{2}, lastActive: 2, incoming: true}
+	fresh := &Peer{peerID: []byte{3}, lastActive: 3, incoming: true}
+
+	g := &Gateway{peers: map[string]*Peer{}}
+	for _, p := range []*Peer{registered, old, fresh} {
+		g.peers[hex.EncodeToString(p.peerID)] = p
+	}
+
+	if p := g.leastActiveIncomingPeer(); p != old {
+		t.Fatal("incorrect peer to drop", p)
+	}
+
+	delete(g.peers, hex.EncodeToString(old.peerID))
+	delete(g.peers, hex.EncodeToString(fresh.peerID))
+
+	if p := g.leastActiveIncomingPeer(); p != nil {
+		t.Fatal("registered peer should not be dropped")
+	}
+}
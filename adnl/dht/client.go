@@ -0,0 +1,518 @@
+package dht
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+const (
+	// _K - how many closest nodes we ask from each node, and how many nodes store the value
+	_K = 10
+	// _Alpha - how many nodes we query in parallel during lookup
+	_Alpha = 3
+	// _QueryTimeout - timeout of one query to dht node
+	_QueryTimeout = 3 * time.Second
+	// _MaxLookupRounds - limit of lookup rounds, in case nodes keep answering with closer and closer nodes
+	_MaxLookupRounds = 20
+	// _BucketSize - max number of known nodes with the same common prefix length with our id
+	_BucketSize = 20
+)
+
+var ErrValueNotFound = errors.New("value is not found")
+
+// Client - finds and stores values in TON DHT, starting from bootstrap nodes and learning new ones during lookups
+type Client struct {
+	gateway *adnl.Gateway
+
+	mx      sync.RWMutex
+	nodes   map[string]*Node
+	buckets [256]int
+	// queries in progress by node, peer is closed after the last of them if node is not in routing table
+	inFlight map[string]int
+}
+
+// NewClient - creates client which bootstraps from the nodes, gateway should be started.
+// Nodes with invalid signatures are skipped.
+func NewClient(gateway *adnl.Gateway, nodes []*Node) (*Client, error) {
+	c := &Client{
+		gateway:  gateway,
+		nodes:    map[string]*Node{},
+		inFlight: map[string]int{},
+	}
+
+	for _, n := range nodes {
+		c.addNode(n)
+	}
+
+	if len(c.nodes) == 0 {
+		return nil, errors.New("no valid dht nodes")
+	}
+	return c, nil
+}
+
+// NewClientFromConfig - creates client which bootstraps from static dht nodes of global config
+func NewClientFromConfig(gateway *adnl.Gateway, cfg *liteclient.GlobalConfig) (*Client, error) {
+	nodes, err := NodesFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(gateway, nodes)
+}
+
+// NodesFromConfig - converts static dht nodes of global config
+func NodesFromConfig(cfg *liteclient.GlobalConfig) ([]*Node, error) {
+	var nodes []*Node
+	for i, n := range cfg.DHT.StaticNodes.Nodes {
+		key, err := base64.StdEncoding.DecodeString(n.ID.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key of node %d: %w", i, err)
+		}
+
+		sign, err := base64.StdEncoding.DecodeString(n.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode signature of node %d: %w", i, err)
+		}
+
+		list := &adnl.AddressList{
+			Version:    int32(n.AddrList.Version),
+			ReinitDate: int32(n.AddrList.ReinitDate),
+			Priority:   int32(n.AddrList.Priority),
+			ExpireAt:   int32(n.AddrList.ExpireAt),
+		}
+
+		for _, a := range n.AddrList.Addrs {
+			ip := make(net.IP, 4)
+			binary.BigEndian.PutUint32(ip, uint32(a.IP))
+
+			list.Addresses = append(list.Addresses, &adnl.Address{IP: ip, Port: int32(a.Port)})
+		}
+
+		nodes = append(nodes, &Node{
+			ID:        key,
+			AddrList:  list,
+			Version:   int32(n.Version),
+			Signature: sign,
+		})
+	}
+	return nodes, nil
+}
+
+// Nodes - returns all known nodes
+func (c *Client) Nodes() []*Node {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	nodes := make([]*Node, 0, len(c.nodes))
+	for _, n := range c.nodes {
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// FindValue - searches value of the key in dht, value is returned only if it is valid
+func (c *Client) FindValue(ctx context.Context, key *Key) (*Value, error) {
+	id := key.Hash()
+
+	req := tl.AppendInt32(nil, _FindValue)
+	req = append(req, id...)
+	req = tl.AppendInt32(req, _K)
+
+	var found *Value
+	_, err := c.lookup(ctx, id, req, func(r *tl.Reader) (bool, []*Node, error) {
+		switch typ := r.Int32(); typ {
+		case _ValueFound:
+			if typ = r.Int32(); typ != _Value {
+				return false, nil, fmt.Errorf("unexpected value type %d", typ)
+			}
+
+			val, err := parseValue(r)
+			if err != nil {
+				return false, nil, err
+			}
+
+			if !bytes.Equal(val.KeyDescription.Key.Hash(), id) {
+				return false, nil, errors.New("value is for another key")
+			}
+
+			if err = val.CheckValid(); err != nil {
+				return false, nil, err
+			}
+
+			found = val
+			return true, nil, nil
+		case _ValueNotFound:
+			nodes, err := parseNodes(r)
+			return false, nodes, err
+		default:
+			return false, nil, fmt.Errorf("unexpected answer type %d", typ)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		return nil, ErrValueNotFound
+	}
+	return found, nil
+}
+
+// FindAddresses - resolves adnl id to address list and public key of the node
+func (c *Client) FindAddresses(ctx context.Context, adnlID []byte) (*adnl.AddressList, ed25519.PublicKey, error) {
+	val, err := c.FindValue(ctx, &Key{
+		ID:   adnlID,
+		Name: []byte("address"),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if val.KeyDescription.UpdateRule != UpdateRuleSignature {
+		return nil, nil, errors.New("address should be signed by its owner")
+	}
+
+	list, err := adnl.ParseAddressList(tl.NewReader(val.Data), true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse address list: %w", err)
+	}
+	return list, val.KeyDescription.ID, nil
+}
+
+// FindOverlayNodes - searches nodes of overlay with the name, each of them is verified
+func (c *Client) FindOverlayNodes(ctx context.Context, overlayName []byte) ([]*OverlayNode, error) {
+	val, err := c.FindValue(ctx, &Key{
+		ID:   OverlayID(overlayName),
+		Name: []byte("nodes"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if val.KeyDescription.UpdateRule != UpdateRuleOverlayNodes {
+		return nil, errors.New("value is not overlay nodes")
+	}
+	return ParseOverlayNodes(val.Data)
+}
+
+// Store - stores value on the closest to the key nodes, returns number of nodes which confirmed storing
+func (c *Client) Store(ctx context.Context, value *Value) (int, error) {
+	if err := value.CheckValid(); err != nil {
+		return 0, fmt.Errorf("value is not valid: %w", err)
+	}
+
+	id := value.KeyDescription.Key.Hash()
+
+	req := tl.AppendInt32(nil, _FindNode)
+	req = append(req, id...)
+	req = tl.AppendInt32(req, _K)
+
+	// walk to the closest nodes
+	closest, err := c.lookup(ctx, id, req, func(r *tl.Reader) (bool, []*Node, error) {
+		if typ := r.Int32(); typ != _Nodes {
+			return false, nil, fmt.Errorf("unexpected answer type %d", typ)
+		}
+		nodes, err := parseNodes(r)
+		return false, nodes, err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	storeReq := append(tl.AppendInt32(nil, _Store), value.Serialize()...)
+
+	var wg sync.WaitGroup
+	var mx sync.Mutex
+	stored := 0
+	for _, n := range closest {
+		wg.Add(1)
+		go func(n *Node) {
+			defer wg.Done()
+
+			resp, err := c.query(ctx, n, storeReq)
+			if err != nil || len(resp) < 4 || int32(binary.LittleEndian.Uint32(resp)) != _Stored {
+				return
+			}
+
+			mx.Lock()
+			stored++
+			mx.Unlock()
+		}(n)
+	}
+	wg.Wait()
+
+	if stored == 0 {
+		return 0, errors.New("value was not stored on any node")
+	}
+	return stored, nil
+}
+
+// StoreAddress - stores our address list in dht, so other nodes can find us by adnl id
+func (c *Client) StoreAddress(ctx context.Context, list *adnl.AddressList, ttl time.Duration, key ed25519.PrivateKey) (int, error) {
+	id, err := liteclient.KeyID(key.Public().(ed25519.PublicKey))
+	if err != nil {
+		return 0, err
+	}
+
+	val := &Value{
+		KeyDescription: KeyDescription{
+			Key: Key{
+				ID:   id,
+				Name: []byte("address"),
+			},
+		},
+		Data: list.Serialize(),
+		TTL:  int32(time.Now().Add(ttl).Unix()),
+	}
+	val.Sign(key)
+
+	return c.Store(ctx, val)
+}
+
+// lookup - iteratively queries nodes closest to the id, until handler says that we are done,
+// or all _K closest nodes seen during the lookup are queried, so no closer node was found.
+// Nodes returned by handler are used only for this lookup, routing table keeps limited number of them.
+// Returns _K closest to the id nodes seen during the lookup.
+func (c *Client) lookup(ctx context.Context, id, req []byte, handler func(r *tl.Reader) (bool, []*Node, error)) ([]*Node, error) {
+	seen := map[string]*Node{}
+	for _, n := range c.closest(id, _K, nil) {
+		seen[nodeKey(n)] = n
+	}
+	queried := map[string]bool{}
+
+	for round := 0; round < _MaxLookupRounds; round++ {
+		var candidates []*Node
+		for _, n := range closestNodes(id, seen, _K, nil) {
+			if len(candidates) < _Alpha && !queried[nodeKey(n)] {
+				candidates = append(candidates, n)
+			}
+		}
+
+		if len(candidates) == 0 {
+			break
+		}
+
+		type result struct {
+			done  bool
+			nodes []*Node
+			err   error
+		}
+
+		results := make(chan result, len(candidates))
+		for _, n := range candidates {
+			queried[nodeKey(n)] = true
+
+			go func(n *Node) {
+				resp, err := c.query(ctx, n, req)
+				if err != nil {
+					results <- result{err: err}
+					return
+				}
+
+				done, nodes, err := handler(tl.NewReader(resp))
+				results <- result{done: done, nodes: nodes, err: err}
+			}(n)
+		}
+
+		done := false
+		for range candidates {
+			// errors of separate nodes are not critical, we just try others
+			res := <-results
+			if res.err != nil {
+				continue
+			}
+
+			if res.done {
+				done = true
+			}
+
+			for _, n := range res.nodes {
+				if !validNode(n) {
+					continue
+				}
+
+				key := nodeKey(n)
+				if old := seen[key]; old == nil || old.Version < n.Version {
+					seen[key] = n
+				}
+				c.addNode(n)
+			}
+		}
+
+		if done {
+			break
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	return closestNodes(id, seen, _K, nil), nil
+}
+
+func (c *Client) query(ctx context.Context, n *Node, req []byte) ([]byte, error) {
+	addr, err := nodeAddr(n)
+	if err != nil {
+		return nil, err
+	}
+
+	key := nodeKey(n)
+
+	c.mx.Lock()
+	c.inFlight[key]++
+	c.mx.Unlock()
+
+	peer, err := c.gateway.RegisterClient(addr, n.ID)
+	defer c.releasePeer(key, peer)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, _QueryTimeout)
+	defer cancel()
+
+	return peer.Query(ctx, req)
+}
+
+// releasePeer - closes peer of the node after its last query, if node is not in routing table,
+// so gateway does not keep peers of all nodes which we met during lookups
+func (c *Client) releasePeer(key string, peer *adnl.Peer) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.inFlight[key]--; c.inFlight[key] > 0 {
+		return
+	}
+	delete(c.inFlight, key)
+
+	// closed under lock, so concurrent query can not get this peer before it is closed
+	if peer != nil && c.nodes[key] == nil {
+		peer.Close()
+	}
+}
+
+func (c *Client) addNode(n *Node) {
+	if !validNode(n) {
+		return
+	}
+
+	key := nodeKey(n)
+	id, _ := hex.DecodeString(key)
+
+	bucket := bucketIndex(c.gateway.ID(), id)
+	if bucket < 0 {
+		// it is our node
+		return
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if old := c.nodes[key]; old != nil {
+		if old.Version < n.Version {
+			c.nodes[key] = n
+		}
+		return
+	}
+
+	// like in kademlia, known nodes are preferred, new node is dropped when its bucket is full
+	if c.buckets[bucket] >= _BucketSize {
+		return
+	}
+	c.buckets[bucket]++
+	c.nodes[key] = n
+}
+
+// closest - returns up to num known nodes closest to id by xor distance, skipping excluded
+func (c *Client) closest(id []byte, num int, exclude map[string]bool) []*Node {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	return closestNodes(id, c.nodes, num, exclude)
+}
+
+// closestNodes - returns up to num nodes closest to id by xor distance, map keys should be nodeKey
+func closestNodes(id []byte, nodes map[string]*Node, num int, exclude map[string]bool) []*Node {
+	type distNode struct {
+		dist []byte
+		node *Node
+	}
+
+	list := make([]distNode, 0, len(nodes))
+	for key, n := range nodes {
+		if exclude[key] {
+			continue
+		}
+
+		nid, _ := hex.DecodeString(key)
+		dist := make([]byte, 32)
+		for i := range dist {
+			dist[i] = nid[i] ^ id[i]
+		}
+		list = append(list, distNode{dist: dist, node: n})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].dist, list[j].dist) < 0
+	})
+
+	if len(list) > num {
+		list = list[:num]
+	}
+
+	res := make([]*Node, 0, len(list))
+	for _, d := range list {
+		res = append(res, d.node)
+	}
+	return res
+}
+
+// bucketIndex - number of common leading bits of ids, -1 if ids are equal
+func bucketIndex(our, id []byte) int {
+	for i := range our {
+		if x := our[i] ^ id[i]; x != 0 {
+			return i*8 + bits.LeadingZeros8(x)
+		}
+	}
+	return -1
+}
+
+func validNode(n *Node) bool {
+	if n.CheckSignature() != nil {
+		return false
+	}
+
+	_, err := nodeAddr(n)
+	return err == nil
+}
+
+// nodeKey - hex of node's adnl id
+func nodeKey(n *Node) string {
+	id, _ := liteclient.KeyID(n.ID)
+	return hex.EncodeToString(id)
+}
+
+func nodeAddr(n *Node) (string, error) {
+	if n.AddrList == nil || len(n.AddrList.Addresses) == 0 {
+		return "", errors.New("node has no addresses")
+	}
+
+	a := n.AddrList.Addresses[0]
+	return net.JoinHostPort(a.IP.String(), strconv.Itoa(int(a.Port))), nil
+}
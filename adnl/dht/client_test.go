@@ -0,0 +1,408 @@
+package dht
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+// testNode - minimal dht node which answers from its own storage and knows some other nodes
+type testNode struct {
+	gate *adnl.Gateway
+	node *Node
+
+	mx     sync.Mutex
+	values map[string]*Value
+	known  []*Node
+	// tamper - value data is changed before answer, so signature becomes invalid
+	tamper bool
+	// queries - number of answered queries
+	queries int
+}
+
+func newTestNode(t *testing.T) *testNode {
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	gate := adnl.NewGateway(key)
+	if err := gate.StartServer("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = gate.Close()
+	})
+
+	n := &Node{
+		AddrList: gate.GetAddressList(),
+		Version:  int32(time.Now().Unix()),
+	}
+	n.Sign(key)
+
+	tn := &testNode{
+		gate:   gate,
+		node:   n,
+		values: map[string]*Value{},
+	}
+
+	gate.SetConnectionHandler(func(peer *adnl.Peer) error {
+		peer.SetQueryHandler(func(msg *adnl.MessageQuery) error {
+			resp, err := tn.handle(msg.Data)
+			if err != nil {
+				return err
+			}
+			return peer.Answer(msg.ID, resp)
+		})
+		return nil
+	})
+
+	return tn
+}
+
+func (tn *testNode) setKnown(nodes ...*Node) {
+	tn.mx.Lock()
+	defer tn.mx.Unlock()
+
+	tn.known = nodes
+}
+
+func (tn *testNode) setValue(val *Value, tamper bool) {
+	tn.mx.Lock()
+	defer tn.mx.Unlock()
+
+	tn.values[hex.EncodeToString(val.KeyDescription.Key.Hash())] = val
+	tn.tamper = tamper
+}
+
+func (tn *testNode) handle(data []byte) ([]byte, error) {
+	tn.mx.Lock()
+	defer tn.mx.Unlock()
+
+	tn.queries++
+
+	r := tl.NewReader(data)
+	switch typ := r.Int32(); typ {
+	case _FindValue:
+		id := r.Int256()
+		if val := tn.values[hex.EncodeToString(id)]; val != nil {
+			v := *val
+			if tn.tamper {
+				v.Data = append([]byte{}, v.Data...)
+				v.Data[len(v.Data)-1] ^= 0xFF
+			}
+			return append(tl.AppendInt32(nil, _ValueFound), v.Serialize()...), nil
+		}
+		return append(tl.AppendInt32(nil, _ValueNotFound), serializeNodes(tn.known)...), nil
+	case _FindNode:
+		return append(tl.AppendInt32(nil, _Nodes), serializeNodes(tn.known)...), nil
+	case _Store:
+		if typ = r.Int32(); typ != _Value {
+			return nil, errors.New("not a value")
+		}
+		val, err := parseValue(r)
+		if err != nil {
+			return nil, err
+		}
+		tn.values[hex.EncodeToString(val.KeyDescription.Key.Hash())] = val
+		return tl.AppendInt32(nil, _Stored), nil
+	}
+	return nil, errors.New("unknown query")
+}
+
+func newTestClient(t *testing.T, bootstrap ...*Node) *Client {
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	gate := adnl.NewGateway(key)
+	if err := gate.StartClient(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = gate.Close()
+	})
+
+	c, err := NewClient(gate, bootstrap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func signedAddressValue(t *testing.T, key ed25519.PrivateKey, list *adnl.AddressList) *Value {
+	id, err := liteclient.KeyID(key.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val := &Value{
+		KeyDescription: KeyDescription{
+			Key: Key{ID: id, Name: []byte("address")},
+		},
+		Data: list.Serialize(),
+		TTL:  int32(time.Now().Add(time.Hour).Unix()),
+	}
+	val.Sign(key)
+	return val
+}
+
+func TestClient_FindAddresses(t *testing.T) {
+	a, b := newTestNode(t), newTestNode(t)
+	// client knows only a, and a knows b, which has the value
+	a.setKnown(b.node)
+
+	_, owner, _ := ed25519.GenerateKey(nil)
+	list := &adnl.AddressList{
+		Addresses: []*adnl.Address{{IP: net.IPv4(1, 2, 3, 4).To4(), Port: 30303}},
+		Version:   7,
+	}
+
+	val := signedAddressValue(t, owner, list)
+	b.setValue(val, false)
+
+	c := newTestClient(t, a.node)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	got, pub, err := c.FindAddresses(ctx, val.KeyDescription.Key.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !pub.Equal(owner.Public()) {
+		t.Fatal("wrong owner key")
+	}
+
+	if len(got.Addresses) != 1 || !got.Addresses[0].IP.Equal(net.IPv4(1, 2, 3, 4)) ||
+		got.Addresses[0].Port != 30303 || got.Version != 7 {
+		t.Fatal("wrong address list", got)
+	}
+
+	if len(c.Nodes()) != 2 {
+		t.Fatal("node b should be learned")
+	}
+}
+
+func TestClient_FindValueTampered(t *testing.T) {
+	a := newTestNode(t)
+
+	_, owner, _ := ed25519.GenerateKey(nil)
+	val := signedAddressValue(t, owner, &adnl.AddressList{})
+	a.setValue(val, true)
+
+	c := newTestClient(t, a.node)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := c.FindValue(ctx, &val.KeyDescription.Key); !errors.Is(err, ErrValueNotFound) {
+		t.Fatal("tampered value should be rejected, got", err)
+	}
+}
+
+func TestClient_StoreAddress(t *testing.T) {
+	a, b := newTestNode(t), newTestNode(t)
+	a.setKnown(b.node)
+
+	c := newTestClient(t, a.node)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, owner, _ := ed25519.GenerateKey(nil)
+	list := &adnl.AddressList{
+		Addresses: []*adnl.Address{{IP: net.IPv4(5, 6, 7, 8).To4(), Port: 1}},
+	}
+
+	stored, err := c.StoreAddress(ctx, list, time.Hour, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored != 2 {
+		t.Fatal("value should be stored on both nodes, stored on", stored)
+	}
+
+	// new client, which knows only b, should find it
+	c2 := newTestClient(t, b.node)
+
+	id, _ := liteclient.KeyID(owner.Public().(ed25519.PublicKey))
+	got, _, err := c2.FindAddresses(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Addresses) != 1 || got.Addresses[0].Port != 1 {
+		t.Fatal("wrong address list", got)
+	}
+}
+
+func TestClient_FindValueStopsWithoutCloserNodes(t *testing.T) {
+	a := newTestNode(t)
+
+	// a knows many nodes, which know nothing, so only the closest of them should be queried
+	var nodes []*testNode
+	var known []*Node
+	for i := 0; i < 3*_K; i++ {
+		n := newTestNode(t)
+		nodes = append(nodes, n)
+		known = append(known, n.node)
+	}
+	a.setKnown(known...)
+
+	c := newTestClient(t, a.node)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := c.FindValue(ctx, &Key{ID: make([]byte, 32), Name: []byte("address")}); !errors.Is(err, ErrValueNotFound) {
+		t.Fatal("value should not be found, got", err)
+	}
+
+	queried := 0
+	for _, n := range nodes {
+		n.mx.Lock()
+		if n.queries > 0 {
+			queried++
+		}
+		n.mx.Unlock()
+	}
+
+	if queried > _K {
+		t.Fatal("lookup should stop when no closer nodes are found, queried", queried)
+	}
+}
+
+func TestClient_QueryClosesPeer(t *testing.T) {
+	a, b := newTestNode(t), newTestNode(t)
+	c := newTestClient(t, a.node)
+
+	peer := func(n *testNode) *adnl.Peer {
+		addr, err := nodeAddr(n.node)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := c.gateway.RegisterClient(addr, n.node.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req := append(tl.AppendInt32(nil, _FindNode), make([]byte, 32)...)
+	req = tl.AppendInt32(req, _K)
+
+	// a is in routing table, so its peer is kept
+	pa := peer(a)
+	if _, err := c.query(ctx, a.node, req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pa.Query(ctx, req); err != nil {
+		t.Fatal("peer of known node should stay open", err)
+	}
+
+	// b is not known, peer is closed after query
+	pb := peer(b)
+	if _, err := c.query(ctx, b.node, req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pb.Query(ctx, req); !errors.Is(err, adnl.ErrPeerClosed) {
+		t.Fatal("peer of unknown node should be closed", err)
+	}
+
+	if len(c.inFlight) != 0 {
+		t.Fatal("queries should be released")
+	}
+}
+
+func TestClient_AddNodeBucketLimit(t *testing.T) {
+	a := newTestNode(t)
+	c := newTestClient(t, a.node)
+
+	for i := 0; i < 200; i++ {
+		_, key, _ := ed25519.GenerateKey(nil)
+		n := &Node{
+			AddrList: &adnl.AddressList{
+				Addresses: []*adnl.Address{{IP: net.IPv4(10, 0, 0, 1).To4(), Port: int32(1000 + i)}},
+			},
+			Version: 1,
+		}
+		n.Sign(key)
+		c.addNode(n)
+	}
+
+	buckets := map[int]int{}
+	for _, n := range c.Nodes() {
+		id, _ := liteclient.KeyID(n.ID)
+		buckets[bucketIndex(c.gateway.ID(), id)]++
+	}
+
+	for b, num := range buckets {
+		if num > _BucketSize {
+			t.Fatal("too many nodes in bucket", b, num)
+		}
+	}
+
+	// half of random nodes goes to the first bucket, so not all of them can be known
+	if len(c.Nodes()) >= 201 {
+		t.Fatal("routing table should be bounded, known", len(c.Nodes()))
+	}
+}
+
+func TestNewClient_InvalidNodes(t *testing.T) {
+	a := newTestNode(t)
+
+	broken := *a.node
+	broken.Version++
+
+	if _, err := NewClient(a.gate, []*Node{&broken}); err == nil {
+		t.Fatal("node with invalid signature should not be accepted")
+	}
+}
+
+func TestNodesFromConfig(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	n := &Node{
+		AddrList: &adnl.AddressList{
+			Addresses: []*adnl.Address{{IP: net.IPv4(192, 168, 1, 10).To4(), Port: 6302}},
+			Version:   0,
+		},
+		Version: -1,
+	}
+	n.Sign(key)
+
+	cfg := &liteclient.GlobalConfig{}
+	cfg.DHT.StaticNodes.Nodes = []liteclient.DHTNode{{
+		ID: liteclient.ServerID{Key: base64.StdEncoding.EncodeToString(n.ID)},
+		AddrList: liteclient.DHTAddressList{
+			Addrs: []liteclient.DHTAddress{{IP: -1062731510, Port: 6302}},
+		},
+		Version:   -1,
+		Signature: base64.StdEncoding.EncodeToString(n.Signature),
+	}}
+
+	nodes, err := NodesFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(nodes) != 1 || !nodes[0].AddrList.Addresses[0].IP.Equal(net.IPv4(192, 168, 1, 10)) {
+		t.Fatal("wrong nodes", nodes)
+	}
+
+	if err = nodes[0].CheckSignature(); err != nil {
+		t.Fatal("converted node should keep valid signature:", err)
+	}
+}
@@ -0,0 +1,464 @@
+package dht
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+const (
+	_Node           int32 = -2074922424
+	_Nodes          int32 = 2037686462
+	_Key            int32 = -160964977
+	_KeyDescription int32 = 673009157
+	_Value          int32 = -1867700277
+	_ValueNotFound  int32 = -1570634392
+	_ValueFound     int32 = -468912268
+	_Stored         int32 = 1881602824
+	_Pong           int32 = 1519054721
+
+	_Ping      int32 = -873775336
+	_Store     int32 = 882065938
+	_FindNode  int32 = 1826803307
+	_FindValue int32 = -1370791919
+
+	_PublicKeyED25519 int32 = 1209251014
+	_PublicKeyOverlay int32 = 884622795
+
+	_OverlayNode       int32 = -1200911741
+	_OverlayNodes      int32 = -460904178
+	_OverlayNodeToSign int32 = 64530657
+)
+
+// UpdateRule - defines who can update value of the key and how value is verified
+type UpdateRule int32
+
+const (
+	// UpdateRuleSignature - value is signed by owner of the key, key id is hash of owner's public key
+	UpdateRuleSignature UpdateRule = -861982217
+	// UpdateRuleAnybody - value is not signed, anyone can update it
+	UpdateRuleAnybody UpdateRule = 1633127956
+	// UpdateRuleOverlayNodes - value is list of overlay nodes, each of them is signed by the node
+	UpdateRuleOverlayNodes UpdateRule = 645370755
+)
+
+// Key - dht.key, value is stored in dht under hash of it
+type Key struct {
+	ID    []byte
+	Name  []byte
+	Index int32
+}
+
+// KeyDescription - describes owner of the key and update rule, signed by owner when rule is UpdateRuleSignature.
+// Owner is ed25519 key, or overlay name for UpdateRuleOverlayNodes.
+type KeyDescription struct {
+	Key         Key
+	ID          ed25519.PublicKey
+	OverlayName []byte
+	UpdateRule  UpdateRule
+	Signature   []byte
+}
+
+// Value - dht.value, stored until ttl
+type Value struct {
+	KeyDescription KeyDescription
+	Data           []byte
+	TTL            int32
+	Signature      []byte
+}
+
+// Node - dht node with its addresses, signed by node's key
+type Node struct {
+	ID        ed25519.PublicKey
+	AddrList  *adnl.AddressList
+	Version   int32
+	Signature []byte
+}
+
+// OverlayNode - member of overlay, signed by node's key
+type OverlayNode struct {
+	ID        ed25519.PublicKey
+	Overlay   []byte
+	Version   int32
+	Signature []byte
+}
+
+// Serialize - boxed dht.key
+func (k *Key) Serialize() []byte {
+	return append(tl.AppendInt32(nil, _Key), k.serializeBare()...)
+}
+
+func (k *Key) serializeBare() []byte {
+	data := append([]byte{}, k.ID...)
+	data = append(data, tl.ToBytes(k.Name)...)
+	return tl.AppendInt32(data, k.Index)
+}
+
+// Hash - id of the key in dht
+func (k *Key) Hash() []byte {
+	h := sha256.Sum256(k.Serialize())
+	return h[:]
+}
+
+func parseKey(r *tl.Reader) Key {
+	return Key{ID: r.Int256(), Name: r.Bytes(), Index: r.Int32()}
+}
+
+// Serialize - boxed dht.keyDescription
+func (d *KeyDescription) Serialize() []byte {
+	return append(tl.AppendInt32(nil, _KeyDescription), d.serializeBare()...)
+}
+
+func (d *KeyDescription) serializeBare() []byte {
+	data := d.Key.serializeBare()
+	if d.OverlayName != nil {
+		data = tl.AppendInt32(data, _PublicKeyOverlay)
+		data = append(data, tl.ToBytes(d.OverlayName)...)
+	} else {
+		data = tl.AppendInt32(data, _PublicKeyED25519)
+		data = append(data, d.ID...)
+	}
+	data = tl.AppendInt32(data, int32(d.UpdateRule))
+	return append(data, tl.ToBytes(d.Signature)...)
+}
+
+func parseKeyDescription(r *tl.Reader) (KeyDescription, error) {
+	d := KeyDescription{Key: parseKey(r)}
+
+	switch typ := r.Int32(); typ {
+	case _PublicKeyED25519:
+		d.ID = r.Int256()
+	case _PublicKeyOverlay:
+		d.OverlayName = r.Bytes()
+	default:
+		if r.Err() == nil {
+			return d, fmt.Errorf("unsupported key type %d", typ)
+		}
+	}
+
+	d.UpdateRule = UpdateRule(r.Int32())
+	d.Signature = r.Bytes()
+
+	return d, r.Err()
+}
+
+// Serialize - boxed dht.value
+func (v *Value) Serialize() []byte {
+	data := tl.AppendInt32(nil, _Value)
+	data = append(data, v.KeyDescription.serializeBare()...)
+	data = append(data, tl.ToBytes(v.Data)...)
+	data = tl.AppendInt32(data, v.TTL)
+	return append(data, tl.ToBytes(v.Signature)...)
+}
+
+// ParseValue - parses boxed dht.value
+func ParseValue(data []byte) (*Value, error) {
+	r := tl.NewReader(data)
+	if typ := r.Int32(); typ != _Value {
+		if r.Err() != nil {
+			return nil, r.Err()
+		}
+		return nil, fmt.Errorf("unexpected value type %d", typ)
+	}
+	return parseValue(r)
+}
+
+func parseValue(r *tl.Reader) (*Value, error) {
+	desc, err := parseKeyDescription(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key description: %w", err)
+	}
+
+	v := &Value{
+		KeyDescription: desc,
+		Data:           r.Bytes(),
+		TTL:            r.Int32(),
+		Signature:      r.Bytes(),
+	}
+
+	if r.Err() != nil {
+		return nil, r.Err()
+	}
+	return v, nil
+}
+
+// Sign - signs key description and value with owner's key, update rule is set to UpdateRuleSignature
+func (v *Value) Sign(key ed25519.PrivateKey) {
+	v.KeyDescription.ID = key.Public().(ed25519.PublicKey)
+	v.KeyDescription.OverlayName = nil
+	v.KeyDescription.UpdateRule = UpdateRuleSignature
+	v.KeyDescription.Signature = nil
+	v.KeyDescription.Signature = ed25519.Sign(key, v.KeyDescription.Serialize())
+
+	v.Signature = nil
+	v.Signature = ed25519.Sign(key, v.Serialize())
+}
+
+// CheckValid - verifies signatures of the value according to its update rule and checks that it is not expired
+func (v *Value) CheckValid() error {
+	if int64(v.TTL) <= time.Now().Unix() {
+		return errors.New("value is expired")
+	}
+
+	desc := v.KeyDescription
+
+	switch desc.UpdateRule {
+	case UpdateRuleSignature:
+		if len(desc.ID) != ed25519.PublicKeySize {
+			return errors.New("key owner should be ed25519 key")
+		}
+
+		id, err := liteclient.KeyID(desc.ID)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(id, desc.Key.ID) {
+			return errors.New("key id is not owner's id")
+		}
+
+		if !checkSignature(desc.ID, desc.Signature, func() []byte {
+			d := desc
+			d.Signature = nil
+			return d.Serialize()
+		}) {
+			return errors.New("invalid key description signature")
+		}
+
+		if !checkSignature(desc.ID, v.Signature, func() []byte {
+			val := *v
+			val.Signature = nil
+			return val.Serialize()
+		}) {
+			return errors.New("invalid value signature")
+		}
+	case UpdateRuleAnybody:
+		if len(desc.Signature) != 0 || len(v.Signature) != 0 {
+			return errors.New("value with anybody rule should not be signed")
+		}
+	case UpdateRuleOverlayNodes:
+		if desc.OverlayName == nil || len(desc.Signature) != 0 || len(v.Signature) != 0 {
+			return errors.New("invalid overlay nodes key")
+		}
+
+		if !bytes.Equal(OverlayID(desc.OverlayName), desc.Key.ID) {
+			return errors.New("key id is not overlay id")
+		}
+
+		nodes, err := ParseOverlayNodes(v.Data)
+		if err != nil {
+			return fmt.Errorf("failed to parse overlay nodes: %w", err)
+		}
+
+		for _, n := range nodes {
+			if !bytes.Equal(n.Overlay, desc.Key.ID) {
+				return errors.New("overlay node is for another overlay")
+			}
+			if err = n.CheckSignature(); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown update rule %d", desc.UpdateRule)
+	}
+
+	return nil
+}
+
+// Serialize - boxed dht.node
+func (n *Node) Serialize() []byte {
+	return append(tl.AppendInt32(nil, _Node), n.serializeBare()...)
+}
+
+func (n *Node) serializeBare() []byte {
+	data := tl.AppendInt32(nil, _PublicKeyED25519)
+	data = append(data, n.ID...)
+	list := n.AddrList
+	if list == nil {
+		list = &adnl.AddressList{}
+	}
+	data = append(data, list.SerializeBare()...)
+	data = tl.AppendInt32(data, n.Version)
+	return append(data, tl.ToBytes(n.Signature)...)
+}
+
+func parseNode(r *tl.Reader) (*Node, error) {
+	if typ := r.Int32(); typ != _PublicKeyED25519 && r.Err() == nil {
+		return nil, fmt.Errorf("unsupported node key type %d", typ)
+	}
+
+	n := &Node{ID: r.Int256()}
+
+	list, err := adnl.ParseAddressList(r, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse address list: %w", err)
+	}
+	n.AddrList = list
+	n.Version = r.Int32()
+	n.Signature = r.Bytes()
+
+	if r.Err() != nil {
+		return nil, r.Err()
+	}
+	return n, nil
+}
+
+// parseNodes - parses bare dht.nodes
+func parseNodes(r *tl.Reader) ([]*Node, error) {
+	num := r.Int32()
+	if num < 0 || int(num) > len(r.Rest()) {
+		return nil, fmt.Errorf("incorrect nodes number %d", num)
+	}
+
+	var nodes []*Node
+	for i := int32(0); i < num; i++ {
+		n, err := parseNode(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse node %d: %w", i, err)
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+func serializeNodes(nodes []*Node) []byte {
+	data := tl.AppendInt32(nil, int32(len(nodes)))
+	for _, n := range nodes {
+		data = append(data, n.serializeBare()...)
+	}
+	return data
+}
+
+// Sign - signs node with its key, id is set to public part of the key
+func (n *Node) Sign(key ed25519.PrivateKey) {
+	n.ID = key.Public().(ed25519.PublicKey)
+	n.Signature = nil
+	n.Signature = ed25519.Sign(key, n.Serialize())
+}
+
+// CheckSignature - verifies that node is signed by its key
+func (n *Node) CheckSignature() error {
+	if len(n.ID) != ed25519.PublicKeySize {
+		return errors.New("invalid node key")
+	}
+
+	if !checkSignature(n.ID, n.Signature, func() []byte {
+		node := *n
+		node.Signature = nil
+		return node.Serialize()
+	}) {
+		return errors.New("invalid node signature")
+	}
+	return nil
+}
+
+// toSign - overlay.node.toSign, this is what overlay node signs
+func (n *OverlayNode) toSign() ([]byte, error) {
+	id, err := liteclient.KeyID(n.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := tl.AppendInt32(nil, _OverlayNodeToSign)
+	data = append(data, id...)
+	data = append(data, n.Overlay...)
+	return tl.AppendInt32(data, n.Version), nil
+}
+
+// Sign - signs overlay node with its key, id is set to public part of the key
+func (n *OverlayNode) Sign(key ed25519.PrivateKey) error {
+	n.ID = key.Public().(ed25519.PublicKey)
+
+	data, err := n.toSign()
+	if err != nil {
+		return err
+	}
+	n.Signature = ed25519.Sign(key, data)
+	return nil
+}
+
+// CheckSignature - verifies that overlay node is signed by its key
+func (n *OverlayNode) CheckSignature() error {
+	if len(n.ID) != ed25519.PublicKeySize || len(n.Signature) != ed25519.SignatureSize {
+		return errors.New("invalid overlay node key or signature")
+	}
+
+	data, err := n.toSign()
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(n.ID, data, n.Signature) {
+		return errors.New("invalid overlay node signature")
+	}
+	return nil
+}
+
+// SerializeOverlayNodes - boxed overlay.nodes, it is stored as dht value of overlay key
+func SerializeOverlayNodes(nodes []*OverlayNode) []byte {
+	data := tl.AppendInt32(nil, _OverlayNodes)
+	data = tl.AppendInt32(data, int32(len(nodes)))
+	for _, n := range nodes {
+		data = tl.AppendInt32(data, _PublicKeyED25519)
+		data = append(data, n.ID...)
+		data = append(data, n.Overlay...)
+		data = tl.AppendInt32(data, n.Version)
+		data = append(data, tl.ToBytes(n.Signature)...)
+	}
+	return data
+}
+
+// ParseOverlayNodes - parses boxed overlay.nodes
+func ParseOverlayNodes(data []byte) ([]*OverlayNode, error) {
+	r := tl.NewReader(data)
+	if typ := r.Int32(); typ != _OverlayNodes && r.Err() == nil {
+		return nil, fmt.Errorf("unexpected overlay nodes type %d", typ)
+	}
+
+	num := r.Int32()
+	if num < 0 || int(num) > len(r.Rest()) {
+		return nil, fmt.Errorf("incorrect overlay nodes number %d", num)
+	}
+
+	var nodes []*OverlayNode
+	for i := int32(0); i < num; i++ {
+		if typ := r.Int32(); typ != _PublicKeyED25519 && r.Err() == nil {
+			return nil, fmt.Errorf("unsupported overlay node key type %d", typ)
+		}
+
+		nodes = append(nodes, &OverlayNode{
+			ID:        r.Int256(),
+			Overlay:   r.Int256(),
+			Version:   r.Int32(),
+			Signature: r.Bytes(),
+		})
+	}
+
+	if r.Err() != nil {
+		return nil, r.Err()
+	}
+	return nodes, nil
+}
+
+// OverlayID - id of overlay, hash of pub.overlay with its name
+func OverlayID(name []byte) []byte {
+	data := tl.AppendInt32(nil, _PublicKeyOverlay)
+	data = append(data, tl.ToBytes(name)...)
+
+	h := sha256.Sum256(data)
+	return h[:]
+}
+
+func checkSignature(key ed25519.PublicKey, signature []byte, data func() []byte) bool {
+	if len(signature) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(key, data(), signature)
+}
@@ -0,0 +1,162 @@
+package dht
+
+import (
+	"crypto/ed25519"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+func TestValue_SerializeParse(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	val := signedAddressValue(t, key, &adnl.AddressList{
+		Addresses: []*adnl.Address{{IP: net.IPv4(10, 0, 0, 1).To4(), Port: 777}},
+		Version:   3,
+	})
+
+	if err := val.CheckValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseValue(val.Serialize())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(val, parsed) {
+		t.Fatal("value is not equal after parse")
+	}
+
+	if err = parsed.CheckValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := adnl.ParseAddressList(tl.NewReader(parsed.Data), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if list.Version != 3 || list.Addresses[0].Port != 777 {
+		t.Fatal("wrong address list", list)
+	}
+}
+
+func TestValue_CheckValid(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+	_, another, _ := ed25519.GenerateKey(nil)
+
+	t.Run("tampered data", func(t *testing.T) {
+		val := signedAddressValue(t, key, &adnl.AddressList{})
+		val.Data[len(val.Data)-1] ^= 1
+		if val.CheckValid() == nil {
+			t.Fatal("should be invalid")
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		val := signedAddressValue(t, key, &adnl.AddressList{})
+		val.TTL = int32(time.Now().Add(-time.Minute).Unix())
+		val.Sign(key)
+		if val.CheckValid() == nil {
+			t.Fatal("should be invalid")
+		}
+	})
+
+	t.Run("foreign key", func(t *testing.T) {
+		// key id belongs to one key, but value is signed by another
+		val := signedAddressValue(t, key, &adnl.AddressList{})
+		val.Sign(another)
+		if val.CheckValid() == nil {
+			t.Fatal("should be invalid")
+		}
+	})
+
+	t.Run("anybody signed", func(t *testing.T) {
+		val := signedAddressValue(t, key, &adnl.AddressList{})
+		val.KeyDescription.UpdateRule = UpdateRuleAnybody
+		if val.CheckValid() == nil {
+			t.Fatal("should be invalid")
+		}
+	})
+}
+
+func TestValue_OverlayNodes(t *testing.T) {
+	name := []byte("some overlay")
+	id := OverlayID(name)
+
+	var nodes []*OverlayNode
+	for i := 0; i < 3; i++ {
+		_, key, _ := ed25519.GenerateKey(nil)
+
+		n := &OverlayNode{Overlay: id, Version: int32(i)}
+		if err := n.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, n)
+	}
+
+	val := &Value{
+		KeyDescription: KeyDescription{
+			Key:         Key{ID: id, Name: []byte("nodes")},
+			ID:          make([]byte, 32),
+			OverlayName: name,
+			UpdateRule:  UpdateRuleOverlayNodes,
+		},
+		Data: SerializeOverlayNodes(nodes),
+		TTL:  int32(time.Now().Add(time.Hour).Unix()),
+	}
+
+	parsed, err := ParseValue(val.Serialize())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = parsed.CheckValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseOverlayNodes(parsed.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(nodes, got) {
+		t.Fatal("overlay nodes are not equal")
+	}
+
+	nodes[1].Version++
+	val.Data = SerializeOverlayNodes(nodes)
+	if val.CheckValid() == nil {
+		t.Fatal("node with broken signature should be rejected")
+	}
+}
+
+func TestNode_SerializeParse(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	n := &Node{
+		AddrList: &adnl.AddressList{
+			Addresses: []*adnl.Address{{IP: net.ParseIP("2001:db8::1"), Port: 1}},
+		},
+		Version: 5,
+	}
+	n.Sign(key)
+
+	nodes, err := parseNodes(tl.NewReader(serializeNodes([]*Node{n, n})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(nodes) != 2 || !reflect.DeepEqual(nodes[1], n) {
+		t.Fatal("nodes are not equal after parse")
+	}
+
+	if err = nodes[0].CheckSignature(); err != nil {
+		t.Fatal(err)
+	}
+}
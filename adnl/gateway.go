@@ -0,0 +1,463 @@
+package adnl
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+const (
+	// _MaxPacketSize - max size of udp packet which we read, adnl packets are always smaller
+	_MaxPacketSize = 4096
+	// _MaxPeers - when there are more peers, the least active incoming peer is dropped to accept new one
+	_MaxPeers = 4096
+)
+
+var ErrGatewayClosed = errors.New("gateway closed")
+
+// ConnectionHandler - called for each new incoming peer before its messages are processed,
+// peer is rejected if error is returned
+type ConnectionHandler func(peer *Peer) error
+
+// Gateway - owns udp socket and our key, routes packets to peers by our key id or by channel id.
+// Client gateway only talks to registered peers, server gateway also accepts new peers.
+type Gateway struct {
+	key        ed25519.PrivateKey
+	id         []byte
+	reinitTime int32
+
+	mx             sync.RWMutex
+	conn           net.PacketConn
+	peers          map[string]*Peer
+	channels       map[string]*Peer
+	addrList       *AddressList
+	connHandler    ConnectionHandler
+	acceptIncoming bool
+	closed         bool
+}
+
+// NewGateway - creates gateway with the given key, adnl id of the node is derived from it
+func NewGateway(key ed25519.PrivateKey) *Gateway {
+	id, _ := liteclient.KeyID(key.Public().(ed25519.PublicKey))
+	now := int32(time.Now().Unix())
+
+	return &Gateway{
+		key:        key,
+		id:         id,
+		reinitTime: now,
+		peers:      map[string]*Peer{},
+		channels:   map[string]*Peer{},
+		addrList: &AddressList{
+			Version:    now,
+			ReinitDate: now,
+		},
+	}
+}
+
+// StartServer - listens on udp address and accepts new peers, connection handler can be used to filter them
+func (g *Gateway) StartServer(addr string) error {
+	return g.listen(addr, true)
+}
+
+// StartClient - listens on random udp port, only registered peers are served
+func (g *Gateway) StartClient() error {
+	return g.listen(":0", false)
+}
+
+func (g *Gateway) listen(addr string, acceptIncoming bool) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	g.mx.Lock()
+	if g.conn != nil || g.closed {
+		g.mx.Unlock()
+		_ = conn.Close()
+		return errors.New("gateway is already started or closed")
+	}
+	g.conn = conn
+	g.acceptIncoming = acceptIncoming
+
+	// if we listen on concrete ip, we can announce it to peers
+	if udp, ok := conn.LocalAddr().(*net.UDPAddr); ok && acceptIncoming && !udp.IP.IsUnspecified() {
+		g.addrList.Addresses = []*Address{{IP: udp.IP, Port: int32(udp.Port)}}
+	}
+	g.mx.Unlock()
+
+	go g.listenPackets(conn)
+
+	return nil
+}
+
+// SetConnectionHandler - sets handler which is called for each new incoming peer
+func (g *Gateway) SetConnectionHandler(handler ConnectionHandler) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	g.connHandler = handler
+}
+
+// SetAddressList - sets addresses which are sent to peers, useful when node is behind NAT
+func (g *Gateway) SetAddressList(addrs []*Address) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	version := int32(time.Now().Unix())
+	if version <= g.addrList.Version {
+		version = g.addrList.Version + 1
+	}
+
+	g.addrList = &AddressList{
+		Addresses:  addrs,
+		Version:    version,
+		ReinitDate: g.reinitTime,
+	}
+}
+
+// GetAddressList - returns addresses which are sent to peers
+func (g *Gateway) GetAddressList() *AddressList {
+	g.mx.RLock()
+	defer g.mx.RUnlock()
+
+	return g.addrList
+}
+
+// Addr - local address of udp socket, nil if gateway is not started
+func (g *Gateway) Addr() net.Addr {
+	g.mx.RLock()
+	defer g.mx.RUnlock()
+
+	if g.conn == nil {
+		return nil
+	}
+	return g.conn.LocalAddr()
+}
+
+// ID - adnl id of our node
+func (g *Gateway) ID() []byte {
+	return g.id
+}
+
+// RegisterClient - returns peer for the address and key, new peer is created if we are not talking with it yet
+func (g *Gateway) RegisterClient(addr string, peerKey ed25519.PublicKey) (*Peer, error) {
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve address: %w", err)
+	}
+
+	id, err := liteclient.KeyID(peerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	if g.conn == nil || g.closed {
+		return nil, errors.New("gateway is not started")
+	}
+
+	if p := g.peers[hex.EncodeToString(id)]; p != nil {
+		// registered peer is never dropped, even if it came to us by itself
+		p.incoming = false
+		p.setAddr(udpAddr)
+		return p, nil
+	}
+
+	p, err := newPeer(g, udpAddr, peerKey, id)
+	if err != nil {
+		return nil, err
+	}
+	g.peers[hex.EncodeToString(id)] = p
+
+	return p, nil
+}
+
+// Close - stops listening and closes all peers
+func (g *Gateway) Close() error {
+	g.mx.Lock()
+	if g.closed {
+		g.mx.Unlock()
+		return nil
+	}
+	g.closed = true
+
+	var peers []*Peer
+	for _, p := range g.peers {
+		peers = append(peers, p)
+	}
+	conn := g.conn
+	g.mx.Unlock()
+
+	for _, p := range peers {
+		p.Close()
+	}
+
+	if conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
+
+func (g *Gateway) listenPackets(conn net.PacketConn) {
+	buf := make([]byte, _MaxPacketSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			g.mx.RLock()
+			closed := g.closed
+			g.mx.RUnlock()
+
+			if closed {
+				return
+			}
+
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return
+		}
+
+		udpAddr, ok := addr.(*net.UDPAddr)
+		if !ok || n < 64 {
+			continue
+		}
+
+		// invalid packets are just dropped, it is normal for udp
+		_ = g.processPacket(append([]byte{}, buf[:n]...), udpAddr)
+	}
+}
+
+func (g *Gateway) processPacket(data []byte, addr *net.UDPAddr) error {
+	if bytes.Equal(data[:32], g.id) {
+		return g.processInitialPacket(data[32:], addr)
+	}
+
+	g.mx.RLock()
+	p := g.channels[hex.EncodeToString(data[:32])]
+	g.mx.RUnlock()
+
+	if p == nil {
+		return errors.New("unknown destination")
+	}
+	return p.processChannelPacket(data, addr)
+}
+
+// processInitialPacket - processes packet which is encrypted with our key, it is signed by sender
+func (g *Gateway) processInitialPacket(data []byte, addr *net.UDPAddr) error {
+	if len(data) < 64 {
+		return tl.ErrTooShort
+	}
+
+	key, checksum, data := data[:32], data[32:64], data[64:]
+
+	packet, err := decryptPacket(g.key, key, checksum, data)
+	if err != nil {
+		return err
+	}
+
+	var peerKey ed25519.PublicKey
+	var p *Peer
+
+	if packet.From != nil {
+		peerKey = packet.From
+	} else if packet.FromIDShort != nil {
+		g.mx.RLock()
+		p = g.peers[hex.EncodeToString(packet.FromIDShort)]
+		g.mx.RUnlock()
+
+		if p == nil {
+			return errors.New("packet from unknown short id")
+		}
+		peerKey = p.peerKey
+	} else {
+		return errors.New("packet has no sender")
+	}
+
+	if len(packet.Signature) != ed25519.SignatureSize {
+		return errors.New("packet is not signed")
+	}
+
+	signed, err := packet.signatureData()
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(peerKey, signed, packet.Signature) {
+		return errors.New("invalid packet signature")
+	}
+
+	if p == nil {
+		if p, err = g.incomingPeer(peerKey, addr); err != nil {
+			return err
+		}
+	}
+
+	return p.processPacket(packet, addr, false)
+}
+
+func (g *Gateway) incomingPeer(peerKey ed25519.PublicKey, addr *net.UDPAddr) (*Peer, error) {
+	id, err := liteclient.KeyID(peerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mx.Lock()
+	if p := g.peers[hex.EncodeToString(id)]; p != nil {
+		g.mx.Unlock()
+		return p, nil
+	}
+
+	if !g.acceptIncoming || g.closed {
+		g.mx.Unlock()
+		return nil, errors.New("incoming peers are not accepted")
+	}
+
+	p, err := newPeer(g, addr, peerKey, id)
+	if err != nil {
+		g.mx.Unlock()
+		return nil, err
+	}
+	handler := g.connHandler
+	g.mx.Unlock()
+
+	// handler is called out of lock, so it can use gateway
+	if handler != nil {
+		if err = handler(p); err != nil {
+			return nil, fmt.Errorf("peer rejected: %w", err)
+		}
+	}
+
+	g.mx.Lock()
+	// it could be added concurrently by another packet
+	if existing := g.peers[hex.EncodeToString(id)]; existing != nil {
+		g.mx.Unlock()
+		return existing, nil
+	}
+
+	var dropped *Peer
+	if len(g.peers) >= _MaxPeers {
+		if dropped = g.leastActiveIncomingPeer(); dropped == nil {
+			g.mx.Unlock()
+			p.Close()
+			return nil, errors.New("too many peers")
+		}
+		delete(g.peers, hex.EncodeToString(dropped.peerID))
+	}
+
+	p.incoming = true
+	g.peers[hex.EncodeToString(id)] = p
+	g.mx.Unlock()
+
+	if dropped != nil {
+		dropped.Close()
+	}
+	return p, nil
+}
+
+// leastActiveIncomingPeer - returns incoming peer which has not sent packets for the longest time,
+// registered peers are never dropped. Should be called under lock.
+func (g *Gateway) leastActiveIncomingPeer() *Peer {
+	var res *Peer
+	var resActive int64
+	for _, p := range g.peers {
+		if !p.incoming {
+			continue
+		}
+
+		if active := atomic.LoadInt64(&p.lastActive); res == nil || active < resActive {
+			res, resActive = p, active
+		}
+	}
+	return res
+}
+
+func (g *Gateway) write(data []byte, addr net.Addr) error {
+	g.mx.RLock()
+	conn, closed := g.conn, g.closed
+	g.mx.RUnlock()
+
+	if closed || conn == nil {
+		return ErrGatewayClosed
+	}
+
+	_, err := conn.WriteTo(data, addr)
+	return err
+}
+
+func (g *Gateway) setChannel(p *Peer, oldID, newID []byte) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	if oldID != nil {
+		delete(g.channels, hex.EncodeToString(oldID))
+	}
+	if newID != nil {
+		g.channels[hex.EncodeToString(newID)] = p
+	}
+}
+
+func (g *Gateway) removePeer(p *Peer, channelID []byte) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
+
+	if g.peers[hex.EncodeToString(p.peerID)] == p {
+		delete(g.peers, hex.EncodeToString(p.peerID))
+	}
+	if channelID != nil {
+		delete(g.channels, hex.EncodeToString(channelID))
+	}
+}
+
+// decryptPacket - decrypts packet with shared key of our key and sender key, and verifies its checksum
+func decryptPacket(ourKey ed25519.PrivateKey, theirKey, checksum, data []byte) (*packet, error) {
+	shared, err := liteclient.SharedKey(ourKey, theirKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute shared key: %w", err)
+	}
+
+	return decryptShared(shared, checksum, data)
+}
+
+func decryptShared(shared, checksum, data []byte) (*packet, error) {
+	ctr, err := liteclient.SharedCipher(shared, checksum)
+	if err != nil {
+		return nil, err
+	}
+	ctr.XORKeyStream(data, data)
+
+	hash := sha256.Sum256(data)
+	if !bytes.Equal(hash[:], checksum) {
+		return nil, errors.New("invalid checksum")
+	}
+
+	return parsePacket(data)
+}
+
+// encryptShared - encrypts data with shared key, result is checksum of data and encrypted data
+func encryptShared(shared, data []byte) ([]byte, error) {
+	checksum := sha256.Sum256(data)
+
+	ctr, err := liteclient.SharedCipher(shared, checksum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]byte, 32+len(data))
+	copy(res, checksum[:])
+	ctr.XORKeyStream(res[32:], data)
+
+	return res, nil
+}
@@ -0,0 +1,678 @@
+package adnl
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	mRand "math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+const (
+	// _MTU - max size of serialized message in one packet, bigger messages are split into parts
+	_MTU = 1024
+	// _MaxMessageSize - max size of message assembled from parts
+	_MaxMessageSize = 1 << 20
+	// _PartsTTL - time to wait for the rest of message parts
+	_PartsTTL = 10 * time.Second
+	// _MaxPartialMessages - how many messages can be assembled from parts at the same time,
+	// each one can take up to _MaxMessageSize of memory, so the oldest is dropped when limit is reached
+	_MaxPartialMessages = 8
+	// _SeqnoWindow - how many previous seqno we remember to drop duplicates and reordered replays
+	_SeqnoWindow = 64
+)
+
+var ErrPeerClosed = errors.New("peer closed")
+
+// QueryHandler - processes query of the peer, answer should be sent using Peer.Answer with the same id
+type QueryHandler func(msg *MessageQuery) error
+
+// CustomMessageHandler - processes custom message of the peer
+type CustomMessageHandler func(msg *MessageCustom) error
+
+// DisconnectHandler - called when peer is closed
+type DisconnectHandler func(addr string, key ed25519.PublicKey)
+
+type channel struct {
+	peerKey []byte
+	encKey  []byte
+	decKey  []byte
+	inID    []byte
+	outID   []byte
+	date    int32
+	// ready - peer confirmed that it knows the channel, so we can send packets through it
+	ready bool
+}
+
+// partialMessage - parts of message, buffer of the full size is allocated only when all parts are received,
+// so peer can not make us allocate memory which it did not send
+type partialMessage struct {
+	totalSize int
+	received  int
+	parts     map[int32][]byte
+	created   time.Time
+}
+
+// Peer - remote adnl node we are talking with. Packets are sent through channel when it is established,
+// before that they are encrypted with peer's key and signed with ours.
+type Peer struct {
+	gate    *Gateway
+	peerKey ed25519.PublicKey
+	peerID  []byte
+
+	mx             sync.Mutex
+	addr           *net.UDPAddr
+	seqno          int64
+	recvSeqno      int64
+	recvMask       uint64
+	peerReinitDate int32
+	peerAddrList   *AddressList
+
+	chanKey  ed25519.PrivateKey
+	chanDate int32
+	channel  *channel
+
+	queries map[string]chan *MessageAnswer
+	parts   map[string]*partialMessage
+
+	queryHandler      QueryHandler
+	customHandler     CustomMessageHandler
+	disconnectHandler DisconnectHandler
+	closed            bool
+
+	// incoming - peer came to us by itself, it is not registered by user, so it can be dropped
+	incoming bool
+	// lastActive - unix nano time of the last valid packet, atomic, it is read by gateway under its lock
+	lastActive int64
+}
+
+func newPeer(gate *Gateway, addr *net.UDPAddr, peerKey ed25519.PublicKey, peerID []byte) (*Peer, error) {
+	_, chanKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate channel key: %w", err)
+	}
+
+	return &Peer{
+		gate:     gate,
+		peerKey:  append(ed25519.PublicKey{}, peerKey...),
+		peerID:   peerID,
+		addr:     addr,
+		chanKey:  chanKey,
+		chanDate: int32(time.Now().Unix()),
+		queries:  map[string]chan *MessageAnswer{},
+		parts:    map[string]*partialMessage{},
+
+		lastActive: time.Now().UnixNano(),
+	}, nil
+}
+
+// SetQueryHandler - sets handler of incoming queries
+func (p *Peer) SetQueryHandler(handler QueryHandler) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.queryHandler = handler
+}
+
+// SetCustomMessageHandler - sets handler of incoming custom messages
+func (p *Peer) SetCustomMessageHandler(handler CustomMessageHandler) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.customHandler = handler
+}
+
+// SetDisconnectHandler - sets handler which is called when peer is closed
+func (p *Peer) SetDisconnectHandler(handler DisconnectHandler) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.disconnectHandler = handler
+}
+
+// RemoteAddr - udp address of the peer, it is updated to the source of the last valid packet
+func (p *Peer) RemoteAddr() string {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	return p.addr.String()
+}
+
+// PeerKey - public key of the peer
+func (p *Peer) PeerKey() ed25519.PublicKey {
+	return p.peerKey
+}
+
+// ID - adnl id of the peer
+func (p *Peer) ID() []byte {
+	return p.peerID
+}
+
+// AddressList - last address list received from the peer, nil if peer didn't send it
+func (p *Peer) AddressList() *AddressList {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	return p.peerAddrList
+}
+
+// Query - sends query to the peer and waits for the answer.
+// Query and answer are serialized tl objects, ADNL does not parse them.
+func (p *Peer) Query(ctx context.Context, req []byte) ([]byte, error) {
+	id := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return nil, err
+	}
+	hexID := hex.EncodeToString(id)
+
+	ch := make(chan *MessageAnswer, 1)
+
+	p.mx.Lock()
+	if p.closed {
+		p.mx.Unlock()
+		return nil, ErrPeerClosed
+	}
+	p.queries[hexID] = ch
+	p.mx.Unlock()
+
+	defer func() {
+		p.mx.Lock()
+		delete(p.queries, hexID)
+		p.mx.Unlock()
+	}()
+
+	if err := p.send(MessageQuery{ID: id, Data: req}); err != nil {
+		return nil, fmt.Errorf("failed to send query: %w", err)
+	}
+
+	select {
+	case answer := <-ch:
+		if answer == nil {
+			return nil, ErrPeerClosed
+		}
+		return answer.Data, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Answer - sends answer to the query with id
+func (p *Peer) Answer(queryID []byte, answer []byte) error {
+	if len(queryID) != 32 {
+		return errors.New("query id should be 32 bytes")
+	}
+	return p.send(MessageAnswer{ID: queryID, Data: answer})
+}
+
+// SendCustomMessage - sends custom message to the peer, delivery is not confirmed
+func (p *Peer) SendCustomMessage(data []byte) error {
+	return p.send(MessageCustom{Data: data})
+}
+
+// Close - forgets peer, waiting queries are failed, disconnect handler is called
+func (p *Peer) Close() {
+	p.mx.Lock()
+	if p.closed {
+		p.mx.Unlock()
+		return
+	}
+	p.closed = true
+
+	var channelID []byte
+	if p.channel != nil {
+		channelID = p.channel.inID
+	}
+
+	for id, ch := range p.queries {
+		ch <- nil
+		delete(p.queries, id)
+	}
+
+	addr := p.addr.String()
+	handler := p.disconnectHandler
+	p.mx.Unlock()
+
+	p.gate.removePeer(p, channelID)
+
+	if handler != nil {
+		handler(addr, p.peerKey)
+	}
+}
+
+func (p *Peer) setAddr(addr *net.UDPAddr) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.addr = addr
+}
+
+// send - sends message, if it is too big it is split into parts, each part is sent in own packet
+func (p *Peer) send(msg any) error {
+	data, err := serializeMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	if len(data) <= _MTU {
+		return p.sendPacket(msg)
+	}
+
+	if len(data) > _MaxMessageSize {
+		return fmt.Errorf("too big message: %d", len(data))
+	}
+
+	hash := sha256.Sum256(data)
+	for offset := 0; offset < len(data); offset += _MTU {
+		end := offset + _MTU
+		if end > len(data) {
+			end = len(data)
+		}
+
+		err = p.sendPacket(MessagePart{
+			Hash:      hash[:],
+			TotalSize: int32(len(data)),
+			Offset:    int32(offset),
+			Data:      data[offset:end],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Peer) sendPacket(msg any) error {
+	p.mx.Lock()
+	if p.closed {
+		p.mx.Unlock()
+		return ErrPeerClosed
+	}
+
+	p.seqno++
+	seqno, confirmSeqno := p.seqno, p.recvSeqno
+	reinitDate, dstReinitDate := p.gate.reinitTime, p.peerReinitDate
+
+	pk := &packet{
+		Rand1:         randBytes(),
+		Seqno:         &seqno,
+		ConfirmSeqno:  &confirmSeqno,
+		ReinitDate:    &reinitDate,
+		DstReinitDate: &dstReinitDate,
+		Rand2:         randBytes(),
+	}
+
+	ch := p.channel
+	if ch != nil && ch.ready {
+		pk.Messages = []any{msg}
+		addr := p.addr
+		p.mx.Unlock()
+
+		data, err := pk.Serialize()
+		if err != nil {
+			return err
+		}
+
+		enc, err := encryptShared(ch.encKey, data)
+		if err != nil {
+			return err
+		}
+
+		return p.gate.write(append(append([]byte{}, ch.outID...), enc...), addr)
+	}
+
+	// until channel is ready we ask peer to create it, or confirm that we created it
+	if ch == nil {
+		pk.Messages = []any{MessageCreateChannel{
+			Key:  p.chanKey.Public().(ed25519.PublicKey),
+			Date: p.chanDate,
+		}, msg}
+	} else {
+		pk.Messages = []any{MessageConfirmChannel{
+			Key:     p.chanKey.Public().(ed25519.PublicKey),
+			PeerKey: ch.peerKey,
+			Date:    ch.date,
+		}, msg}
+	}
+	addr := p.addr
+	p.mx.Unlock()
+
+	pk.From = p.gate.key.Public().(ed25519.PublicKey)
+	pk.Address = p.gate.GetAddressList()
+
+	toSign, err := pk.Serialize()
+	if err != nil {
+		return err
+	}
+	pk.Signature = ed25519.Sign(p.gate.key, toSign)
+
+	data, err := pk.Serialize()
+	if err != nil {
+		return err
+	}
+
+	// packet is encrypted with one-time key, sender is identified by signed from field
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return err
+	}
+
+	shared, err := liteclient.SharedKey(key, p.peerKey)
+	if err != nil {
+		return err
+	}
+
+	enc, err := encryptShared(shared, data)
+	if err != nil {
+		return err
+	}
+
+	res := make([]byte, 0, 64+len(enc))
+	res = append(res, p.peerID...)
+	res = append(res, pub...)
+	res = append(res, enc...)
+
+	return p.gate.write(res, addr)
+}
+
+func (p *Peer) processChannelPacket(data []byte, addr *net.UDPAddr) error {
+	p.mx.Lock()
+	ch := p.channel
+	p.mx.Unlock()
+
+	if ch == nil || !bytes.Equal(ch.inID, data[:32]) {
+		return errors.New("unknown channel")
+	}
+
+	packet, err := decryptShared(ch.decKey, data[32:64], data[64:])
+	if err != nil {
+		return err
+	}
+
+	return p.processPacket(packet, addr, true)
+}
+
+func (p *Peer) processPacket(packet *packet, addr *net.UDPAddr, viaChannel bool) error {
+	p.mx.Lock()
+	if p.closed {
+		p.mx.Unlock()
+		return ErrPeerClosed
+	}
+
+	if packet.ReinitDate != nil {
+		if *packet.DstReinitDate > p.gate.reinitTime {
+			p.mx.Unlock()
+			return errors.New("packet is for our future instance")
+		}
+
+		if *packet.ReinitDate < p.peerReinitDate {
+			p.mx.Unlock()
+			return errors.New("packet is from old instance of peer")
+		}
+
+		if *packet.ReinitDate > p.peerReinitDate {
+			if p.peerReinitDate != 0 && !viaChannel {
+				// peer was restarted, its seqno and channel are new
+				p.resetState()
+			}
+			p.peerReinitDate = *packet.ReinitDate
+		}
+	}
+
+	if packet.Seqno != nil && !p.checkSeqno(*packet.Seqno) {
+		p.mx.Unlock()
+		return errors.New("duplicate packet")
+	}
+
+	p.addr = addr
+	atomic.StoreInt64(&p.lastActive, time.Now().UnixNano())
+	if viaChannel && p.channel != nil {
+		p.channel.ready = true
+	}
+	if packet.Address != nil && (p.peerAddrList == nil || packet.Address.Version > p.peerAddrList.Version) {
+		p.peerAddrList = packet.Address
+	}
+	p.mx.Unlock()
+
+	for _, msg := range packet.Messages {
+		if err := p.processMessage(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Peer) processMessage(msg any) error {
+	switch m := msg.(type) {
+	case MessageCreateChannel:
+		return p.setupChannel(m.Key, m.Date, false)
+	case MessageConfirmChannel:
+		if !bytes.Equal(m.PeerKey, p.chanKey.Public().(ed25519.PublicKey)) {
+			return errors.New("channel is confirmed for another key")
+		}
+		return p.setupChannel(m.Key, m.Date, true)
+	case MessageQuery:
+		p.mx.Lock()
+		handler := p.queryHandler
+		p.mx.Unlock()
+
+		if handler != nil {
+			go func() {
+				_ = handler(&m)
+			}()
+		}
+	case MessageAnswer:
+		p.mx.Lock()
+		ch := p.queries[hex.EncodeToString(m.ID)]
+		if ch != nil {
+			delete(p.queries, hex.EncodeToString(m.ID))
+		}
+		p.mx.Unlock()
+
+		if ch != nil {
+			ch <- &m
+		}
+	case MessageCustom:
+		p.mx.Lock()
+		handler := p.customHandler
+		p.mx.Unlock()
+
+		if handler != nil {
+			go func() {
+				_ = handler(&m)
+			}()
+		}
+	case MessagePart:
+		full, err := p.addPart(m)
+		if err != nil || full == nil {
+			return err
+		}
+
+		inner, err := parseMessage(tl.NewReader(full))
+		if err != nil {
+			return fmt.Errorf("failed to parse assembled message: %w", err)
+		}
+
+		if _, ok := inner.(MessagePart); ok {
+			return errors.New("part in part is not allowed")
+		}
+		return p.processMessage(inner)
+	}
+	return nil
+}
+
+func (p *Peer) addPart(part MessagePart) ([]byte, error) {
+	if part.TotalSize <= 0 || part.TotalSize > _MaxMessageSize || part.Offset < 0 ||
+		int(part.Offset)+len(part.Data) > int(part.TotalSize) {
+		return nil, errors.New("invalid message part")
+	}
+
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	for k, v := range p.parts {
+		if time.Since(v.created) > _PartsTTL {
+			delete(p.parts, k)
+		}
+	}
+
+	key := hex.EncodeToString(part.Hash)
+	msg := p.parts[key]
+	if msg == nil {
+		if len(p.parts) >= _MaxPartialMessages {
+			var oldest string
+			for k, v := range p.parts {
+				if oldest == "" || v.created.Before(p.parts[oldest].created) {
+					oldest = k
+				}
+			}
+			delete(p.parts, oldest)
+		}
+
+		msg = &partialMessage{
+			totalSize: int(part.TotalSize),
+			parts:     map[int32][]byte{},
+			created:   time.Now(),
+		}
+		p.parts[key] = msg
+	}
+
+	if msg.totalSize != int(part.TotalSize) {
+		return nil, errors.New("message part size mismatch")
+	}
+
+	if _, ok := msg.parts[part.Offset]; ok {
+		return nil, nil
+	}
+	msg.parts[part.Offset] = append([]byte{}, part.Data...)
+	msg.received += len(part.Data)
+
+	if msg.received < msg.totalSize {
+		return nil, nil
+	}
+	delete(p.parts, key)
+
+	data := make([]byte, msg.totalSize)
+	for offset, d := range msg.parts {
+		copy(data[offset:], d)
+	}
+
+	hash := sha256.Sum256(data)
+	if !bytes.Equal(hash[:], part.Hash) {
+		return nil, errors.New("invalid hash of assembled message")
+	}
+	return data, nil
+}
+
+// setupChannel - creates channel with peer's channel key, if it is not created yet
+func (p *Peer) setupChannel(theirKey []byte, date int32, confirmed bool) error {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	if p.channel != nil && bytes.Equal(p.channel.peerKey, theirKey) {
+		if confirmed {
+			p.channel.ready = true
+		}
+		return nil
+	}
+
+	secret, err := liteclient.SharedKey(p.chanKey, theirKey)
+	if err != nil {
+		return fmt.Errorf("failed to compute channel key: %w", err)
+	}
+
+	rev := make([]byte, len(secret))
+	for i := range secret {
+		rev[len(secret)-1-i] = secret[i]
+	}
+
+	ch := &channel{
+		peerKey: append([]byte{}, theirKey...),
+		date:    date,
+		ready:   confirmed,
+	}
+
+	// keys are mirrored, so what one side encrypts the other decrypts
+	switch bytes.Compare(p.gate.id, p.peerID) {
+	case -1:
+		ch.decKey, ch.encKey = secret, rev
+	case 1:
+		ch.decKey, ch.encKey = rev, secret
+	default:
+		ch.decKey, ch.encKey = secret, secret
+	}
+	ch.inID = aesKeyID(ch.decKey)
+	ch.outID = aesKeyID(ch.encKey)
+
+	var oldID []byte
+	if p.channel != nil {
+		oldID = p.channel.inID
+	}
+	p.channel = ch
+	p.gate.setChannel(p, oldID, ch.inID)
+
+	return nil
+}
+
+// resetState - forgets seqno and channel of the peer, must be called under lock
+func (p *Peer) resetState() {
+	p.recvSeqno, p.recvMask = 0, 0
+
+	if p.channel != nil {
+		p.gate.setChannel(p, p.channel.inID, nil)
+		p.channel = nil
+	}
+}
+
+// checkSeqno - remembers seqno and returns false if it was already received or it is too old,
+// must be called under lock
+func (p *Peer) checkSeqno(seqno int64) bool {
+	if seqno > p.recvSeqno {
+		shift := seqno - p.recvSeqno
+		if shift >= _SeqnoWindow {
+			p.recvMask = 0
+		} else {
+			p.recvMask <<= uint(shift)
+		}
+		p.recvMask |= 1
+		p.recvSeqno = seqno
+		return true
+	}
+
+	diff := p.recvSeqno - seqno
+	if diff >= _SeqnoWindow {
+		return false
+	}
+
+	bit := uint64(1) << uint(diff)
+	if p.recvMask&bit != 0 {
+		return false
+	}
+	p.recvMask |= bit
+	return true
+}
+
+func aesKeyID(key []byte) []byte {
+	hash := sha256.New()
+	hash.Write(tl.AppendInt32(nil, _PublicKeyAES))
+	hash.Write(key)
+	return hash.Sum(nil)
+}
+
+// randBytes - random padding of packet, 7 or 15 bytes, so with length prefix it is aligned
+func randBytes() []byte {
+	b := make([]byte, 7+8*mRand.Intn(2))
+	_, _ = io.ReadFull(rand.Reader, b)
+	return b
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"github.com/sigurn/crc16"
+)
+
+// _ADNLAddrTag - first byte of encoded address, it makes first base32 char always f, so it is dropped
+const _ADNLAddrTag = 0x2d
+
+var crcTable = crc16.MakeTable(crc16.CRC16_XMODEM)
+
+// ParseADNLAddress - parses address of site in form of 55 base32 chars, as in <addr>.adnl hosts
+func ParseADNLAddress(addr string) ([]byte, error) {
+	if len(addr) != 55 {
+		return nil, errors.New("adnl address should be 55 chars")
+	}
+
+	data, err := base32.StdEncoding.DecodeString("F" + strings.ToUpper(addr))
+	if err != nil {
+		return nil, errors.New("adnl address is not base32")
+	}
+
+	if data[0] != _ADNLAddrTag {
+		return nil, errors.New("incorrect adnl address tag")
+	}
+
+	if binary.BigEndian.Uint16(data[33:]) != crc16.Checksum(data[:33], crcTable) {
+		return nil, errors.New("incorrect adnl address checksum")
+	}
+	return data[1:33], nil
+}
+
+// SerializeADNLAddress - encodes adnl id of site to its address, host of the site is <addr>.adnl
+func SerializeADNLAddress(id []byte) (string, error) {
+	if len(id) != 32 {
+		return "", errors.New("adnl id should be 32 bytes")
+	}
+
+	data := make([]byte, 35)
+	data[0] = _ADNLAddrTag
+	copy(data[1:], id)
+	binary.BigEndian.PutUint16(data[33:], crc16.Checksum(data[:33], crcTable))
+
+	return strings.ToLower(base32.StdEncoding.EncodeToString(data)[1:]), nil
+}
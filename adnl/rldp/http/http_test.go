@@ -0,0 +1,380 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/ton/dns"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type mockDHT struct {
+	gates map[string]*adnl.Gateway
+	keys  map[string]ed25519.PublicKey
+}
+
+func (m *mockDHT) FindAddresses(ctx context.Context, adnlID []byte) (*adnl.AddressList, ed25519.PublicKey, error) {
+	g := m.gates[hex.EncodeToString(adnlID)]
+	if g == nil {
+		return nil, nil, errors.New("not found")
+	}
+
+	addr := g.Addr().(*net.UDPAddr)
+	return &adnl.AddressList{
+		Addresses: []*adnl.Address{{IP: addr.IP, Port: int32(addr.Port)}},
+	}, m.keys[hex.EncodeToString(adnlID)], nil
+}
+
+type mockResolver map[string]*cell.Cell
+
+func (m mockResolver) Resolve(ctx context.Context, domain string) (*dns.Domain, error) {
+	rec := m[domain]
+	if rec == nil {
+		return nil, dns.ErrNoSuchRecord
+	}
+
+	d := &dns.Domain{Name: domain, Records: cell.NewDict(256)}
+	h := sha256.Sum256([]byte("site"))
+	if err := d.Records.Set(cell.BeginCell().MustStoreSlice(h[:], 256).EndCell(), cell.BeginCell().MustStoreRef(rec).EndCell()); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// startSite - starts gateway with site server and registers it in mocks
+func startSite(t *testing.T, m *mockDHT, create func(g *adnl.Gateway) *Server) []byte {
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	gate := adnl.NewGateway(key)
+	create(gate)
+	if err := gate.StartServer("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = gate.Close()
+	})
+
+	id, err := liteclient.KeyID(key.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.gates[hex.EncodeToString(id)] = gate
+	m.keys[hex.EncodeToString(id)] = key.Public().(ed25519.PublicKey)
+	return id
+}
+
+func newTestClient(t *testing.T, m *mockDHT, r Resolver) *http.Client {
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	gate := adnl.NewGateway(key)
+	if err := gate.StartClient(); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := NewTransport(gate, m, r)
+	t.Cleanup(func() {
+		tr.Close()
+		_ = gate.Close()
+	})
+
+	return &http.Client{Transport: tr, Timeout: 10 * time.Second}
+}
+
+func testData(n int) []byte {
+	data := make([]byte, n)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	return data
+}
+
+func TestTransport(t *testing.T) {
+	big := testData(3*_ChunkSize + 1000)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Host", r.Host)
+		w.Header().Set("X-Client", r.Header.Get("X-Adnl-Id"))
+		_, _ = w.Write([]byte("hello " + r.URL.Query().Get("name")))
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < len(big); i += 10000 {
+			end := i + 10000
+			if end > len(big) {
+				end = len(big)
+			}
+			_, _ = w.Write(big[i:end])
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	m := &mockDHT{gates: map[string]*adnl.Gateway{}, keys: map[string]ed25519.PublicKey{}}
+	id := startSite(t, m, func(g *adnl.Gateway) *Server {
+		return NewServer(g, mux)
+	})
+
+	addr, err := SerializeADNLAddress(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := newTestClient(t, m, mockResolver{
+		"example.ton": dns.SiteRecord(id, false),
+		"bag.ton":     dns.SiteRecord(id, true),
+	})
+
+	t.Run("get", func(t *testing.T) {
+		resp, err := client.Get("http://example.ton/hello?name=ton")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK || string(body) != "hello ton" {
+			t.Fatal("incorrect response", resp.Status, string(body))
+		}
+
+		if resp.ContentLength != int64(len(body)) {
+			t.Fatal("incorrect content length", resp.ContentLength)
+		}
+
+		if resp.Header.Get("X-Host") != "example.ton" {
+			t.Fatal("incorrect host", resp.Header.Get("X-Host"))
+		}
+
+		if len(resp.Header.Get("X-Client")) != 64 {
+			t.Fatal("no client adnl id")
+		}
+	})
+
+	t.Run("post", func(t *testing.T) {
+		resp, err := client.Post("http://"+addr+".adnl/echo", "application/octet-stream", bytes.NewReader(big))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusCreated || !bytes.Equal(body, big) {
+			t.Fatal("incorrect response", resp.Status, len(body))
+		}
+	})
+
+	t.Run("post unknown length", func(t *testing.T) {
+		resp, err := client.Post("http://example.ton/echo", "text/plain", io.MultiReader(strings.NewReader("abc"), strings.NewReader("def")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(body) != "abcdef" {
+			t.Fatal("incorrect response", string(body))
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		resp, err := client.Get("http://example.ton/stream")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.ContentLength != -1 {
+			t.Fatal("length of streamed response should be unknown", resp.ContentLength)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(body, big) {
+			t.Fatal("incorrect streamed data", len(body))
+		}
+	})
+
+	t.Run("no payload", func(t *testing.T) {
+		resp, err := client.Get("http://example.ton/empty")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusNoContent || resp.ContentLength != 0 || len(body) != 0 {
+			t.Fatal("incorrect response", resp.Status)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		resp, err := client.Get("http://example.ton/unknown")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatal("incorrect status", resp.Status)
+		}
+	})
+
+	t.Run("bad hosts", func(t *testing.T) {
+		if _, err := client.Get("http://bag.ton/"); !errors.Is(err, ErrSiteUsesStorage) {
+			t.Fatal("should be storage site error, got", err)
+		}
+
+		if _, err := client.Get("http://unknown.ton/"); !errors.Is(err, dns.ErrNoSuchRecord) {
+			t.Fatal("should be not found, got", err)
+		}
+
+		if _, err := client.Get("http://example.com/"); !errors.Is(err, ErrUnsupportedHost) {
+			t.Fatal("should be unsupported host, got", err)
+		}
+	})
+}
+
+func TestReverseProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.Header.Get("X-Adnl-Id")))
+	}))
+	defer backend.Close()
+
+	target, err := url.Parse(backend.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockDHT{gates: map[string]*adnl.Gateway{}, keys: map[string]ed25519.PublicKey{}}
+	id := startSite(t, m, func(g *adnl.Gateway) *Server {
+		return NewReverseProxy(g, target)
+	})
+
+	client := newTestClient(t, m, mockResolver{"proxy.ton": dns.SiteRecord(id, false)})
+
+	resp, err := client.Get("http://proxy.ton/some/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(string(body), " ")
+	if len(parts) != 3 || parts[0] != "GET" || parts[1] != "/some/page" || len(parts[2]) != 64 {
+		t.Fatal("incorrect proxied response", string(body))
+	}
+}
+
+func TestADNLAddress(t *testing.T) {
+	id := testData(32)
+
+	addr, err := SerializeADNLAddress(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(addr) != 55 || strings.ToLower(addr) != addr {
+		t.Fatal("incorrect address", addr)
+	}
+
+	parsed, err := ParseADNLAddress(strings.ToUpper(addr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(parsed, id) {
+		t.Fatal("incorrect parsed id")
+	}
+
+	broken := []byte(addr)
+	broken[10] = map[bool]byte{true: 'a', false: 'b'}[broken[10] != 'a']
+	if _, err = ParseADNLAddress(string(broken)); err == nil {
+		t.Fatal("checksum should be incorrect")
+	}
+
+	if _, err = ParseADNLAddress(addr[1:]); err == nil {
+		t.Fatal("short address should not be parsed")
+	}
+}
+
+func TestTL(t *testing.T) {
+	msgs := []any{
+		request{ID: testData(32), Method: "GET", URL: "http://a.ton/", Version: "HTTP/1.1", Headers: []header{{Name: "A", Value: "b"}}},
+		response{Version: "HTTP/1.1", StatusCode: 200, Reason: "OK", Headers: []header{}, NoPayload: true},
+		getNextPayloadPart{ID: testData(32), Seqno: 3, MaxChunkSize: _ChunkSize},
+		payloadPart{Data: []byte("data"), Trailer: []header{{Name: "X", Value: "y"}}, IsLast: false},
+	}
+
+	for _, msg := range msgs {
+		data, err := serialize(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parsed, err := parse(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		again, err := serialize(parsed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(data, again) {
+			t.Fatalf("%T is changed after parse", msg)
+		}
+	}
+
+	if _, err := parse([]byte{1, 2, 3, 4}); err == nil {
+		t.Fatal("unknown message should not be parsed")
+	}
+}
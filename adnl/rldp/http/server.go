@@ -0,0 +1,399 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/rldp"
+)
+
+// _StreamTimeout - response stream is dropped when client does not pull its parts during this time
+const _StreamTimeout = 30 * time.Second
+
+var errStreamTimeout = errors.New("response stream is not pulled by client")
+
+// Server - serves TON Site, requests which come over RLDP are passed to http handler.
+// Handler gets ADNL id of the client in X-Adnl-Id header.
+type Server struct {
+	handler http.Handler
+
+	mx sync.Mutex
+	// streams - response bodies which are pulled by clients, key is request id
+	streams map[string]*stream
+}
+
+// stream - body of the response, it is closed when it is fully pulled or not pulled for too long
+type stream struct {
+	payload
+	pr    *io.PipeReader
+	timer *time.Timer
+}
+
+// responseWriter - collects response until it is ready to be sent: handler has finished,
+// written data exceeds one chunk, or handler flushed. Then rest of data goes to the stream pipe.
+type responseWriter struct {
+	header http.Header
+	status int
+
+	mx    sync.Mutex
+	buf   []byte
+	pw    *io.PipeWriter
+	ready chan struct{}
+	// sentCh - closed when response is taken for sending, next writes go to pipe
+	sentCh chan struct{}
+	sent   bool
+	done   bool
+}
+
+// NewServer - creates server of the site, gateway should be started as server, its address should be
+// stored in dht, and adnl address should be set to site record of the domain.
+func NewServer(gateway *adnl.Gateway, handler http.Handler) *Server {
+	s := &Server{
+		handler: handler,
+		streams: map[string]*stream{},
+	}
+
+	gateway.SetConnectionHandler(func(peer *adnl.Peer) error {
+		r := rldp.NewClient(peer)
+		r.SetOnQuery(s.queryHandler(r, peer))
+		return nil
+	})
+	return s
+}
+
+// NewReverseProxy - creates server of the site which passes requests to the local http service
+func NewReverseProxy(gateway *adnl.Gateway, target *url.URL) *Server {
+	return NewServer(gateway, httputil.NewSingleHostReverseProxy(target))
+}
+
+func (s *Server) queryHandler(r *rldp.RLDP, peer *adnl.Peer) rldp.QueryHandler {
+	return func(transferID []byte, query *rldp.Query) error {
+		msg, err := parse(query.Data)
+		if err != nil {
+			return err
+		}
+
+		var answer []byte
+		switch m := msg.(type) {
+		case request:
+			answer, err = s.serveRequest(r, peer, m)
+		case getNextPayloadPart:
+			answer, err = s.servePayloadPart(m)
+		default:
+			return fmt.Errorf("unexpected query %T", msg)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), _AnswerTimeout)
+		defer cancel()
+
+		return r.SendAnswer(ctx, query.MaxAnswerSize, transferID, query.ID, answer)
+	}
+}
+
+// serveRequest - runs handler and returns serialized response when it is ready,
+// rest of the body is pulled by client from stream
+func (s *Server) serveRequest(r *rldp.RLDP, peer *adnl.Peer, req request) ([]byte, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	major, minor, ok := http.ParseHTTPVersion(req.Version)
+	if !ok {
+		return nil, fmt.Errorf("incorrect http version %s", req.Version)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	httpReq := (&http.Request{
+		Method:        req.Method,
+		URL:           u,
+		Proto:         req.Version,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        http.Header{},
+		Body:          http.NoBody,
+		Host:          u.Host,
+		RemoteAddr:    peer.RemoteAddr(),
+		RequestURI:    req.URL,
+		ContentLength: 0,
+	}).WithContext(ctx)
+
+	for _, h := range req.Headers {
+		if strings.EqualFold(h.Name, "Host") {
+			httpReq.Host = h.Value
+			continue
+		}
+		httpReq.Header.Add(h.Name, h.Value)
+	}
+	httpReq.Header.Set("X-Adnl-Id", hex.EncodeToString(peer.ID()))
+
+	cl := httpReq.Header.Get("Content-Length")
+	if cl != "" || httpReq.Header.Get("Transfer-Encoding") != "" || req.Method == http.MethodConnect {
+		httpReq.ContentLength = -1
+		if cl != "" {
+			if httpReq.ContentLength, err = strconv.ParseInt(cl, 10, 64); err != nil || httpReq.ContentLength < 0 {
+				cancel()
+				return nil, errors.New("incorrect content length")
+			}
+		}
+		httpReq.Header.Del("Transfer-Encoding")
+
+		pr, pw := io.Pipe()
+		httpReq.Body = pr
+		go pullRequestBody(ctx, r, req.ID, pw)
+	}
+
+	w := &responseWriter{
+		header: http.Header{},
+		ready:  make(chan struct{}),
+		sentCh: make(chan struct{}),
+	}
+
+	go func() {
+		defer cancel()
+		defer w.finish()
+		defer httpReq.Body.Close()
+
+		s.handler.ServeHTTP(w, httpReq)
+	}()
+
+	<-w.ready
+
+	w.mx.Lock()
+	defer w.mx.Unlock()
+	w.sent = true
+	close(w.sentCh)
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	res := response{
+		Version:    "HTTP/1.1",
+		StatusCode: int32(w.status),
+		Reason:     http.StatusText(w.status),
+		Headers:    []header{},
+	}
+
+	if w.done && w.header.Get("Content-Length") == "" && w.header.Get("Transfer-Encoding") == "" {
+		w.header.Set("Content-Length", strconv.Itoa(len(w.buf)))
+	}
+
+	for name, values := range w.header {
+		for _, v := range values {
+			res.Headers = append(res.Headers, header{Name: name, Value: v})
+		}
+	}
+
+	switch {
+	case !w.done:
+		// handler still writes, next data will be read from pipe
+		pr, pw := io.Pipe()
+		w.pw = pw
+		s.addStream(req.ID, io.MultiReader(bytes.NewReader(w.buf), pr), pr, cancel)
+	case len(w.buf) > 0:
+		s.addStream(req.ID, bytes.NewReader(w.buf), nil, nil)
+	default:
+		res.NoPayload = true
+	}
+
+	return serialize(res)
+}
+
+// pullRequestBody - requests body parts from client and writes them to pipe which is read by handler
+func pullRequestBody(ctx context.Context, r *rldp.RLDP, id []byte, pw *io.PipeWriter) {
+	for seqno := int32(0); ; seqno++ {
+		data, err := serialize(getNextPayloadPart{
+			ID:           id,
+			Seqno:        seqno,
+			MaxChunkSize: _ChunkSize,
+		})
+		if err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+
+		answer, err := r.DoQuery(ctx, _MaxAnswerSize, data)
+		if err != nil {
+			_ = pw.CloseWithError(fmt.Errorf("failed to get request payload part: %w", err))
+			return
+		}
+
+		msg, err := parse(answer)
+		if err != nil {
+			_ = pw.CloseWithError(fmt.Errorf("failed to parse request payload part: %w", err))
+			return
+		}
+
+		part, ok := msg.(payloadPart)
+		if !ok {
+			_ = pw.CloseWithError(fmt.Errorf("unexpected answer %T", msg))
+			return
+		}
+
+		if _, err = pw.Write(part.Data); err != nil {
+			// handler closed body
+			return
+		}
+
+		if part.IsLast {
+			_ = pw.Close()
+			return
+		}
+	}
+}
+
+func (s *Server) addStream(id []byte, body io.Reader, pr *io.PipeReader, cancel func()) {
+	key := hex.EncodeToString(id)
+
+	st := &stream{
+		payload: payload{r: body, partial: pr != nil},
+		pr:      pr,
+	}
+	st.timer = time.AfterFunc(_StreamTimeout, func() {
+		s.removeStream(key, st)
+		if pr != nil {
+			_ = pr.CloseWithError(errStreamTimeout)
+		}
+		if cancel != nil {
+			cancel()
+		}
+	})
+
+	s.mx.Lock()
+	s.streams[key] = st
+	s.mx.Unlock()
+}
+
+func (s *Server) removeStream(key string, st *stream) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.streams[key] == st {
+		delete(s.streams, key)
+	}
+}
+
+// servePayloadPart - returns next part of response body to the client
+func (s *Server) servePayloadPart(q getNextPayloadPart) ([]byte, error) {
+	key := hex.EncodeToString(q.ID)
+
+	s.mx.Lock()
+	st := s.streams[key]
+	s.mx.Unlock()
+
+	if st == nil {
+		return nil, errors.New("unknown response")
+	}
+
+	if !st.timer.Stop() {
+		// stream is already timed out
+		return nil, errStreamTimeout
+	}
+
+	data, last, err := st.next(q)
+	if err != nil {
+		s.removeStream(key, st)
+		if st.pr != nil {
+			_ = st.pr.CloseWithError(err)
+		}
+		return nil, err
+	}
+
+	if last {
+		s.removeStream(key, st)
+		return data, nil
+	}
+
+	st.timer.Reset(_StreamTimeout)
+	return data, nil
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.mx.Lock()
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if !w.sent {
+		w.buf = append(w.buf, p...)
+		full := len(w.buf) >= _ChunkSize
+		if full {
+			w.setReady()
+		}
+		w.mx.Unlock()
+
+		if full {
+			<-w.sentCh
+		}
+		return len(p), nil
+	}
+	pw := w.pw
+	w.mx.Unlock()
+
+	// blocks until client pulls data
+	return pw.Write(p)
+}
+
+// Flush - sends response headers and written data, rest of data will be streamed
+func (w *responseWriter) Flush() {
+	w.mx.Lock()
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.setReady()
+	w.mx.Unlock()
+
+	<-w.sentCh
+}
+
+// finish - called when handler returned
+func (w *responseWriter) finish() {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	if !w.sent {
+		w.done = true
+		w.setReady()
+		return
+	}
+	_ = w.pw.Close()
+}
+
+func (w *responseWriter) setReady() {
+	select {
+	case <-w.ready:
+	default:
+		close(w.ready)
+	}
+}
@@ -0,0 +1,192 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+const (
+	_Request            int32 = -747874710
+	_Response           int32 = -70876990
+	_GetNextPayloadPart int32 = -1871422196
+	_PayloadPart        int32 = 53946923
+	_Header             int32 = -1902385903
+
+	_BoolTrue  int32 = -1720552011
+	_BoolFalse int32 = -1132882121
+)
+
+// _MaxHeaders - we do not accept messages with more headers
+const _MaxHeaders = 1024
+
+type header struct {
+	Name  string
+	Value string
+}
+
+// request - http.request, body of the request is pulled by server using http.getNextPayloadPart with the same id
+type request struct {
+	ID      []byte
+	Method  string
+	URL     string
+	Version string
+	Headers []header
+}
+
+// response - http.response, it is the answer to http.request, body is pulled the same way as for request
+type response struct {
+	Version    string
+	StatusCode int32
+	Reason     string
+	Headers    []header
+	NoPayload  bool
+}
+
+// getNextPayloadPart - http.getNextPayloadPart, requests part of the body with seqno, starting from 0
+type getNextPayloadPart struct {
+	ID           []byte
+	Seqno        int32
+	MaxChunkSize int32
+}
+
+// payloadPart - http.payloadPart, part of the body, last part has last flag and may have trailer
+type payloadPart struct {
+	Data    []byte
+	Trailer []header
+	IsLast  bool
+}
+
+func serialize(msg any) ([]byte, error) {
+	switch m := msg.(type) {
+	case request:
+		if len(m.ID) != 32 {
+			return nil, errors.New("request id should be 32 bytes")
+		}
+		data := tl.AppendInt32(nil, _Request)
+		data = append(data, m.ID...)
+		data = append(data, tl.ToBytes([]byte(m.Method))...)
+		data = append(data, tl.ToBytes([]byte(m.URL))...)
+		data = append(data, tl.ToBytes([]byte(m.Version))...)
+		return appendHeaders(data, m.Headers), nil
+	case response:
+		data := tl.AppendInt32(nil, _Response)
+		data = append(data, tl.ToBytes([]byte(m.Version))...)
+		data = tl.AppendInt32(data, m.StatusCode)
+		data = append(data, tl.ToBytes([]byte(m.Reason))...)
+		data = appendHeaders(data, m.Headers)
+		return appendBool(data, m.NoPayload), nil
+	case getNextPayloadPart:
+		if len(m.ID) != 32 {
+			return nil, errors.New("request id should be 32 bytes")
+		}
+		data := tl.AppendInt32(nil, _GetNextPayloadPart)
+		data = append(data, m.ID...)
+		data = tl.AppendInt32(data, m.Seqno)
+		return tl.AppendInt32(data, m.MaxChunkSize), nil
+	case payloadPart:
+		data := tl.AppendInt32(nil, _PayloadPart)
+		data = append(data, tl.ToBytes(m.Data)...)
+		data = appendHeaders(data, m.Trailer)
+		return appendBool(data, m.IsLast), nil
+	}
+	return nil, fmt.Errorf("unsupported message type %T", msg)
+}
+
+func parse(data []byte) (any, error) {
+	r := tl.NewReader(data)
+
+	var msg any
+	switch typ := r.Int32(); typ {
+	case _Request:
+		msg = request{
+			ID:      r.Int256(),
+			Method:  string(r.Bytes()),
+			URL:     string(r.Bytes()),
+			Version: string(r.Bytes()),
+			Headers: readHeaders(r),
+		}
+	case _Response:
+		msg = response{
+			Version:    string(r.Bytes()),
+			StatusCode: r.Int32(),
+			Reason:     string(r.Bytes()),
+			Headers:    readHeaders(r),
+			NoPayload:  readBool(r),
+		}
+	case _GetNextPayloadPart:
+		msg = getNextPayloadPart{
+			ID:           r.Int256(),
+			Seqno:        r.Int32(),
+			MaxChunkSize: r.Int32(),
+		}
+	case _PayloadPart:
+		msg = payloadPart{
+			Data:    r.Bytes(),
+			Trailer: readHeaders(r),
+			IsLast:  readBool(r),
+		}
+	default:
+		if r.Err() != nil {
+			return nil, r.Err()
+		}
+		return nil, fmt.Errorf("unknown message type %d", typ)
+	}
+
+	if r.Err() != nil {
+		return nil, r.Err()
+	}
+	return msg, nil
+}
+
+func appendHeaders(data []byte, headers []header) []byte {
+	data = tl.AppendInt32(data, int32(len(headers)))
+	for _, h := range headers {
+		data = tl.AppendInt32(data, _Header)
+		data = append(data, tl.ToBytes([]byte(h.Name))...)
+		data = append(data, tl.ToBytes([]byte(h.Value))...)
+	}
+	return data
+}
+
+func readHeaders(r *tl.Reader) []header {
+	num := r.Int32()
+	if num < 0 || num > _MaxHeaders {
+		r.Fail(fmt.Errorf("incorrect headers number %d", num))
+		return nil
+	}
+
+	headers := make([]header, 0, num)
+	for i := int32(0); i < num && r.Err() == nil; i++ {
+		if id := r.Int32(); id != _Header && r.Err() == nil {
+			r.Fail(fmt.Errorf("unexpected header type %d", id))
+			return nil
+		}
+		headers = append(headers, header{
+			Name:  string(r.Bytes()),
+			Value: string(r.Bytes()),
+		})
+	}
+	return headers
+}
+
+func appendBool(data []byte, v bool) []byte {
+	if v {
+		return tl.AppendInt32(data, _BoolTrue)
+	}
+	return tl.AppendInt32(data, _BoolFalse)
+}
+
+func readBool(r *tl.Reader) bool {
+	switch v := r.Int32(); v {
+	case _BoolTrue:
+		return true
+	case _BoolFalse:
+	default:
+		if r.Err() == nil {
+			r.Fail(fmt.Errorf("incorrect bool %d", v))
+		}
+	}
+	return false
+}
@@ -0,0 +1,510 @@
+package http
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/rldp"
+	"github.com/xssnick/tonutils-go/ton/dns"
+)
+
+const (
+	// _ChunkSize - max size of body part which is sent in one payload part
+	_ChunkSize = 1 << 17
+	// _MaxAnswerSize - answer is a payload part or response with headers
+	_MaxAnswerSize = 2*_ChunkSize + 1024
+	// _ResolveTTL - how long resolved .ton domains are cached
+	_ResolveTTL = time.Minute
+	// _AnswerTimeout - time to send answer to the peer query
+	_AnswerTimeout = 15 * time.Second
+)
+
+var ErrSiteUsesStorage = errors.New("site is stored in ton storage, it cannot be reached over rldp http")
+var ErrUnsupportedHost = errors.New("host is not .ton or .adnl domain")
+
+// DHT - finds addresses of sites by their adnl id, *dht.Client implements it
+type DHT interface {
+	FindAddresses(ctx context.Context, adnlID []byte) (*adnl.AddressList, ed25519.PublicKey, error)
+}
+
+// Resolver - resolves .ton domains to get site record, *dns.Client implements it
+type Resolver interface {
+	Resolve(ctx context.Context, domain string) (*dns.Domain, error)
+}
+
+// Transport - http.RoundTripper which sends requests to TON Sites over RLDP.
+// Hosts can be .ton domains with site record, or <addr>.adnl with address of the site.
+type Transport struct {
+	gateway  *adnl.Gateway
+	dht      DHT
+	resolver Resolver
+
+	mx       sync.Mutex
+	sites    map[string]*rldp.RLDP
+	resolved map[string]resolvedHost
+	// bodies - bodies of our requests which are pulled by sites, key is request id
+	bodies map[string]*payload
+}
+
+type resolvedHost struct {
+	id     []byte
+	expire time.Time
+}
+
+// payload - body which is sent part by part, peer pulls parts with seqno one by one
+type payload struct {
+	mx    sync.Mutex
+	r     io.Reader
+	seqno int32
+	// partial - part is returned when any data is read, without waiting for full chunk
+	partial bool
+}
+
+type responseBody struct {
+	r       *rldp.RLDP
+	ctx     context.Context
+	id      []byte
+	resp    *http.Response
+	release func()
+
+	mx     sync.Mutex
+	seqno  int32
+	buf    []byte
+	last   bool
+	closed bool
+}
+
+// NewTransport - creates transport which uses gateway to talk with sites, resolver can be nil,
+// then only .adnl hosts are supported. Gateway should be started as client or server.
+func NewTransport(gateway *adnl.Gateway, dht DHT, resolver Resolver) *Transport {
+	return &Transport{
+		gateway:  gateway,
+		dht:      dht,
+		resolver: resolver,
+		sites:    map[string]*rldp.RLDP{},
+		resolved: map[string]resolvedHost{},
+		bodies:   map[string]*payload{},
+	}
+}
+
+// RoundTrip - sends request to the site and returns response, its body is downloaded when it is read
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	// request body can be pulled by site until response body is closed
+	release := func() {}
+	if req.Body != nil {
+		key := hex.EncodeToString(id)
+		if req.Body != http.NoBody {
+			t.mx.Lock()
+			t.bodies[key] = &payload{r: req.Body}
+			t.mx.Unlock()
+		}
+
+		var once sync.Once
+		release = func() {
+			once.Do(func() {
+				t.mx.Lock()
+				delete(t.bodies, key)
+				t.mx.Unlock()
+				_ = req.Body.Close()
+			})
+		}
+	}
+
+	resp, err := t.roundTrip(req, id, release)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *Transport) roundTrip(req *http.Request, reqID []byte, release func()) (*http.Response, error) {
+	ctx := req.Context()
+
+	id, err := t.resolve(ctx, req.URL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := t.getSite(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	r := request{
+		ID:      reqID,
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Version: "HTTP/1.1",
+		Headers: []header{{Name: "Host", Value: host}},
+	}
+
+	for name, values := range req.Header {
+		if name == "Host" {
+			continue
+		}
+		for _, v := range values {
+			r.Headers = append(r.Headers, header{Name: name, Value: v})
+		}
+	}
+
+	// site pulls body only when request says that it has one
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.ContentLength > 0 {
+			r.Headers = append(r.Headers, header{Name: "Content-Length", Value: strconv.FormatInt(req.ContentLength, 10)})
+		} else if req.Header.Get("Transfer-Encoding") == "" {
+			r.Headers = append(r.Headers, header{Name: "Transfer-Encoding", Value: "chunked"})
+		}
+	}
+
+	data, err := serialize(r)
+	if err != nil {
+		return nil, err
+	}
+
+	answer, err := client.DoQuery(ctx, _MaxAnswerSize, data)
+	if err != nil {
+		if errors.Is(err, rldp.ErrClosed) {
+			t.dropSite(id, client)
+		}
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	msg, err := parse(answer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	res, ok := msg.(response)
+	if !ok {
+		return nil, fmt.Errorf("unexpected answer %T", msg)
+	}
+
+	major, minor, ok := http.ParseHTTPVersion(res.Version)
+	if !ok {
+		major, minor = 1, 1
+	}
+
+	resp := &http.Response{
+		Status:        strconv.Itoa(int(res.StatusCode)) + " " + res.Reason,
+		StatusCode:    int(res.StatusCode),
+		Proto:         res.Version,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        http.Header{},
+		ContentLength: -1,
+		Request:       req,
+	}
+
+	for _, h := range res.Headers {
+		resp.Header.Add(h.Name, h.Value)
+	}
+	// body is already split to parts by rldp, so these headers are not related to it
+	resp.Header.Del("Transfer-Encoding")
+	resp.Header.Del("Connection")
+
+	if cl := resp.Header.Get("Content-Length"); cl != "" {
+		if n, err := strconv.ParseInt(cl, 10, 64); err == nil && n >= 0 {
+			resp.ContentLength = n
+		}
+	}
+
+	if res.NoPayload {
+		release()
+		resp.Body = http.NoBody
+		resp.ContentLength = 0
+		return resp, nil
+	}
+
+	resp.Body = &responseBody{
+		r:       client,
+		ctx:     ctx,
+		id:      r.ID,
+		resp:    resp,
+		release: release,
+	}
+	return resp, nil
+}
+
+// resolve - returns adnl id of the site
+func (t *Transport) resolve(ctx context.Context, host string) ([]byte, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if strings.HasSuffix(host, ".adnl") {
+		// it can be subdomain of adnl address, site decides what to do with it
+		parts := strings.Split(strings.TrimSuffix(host, ".adnl"), ".")
+		return ParseADNLAddress(parts[len(parts)-1])
+	}
+
+	if !strings.HasSuffix(host, ".ton") || t.resolver == nil {
+		return nil, ErrUnsupportedHost
+	}
+
+	t.mx.Lock()
+	rh, ok := t.resolved[host]
+	t.mx.Unlock()
+
+	if ok && time.Now().Before(rh.expire) {
+		return rh.id, nil
+	}
+
+	domain, err := t.resolver.Resolve(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve domain %s: %w", host, err)
+	}
+
+	id, inStorage, err := domain.GetSiteRecord()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get site record of %s: %w", host, err)
+	}
+
+	if inStorage {
+		return nil, ErrSiteUsesStorage
+	}
+
+	t.mx.Lock()
+	t.resolved[host] = resolvedHost{id: id, expire: time.Now().Add(_ResolveTTL)}
+	t.mx.Unlock()
+
+	return id, nil
+}
+
+// getSite - returns rldp connection with the site, it is searched in dht if we are not connected yet
+func (t *Transport) getSite(ctx context.Context, id []byte) (*rldp.RLDP, error) {
+	key := hex.EncodeToString(id)
+
+	t.mx.Lock()
+	client := t.sites[key]
+	t.mx.Unlock()
+
+	if client != nil {
+		return client, nil
+	}
+
+	addrs, pubKey, err := t.dht.FindAddresses(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find site address: %w", err)
+	}
+
+	if len(addrs.Addresses) == 0 {
+		return nil, errors.New("site has no addresses")
+	}
+	addr := addrs.Addresses[0]
+
+	peer, err := t.gateway.RegisterClient(net.JoinHostPort(addr.IP.String(), strconv.Itoa(int(addr.Port))), pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to site: %w", err)
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	// it could be connected concurrently
+	if client = t.sites[key]; client != nil {
+		return client, nil
+	}
+
+	client = rldp.NewClient(peer)
+	client.SetOnQuery(t.queryHandler(client))
+	peer.SetDisconnectHandler(func(addr string, key ed25519.PublicKey) {
+		t.dropSite(id, client)
+	})
+	t.sites[key] = client
+
+	return client, nil
+}
+
+func (t *Transport) dropSite(id []byte, client *rldp.RLDP) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if t.sites[hex.EncodeToString(id)] == client {
+		delete(t.sites, hex.EncodeToString(id))
+	}
+}
+
+// queryHandler - site pulls bodies of our requests
+func (t *Transport) queryHandler(client *rldp.RLDP) rldp.QueryHandler {
+	return func(transferID []byte, query *rldp.Query) error {
+		msg, err := parse(query.Data)
+		if err != nil {
+			return err
+		}
+
+		q, ok := msg.(getNextPayloadPart)
+		if !ok {
+			return fmt.Errorf("unexpected query %T", msg)
+		}
+
+		t.mx.Lock()
+		p := t.bodies[hex.EncodeToString(q.ID)]
+		t.mx.Unlock()
+
+		if p == nil {
+			return errors.New("unknown request")
+		}
+
+		part, _, err := p.next(q)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), _AnswerTimeout)
+		defer cancel()
+
+		return client.SendAnswer(ctx, query.MaxAnswerSize, transferID, query.ID, part)
+	}
+}
+
+// Close - closes connections with sites
+func (t *Transport) Close() {
+	t.mx.Lock()
+	sites := t.sites
+	t.sites = map[string]*rldp.RLDP{}
+	t.mx.Unlock()
+
+	for _, s := range sites {
+		s.Close()
+	}
+}
+
+// next - reads part of the body with requested seqno, serialized http.payloadPart is returned
+func (p *payload) next(q getNextPayloadPart) (_ []byte, last bool, err error) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	if q.Seqno != p.seqno {
+		return nil, false, fmt.Errorf("unexpected part seqno %d, want %d", q.Seqno, p.seqno)
+	}
+
+	size := int(q.MaxChunkSize)
+	if size <= 0 || size > _ChunkSize {
+		size = _ChunkSize
+	}
+
+	buf := make([]byte, size)
+
+	var n int
+	if p.partial {
+		// streamed data is sent as soon as it is written
+		for n == 0 && err == nil {
+			n, err = p.r.Read(buf)
+		}
+	} else {
+		n, err = io.ReadFull(p.r, buf)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+	}
+
+	if err != nil && err != io.EOF {
+		return nil, false, fmt.Errorf("failed to read body: %w", err)
+	}
+	p.seqno++
+	last = err == io.EOF
+
+	data, err := serialize(payloadPart{
+		Data:    buf[:n],
+		Trailer: []header{},
+		IsLast:  last,
+	})
+	return data, last, err
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	for len(b.buf) == 0 {
+		if b.closed {
+			return 0, errors.New("read on closed body")
+		}
+
+		if b.last {
+			return 0, io.EOF
+		}
+
+		if err := b.pull(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, b.buf)
+	b.buf = b.buf[n:]
+	return n, nil
+}
+
+// pull - requests next part of the body from the site
+func (b *responseBody) pull() error {
+	data, err := serialize(getNextPayloadPart{
+		ID:           b.id,
+		Seqno:        b.seqno,
+		MaxChunkSize: _ChunkSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	answer, err := b.r.DoQuery(b.ctx, _MaxAnswerSize, data)
+	if err != nil {
+		return fmt.Errorf("failed to get payload part: %w", err)
+	}
+
+	msg, err := parse(answer)
+	if err != nil {
+		return fmt.Errorf("failed to parse payload part: %w", err)
+	}
+
+	part, ok := msg.(payloadPart)
+	if !ok {
+		return fmt.Errorf("unexpected answer %T", msg)
+	}
+
+	if len(part.Data) > _ChunkSize {
+		return errors.New("too big payload part")
+	}
+
+	b.seqno++
+	b.buf = part.Data
+	b.last = part.IsLast
+
+	if b.last && len(part.Trailer) > 0 {
+		b.resp.Trailer = http.Header{}
+		for _, h := range part.Trailer {
+			b.resp.Trailer.Add(h.Name, h.Value)
+		}
+	}
+	return nil
+}
+
+func (b *responseBody) Close() error {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	b.closed = true
+	b.buf = nil
+	b.release()
+	return nil
+}
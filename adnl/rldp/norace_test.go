@@ -0,0 +1,5 @@
+//go:build !race
+
+package rldp
+
+const raceEnabled = false
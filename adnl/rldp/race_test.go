@@ -0,0 +1,6 @@
+//go:build race
+
+package rldp
+
+// raceEnabled - tests are run with race detector, which makes symbols processing much slower
+const raceEnabled = true
@@ -0,0 +1,93 @@
+package raptorq
+
+import (
+	"errors"
+	"fmt"
+)
+
+// _MaxExtraSymbols - how many symbols above source number we keep, more is never needed in practice
+const _MaxExtraSymbols = 32
+
+// Decoder - collects symbols and restores data from them
+type Decoder struct {
+	params   *params
+	dataSize int
+
+	symbols map[uint32][]byte
+	// sourceNum - number of received source symbols, when we have all of them no solving is needed
+	sourceNum uint32
+}
+
+// NewDecoder - creates decoder for data of dataSize, which is encoded with symbols of symbolSize
+func NewDecoder(dataSize, symbolSize int) (*Decoder, error) {
+	p, err := newParams(dataSize, symbolSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Decoder{
+		params:   p,
+		dataSize: dataSize,
+		symbols:  map[uint32][]byte{},
+	}, nil
+}
+
+// AddSymbol - remembers symbol, returns true when there are enough symbols to try to decode
+func (d *Decoder) AddSymbol(id uint32, data []byte) (bool, error) {
+	if len(data) != d.params.symbol {
+		return false, fmt.Errorf("incorrect symbol size %d, should be %d", len(data), d.params.symbol)
+	}
+
+	if _, ok := d.symbols[id]; !ok && uint32(len(d.symbols)) < d.params.K+_MaxExtraSymbols {
+		d.symbols[id] = append([]byte{}, data...)
+		if id < d.params.K {
+			d.sourceNum++
+		}
+	}
+
+	return uint32(len(d.symbols)) >= d.params.K, nil
+}
+
+// Decode - tries to restore data, false is returned when more symbols are needed
+func (d *Decoder) Decode() (bool, []byte, error) {
+	p := d.params
+	if uint32(len(d.symbols)) < p.K {
+		return false, nil, nil
+	}
+
+	if d.sourceNum < p.K {
+		ids := make([]uint32, 0, len(d.symbols)+int(p.KPad-p.K))
+		symbols := make([][]byte, 0, cap(ids))
+		for id, data := range d.symbols {
+			ids = append(ids, p.internalID(id))
+			symbols = append(symbols, data)
+		}
+
+		// padding symbols are known zeroes
+		for id := p.K; id < p.KPad; id++ {
+			ids = append(ids, id)
+			symbols = append(symbols, make([]byte, p.symbol))
+		}
+
+		intermediate, err := p.solve(ids, symbols)
+		if err != nil {
+			if errors.Is(err, ErrNotEnoughSymbols) {
+				return false, nil, nil
+			}
+			return false, nil, err
+		}
+
+		for id := uint32(0); id < p.K; id++ {
+			if _, ok := d.symbols[id]; !ok {
+				d.symbols[id] = p.genSymbol(intermediate, id)
+				d.sourceNum++
+			}
+		}
+	}
+
+	data := make([]byte, int(p.K)*p.symbol)
+	for id := uint32(0); id < p.K; id++ {
+		copy(data[int(id)*p.symbol:], d.symbols[id])
+	}
+	return true, data[:d.dataSize], nil
+}
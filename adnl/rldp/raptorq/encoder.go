@@ -0,0 +1,56 @@
+package raptorq
+
+import (
+	"fmt"
+)
+
+// Encoder - produces symbols of data, first BaseSymbolsNum symbols are parts of data as is,
+// next ones are repair symbols, any BaseSymbolsNum of them (with small overhead) are enough to restore data
+type Encoder struct {
+	params       *params
+	source       [][]byte
+	intermediate [][]byte
+}
+
+// NewEncoder - splits data to symbols of symbolSize and calculates intermediate symbols
+func NewEncoder(data []byte, symbolSize int) (*Encoder, error) {
+	p, err := newParams(len(data), symbolSize)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint32, p.KPad)
+	source := make([][]byte, p.KPad)
+	for i := range source {
+		ids[i] = uint32(i)
+		source[i] = make([]byte, symbolSize)
+
+		if off := i * symbolSize; off < len(data) {
+			copy(source[i], data[off:])
+		}
+	}
+
+	intermediate, err := p.solve(ids, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate intermediate symbols: %w", err)
+	}
+
+	return &Encoder{
+		params:       p,
+		source:       source[:p.K],
+		intermediate: intermediate,
+	}, nil
+}
+
+// GenSymbol - returns symbol with id
+func (e *Encoder) GenSymbol(id uint32) []byte {
+	if id < e.params.K {
+		return append([]byte{}, e.source[id]...)
+	}
+	return e.params.genSymbol(e.intermediate, e.params.internalID(id))
+}
+
+// BaseSymbolsNum - number of source symbols, at least this number of symbols is needed to decode
+func (e *Encoder) BaseSymbolsNum() uint32 {
+	return e.params.K
+}
@@ -0,0 +1,86 @@
+package raptorq
+
+import "encoding/binary"
+
+// octets are elements of GF(256) with reducing polynomial x^8 + x^4 + x^3 + x^2 + 1, as in RFC 6330 section 5.7
+
+var octExp [510]byte
+var octLog [256]int
+
+// octMulTable - full multiplication table, 64KB, makes symbol operations fast
+var octMulTable [256][256]byte
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		octExp[i] = byte(x)
+		octExp[i+255] = byte(x)
+		octLog[x] = i
+
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			octMulTable[a][b] = octExp[octLog[a]+octLog[b]]
+		}
+	}
+}
+
+func octMul(a, b byte) byte {
+	return octMulTable[a][b]
+}
+
+func octDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return octExp[octLog[a]-octLog[b]+255]
+}
+
+// octAlphaPow - alpha^i, alpha is 2
+func octAlphaPow(i int) byte {
+	return octExp[i%255]
+}
+
+// xorSymbol - dst ^= src, it is the hottest place of encoding, so it works with 8 bytes at once
+func xorSymbol(dst, src []byte) {
+	n := len(dst) &^ 7
+	for i := 0; i < n; i += 8 {
+		binary.LittleEndian.PutUint64(dst[i:], binary.LittleEndian.Uint64(dst[i:])^binary.LittleEndian.Uint64(src[i:]))
+	}
+	for i := n; i < len(dst); i++ {
+		dst[i] ^= src[i]
+	}
+}
+
+// mulAddSymbol - dst ^= src * m
+func mulAddSymbol(dst, src []byte, m byte) {
+	if m == 0 {
+		return
+	}
+	if m == 1 {
+		xorSymbol(dst, src)
+		return
+	}
+
+	t := &octMulTable[m]
+	for i := range dst {
+		dst[i] ^= t[src[i]]
+	}
+}
+
+// mulSymbol - dst *= m
+func mulSymbol(dst []byte, m byte) {
+	if m == 1 {
+		return
+	}
+
+	t := &octMulTable[m]
+	for i := range dst {
+		dst[i] = t[dst[i]]
+	}
+}
@@ -0,0 +1,166 @@
+package raptorq
+
+import (
+	"errors"
+)
+
+// MaxSourceSymbols - max number of source symbols in one block, RFC 6330 does not define more
+const MaxSourceSymbols = 56403
+
+var ErrTooBigData = errors.New("too big data for one block")
+
+var degreeDistribution = [...]uint32{
+	0, 5243, 529531, 704294, 791675, 844104, 879057, 904023, 922747, 937311, 948962,
+	958494, 966438, 973160, 978921, 983914, 988283, 992138, 995565, 998631, 1001391, 1003887,
+	1006157, 1008229, 1010129, 1011876, 1013490, 1014983, 1016370, 1017662, 1048576,
+}
+
+// params - parameters of block with K source symbols, names are as in RFC 6330
+type params struct {
+	K      uint32
+	KPad   uint32
+	J      uint32
+	S      uint32
+	H      uint32
+	W      uint32
+	L      uint32
+	P      uint32
+	P1     uint32
+	B      uint32
+	symbol int
+}
+
+// tuple - parameters of encoding row, RFC 6330 section 5.3.5.4
+type tuple struct {
+	d, a, b, d1, a1, b1 uint32
+}
+
+func newParams(dataSize, symbolSize int) (*params, error) {
+	if symbolSize <= 0 {
+		return nil, errors.New("symbol size should be positive")
+	}
+	if dataSize <= 0 {
+		return nil, errors.New("data size should be positive")
+	}
+
+	k := (dataSize + symbolSize - 1) / symbolSize
+	if k > MaxSourceSymbols {
+		return nil, ErrTooBigData
+	}
+
+	p := &params{K: uint32(k), symbol: symbolSize}
+	for _, row := range _SystematicIndices {
+		if row[0] >= p.K {
+			p.KPad, p.J, p.S, p.H, p.W = row[0], row[1], row[2], row[3], row[4]
+			break
+		}
+	}
+
+	p.L = p.KPad + p.S + p.H
+	p.P = p.L - p.W
+	p.B = p.W - p.S
+
+	// RFC says P1 >= P, but TON implementation takes prime strictly greater than P,
+	// we should produce the same symbols
+	p.P1 = p.P + 1
+	for !isPrime(p.P1) {
+		p.P1++
+	}
+	return p, nil
+}
+
+// random - Rand[y, i, m], RFC 6330 section 5.3.5.1
+func random(y, i, m uint32) uint32 {
+	return (_V0[(y+i)&0xFF] ^ _V1[((y>>8)+i)&0xFF] ^ _V2[((y>>16)+i)&0xFF] ^ _V3[((y>>24)+i)&0xFF]) % m
+}
+
+func (p *params) degree(v uint32) uint32 {
+	d := uint32(1)
+	for v >= degreeDistribution[d] {
+		d++
+	}
+
+	if d > p.W-2 {
+		return p.W - 2
+	}
+	return d
+}
+
+// tuple - generates encoding row parameters for internal symbol id
+func (p *params) tuple(x uint32) tuple {
+	a := 53591 + p.J*997
+	if a%2 == 0 {
+		a++
+	}
+	b := 10267 * (p.J + 1)
+	y := b + x*a
+
+	t := tuple{
+		d:  p.degree(random(y, 0, 1<<20)),
+		a:  1 + random(y, 1, p.W-1),
+		b:  random(y, 2, p.W),
+		d1: 2,
+		a1: 1 + random(x, 4, p.P1-1),
+		b1: random(x, 5, p.P1),
+	}
+	if t.d < 4 {
+		t.d1 = 2 + random(x, 3, 2)
+	}
+	return t
+}
+
+// columns - calls fn for each intermediate symbol which is used to produce symbol with internal id x,
+// RFC 6330 section 5.3.5.3
+func (p *params) columns(x uint32, fn func(col uint32)) {
+	t := p.tuple(x)
+
+	b := t.b
+	fn(b)
+	for j := uint32(1); j < t.d; j++ {
+		b = (b + t.a) % p.W
+		fn(b)
+	}
+
+	b1 := t.b1
+	for b1 >= p.P {
+		b1 = (b1 + t.a1) % p.P1
+	}
+	fn(p.W + b1)
+
+	for j := uint32(1); j < t.d1; j++ {
+		b1 = (b1 + t.a1) % p.P1
+		for b1 >= p.P {
+			b1 = (b1 + t.a1) % p.P1
+		}
+		fn(p.W + b1)
+	}
+}
+
+// internalID - converts encoding symbol id to internal one, repair symbols are shifted by padding symbols
+func (p *params) internalID(id uint32) uint32 {
+	if id < p.K {
+		return id
+	}
+	return id + p.KPad - p.K
+}
+
+// genSymbol - produces symbol with internal id from intermediate symbols
+func (p *params) genSymbol(intermediate [][]byte, x uint32) []byte {
+	res := make([]byte, p.symbol)
+	p.columns(x, func(col uint32) {
+		xorSymbol(res, intermediate[col])
+	})
+	return res
+}
+
+func isPrime(n uint32) bool {
+	if n < 2 {
+		return false
+	}
+	for i := uint32(2); i*i <= n; i++ {
+		if n%i == 0 {
+			return false
+		}
+	}
+	return true
+}
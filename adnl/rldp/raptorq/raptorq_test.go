@@ -0,0 +1,133 @@
+package raptorq
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	mrand "math/rand"
+	"testing"
+)
+
+func TestEncoder_KnownSymbol(t *testing.T) {
+	enc, err := NewEncoder([]byte("hello world bro! keke meme 881"), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// symbol which is produced by reference implementation
+	should, _ := hex.DecodeString("05e6ddeb1f820e0a0f318b23128d889623663e66")
+	if got := enc.GenSymbol(68238283); !bytes.Equal(got, should) {
+		t.Fatal("wrong symbol", hex.EncodeToString(got))
+	}
+}
+
+func TestDecoder_OnlyRepairSymbols(t *testing.T) {
+	data := []byte("hello world bro! keke meme 881")
+
+	enc, err := NewEncoder(data, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dec, err := NewDecoder(len(data), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id := uint32(10000); ; id++ {
+		can, err := dec.AddSymbol(id, enc.GenSymbol(id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !can {
+			continue
+		}
+
+		ok, res, err := dec.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			if !bytes.Equal(res, data) {
+				t.Fatal("decoded data is not equal")
+			}
+			return
+		}
+
+		if id > 10010 {
+			t.Fatal("too many symbols needed")
+		}
+	}
+}
+
+func TestDecoder_Losses(t *testing.T) {
+	for _, sz := range []int{1, 100, 768, 4096, 50000, 300000} {
+		data := make([]byte, sz)
+		_, _ = rand.Read(data)
+
+		enc, err := NewEncoder(data, 768)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dec, err := NewDecoder(len(data), 768)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// every symbol is lost with 30% probability
+		rnd := mrand.New(mrand.NewSource(int64(sz)))
+		var res []byte
+		for id := uint32(0); res == nil; id++ {
+			if rnd.Intn(10) < 3 {
+				continue
+			}
+
+			if id > enc.BaseSymbolsNum()*3 {
+				t.Fatal("too many symbols needed for size", sz)
+			}
+
+			can, err := dec.AddSymbol(id, enc.GenSymbol(id))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !can {
+				continue
+			}
+
+			ok, decoded, err := dec.Decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok {
+				res = decoded
+			}
+		}
+
+		if !bytes.Equal(res, data) {
+			t.Fatal("decoded data is not equal for size", sz)
+		}
+	}
+}
+
+func TestDecoder_WrongSymbolSize(t *testing.T) {
+	dec, err := NewDecoder(100, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = dec.AddSymbol(0, make([]byte, 19)); err == nil {
+		t.Fatal("symbol of wrong size should be rejected")
+	}
+}
+
+func BenchmarkEncoder_2MB(b *testing.B) {
+	data := make([]byte, 2<<20)
+	_, _ = rand.Read(data)
+
+	for i := 0; i < b.N; i++ {
+		if _, err := NewEncoder(data, 768); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
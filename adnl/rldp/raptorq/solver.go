@@ -0,0 +1,317 @@
+package raptorq
+
+import (
+	"errors"
+	"math/bits"
+)
+
+var ErrNotEnoughSymbols = errors.New("not enough symbols")
+
+// gf2Row - row of constraint matrix which has only 0 and 1 coefficients (LDPC and LT rows)
+type gf2Row struct {
+	bits []uint64
+	// cols - nonzero columns of the original row
+	cols []uint32
+	data []byte
+}
+
+func (r *gf2Row) has(col uint32) bool {
+	return r.bits[col/64]&(1<<(col%64)) != 0
+}
+
+func (r *gf2Row) set(col uint32) {
+	if !r.has(col) {
+		r.bits[col/64] |= 1 << (col % 64)
+		r.cols = append(r.cols, col)
+	}
+}
+
+// forEach - calls fn for each nonzero column of the current row
+func (r *gf2Row) forEach(fn func(col uint32)) {
+	for i, w := range r.bits {
+		for w != 0 {
+			fn(uint32(i*64 + bits.TrailingZeros64(w)))
+			w &= w - 1
+		}
+	}
+}
+
+// solve - calculates L intermediate symbols from known symbols with internal ids,
+// RFC 6330 section 5.4 with inactivation decoding.
+//
+// First we peel rows with the least number of not processed columns, other columns of such row are inactivated.
+// Processed part of matrix never gets new nonzeros, so it stays sparse and peeling is cheap.
+// Then small dense system of inactive columns is solved by gaussian elimination,
+// and values of peeled columns are calculated back from it.
+func (p *params) solve(ids []uint32, symbols [][]byte) ([][]byte, error) {
+	if uint32(len(ids))+p.S+p.H < p.L {
+		return nil, ErrNotEnoughSymbols
+	}
+
+	words := int(p.L+63) / 64
+	newRow := func(data []byte) *gf2Row {
+		return &gf2Row{bits: make([]uint64, words), data: data}
+	}
+
+	rows := make([]*gf2Row, 0, int(p.S)+len(ids))
+
+	// LDPC rows
+	for i := uint32(0); i < p.S; i++ {
+		rows = append(rows, newRow(make([]byte, p.symbol)))
+	}
+	for i := uint32(0); i < p.B; i++ {
+		a := 1 + i/p.S
+		b := i % p.S
+		rows[b].set(i)
+		b = (b + a) % p.S
+		rows[b].set(i)
+		b = (b + a) % p.S
+		rows[b].set(i)
+	}
+	for i := uint32(0); i < p.S; i++ {
+		rows[i].set(p.B + i)
+		rows[i].set(p.W + i%p.P)
+		rows[i].set(p.W + (i+1)%p.P)
+	}
+
+	// LT rows
+	for i, id := range ids {
+		r := newRow(append([]byte{}, symbols[i]...))
+		p.columns(id, r.set)
+		rows = append(rows, r)
+	}
+
+	hdpc, hdpcData := p.hdpcRows()
+
+	colRows := make([][]int32, p.L)
+	for i, r := range rows {
+		for _, c := range r.cols {
+			colRows[c] = append(colRows[c], int32(i))
+		}
+	}
+
+	// PI columns are inactive from the start
+	active := make([]bool, p.L)
+	for c := uint32(0); c < p.W; c++ {
+		active[c] = true
+	}
+
+	degree := make([]int, len(rows))
+	buckets := make([][]int32, p.L+1)
+	for i, r := range rows {
+		for _, c := range r.cols {
+			if active[c] {
+				degree[i]++
+			}
+		}
+		buckets[degree[i]] = append(buckets[degree[i]], int32(i))
+	}
+
+	done := make([]bool, len(rows))
+	decrease := func(col uint32) {
+		for _, ri := range colRows[col] {
+			if !done[ri] && rows[ri].has(col) {
+				degree[ri]--
+				buckets[degree[ri]] = append(buckets[degree[ri]], ri)
+			}
+		}
+	}
+
+	var pivotRows []int32
+	var pivotCols []uint32
+
+	for {
+		// find not processed row with the least active columns, bucket can have stale entries
+		chosen := int32(-1)
+		for d := 1; d < len(buckets) && chosen < 0; d++ {
+			for len(buckets[d]) > 0 {
+				ri := buckets[d][len(buckets[d])-1]
+				buckets[d] = buckets[d][:len(buckets[d])-1]
+
+				if !done[ri] && degree[ri] == d {
+					chosen = ri
+					break
+				}
+			}
+		}
+
+		if chosen < 0 {
+			break
+		}
+
+		r := rows[chosen]
+		done[chosen] = true
+
+		pivot := uint32(0)
+		first := true
+		for _, c := range r.cols {
+			if !active[c] {
+				continue
+			}
+
+			active[c] = false
+			if first {
+				pivot, first = c, false
+				continue
+			}
+			// other active columns of the row become inactive
+			decrease(c)
+		}
+
+		for _, ri := range colRows[pivot] {
+			if done[ri] || !rows[ri].has(pivot) {
+				continue
+			}
+
+			o := rows[ri]
+			for w := range o.bits {
+				o.bits[w] ^= r.bits[w]
+			}
+			xorSymbol(o.data, r.data)
+
+			degree[ri]--
+			buckets[degree[ri]] = append(buckets[degree[ri]], ri)
+		}
+
+		for hi, h := range hdpc {
+			if m := h[pivot]; m != 0 {
+				r.forEach(func(col uint32) {
+					h[col] ^= m
+				})
+				mulAddSymbol(hdpcData[hi], r.data, m)
+			}
+		}
+
+		pivotRows = append(pivotRows, chosen)
+		pivotCols = append(pivotCols, pivot)
+	}
+
+	// all not peeled columns are inactive now
+	var inactive []uint32
+	index := make([]int, p.L)
+	for c := uint32(0); c < p.L; c++ {
+		index[c] = -1
+	}
+	peeled := make([]bool, p.L)
+	for _, c := range pivotCols {
+		peeled[c] = true
+	}
+	for c := uint32(0); c < p.L; c++ {
+		if !peeled[c] {
+			index[c] = len(inactive)
+			inactive = append(inactive, c)
+		}
+	}
+
+	// dense system from not peeled rows and hdpc rows, they have nonzeros only in inactive columns
+	var dense [][]byte
+	var denseData [][]byte
+	for i, r := range rows {
+		if done[i] {
+			continue
+		}
+
+		coef := make([]byte, len(inactive))
+		r.forEach(func(col uint32) {
+			coef[index[col]] = 1
+		})
+		dense = append(dense, coef)
+		denseData = append(denseData, r.data)
+	}
+	for i, h := range hdpc {
+		coef := make([]byte, len(inactive))
+		for j, c := range inactive {
+			coef[j] = h[c]
+		}
+		dense = append(dense, coef)
+		denseData = append(denseData, hdpcData[i])
+	}
+
+	if err := gaussJordan(dense, denseData, len(inactive)); err != nil {
+		return nil, err
+	}
+
+	res := make([][]byte, p.L)
+	for j, c := range inactive {
+		res[c] = denseData[j]
+	}
+
+	// peeled row has only its pivot column and inactive columns
+	for i, ri := range pivotRows {
+		r := rows[ri]
+		pivot := pivotCols[i]
+
+		r.forEach(func(col uint32) {
+			if col != pivot {
+				xorSymbol(r.data, res[col])
+			}
+		})
+		res[pivot] = r.data
+	}
+
+	return res, nil
+}
+
+// hdpcRows - H rows of G_HDPC = MT * GAMMA with identity part, RFC 6330 section 5.3.3.3
+func (p *params) hdpcRows() ([][]byte, [][]byte) {
+	ks := p.KPad + p.S
+
+	mt := make([][]byte, p.H)
+	for i := range mt {
+		mt[i] = make([]byte, p.L)
+		mt[i][ks-1] = octAlphaPow(i)
+	}
+	for j := uint32(0); j < ks-1; j++ {
+		a := random(j+1, 6, p.H)
+		b := (a + random(j+1, 7, p.H-1) + 1) % p.H
+		mt[a][j] = 1
+		mt[b][j] = 1
+	}
+
+	data := make([][]byte, p.H)
+	for i, row := range mt {
+		// multiplication by GAMMA: g[j] = mt[j] + alpha * g[j+1]
+		for j := int(ks) - 2; j >= 0; j-- {
+			row[j] ^= octMul(2, row[j+1])
+		}
+		row[ks+uint32(i)] = 1
+		data[i] = make([]byte, p.symbol)
+	}
+	return mt, data
+}
+
+// gaussJordan - solves dense system over GF(256), after it first cols rows contain values of variables
+func gaussJordan(coef, data [][]byte, cols int) error {
+	for c := 0; c < cols; c++ {
+		pivot := -1
+		for r := c; r < len(coef); r++ {
+			if coef[r][c] != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot < 0 {
+			return ErrNotEnoughSymbols
+		}
+
+		coef[c], coef[pivot] = coef[pivot], coef[c]
+		data[c], data[pivot] = data[pivot], data[c]
+
+		if m := coef[c][c]; m != 1 {
+			inv := octDiv(1, m)
+			mulSymbol(coef[c][c:], inv)
+			mulSymbol(data[c], inv)
+		}
+
+		for r := range coef {
+			if r == c {
+				continue
+			}
+			if m := coef[r][c]; m != 0 {
+				mulAddSymbol(coef[r][c:], coef[c][c:], m)
+				mulAddSymbol(data[r], data[c], m)
+			}
+		}
+	}
+	return nil
+}
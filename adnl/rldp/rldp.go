@@ -0,0 +1,564 @@
+package rldp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/rldp/raptorq"
+)
+
+const (
+	_SymbolSize = 768
+	// _PartSize - transfer is split to parts of this size, each part is encoded separately
+	_PartSize = 2000000
+	// _MaxSymbolSize - we do not accept bigger symbols from peers
+	_MaxSymbolSize = 4096
+	// _MaxQuerySize - max size of incoming transfer which we did not expect, like query from peer
+	_MaxQuerySize = 16 << 20
+
+	// _Window - how many symbols sender can send above confirmed seqno
+	_Window = 256
+	// _ConfirmEvery - receiver confirms each n-th symbol
+	_ConfirmEvery = 16
+	// _ResendInterval - if there are no confirms during this time, sender sends more symbols,
+	// because symbols or confirms could be lost
+	_ResendInterval = 20 * time.Millisecond
+
+	// _FinishedTTL - how long we remember finished transfers, to answer complete to late symbols
+	_FinishedTTL = 30 * time.Second
+	// _IdleTTL - not finished transfer without new symbols during this time is dropped
+	_IdleTTL = 60 * time.Second
+	// _DefaultTimeout - timeout of query if context has no deadline
+	_DefaultTimeout = 15 * time.Second
+)
+
+var ErrClosed = errors.New("rldp closed")
+
+// ADNL - unreliable transport which rldp works over, adnl.Peer implements it
+type ADNL interface {
+	SendCustomMessage(data []byte) error
+	SetCustomMessageHandler(handler adnl.CustomMessageHandler)
+	Close()
+}
+
+// QueryHandler - processes query of the peer, answer should be sent using RLDP.SendAnswer with transfer id
+type QueryHandler func(transferID []byte, query *Query) error
+
+type recvTransfer struct {
+	mx sync.Mutex
+
+	totalSize int64
+	data      []byte
+
+	part      int32
+	decoder   *raptorq.Decoder
+	fec       fecRaptorQ
+	maxSeqno  int32
+	received  int32
+	confirmed int32
+	// receivedMask - bit i is set when symbol maxSeqno-i is received
+	receivedMask uint32
+
+	finished   bool
+	lastUpdate time.Time
+}
+
+type sendTransfer struct {
+	mx        sync.Mutex
+	part      int32
+	confirmed int32
+	completed bool
+	signal    chan struct{}
+}
+
+// RLDP - reliable large datagrams over adnl. Data is split to parts, each part is encoded with RaptorQ,
+// and symbols are sent until receiver says that it decoded the part.
+type RLDP struct {
+	adnl  ADNL
+	useV2 bool
+
+	mx        sync.Mutex
+	recv      map[string]*recvTransfer
+	send      map[string]*sendTransfer
+	queries   map[string]chan *Answer
+	expected  map[string]int64
+	onQuery   QueryHandler
+	closed    bool
+	lastSweep time.Time
+}
+
+// NewClient - creates rldp over transport, it takes custom messages handler of the transport
+func NewClient(a ADNL) *RLDP {
+	r := &RLDP{
+		adnl:     a,
+		recv:     map[string]*recvTransfer{},
+		send:     map[string]*sendTransfer{},
+		queries:  map[string]chan *Answer{},
+		expected: map[string]int64{},
+	}
+	a.SetCustomMessageHandler(r.handleMessage)
+	return r
+}
+
+// NewClientV2 - creates rldp which sends transfers using second version of protocol, it is used by storage nodes.
+// Transfers of both versions are accepted, control messages are answered with the version of transfer.
+func NewClientV2(a ADNL) *RLDP {
+	r := NewClient(a)
+	r.useV2 = true
+	return r
+}
+
+// SetOnQuery - sets handler of incoming queries
+func (r *RLDP) SetOnQuery(handler QueryHandler) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.onQuery = handler
+}
+
+// Close - closes transport, waiting queries are failed
+func (r *RLDP) Close() {
+	r.mx.Lock()
+	if r.closed {
+		r.mx.Unlock()
+		return
+	}
+	r.closed = true
+
+	for id, ch := range r.queries {
+		close(ch)
+		delete(r.queries, id)
+	}
+	r.mx.Unlock()
+
+	r.adnl.Close()
+}
+
+// DoQuery - sends query and waits for answer, answer bigger than maxAnswerSize is not accepted
+func (r *RLDP) DoQuery(ctx context.Context, maxAnswerSize int64, query []byte) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, _DefaultTimeout)
+		defer cancel()
+	}
+	deadline, _ := ctx.Deadline()
+
+	queryID, transferID := make([]byte, 32), make([]byte, 32)
+	if _, err := rand.Read(queryID); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(transferID); err != nil {
+		return nil, err
+	}
+
+	data, err := serializeMessage(Query{
+		ID:            queryID,
+		MaxAnswerSize: maxAnswerSize,
+		Timeout:       int32(deadline.Unix()),
+		Data:          query,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *Answer, 1)
+	answerTransfer := hex.EncodeToString(reverseTransferID(transferID))
+
+	r.mx.Lock()
+	if r.closed {
+		r.mx.Unlock()
+		return nil, ErrClosed
+	}
+	r.queries[hex.EncodeToString(queryID)] = ch
+	r.expected[answerTransfer] = maxAnswerSize
+	r.mx.Unlock()
+
+	defer func() {
+		r.mx.Lock()
+		delete(r.queries, hex.EncodeToString(queryID))
+		delete(r.expected, answerTransfer)
+		r.mx.Unlock()
+	}()
+
+	sendCtx, cancelSend := context.WithCancel(ctx)
+	defer cancelSend()
+
+	sendErr := make(chan error, 1)
+	go func() {
+		sendErr <- r.sendTransfer(sendCtx, transferID, data)
+	}()
+
+	for {
+		select {
+		case ans, ok := <-ch:
+			if !ok {
+				return nil, ErrClosed
+			}
+			return ans.Data, nil
+		case err = <-sendErr:
+			if err != nil && !errors.Is(err, context.Canceled) {
+				return nil, fmt.Errorf("failed to send query: %w", err)
+			}
+			// query is delivered, waiting for answer
+			sendErr = nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// SendAnswer - sends answer to the query which was received in transfer
+func (r *RLDP) SendAnswer(ctx context.Context, maxAnswerSize int64, transferID, queryID, answer []byte) error {
+	if int64(len(answer)) > maxAnswerSize {
+		return fmt.Errorf("answer is too big: %d, max %d", len(answer), maxAnswerSize)
+	}
+
+	data, err := serializeMessage(Answer{ID: queryID, Data: answer})
+	if err != nil {
+		return err
+	}
+
+	return r.sendTransfer(ctx, reverseTransferID(transferID), data)
+}
+
+// sendTransfer - sends data part by part, returns when all parts are completed by receiver
+func (r *RLDP) sendTransfer(ctx context.Context, transferID, data []byte) error {
+	st := &sendTransfer{signal: make(chan struct{}, 1)}
+	key := hex.EncodeToString(transferID)
+
+	r.mx.Lock()
+	if r.closed {
+		r.mx.Unlock()
+		return ErrClosed
+	}
+	r.send[key] = st
+	r.mx.Unlock()
+
+	defer func() {
+		r.mx.Lock()
+		delete(r.send, key)
+		r.mx.Unlock()
+	}()
+
+	for part, offset := int32(0), 0; offset < len(data); part, offset = part+1, offset+_PartSize {
+		partData := data[offset:]
+		if len(partData) > _PartSize {
+			partData = partData[:_PartSize]
+		}
+
+		enc, err := raptorq.NewEncoder(partData, _SymbolSize)
+		if err != nil {
+			return fmt.Errorf("failed to create encoder for part %d: %w", part, err)
+		}
+
+		st.mx.Lock()
+		st.part, st.confirmed, st.completed = part, 0, false
+		st.mx.Unlock()
+
+		msg := messagePart{
+			V2:         r.useV2,
+			TransferID: transferID,
+			FEC: fecRaptorQ{
+				DataSize:     int32(len(partData)),
+				SymbolSize:   _SymbolSize,
+				SymbolsCount: int32(enc.BaseSymbolsNum()),
+			},
+			Part:      part,
+			TotalSize: int64(len(data)),
+		}
+
+		// small parts do not need the whole window, receiver decodes them from a few symbols
+		window := int32(_Window)
+		if sz := msg.FEC.SymbolsCount + _ConfirmEvery; sz < window {
+			window = sz
+		}
+
+		extra := int32(0)
+		for seqno := int32(0); ; {
+			st.mx.Lock()
+			completed, confirmed := st.completed, st.confirmed
+			st.mx.Unlock()
+
+			if completed {
+				break
+			}
+
+			if seqno < confirmed+window+extra {
+				msg.Seqno = seqno
+				msg.Data = enc.GenSymbol(uint32(seqno))
+
+				if err = r.sendMessage(msg); err != nil {
+					return fmt.Errorf("failed to send symbol %d of part %d: %w", seqno, part, err)
+				}
+				seqno++
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-st.signal:
+			case <-time.After(_ResendInterval):
+				// nothing is confirmed for a while, something is lost
+				extra += _ConfirmEvery
+			}
+		}
+	}
+	return nil
+}
+
+func (r *RLDP) sendMessage(msg any) error {
+	data, err := serializeMessage(msg)
+	if err != nil {
+		return err
+	}
+	return r.adnl.SendCustomMessage(data)
+}
+
+func (r *RLDP) handleMessage(msg *adnl.MessageCustom) error {
+	m, err := parseMessage(msg.Data)
+	if err != nil {
+		return err
+	}
+
+	switch m := m.(type) {
+	case messagePart:
+		return r.handlePart(m)
+	case confirm:
+		r.updateSend(m.TransferID, m.Part, func(st *sendTransfer) {
+			if m.Seqno+1 > st.confirmed {
+				st.confirmed = m.Seqno + 1
+			}
+		})
+	case complete:
+		r.updateSend(m.TransferID, m.Part, func(st *sendTransfer) {
+			st.completed = true
+		})
+	default:
+		return fmt.Errorf("unexpected message type %T", m)
+	}
+	return nil
+}
+
+func (r *RLDP) updateSend(transferID []byte, part int32, fn func(st *sendTransfer)) {
+	r.mx.Lock()
+	st := r.send[hex.EncodeToString(transferID)]
+	r.mx.Unlock()
+
+	if st == nil {
+		return
+	}
+
+	st.mx.Lock()
+	if st.part == part {
+		fn(st)
+	}
+	st.mx.Unlock()
+
+	select {
+	case st.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (r *RLDP) handlePart(m messagePart) error {
+	if m.TotalSize <= 0 || m.Seqno < 0 || m.Part < 0 {
+		return errors.New("invalid message part")
+	}
+
+	t, err := r.getRecvTransfer(m.TransferID, m.TotalSize)
+	if err != nil {
+		return err
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	if m.TotalSize != t.totalSize {
+		return errors.New("total size of transfer is changed")
+	}
+
+	if t.finished || m.Part < t.part {
+		// sender did not get our complete, repeat it
+		return r.sendMessage(complete{V2: m.V2, TransferID: m.TransferID, Part: m.Part})
+	}
+
+	if m.Part > t.part {
+		return nil
+	}
+	t.lastUpdate = time.Now()
+
+	if t.decoder == nil {
+		if m.FEC.DataSize <= 0 || int64(m.FEC.DataSize) > t.totalSize-int64(len(t.data)) ||
+			m.FEC.SymbolSize <= 0 || m.FEC.SymbolSize > _MaxSymbolSize {
+			return errors.New("invalid fec parameters")
+		}
+
+		if t.decoder, err = raptorq.NewDecoder(int(m.FEC.DataSize), int(m.FEC.SymbolSize)); err != nil {
+			return fmt.Errorf("failed to create decoder: %w", err)
+		}
+		t.fec = m.FEC
+		t.maxSeqno, t.received, t.confirmed, t.receivedMask = 0, 0, 0, 0
+	} else if m.FEC != t.fec {
+		return errors.New("fec parameters of part are changed")
+	}
+
+	canDecode, err := t.decoder.AddSymbol(uint32(m.Seqno), m.Data)
+	if err != nil {
+		return err
+	}
+
+	t.received++
+	if m.Seqno > t.maxSeqno {
+		shift := m.Seqno - t.maxSeqno
+		if shift >= 32 {
+			t.receivedMask = 0
+		} else {
+			t.receivedMask <<= uint(shift)
+		}
+		t.maxSeqno = m.Seqno
+	}
+	if diff := t.maxSeqno - m.Seqno; diff < 32 {
+		t.receivedMask |= 1 << uint(diff)
+	}
+
+	if canDecode {
+		ok, data, err := t.decoder.Decode()
+		if err != nil {
+			return fmt.Errorf("failed to decode part: %w", err)
+		}
+
+		if ok {
+			t.data = append(t.data, data...)
+			t.decoder = nil
+			t.part++
+
+			if err = r.sendMessage(complete{V2: m.V2, TransferID: m.TransferID, Part: m.Part}); err != nil {
+				return err
+			}
+
+			if int64(len(t.data)) == t.totalSize {
+				t.finished = true
+				data, t.data = t.data, nil
+				go r.processTransfer(m.TransferID, data)
+			}
+			return nil
+		}
+	}
+
+	if t.received-t.confirmed >= _ConfirmEvery {
+		t.confirmed = t.received
+		return r.sendMessage(confirm{
+			V2:            m.V2,
+			TransferID:    m.TransferID,
+			Part:          m.Part,
+			Seqno:         t.maxSeqno,
+			ReceivedMask:  int32(t.receivedMask),
+			ReceivedCount: t.received,
+		})
+	}
+	return nil
+}
+
+// getRecvTransfer - returns transfer or creates new one, answers are limited by the size which we expect
+func (r *RLDP) getRecvTransfer(transferID []byte, totalSize int64) (*recvTransfer, error) {
+	key := hex.EncodeToString(transferID)
+
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if r.closed {
+		return nil, ErrClosed
+	}
+
+	if t := r.recv[key]; t != nil {
+		return t, nil
+	}
+
+	maxSize := int64(_MaxQuerySize)
+	if sz, ok := r.expected[key]; ok {
+		// answer contains rldp.answer with query id and bytes
+		maxSize = sz + 64
+	}
+
+	if totalSize > maxSize {
+		return nil, fmt.Errorf("transfer is too big: %d, max %d", totalSize, maxSize)
+	}
+
+	r.sweep()
+
+	t := &recvTransfer{
+		totalSize:  totalSize,
+		lastUpdate: time.Now(),
+	}
+	r.recv[key] = t
+
+	return t, nil
+}
+
+// sweep - forgets old transfers, should be called under lock
+func (r *RLDP) sweep() {
+	now := time.Now()
+	if now.Sub(r.lastSweep) < time.Second {
+		return
+	}
+	r.lastSweep = now
+
+	for key, t := range r.recv {
+		t.mx.Lock()
+		ttl := _IdleTTL
+		if t.finished {
+			ttl = _FinishedTTL
+		}
+		expired := now.Sub(t.lastUpdate) > ttl
+		t.mx.Unlock()
+
+		if expired {
+			delete(r.recv, key)
+		}
+	}
+}
+
+func (r *RLDP) processTransfer(transferID, data []byte) {
+	msg, err := parseMessage(data)
+	if err != nil {
+		return
+	}
+
+	switch m := msg.(type) {
+	case Query:
+		r.mx.Lock()
+		handler := r.onQuery
+		r.mx.Unlock()
+
+		if handler != nil {
+			_ = handler(transferID, &m)
+		}
+	case Answer:
+		r.mx.Lock()
+		ch := r.queries[hex.EncodeToString(m.ID)]
+		if ch != nil {
+			delete(r.queries, hex.EncodeToString(m.ID))
+		}
+		r.mx.Unlock()
+
+		if ch != nil {
+			ch <- &m
+		}
+	}
+}
+
+// reverseTransferID - answer is sent in transfer with inverted bits of query transfer id
+func reverseTransferID(id []byte) []byte {
+	res := make([]byte, len(id))
+	for i := range id {
+		res[i] = id[i] ^ 0xFF
+	}
+	return res
+}
@@ -0,0 +1,329 @@
+package rldp
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	mrand "math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+)
+
+// loopback - in memory transport, messages are delivered to the other side in order,
+// some of them can be dropped to emulate udp
+type loopback struct {
+	mx      sync.Mutex
+	other   *loopback
+	handler adnl.CustomMessageHandler
+	rnd     *mrand.Rand
+	// lossPercent - percent of messages which are dropped
+	lossPercent int
+	closed      bool
+
+	// queue - messages for handler of this side, when it is full messages are dropped like by udp socket
+	queue chan []byte
+	stop  chan struct{}
+}
+
+func newLoopbackPair(lossPercent int) (*loopback, *loopback) {
+	a, b := newLoopback(1, lossPercent), newLoopback(2, lossPercent)
+	a.other, b.other = b, a
+	return a, b
+}
+
+func newLoopback(seed int64, lossPercent int) *loopback {
+	l := &loopback{
+		rnd:         mrand.New(mrand.NewSource(seed)),
+		lossPercent: lossPercent,
+		queue:       make(chan []byte, 4096),
+		stop:        make(chan struct{}),
+	}
+	go l.deliver()
+	return l
+}
+
+func (l *loopback) deliver() {
+	for {
+		select {
+		case <-l.stop:
+			return
+		case data := <-l.queue:
+			l.mx.Lock()
+			handler := l.handler
+			l.mx.Unlock()
+
+			if handler != nil {
+				_ = handler(&adnl.MessageCustom{Data: data})
+			}
+		}
+	}
+}
+
+func (l *loopback) SendCustomMessage(data []byte) error {
+	l.mx.Lock()
+	if l.closed {
+		l.mx.Unlock()
+		return errors.New("closed")
+	}
+	drop := l.rnd.Intn(100) < l.lossPercent
+	l.mx.Unlock()
+
+	if drop {
+		return nil
+	}
+
+	select {
+	case l.other.queue <- append([]byte{}, data...):
+	default:
+	}
+	return nil
+}
+
+func (l *loopback) SetCustomMessageHandler(handler adnl.CustomMessageHandler) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.handler = handler
+}
+
+func (l *loopback) Close() {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	if !l.closed {
+		l.closed = true
+		close(l.stop)
+	}
+}
+
+// startEchoServer - answers each query with its data repeated n times
+func startEchoServer(srv *RLDP, n int) {
+	srv.SetOnQuery(func(transferID []byte, query *Query) error {
+		return srv.SendAnswer(context.Background(), query.MaxAnswerSize, transferID, query.ID, bytes.Repeat(query.Data, n))
+	})
+}
+
+func TestRLDP_Query(t *testing.T) {
+	a, b := newLoopbackPair(0)
+	cli, srv := NewClient(a), NewClient(b)
+	defer cli.Close()
+	defer srv.Close()
+
+	startEchoServer(srv, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := cli.DoQuery(ctx, 1<<10, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(resp) != "hello" {
+		t.Fatal("wrong answer", string(resp))
+	}
+}
+
+func TestRLDP_QueryV2(t *testing.T) {
+	a, b := newLoopbackPair(10)
+	// server answers with the first version, to check that both are understood
+	cli, srv := NewClientV2(a), NewClient(b)
+	defer cli.Close()
+	defer srv.Close()
+
+	startEchoServer(srv, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := make([]byte, 100000)
+	_, _ = rand.Read(query)
+
+	resp, err := cli.DoQuery(ctx, 1<<20, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(resp, bytes.Repeat(query, 3)) {
+		t.Fatal("wrong answer")
+	}
+}
+
+func TestRLDP_BigQueryAndAnswer(t *testing.T) {
+	cases := []struct {
+		loss int
+		size int
+	}{
+		{loss: 0, size: 3 << 20},
+		{loss: 20, size: 1 << 20},
+	}
+
+	if raceEnabled || testing.Short() {
+		// answer is still bigger than one part
+		cases = []struct {
+			loss int
+			size int
+		}{
+			{loss: 0, size: 1 << 20},
+			{loss: 20, size: 256 << 10},
+		}
+	}
+
+	timeout := 30 * time.Second
+	if deadline, ok := t.Deadline(); ok {
+		timeout = time.Until(deadline) / time.Duration(len(cases)+1)
+	}
+
+	for _, tc := range cases {
+		loss := tc.loss
+		a, b := newLoopbackPair(loss)
+		cli, srv := NewClient(a), NewClient(b)
+
+		// answer is 2 times bigger than query, big ones are split to several parts
+		startEchoServer(srv, 2)
+
+		query := make([]byte, tc.size)
+		_, _ = rand.Read(query)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		resp, err := cli.DoQuery(ctx, 8<<20, query)
+		cancel()
+		if err != nil {
+			t.Fatal("loss", loss, err)
+		}
+
+		if !bytes.Equal(resp, bytes.Repeat(query, 2)) {
+			t.Fatal("wrong answer with loss", loss)
+		}
+
+		cli.Close()
+		srv.Close()
+	}
+}
+
+func TestRLDP_AnswerTooBig(t *testing.T) {
+	a, b := newLoopbackPair(0)
+	cli, srv := NewClient(a), NewClient(b)
+	defer cli.Close()
+	defer srv.Close()
+
+	answerErr := make(chan error, 1)
+	srv.SetOnQuery(func(transferID []byte, query *Query) error {
+		err := srv.SendAnswer(context.Background(), query.MaxAnswerSize, transferID, query.ID, make([]byte, 2000))
+		answerErr <- err
+		return err
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	if _, err := cli.DoQuery(ctx, 1000, []byte("x")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatal("query should time out, got", err)
+	}
+
+	if err := <-answerErr; err == nil {
+		t.Fatal("too big answer should not be sent")
+	}
+}
+
+func TestRLDP_OverADNL(t *testing.T) {
+	_, srvKey, _ := ed25519.GenerateKey(nil)
+	_, cliKey, _ := ed25519.GenerateKey(nil)
+
+	srvGate := adnl.NewGateway(srvKey)
+	if err := srvGate.StartServer("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	defer srvGate.Close()
+
+	srvGate.SetConnectionHandler(func(peer *adnl.Peer) error {
+		startEchoServer(NewClient(peer), 1)
+		return nil
+	})
+
+	cliGate := adnl.NewGateway(cliKey)
+	if err := cliGate.StartClient(); err != nil {
+		t.Fatal(err)
+	}
+	defer cliGate.Close()
+
+	peer, err := cliGate.RegisterClient(srvGate.Addr().String(), srvKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := NewClient(peer)
+
+	query := make([]byte, 500000)
+	_, _ = rand.Read(query)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	resp, err := cli.DoQuery(ctx, 1<<20, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(resp, query) {
+		t.Fatal("wrong answer")
+	}
+}
+
+func TestTL_MessagePart(t *testing.T) {
+	m := messagePart{
+		TransferID: bytes.Repeat([]byte{1}, 32),
+		FEC:        fecRaptorQ{DataSize: 100, SymbolSize: 768, SymbolsCount: 1},
+		Part:       2,
+		TotalSize:  4000100,
+		Seqno:      5,
+		Data:       []byte{1, 2, 3},
+	}
+
+	data, err := serializeMessage(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parseMessage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, ok := parsed.(messagePart)
+	if !ok || !bytes.Equal(p.TransferID, m.TransferID) || p.FEC != m.FEC || p.Part != m.Part ||
+		p.TotalSize != m.TotalSize || p.Seqno != m.Seqno || !bytes.Equal(p.Data, m.Data) {
+		t.Fatal("message part is not equal after parse", parsed)
+	}
+}
+
+func TestTL_ConfirmV2(t *testing.T) {
+	m := confirm{
+		V2:            true,
+		TransferID:    bytes.Repeat([]byte{2}, 32),
+		Part:          1,
+		Seqno:         77,
+		ReceivedMask:  -5,
+		ReceivedCount: 70,
+	}
+
+	data, err := serializeMessage(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parseMessage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, ok := parsed.(confirm)
+	if !ok || !bytes.Equal(c.TransferID, m.TransferID) || c.V2 != m.V2 || c.Part != m.Part || c.Seqno != m.Seqno ||
+		c.ReceivedMask != m.ReceivedMask || c.ReceivedCount != m.ReceivedCount {
+		t.Fatal("confirm is not equal after parse", parsed)
+	}
+}
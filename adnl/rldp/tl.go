@@ -0,0 +1,193 @@
+package rldp
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+const (
+	_Query   int32 = -1971761815
+	_Answer  int32 = -1543742461
+	_Message int32 = 2098973982
+
+	_MessagePart int32 = 408691404
+	_Confirm     int32 = -175973288
+	_Complete    int32 = -1140018497
+
+	_MessagePartV2 int32 = 289934190
+	_ConfirmV2     int32 = 602315077
+	_CompleteV2    int32 = 918095903
+
+	_FECRaptorQ int32 = -1953257504
+)
+
+// Query - rldp.query, it is sent in transfer, answer is sent back in transfer with inverted id
+type Query struct {
+	ID            []byte
+	MaxAnswerSize int64
+	Timeout       int32
+	Data          []byte
+}
+
+// Answer - rldp.answer to query with the same id
+type Answer struct {
+	ID   []byte
+	Data []byte
+}
+
+// fecRaptorQ - fec.raptorQ, describes how one part of transfer is encoded
+type fecRaptorQ struct {
+	DataSize     int32
+	SymbolSize   int32
+	SymbolsCount int32
+}
+
+// messagePart - rldp.messagePart or rldp2.messagePart, carries one symbol of transfer part
+type messagePart struct {
+	V2         bool
+	TransferID []byte
+	FEC        fecRaptorQ
+	Part       int32
+	TotalSize  int64
+	Seqno      int32
+	Data       []byte
+}
+
+// confirm - rldp.confirm or rldp2.confirm, receiver tells max seqno it got, so sender can send more.
+// Second version also has mask of last received symbols and their count.
+type confirm struct {
+	V2            bool
+	TransferID    []byte
+	Part          int32
+	Seqno         int32
+	ReceivedMask  int32
+	ReceivedCount int32
+}
+
+// complete - rldp.complete or rldp2.complete, receiver decoded the part, sender should stop sending it
+type complete struct {
+	V2         bool
+	TransferID []byte
+	Part       int32
+}
+
+func serializeMessage(msg any) ([]byte, error) {
+	switch m := msg.(type) {
+	case Query:
+		if len(m.ID) != 32 {
+			return nil, errors.New("query id should be 32 bytes")
+		}
+		data := tl.AppendInt32(nil, _Query)
+		data = append(data, m.ID...)
+		data = tl.AppendInt64(data, m.MaxAnswerSize)
+		data = tl.AppendInt32(data, m.Timeout)
+		return append(data, tl.ToBytes(m.Data)...), nil
+	case Answer:
+		if len(m.ID) != 32 {
+			return nil, errors.New("query id should be 32 bytes")
+		}
+		data := tl.AppendInt32(nil, _Answer)
+		data = append(data, m.ID...)
+		return append(data, tl.ToBytes(m.Data)...), nil
+	case messagePart:
+		data := tl.AppendInt32(nil, pick(m.V2, _MessagePartV2, _MessagePart))
+		data = append(data, m.TransferID...)
+		data = tl.AppendInt32(data, _FECRaptorQ)
+		data = tl.AppendInt32(data, m.FEC.DataSize)
+		data = tl.AppendInt32(data, m.FEC.SymbolSize)
+		data = tl.AppendInt32(data, m.FEC.SymbolsCount)
+		data = tl.AppendInt32(data, m.Part)
+		data = tl.AppendInt64(data, m.TotalSize)
+		data = tl.AppendInt32(data, m.Seqno)
+		return append(data, tl.ToBytes(m.Data)...), nil
+	case confirm:
+		data := tl.AppendInt32(nil, pick(m.V2, _ConfirmV2, _Confirm))
+		data = append(data, m.TransferID...)
+		data = tl.AppendInt32(data, m.Part)
+		data = tl.AppendInt32(data, m.Seqno)
+		if m.V2 {
+			data = tl.AppendInt32(data, m.ReceivedMask)
+			data = tl.AppendInt32(data, m.ReceivedCount)
+		}
+		return data, nil
+	case complete:
+		data := tl.AppendInt32(nil, pick(m.V2, _CompleteV2, _Complete))
+		data = append(data, m.TransferID...)
+		return tl.AppendInt32(data, m.Part), nil
+	}
+	return nil, fmt.Errorf("unsupported message type %T", msg)
+}
+
+func parseMessage(data []byte) (any, error) {
+	r := tl.NewReader(data)
+
+	var msg any
+	switch typ := r.Int32(); typ {
+	case _Query:
+		msg = Query{
+			ID:            r.Int256(),
+			MaxAnswerSize: r.Int64(),
+			Timeout:       r.Int32(),
+			Data:          r.Bytes(),
+		}
+	case _Answer:
+		msg = Answer{
+			ID:   r.Int256(),
+			Data: r.Bytes(),
+		}
+	case _MessagePart, _MessagePartV2:
+		m := messagePart{V2: typ == _MessagePartV2, TransferID: r.Int256()}
+		if fec := r.Int32(); fec != _FECRaptorQ && r.Err() == nil {
+			return nil, fmt.Errorf("unsupported fec type %d", fec)
+		}
+		m.FEC = fecRaptorQ{
+			DataSize:     r.Int32(),
+			SymbolSize:   r.Int32(),
+			SymbolsCount: r.Int32(),
+		}
+		m.Part = r.Int32()
+		m.TotalSize = r.Int64()
+		m.Seqno = r.Int32()
+		m.Data = r.Bytes()
+		msg = m
+	case _Confirm, _ConfirmV2:
+		m := confirm{
+			V2:         typ == _ConfirmV2,
+			TransferID: r.Int256(),
+			Part:       r.Int32(),
+			Seqno:      r.Int32(),
+		}
+		if m.V2 {
+			m.ReceivedMask = r.Int32()
+			m.ReceivedCount = r.Int32()
+		}
+		msg = m
+	case _Complete, _CompleteV2:
+		msg = complete{
+			V2:         typ == _CompleteV2,
+			TransferID: r.Int256(),
+			Part:       r.Int32(),
+		}
+	case _Message:
+		return nil, errors.New("rldp.message is not supported")
+	default:
+		if r.Err() != nil {
+			return nil, r.Err()
+		}
+		return nil, fmt.Errorf("unknown message type %d", typ)
+	}
+
+	if r.Err() != nil {
+		return nil, r.Err()
+	}
+	return msg, nil
+}
+
+func pick(v2 bool, idV2, id int32) int32 {
+	if v2 {
+		return idV2
+	}
+	return id
+}
@@ -0,0 +1,474 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/dht"
+	"github.com/xssnick/tonutils-go/adnl/rldp"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	// _MaxPeers - we do not connect to more nodes of the bag
+	_MaxPeers = 8
+	// _MaxInfoSize - max size of torrent info answer
+	_MaxInfoSize = 64 << 10
+	// _PieceTimeout - time for one attempt to download piece from peer
+	_PieceTimeout = 20 * time.Second
+	// _QueryTimeout - time for service queries, like session updates
+	_QueryTimeout = 10 * time.Second
+	// _MaxHeaderSize - we do not accept bags with bigger headers
+	_MaxHeaderSize = 64 << 20
+	// _CachedPieces - how many last downloaded pieces are kept in memory, files are usually read sequentially
+	_CachedPieces = 16
+)
+
+var ErrNoPeers = errors.New("no peers of the bag are available")
+
+// Downloader - downloads bag from storage nodes, pieces are verified with merkle proofs against bag id.
+// Files of the bag can be read through fs.FS interface, data is downloaded when it is read.
+type Downloader struct {
+	bagID   []byte
+	overlay []byte
+
+	info   *TorrentInfo
+	header *Header
+	files  map[string]int
+
+	mx     sync.Mutex
+	peers  []*peer
+	next   int
+	cache  map[uint32][]byte
+	cached []uint32
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type peer struct {
+	d    *Downloader
+	rldp *rldp.RLDP
+
+	mx      sync.Mutex
+	updated bool
+}
+
+// NewDownloader - searches nodes of the bag in dht, connects to them through gateway and loads bag header
+func NewDownloader(ctx context.Context, gateway *adnl.Gateway, dhtClient *dht.Client, bagID []byte) (*Downloader, error) {
+	nodes, err := dhtClient.FindOverlayNodes(ctx, bagID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find bag nodes: %w", err)
+	}
+
+	return newDownloader(ctx, bagID, len(nodes), func(ctx context.Context, i int) (rldp.ADNL, error) {
+		id, err := liteclient.KeyID(nodes[i].ID)
+		if err != nil {
+			return nil, err
+		}
+
+		addrs, key, err := dhtClient.FindAddresses(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find node address: %w", err)
+		}
+
+		if len(addrs.Addresses) == 0 {
+			return nil, errors.New("node has no addresses")
+		}
+		addr := addrs.Addresses[0]
+
+		return gateway.RegisterClient(net.JoinHostPort(addr.IP.String(), strconv.Itoa(int(addr.Port))), key)
+	})
+}
+
+// NewDownloaderFromPeers - creates downloader of the bag which uses already known nodes
+func NewDownloaderFromPeers(ctx context.Context, bagID []byte, peers ...rldp.ADNL) (*Downloader, error) {
+	return newDownloader(ctx, bagID, len(peers), func(ctx context.Context, i int) (rldp.ADNL, error) {
+		return peers[i], nil
+	})
+}
+
+func newDownloader(ctx context.Context, bagID []byte, nodesNum int, getNode func(ctx context.Context, i int) (rldp.ADNL, error)) (*Downloader, error) {
+	if len(bagID) != 32 {
+		return nil, errors.New("bag id should be 32 bytes")
+	}
+
+	d := &Downloader{
+		bagID:   append([]byte{}, bagID...),
+		overlay: dht.OverlayID(bagID),
+		cache:   map[uint32][]byte{},
+	}
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+
+	sem := make(chan struct{}, _MaxPeers)
+	errs := make(chan error, nodesNum)
+	var wg sync.WaitGroup
+	for i := 0; i < nodesNum; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if d.PeersNum() >= _MaxPeers {
+				return
+			}
+
+			a, err := getNode(ctx, i)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			if err = d.connect(ctx, a); err != nil {
+				a.Close()
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	if d.PeersNum() == 0 {
+		d.Close()
+
+		if err := <-errs; err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoPeers, err)
+		}
+		return nil, ErrNoPeers
+	}
+
+	if err := d.loadHeader(ctx); err != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed to load header: %w", err)
+	}
+	return d, nil
+}
+
+// connect - starts storage session over transport and checks that node has our bag
+func (d *Downloader) connect(ctx context.Context, a rldp.ADNL) error {
+	p := &peer{d: d, rldp: rldp.NewClientV2(a)}
+	p.rldp.SetOnQuery(p.handleQuery)
+
+	data, err := p.rldp.DoQuery(ctx, _MaxInfoSize, overlayQuery(d.overlay, tl.AppendInt32(nil, _GetTorrentInfo)))
+	if err != nil {
+		return fmt.Errorf("failed to get torrent info: %w", err)
+	}
+
+	c, err := parseTorrentInfo(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse torrent info: %w", err)
+	}
+
+	if !bytes.Equal(c.Hash(), d.bagID) {
+		return errors.New("torrent info is not matches bag id")
+	}
+
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	if d.info == nil {
+		if d.info, err = ParseTorrentInfo(c); err != nil {
+			return fmt.Errorf("invalid torrent info: %w", err)
+		}
+	}
+	d.peers = append(d.peers, p)
+	return nil
+}
+
+// handleQuery - node pings us to keep session, we should tell it that we want to download
+func (p *peer) handleQuery(transferID []byte, query *rldp.Query) error {
+	_, data, err := unwrapOverlayQuery(query.Data)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(p.d.ctx, _QueryTimeout)
+	defer cancel()
+
+	r := tl.NewReader(data)
+	switch id := r.Int32(); id {
+	case _Ping:
+		sessionID := r.Int64()
+		if r.Err() != nil {
+			return r.Err()
+		}
+
+		if err = p.rldp.SendAnswer(ctx, query.MaxAnswerSize, transferID, query.ID, tl.AppendInt32(nil, _Pong)); err != nil {
+			return err
+		}
+
+		p.mx.Lock()
+		defer p.mx.Unlock()
+
+		if !p.updated {
+			_, err = p.rldp.DoQuery(ctx, _MaxInfoSize, overlayQuery(p.d.overlay, serializeAddUpdate(sessionID, 1, true)))
+			if err != nil {
+				// will try again on the next ping
+				return fmt.Errorf("failed to send session update: %w", err)
+			}
+			p.updated = true
+		}
+		return nil
+	case _AddUpdate:
+		// we do not upload, so pieces of the node are not interesting
+		return p.rldp.SendAnswer(ctx, query.MaxAnswerSize, transferID, query.ID, tl.AppendInt32(nil, _Ok))
+	default:
+		if r.Err() != nil {
+			return r.Err()
+		}
+		return fmt.Errorf("unexpected query %d", id)
+	}
+}
+
+func (d *Downloader) loadHeader(ctx context.Context) error {
+	if d.info.HeaderSize > _MaxHeaderSize {
+		return fmt.Errorf("header is too big: %d", d.info.HeaderSize)
+	}
+
+	data := make([]byte, d.info.HeaderSize)
+	if _, err := d.readAt(ctx, data, 0); err != nil {
+		return err
+	}
+
+	if hash := sha256.Sum256(data); !bytes.Equal(hash[:], d.info.HeaderHash) {
+		return errors.New("header hash is not matches")
+	}
+
+	header, err := ParseHeader(data)
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]int, len(header.Files))
+	for i, f := range header.Files {
+		if f.Offset+f.Size > d.info.FileSize {
+			return fmt.Errorf("file %s is out of bag", f.Name)
+		}
+		files[f.Name] = i
+	}
+
+	d.header, d.files = header, files
+	return nil
+}
+
+// BagID - id of the bag, hash of torrent info cell
+func (d *Downloader) BagID() []byte {
+	return d.bagID
+}
+
+// Info - torrent info of the bag
+func (d *Downloader) Info() *TorrentInfo {
+	return d.info
+}
+
+// Header - header of the bag with its files
+func (d *Downloader) Header() *Header {
+	return d.header
+}
+
+// ListFiles - names of all files in the bag
+func (d *Downloader) ListFiles() []string {
+	names := make([]string, len(d.header.Files))
+	for i, f := range d.header.Files {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// PeersNum - number of connected nodes
+func (d *Downloader) PeersNum() int {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	return len(d.peers)
+}
+
+// Close - closes connections to nodes, files cannot be read after it
+func (d *Downloader) Close() {
+	d.cancel()
+
+	d.mx.Lock()
+	peers := d.peers
+	d.peers = nil
+	d.mx.Unlock()
+
+	for _, p := range peers {
+		p.rldp.Close()
+	}
+}
+
+// DownloadPiece - downloads piece and verifies it, nodes are tried one by one until one of them returns it
+func (d *Downloader) DownloadPiece(ctx context.Context, index uint32) ([]byte, error) {
+	if index >= d.info.PiecesNum() {
+		return nil, fmt.Errorf("piece %d is out of bag", index)
+	}
+
+	d.mx.Lock()
+	if data, ok := d.cache[index]; ok {
+		d.mx.Unlock()
+		return data, nil
+	}
+
+	// start from the next peer each time, to spread load
+	peers := make([]*peer, 0, len(d.peers))
+	for i := range d.peers {
+		peers = append(peers, d.peers[(d.next+i)%len(d.peers)])
+	}
+	d.next++
+	d.mx.Unlock()
+
+	err := ErrNoPeers
+	for _, p := range peers {
+		var data []byte
+		data, err = d.downloadPiece(ctx, p, index)
+		if err == nil {
+			d.cachePiece(index, data)
+			return data, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, fmt.Errorf("failed to download piece %d: %w", index, err)
+}
+
+func (d *Downloader) downloadPiece(ctx context.Context, p *peer, index uint32) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, _PieceTimeout)
+	defer cancel()
+
+	data, err := p.rldp.DoQuery(ctx, 4096+3*int64(d.info.PieceSize), overlayQuery(d.overlay, serializeGetPiece(index)))
+	if err != nil {
+		return nil, err
+	}
+
+	pc, err := parsePiece(data)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := cell.FromBOC(pc.Proof)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proof: %w", err)
+	}
+
+	if err = d.info.CheckPiece(index, proof, pc.Data); err != nil {
+		// node gives wrong data, we will not use it anymore
+		d.removePeer(p)
+		return nil, fmt.Errorf("invalid piece: %w", err)
+	}
+	return pc.Data, nil
+}
+
+func (d *Downloader) removePeer(p *peer) {
+	d.mx.Lock()
+	for i, x := range d.peers {
+		if x == p {
+			d.peers = append(d.peers[:i:i], d.peers[i+1:]...)
+			break
+		}
+	}
+	d.mx.Unlock()
+
+	p.rldp.Close()
+}
+
+func (d *Downloader) cachePiece(index uint32, data []byte) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	if _, ok := d.cache[index]; ok {
+		return
+	}
+
+	if len(d.cached) >= _CachedPieces {
+		delete(d.cache, d.cached[0])
+		d.cached = d.cached[1:]
+	}
+	d.cache[index] = data
+	d.cached = append(d.cached, index)
+}
+
+// readAt - reads data of the bag from offset, pieces are downloaded when needed
+func (d *Downloader) readAt(ctx context.Context, p []byte, off uint64) (int, error) {
+	n := 0
+	for n < len(p) {
+		pos := off + uint64(n)
+		index := uint32(pos / uint64(d.info.PieceSize))
+
+		data, err := d.DownloadPiece(ctx, index)
+		if err != nil {
+			return n, err
+		}
+
+		n += copy(p[n:], data[pos-uint64(index)*uint64(d.info.PieceSize):])
+	}
+	return n, nil
+}
+
+// CheckPiece - verifies piece data with its merkle proof. Leaves of the tree are hashes of pieces,
+// path to the leaf is defined by bits of piece index.
+func (t *TorrentInfo) CheckPiece(index uint32, proof *cell.Cell, data []byte) error {
+	piecesNum := t.PiecesNum()
+	if index >= piecesNum {
+		return fmt.Errorf("piece %d is out of bag", index)
+	}
+
+	size := uint64(t.PieceSize)
+	if index == piecesNum-1 {
+		size = t.FileSize - uint64(index)*uint64(t.PieceSize)
+	}
+	if uint64(len(data)) != size {
+		return fmt.Errorf("piece size should be %d, got %d", size, len(data))
+	}
+
+	tree, err := cell.UnwrapProof(proof, t.RootHash)
+	if err != nil {
+		return fmt.Errorf("failed to check proof: %w", err)
+	}
+
+	s := tree.BeginParse()
+	for i := bits.Len32(piecesNum-1) - 1; i >= 0; i-- {
+		if s.RefsNum() != 2 {
+			return errors.New("invalid tree node in proof")
+		}
+
+		left, err := s.LoadRef()
+		if err != nil {
+			return err
+		}
+		right, err := s.LoadRef()
+		if err != nil {
+			return err
+		}
+
+		s = left
+		if (index>>uint(i))&1 == 1 {
+			s = right
+		}
+	}
+
+	// pruned branch has more bits, so piece which is not in proof will not pass
+	if s.RefsNum() != 0 || s.BitsLeft() != 256 {
+		return errors.New("invalid leaf in proof")
+	}
+
+	hash := sha256.Sum256(data)
+	if !bytes.Equal(s.MustLoadSlice(256), hash[:]) {
+		return errors.New("piece hash is not matches proof")
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// File - file of the bag, its data is downloaded by pieces when it is read
+type File struct {
+	d      *Downloader
+	info   FileInfo
+	offset int64
+}
+
+type fileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+type dir struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+// Open - opens file or directory of the bag, it makes Downloader a fs.FS.
+// Files are *File, so they can be read at any offset.
+func (d *Downloader) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if i, ok := d.files[name]; ok {
+		return &File{d: d, info: d.header.Files[i]}, nil
+	}
+
+	entries := d.readDir(name)
+	if entries == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &dir{info: fileInfo{name: path.Base(name), dir: true}, entries: entries}, nil
+}
+
+// OpenFile - opens file of the bag
+func (d *Downloader) OpenFile(name string) (*File, error) {
+	i, ok := d.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &File{d: d, info: d.header.Files[i]}, nil
+}
+
+// readDir - lists entries of the directory, nil is returned when there is no such directory.
+// Directories are not stored in bag, they are taken from file names.
+func (d *Downloader) readDir(name string) []fs.DirEntry {
+	prefix := ""
+	if name != "." {
+		prefix = name + "/"
+	}
+
+	found := false
+	dirs := map[string]bool{}
+	var entries []fs.DirEntry
+	for _, f := range d.header.Files {
+		if !strings.HasPrefix(f.Name, prefix) {
+			continue
+		}
+		found = true
+
+		rest := f.Name[len(prefix):]
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			if sub := rest[:i]; !dirs[sub] {
+				dirs[sub] = true
+				entries = append(entries, fileInfo{name: sub, dir: true})
+			}
+			continue
+		}
+		entries = append(entries, fileInfo{name: rest, size: int64(f.Size)})
+	}
+
+	if !found && name != "." {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	if entries == nil {
+		entries = []fs.DirEntry{}
+	}
+	return entries
+}
+
+// Info - name and position of the file in the bag
+func (f *File) Info() FileInfo {
+	return f.info
+}
+
+func (f *File) Stat() (fs.FileInfo, error) {
+	return fileInfo{name: path.Base(f.info.Name), size: int64(f.info.Size)}, nil
+}
+
+// ReadAt - reads file data from offset, it can be called concurrently
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+
+	if off >= int64(f.info.Size) {
+		return 0, io.EOF
+	}
+
+	var err error
+	if rest := int64(f.info.Size) - off; int64(len(p)) > rest {
+		p, err = p[:rest], io.EOF
+	}
+
+	n, readErr := f.d.readAt(f.d.ctx, p, f.info.Offset+uint64(off))
+	if readErr != nil {
+		return n, readErr
+	}
+	return n, err
+}
+
+func (f *File) Read(p []byte) (int, error) {
+	n, err := f.ReadAt(p, f.offset)
+	f.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(f.info.Size)
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *File) Close() error {
+	return nil
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) Close() error {
+	return nil
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
+
+func (i fileInfo) Name() string {
+	return i.name
+}
+
+func (i fileInfo) Size() int64 {
+	return i.size
+}
+
+func (i fileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+func (i fileInfo) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (i fileInfo) IsDir() bool {
+	return i.dir
+}
+
+func (i fileInfo) Sys() any {
+	return nil
+}
+
+func (i fileInfo) Type() fs.FileMode {
+	return i.Mode().Type()
+}
+
+func (i fileInfo) Info() (fs.FileInfo, error) {
+	return i, nil
+}
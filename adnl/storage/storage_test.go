@@ -0,0 +1,397 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/bits"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/dht"
+	"github.com/xssnick/tonutils-go/adnl/rldp"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type testFile struct {
+	name string
+	data []byte
+}
+
+// testBag - bag built the same way as storage daemon does it
+type testBag struct {
+	id     []byte
+	info   *TorrentInfo
+	infoC  *cell.Cell
+	data   []byte
+	tree   *cell.Cell
+	header *Header
+}
+
+func buildBag(t *testing.T, pieceSize uint32, files []testFile) *testBag {
+	header := &Header{DirName: "bag"}
+	for _, f := range files {
+		header.Files = append(header.Files, FileInfo{Name: f.name, Size: uint64(len(f.data))})
+	}
+
+	headerData := header.Serialize()
+	data := append([]byte{}, headerData...)
+	for _, f := range files {
+		data = append(data, f.data...)
+	}
+
+	piecesNum := (len(data) + int(pieceSize) - 1) / int(pieceSize)
+
+	// tree is full, leaves after the last piece are zero hashes
+	leaves := make([]*cell.Cell, 1<<bits.Len32(uint32(piecesNum-1)))
+	for i := range leaves {
+		hash := make([]byte, 32)
+		if i < piecesNum {
+			end := (i + 1) * int(pieceSize)
+			if end > len(data) {
+				end = len(data)
+			}
+			h := sha256.Sum256(data[i*int(pieceSize) : end])
+			hash = h[:]
+		}
+		leaves[i] = cell.BeginCell().MustStoreSlice(hash, 256).EndCell()
+	}
+
+	for len(leaves) > 1 {
+		var next []*cell.Cell
+		for i := 0; i < len(leaves); i += 2 {
+			next = append(next, cell.BeginCell().MustStoreRef(leaves[i]).MustStoreRef(leaves[i+1]).EndCell())
+		}
+		leaves = next
+	}
+
+	headerHash := sha256.Sum256(headerData)
+	info := &TorrentInfo{
+		PieceSize:   pieceSize,
+		FileSize:    uint64(len(data)),
+		RootHash:    leaves[0].Hash(),
+		HeaderSize:  uint64(len(headerData)),
+		HeaderHash:  headerHash[:],
+		Description: "test bag",
+	}
+
+	infoCell, err := info.ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testBag{
+		id:     infoCell.Hash(),
+		info:   info,
+		infoC:  infoCell,
+		data:   data,
+		tree:   leaves[0],
+		header: header,
+	}
+}
+
+func (b *testBag) piece(t *testing.T, index uint32) ([]byte, *cell.Cell) {
+	end := uint64(index+1) * uint64(b.info.PieceSize)
+	if end > b.info.FileSize {
+		end = b.info.FileSize
+	}
+	data := b.data[uint64(index)*uint64(b.info.PieceSize) : end]
+
+	// only cells on the path to the leaf are kept in proof, they are found by hash,
+	// because cells of slices are copies
+	path := map[string]bool{}
+	c := b.tree
+	path[string(c.Hash())] = true
+	for i := bits.Len32(b.info.PiecesNum()-1) - 1; i >= 0; i-- {
+		s := c.BeginParse()
+		left, right := s.MustLoadRef().MustToCell(), s.MustLoadRef().MustToCell()
+
+		c = left
+		if (index>>uint(i))&1 == 1 {
+			c = right
+		}
+		path[string(c.Hash())] = true
+	}
+
+	proof, err := cell.CreateProof(b.tree, func(c *cell.Cell) bool {
+		return path[string(c.Hash())]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, proof
+}
+
+// testNode - storage node which serves the bag over adnl
+type testNode struct {
+	gate    *adnl.Gateway
+	key     ed25519.PrivateKey
+	updates chan bool
+}
+
+// startTestNode - starts node, corrupt node returns wrong data of pieces
+func startTestNode(t *testing.T, bag *testBag, corrupt bool) *testNode {
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	n := &testNode{
+		gate:    adnl.NewGateway(key),
+		key:     key,
+		updates: make(chan bool, 10),
+	}
+	if err := n.gate.StartServer("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = n.gate.Close()
+	})
+
+	overlay := dht.OverlayID(bag.id)
+	n.gate.SetConnectionHandler(func(p *adnl.Peer) error {
+		r := rldp.NewClientV2(p)
+		r.SetOnQuery(func(transferID []byte, query *rldp.Query) error {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			ov, data, err := unwrapOverlayQuery(query.Data)
+			if err != nil || !bytes.Equal(ov, overlay) {
+				return errors.New("wrong overlay")
+			}
+
+			rd := tl.NewReader(data)
+			switch rd.Int32() {
+			case _GetTorrentInfo:
+				go func() {
+					// node starts session with the peer
+					ping := tl.AppendInt64(tl.AppendInt32(nil, _Ping), 777)
+					_, _ = r.DoQuery(ctx, 1<<10, overlayQuery(overlay, ping))
+				}()
+
+				answer := tl.AppendInt32(nil, _TorrentInfo)
+				answer = append(answer, tl.ToBytes(bag.infoC.ToBOC())...)
+				return r.SendAnswer(ctx, query.MaxAnswerSize, transferID, query.ID, answer)
+			case _GetPiece:
+				pieceData, proof := bag.piece(t, uint32(rd.Int32()))
+				if corrupt {
+					pieceData = append([]byte{}, pieceData...)
+					pieceData[0]++
+				}
+
+				answer := tl.AppendInt32(nil, _Piece)
+				answer = append(answer, tl.ToBytes(proof.ToBOC())...)
+				answer = append(answer, tl.ToBytes(pieceData)...)
+				return r.SendAnswer(ctx, query.MaxAnswerSize, transferID, query.ID, answer)
+			case _AddUpdate:
+				if rd.Int64() != 777 {
+					return errors.New("wrong session")
+				}
+				rest := rd.Rest()
+				n.updates <- int32(tl.NewReader(rest[len(rest)-4:]).Int32()) == _BoolTrue
+				return r.SendAnswer(ctx, query.MaxAnswerSize, transferID, query.ID, tl.AppendInt32(nil, _Ok))
+			}
+			return errors.New("unexpected query")
+		})
+		return nil
+	})
+	return n
+}
+
+func connectTestNode(t *testing.T, n *testNode) *adnl.Peer {
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	gate := adnl.NewGateway(key)
+	if err := gate.StartClient(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = gate.Close()
+	})
+
+	p, err := gate.RegisterClient(n.gate.Addr().String(), n.key.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func testFiles() []testFile {
+	files := []testFile{
+		{name: "index.html", data: []byte("<html>hello</html>")},
+		{name: "img/1.png", data: make([]byte, 30000)},
+		{name: "img/2.png", data: make([]byte, 7777)},
+		{name: "img/meta/info.json", data: []byte(`{"name":"nft"}`)},
+		{name: "empty.txt", data: nil},
+	}
+	for _, f := range files {
+		_, _ = rand.Read(f.data)
+	}
+	return files
+}
+
+func TestDownloader(t *testing.T) {
+	files := testFiles()
+	bag := buildBag(t, 1024, files)
+
+	bad, good := startTestNode(t, bag, true), startTestNode(t, bag, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	d, err := NewDownloaderFromPeers(ctx, bag.id, connectTestNode(t, bad), connectTestNode(t, good))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	if d.Info().Description != "test bag" || d.Header().DirName != "bag" {
+		t.Fatal("wrong bag info")
+	}
+
+	if err = fstest.TestFS(d, "index.html", "img/1.png", "img/2.png", "img/meta/info.json", "empty.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range files {
+		file, err := d.OpenFile(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := io.ReadAll(io.NewSectionReader(file, 0, int64(file.Info().Size)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(data, f.data) {
+			t.Fatal("wrong data of file", f.name)
+		}
+	}
+
+	if d.PeersNum() != 1 {
+		t.Fatal("node with wrong pieces should be dropped")
+	}
+
+	select {
+	case want := <-good.updates:
+		if !want {
+			t.Fatal("we should want to download")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("session update is not sent")
+	}
+}
+
+func TestDownloader_WrongBag(t *testing.T) {
+	bag := buildBag(t, 1024, testFiles())
+	node := startTestNode(t, bag, false)
+
+	// node does not answer queries in overlay of another bag
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := NewDownloaderFromPeers(ctx, make([]byte, 32), connectTestNode(t, node))
+	if !errors.Is(err, ErrNoPeers) {
+		t.Fatal("node with another bag should not be used, got", err)
+	}
+}
+
+func TestTorrentInfo_CheckPiece(t *testing.T) {
+	for _, sz := range []uint32{4096, 1000, 777} {
+		bag := buildBag(t, sz, testFiles())
+
+		for i := uint32(0); i < bag.info.PiecesNum(); i++ {
+			data, proof := bag.piece(t, i)
+			if err := bag.info.CheckPiece(i, proof, data); err != nil {
+				t.Fatal("piece", i, "of size", sz, err)
+			}
+
+			if i > 0 {
+				if err := bag.info.CheckPiece(i-1, proof, data); err == nil {
+					t.Fatal("piece should not pass with proof of another index")
+				}
+			}
+
+			corrupted := append([]byte{}, data...)
+			corrupted[len(corrupted)-1]++
+			if err := bag.info.CheckPiece(i, proof, corrupted); err == nil {
+				t.Fatal("corrupted piece should not pass")
+			}
+		}
+	}
+
+	// single piece, tree is only leaf
+	bag := buildBag(t, 1<<20, testFiles())
+	data, proof := bag.piece(t, 0)
+	if err := bag.info.CheckPiece(0, proof, data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTorrentInfo_Cell(t *testing.T) {
+	info := &TorrentInfo{
+		PieceSize:      128 << 10,
+		FileSize:       1 << 40,
+		RootHash:       bytes.Repeat([]byte{1}, 32),
+		HeaderSize:     500,
+		HeaderHash:     bytes.Repeat([]byte{2}, 32),
+		MicrochunkHash: bytes.Repeat([]byte{3}, 32),
+		Description:    strings.Repeat("long description ", 50),
+	}
+
+	c, err := info.ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = cell.FromBOC(c.ToBOC())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseTorrentInfo(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.PieceSize != info.PieceSize || parsed.FileSize != info.FileSize || parsed.HeaderSize != info.HeaderSize ||
+		!bytes.Equal(parsed.RootHash, info.RootHash) || !bytes.Equal(parsed.HeaderHash, info.HeaderHash) ||
+		!bytes.Equal(parsed.MicrochunkHash, info.MicrochunkHash) || parsed.Description != info.Description {
+		t.Fatal("torrent info is not equal after parse")
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	h := &Header{
+		DirName: "dir",
+		Files: []FileInfo{
+			{Name: "a.txt", Size: 10},
+			{Name: "b/c.txt", Size: 0},
+			{Name: "d", Size: 3},
+		},
+	}
+
+	data := h.Serialize()
+	parsed, err := ParseHeader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offset := uint64(len(data))
+	for i, f := range parsed.Files {
+		if f.Name != h.Files[i].Name || f.Size != h.Files[i].Size || f.Offset != offset {
+			t.Fatal("wrong file", i, f)
+		}
+		offset += f.Size
+	}
+
+	if _, err = ParseHeader(data[:len(data)-1]); err == nil {
+		t.Fatal("truncated header should not be parsed")
+	}
+}
@@ -0,0 +1,411 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	_OverlayQuery int32 = -855800765
+
+	_TorrentInfo    int32 = 349098222
+	_GetTorrentInfo int32 = -1849387478
+	_Piece          int32 = -2135623155
+	_GetPiece       int32 = -2139429280
+	_Ping           int32 = 1156837905
+	_Pong           int32 = 1828046501
+	_AddUpdate      int32 = 1295070674
+	_State          int32 = 856912010
+	_UpdateInit     int32 = -835460938
+	_Ok             int32 = -1020584955
+
+	_BoolTrue  int32 = -1720552011
+	_BoolFalse int32 = -1132882121
+
+	_TorrentHeader int32 = -1859605833
+	_FECInfoNone   int32 = -936765084
+)
+
+// _MaxFilesNum - we do not accept headers with more files
+const _MaxFilesNum = 1 << 20
+
+// TorrentInfo - description of the bag, hash of its cell is bag id
+type TorrentInfo struct {
+	PieceSize      uint32
+	FileSize       uint64
+	RootHash       []byte
+	HeaderSize     uint64
+	HeaderHash     []byte
+	MicrochunkHash []byte
+	Description    string
+}
+
+// FileInfo - file of the bag, offset is position of its data in the bag
+type FileInfo struct {
+	Name   string
+	Offset uint64
+	Size   uint64
+}
+
+// Header - torrent_header, it is stored at the beginning of the bag and lists its files.
+// Data of the files follows the header in the same order.
+type Header struct {
+	DirName string
+	Files   []FileInfo
+}
+
+// piece - storage.piece, data of the piece with merkle proof of it
+type piece struct {
+	Proof []byte
+	Data  []byte
+}
+
+// ParseTorrentInfo - loads torrent info from its cell
+func ParseTorrentInfo(c *cell.Cell) (*TorrentInfo, error) {
+	s := c.BeginParse()
+
+	pieceSize, err := s.LoadUInt(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load piece size: %w", err)
+	}
+	fileSize, err := s.LoadUInt(64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load file size: %w", err)
+	}
+	rootHash, err := s.LoadSlice(256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load root hash: %w", err)
+	}
+	headerSize, err := s.LoadUInt(64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load header size: %w", err)
+	}
+	headerHash, err := s.LoadSlice(256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load header hash: %w", err)
+	}
+
+	info := &TorrentInfo{
+		PieceSize:  uint32(pieceSize),
+		FileSize:   fileSize,
+		RootHash:   rootHash,
+		HeaderSize: headerSize,
+		HeaderHash: headerHash,
+	}
+
+	hasMicrochunk, err := s.LoadBoolBit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load microchunk hash flag: %w", err)
+	}
+	if hasMicrochunk {
+		if info.MicrochunkHash, err = s.LoadSlice(256); err != nil {
+			return nil, fmt.Errorf("failed to load microchunk hash: %w", err)
+		}
+	}
+
+	if info.Description, err = loadText(s); err != nil {
+		return nil, fmt.Errorf("failed to load description: %w", err)
+	}
+
+	if info.PieceSize == 0 {
+		return nil, errors.New("piece size is zero")
+	}
+	if info.HeaderSize > info.FileSize {
+		return nil, errors.New("header is bigger than bag")
+	}
+	return info, nil
+}
+
+// ToCell - serializes torrent info, hash of the cell is bag id
+func (t *TorrentInfo) ToCell() (*cell.Cell, error) {
+	if len(t.RootHash) != 32 || len(t.HeaderHash) != 32 {
+		return nil, errors.New("hashes should be 32 bytes")
+	}
+
+	b := cell.BeginCell().
+		MustStoreUInt(uint64(t.PieceSize), 32).
+		MustStoreUInt(t.FileSize, 64).
+		MustStoreSlice(t.RootHash, 256).
+		MustStoreUInt(t.HeaderSize, 64).
+		MustStoreSlice(t.HeaderHash, 256).
+		MustStoreBoolBit(t.MicrochunkHash != nil)
+
+	if t.MicrochunkHash != nil {
+		if len(t.MicrochunkHash) != 32 {
+			return nil, errors.New("microchunk hash should be 32 bytes")
+		}
+		b.MustStoreSlice(t.MicrochunkHash, 256)
+	}
+
+	if err := storeText(b, t.Description); err != nil {
+		return nil, fmt.Errorf("failed to store description: %w", err)
+	}
+	return b.EndCell(), nil
+}
+
+// PiecesNum - number of pieces in the bag
+func (t *TorrentInfo) PiecesNum() uint32 {
+	return uint32((t.FileSize + uint64(t.PieceSize) - 1) / uint64(t.PieceSize))
+}
+
+// loadText - reads Text, it is a number of chunks, each of them is prefixed with its length,
+// and all chunks except the first one are stored in refs chain
+func loadText(s *cell.Slice) (string, error) {
+	chunks, err := s.LoadUInt(8)
+	if err != nil {
+		return "", err
+	}
+
+	var res []byte
+	for i := uint64(0); i < chunks; i++ {
+		if i > 0 {
+			if s, err = s.LoadRef(); err != nil {
+				return "", err
+			}
+		}
+
+		ln, err := s.LoadUInt(8)
+		if err != nil {
+			return "", err
+		}
+
+		data, err := s.LoadSlice(uint(ln * 8))
+		if err != nil {
+			return "", err
+		}
+		res = append(res, data...)
+	}
+	return string(res), nil
+}
+
+func storeText(b *cell.Builder, text string) error {
+	data := []byte(text)
+
+	// split text to chunks which fit cells, first one is stored in the rest of the current cell
+	var chunks [][]byte
+	free := (b.BitsLeft() - 16) / 8
+	for len(data) > 0 {
+		sz := int(free)
+		if sz > 255 {
+			sz = 255
+		}
+		if sz > len(data) {
+			sz = len(data)
+		}
+		if sz == 0 {
+			return errors.New("not enough space in cell")
+		}
+
+		chunks = append(chunks, data[:sz])
+		data = data[sz:]
+		free = (1023 - 8) / 8
+	}
+
+	if len(chunks) > 255 {
+		return errors.New("text is too long")
+	}
+
+	var next *cell.Cell
+	for i := len(chunks) - 1; i > 0; i-- {
+		cb := cell.BeginCell().MustStoreUInt(uint64(len(chunks[i])), 8).MustStoreSlice(chunks[i], uint(len(chunks[i])*8))
+		if next != nil {
+			cb.MustStoreRef(next)
+		}
+		next = cb.EndCell()
+	}
+
+	if err := b.StoreUInt(uint64(len(chunks)), 8); err != nil {
+		return err
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	if err := b.StoreUInt(uint64(len(chunks[0])), 8); err != nil {
+		return err
+	}
+	if err := b.StoreSlice(chunks[0], uint(len(chunks[0])*8)); err != nil {
+		return err
+	}
+	if next != nil {
+		return b.StoreRef(next)
+	}
+	return nil
+}
+
+// ParseHeader - parses torrent header, file offsets are calculated relative to the beginning of the bag
+func ParseHeader(data []byte) (*Header, error) {
+	r := tl.NewReader(data)
+
+	if id := r.Int32(); id != _TorrentHeader && r.Err() == nil {
+		return nil, fmt.Errorf("unexpected header type %d", id)
+	}
+
+	filesNum := uint32(r.Int32())
+	totalNameSize := uint64(r.Int64())
+	totalDataSize := uint64(r.Int64())
+
+	if fec := r.Int32(); fec != _FECInfoNone && r.Err() == nil {
+		return nil, fmt.Errorf("unsupported fec type %d", fec)
+	}
+
+	dirNameSize := uint32(r.Int32())
+	if r.Err() != nil {
+		return nil, r.Err()
+	}
+
+	if filesNum > _MaxFilesNum {
+		return nil, fmt.Errorf("too many files in header: %d", filesNum)
+	}
+
+	// each file has 2 indexes
+	rest := uint64(len(r.Rest()))
+	if totalNameSize > rest || uint64(dirNameSize)+totalNameSize+uint64(filesNum)*16 > rest {
+		return nil, errors.New("header is too short")
+	}
+
+	h := &Header{
+		DirName: string(r.Raw(int(dirNameSize))),
+		Files:   make([]FileInfo, filesNum),
+	}
+
+	nameIndex := make([]uint64, filesNum)
+	for i := range nameIndex {
+		nameIndex[i] = uint64(r.Int64())
+	}
+	dataIndex := make([]uint64, filesNum)
+	for i := range dataIndex {
+		dataIndex[i] = uint64(r.Int64())
+	}
+	names := r.Raw(int(totalNameSize))
+
+	if r.Err() != nil {
+		return nil, r.Err()
+	}
+	if len(r.Rest()) != 0 {
+		return nil, errors.New("unexpected data after header")
+	}
+
+	headerSize := uint64(len(data))
+
+	var prevName, prevData uint64
+	for i := range h.Files {
+		if nameIndex[i] < prevName || nameIndex[i] > totalNameSize ||
+			dataIndex[i] < prevData || dataIndex[i] > totalDataSize {
+			return nil, fmt.Errorf("invalid index of file %d", i)
+		}
+
+		h.Files[i] = FileInfo{
+			Name:   string(names[prevName:nameIndex[i]]),
+			Offset: headerSize + prevData,
+			Size:   dataIndex[i] - prevData,
+		}
+		prevName, prevData = nameIndex[i], dataIndex[i]
+	}
+
+	if prevName != totalNameSize || prevData != totalDataSize {
+		return nil, errors.New("indexes are not matching total sizes")
+	}
+	return h, nil
+}
+
+// Serialize - serializes header, offsets of files are ignored, they follow each other
+func (h *Header) Serialize() []byte {
+	var names []byte
+	var nameIndex, dataIndex []uint64
+	var dataSize uint64
+	for _, f := range h.Files {
+		names = append(names, f.Name...)
+		dataSize += f.Size
+
+		nameIndex = append(nameIndex, uint64(len(names)))
+		dataIndex = append(dataIndex, dataSize)
+	}
+
+	data := tl.AppendInt32(nil, _TorrentHeader)
+	data = tl.AppendInt32(data, int32(len(h.Files)))
+	data = tl.AppendInt64(data, int64(len(names)))
+	data = tl.AppendInt64(data, int64(dataSize))
+	data = tl.AppendInt32(data, _FECInfoNone)
+	data = tl.AppendInt32(data, int32(len(h.DirName)))
+	data = append(data, h.DirName...)
+	for _, v := range nameIndex {
+		data = tl.AppendInt64(data, int64(v))
+	}
+	for _, v := range dataIndex {
+		data = tl.AppendInt64(data, int64(v))
+	}
+	return append(data, names...)
+}
+
+// overlayQuery - wraps query to overlay.query prefix, storage nodes process queries only in overlay of the bag
+func overlayQuery(overlay, query []byte) []byte {
+	data := tl.AppendInt32(nil, _OverlayQuery)
+	data = append(data, overlay...)
+	return append(data, query...)
+}
+
+// unwrapOverlayQuery - removes overlay.query prefix if it exists
+func unwrapOverlayQuery(data []byte) ([]byte, []byte, error) {
+	r := tl.NewReader(data)
+	if r.Int32() != _OverlayQuery {
+		return nil, data, nil
+	}
+
+	overlay := r.Int256()
+	if r.Err() != nil {
+		return nil, nil, r.Err()
+	}
+	return overlay, r.Rest(), nil
+}
+
+func serializeGetPiece(id uint32) []byte {
+	return tl.AppendInt32(tl.AppendInt32(nil, _GetPiece), int32(id))
+}
+
+func serializeAddUpdate(sessionID int64, seqno int32, wantDownload bool) []byte {
+	data := tl.AppendInt32(nil, _AddUpdate)
+	data = tl.AppendInt64(data, sessionID)
+	data = tl.AppendInt32(data, seqno)
+	data = tl.AppendInt32(data, _UpdateInit)
+	data = append(data, tl.ToBytes(nil)...)
+	data = tl.AppendInt32(data, 0)
+	data = tl.AppendInt32(data, _State)
+	data = tl.AppendInt32(data, _BoolFalse)
+	if wantDownload {
+		return tl.AppendInt32(data, _BoolTrue)
+	}
+	return tl.AppendInt32(data, _BoolFalse)
+}
+
+func parseTorrentInfo(data []byte) (*cell.Cell, error) {
+	r := tl.NewReader(data)
+	if id := r.Int32(); id != _TorrentInfo && r.Err() == nil {
+		return nil, fmt.Errorf("unexpected answer type %d", id)
+	}
+
+	boc := r.Bytes()
+	if r.Err() != nil {
+		return nil, r.Err()
+	}
+	return cell.FromBOC(boc)
+}
+
+func parsePiece(data []byte) (*piece, error) {
+	r := tl.NewReader(data)
+	if id := r.Int32(); id != _Piece && r.Err() == nil {
+		return nil, fmt.Errorf("unexpected answer type %d", id)
+	}
+
+	p := &piece{
+		Proof: r.Bytes(),
+		Data:  r.Bytes(),
+	}
+	if r.Err() != nil {
+		return nil, r.Err()
+	}
+	return p, nil
+}
@@ -0,0 +1,469 @@
+package adnl
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+const (
+	_PacketContents int32 = -784151159
+	_PublicKey      int32 = 1209251014
+	_PublicKeyAES   int32 = 767339988
+
+	_MessageCreateChannel  int32 = -428620869
+	_MessageConfirmChannel int32 = 1625103721
+	_MessageCustom         int32 = 541595893
+	_MessageNop            int32 = 402186202
+	_MessageReinit         int32 = 281150752
+	_MessageQuery          int32 = -1265895046
+	_MessageAnswer         int32 = 262964246
+	_MessagePart           int32 = -45798087
+
+	_AddressUDP  int32 = 1728947943
+	_AddressUDP6 int32 = -484613126
+	_AddressList int32 = 573040216
+)
+
+// packet flags
+const (
+	_FlagFrom                        = 1 << 0
+	_FlagFromShort                   = 1 << 1
+	_FlagMessage                     = 1 << 2
+	_FlagMessages                    = 1 << 3
+	_FlagAddress                     = 1 << 4
+	_FlagPriorityAddress             = 1 << 5
+	_FlagSeqno                       = 1 << 6
+	_FlagConfirmSeqno                = 1 << 7
+	_FlagRecvAddrListVersion         = 1 << 8
+	_FlagRecvPriorityAddrListVersion = 1 << 9
+	_FlagReinitDate                  = 1 << 10
+	_FlagSignature                   = 1 << 11
+)
+
+// MessageCreateChannel - asks peer to create channel with our channel key
+type MessageCreateChannel struct {
+	Key  []byte
+	Date int32
+}
+
+// MessageConfirmChannel - confirms that channel with peer's key is created on our side
+type MessageConfirmChannel struct {
+	Key     []byte
+	PeerKey []byte
+	Date    int32
+}
+
+// MessageCustom - message with arbitrary payload, which is not expecting answer
+type MessageCustom struct {
+	Data []byte
+}
+
+// MessageNop - empty message, used to confirm seqno or keep channel alive
+type MessageNop struct{}
+
+// MessageReinit - notifies peer that we were reinitialized at the date
+type MessageReinit struct {
+	Date int32
+}
+
+// MessageQuery - query which expects answer with the same id
+type MessageQuery struct {
+	ID   []byte
+	Data []byte
+}
+
+// MessageAnswer - answer to query
+type MessageAnswer struct {
+	ID   []byte
+	Data []byte
+}
+
+// MessagePart - part of message which is too big to fit into one packet
+type MessagePart struct {
+	Hash      []byte
+	TotalSize int32
+	Offset    int32
+	Data      []byte
+}
+
+// Address - udp address of the node, ipv4 or ipv6
+type Address struct {
+	IP   net.IP
+	Port int32
+}
+
+// AddressList - list of node addresses, peers use newest version of it to reach the node
+type AddressList struct {
+	Addresses  []*Address
+	Version    int32
+	ReinitDate int32
+	Priority   int32
+	ExpireAt   int32
+}
+
+// packet - adnl.packetContents, fields which are nil are not present
+type packet struct {
+	Rand1                       []byte
+	From                        ed25519.PublicKey
+	FromIDShort                 []byte
+	Messages                    []any
+	Address                     *AddressList
+	PriorityAddress             *AddressList
+	Seqno                       *int64
+	ConfirmSeqno                *int64
+	RecvAddrListVersion         *int32
+	RecvPriorityAddrListVersion *int32
+	ReinitDate                  *int32
+	DstReinitDate               *int32
+	Signature                   []byte
+	Rand2                       []byte
+
+	// single message is serialized as message field, not as vector,
+	// we keep original form to verify signature
+	messagesVector bool
+}
+
+func (p *packet) flags() uint32 {
+	var flags uint32
+	if p.From != nil {
+		flags |= _FlagFrom
+	}
+	if p.FromIDShort != nil {
+		flags |= _FlagFromShort
+	}
+	if len(p.Messages) == 1 && !p.messagesVector {
+		flags |= _FlagMessage
+	} else if len(p.Messages) > 0 {
+		flags |= _FlagMessages
+	}
+	if p.Address != nil {
+		flags |= _FlagAddress
+	}
+	if p.PriorityAddress != nil {
+		flags |= _FlagPriorityAddress
+	}
+	if p.Seqno != nil {
+		flags |= _FlagSeqno
+	}
+	if p.ConfirmSeqno != nil {
+		flags |= _FlagConfirmSeqno
+	}
+	if p.RecvAddrListVersion != nil {
+		flags |= _FlagRecvAddrListVersion
+	}
+	if p.RecvPriorityAddrListVersion != nil {
+		flags |= _FlagRecvPriorityAddrListVersion
+	}
+	if p.ReinitDate != nil && p.DstReinitDate != nil {
+		flags |= _FlagReinitDate
+	}
+	if p.Signature != nil {
+		flags |= _FlagSignature
+	}
+	return flags
+}
+
+func (p *packet) Serialize() ([]byte, error) {
+	flags := p.flags()
+
+	data := tl.AppendInt32(nil, _PacketContents)
+	data = append(data, tl.ToBytes(p.Rand1)...)
+	data = tl.AppendInt32(data, int32(flags))
+
+	if flags&_FlagFrom != 0 {
+		data = tl.AppendInt32(data, _PublicKey)
+		data = append(data, p.From...)
+	}
+	if flags&_FlagFromShort != 0 {
+		data = append(data, p.FromIDShort...)
+	}
+	if flags&_FlagMessage != 0 {
+		msg, err := serializeMessage(p.Messages[0])
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, msg...)
+	}
+	if flags&_FlagMessages != 0 {
+		data = tl.AppendInt32(data, int32(len(p.Messages)))
+		for _, m := range p.Messages {
+			msg, err := serializeMessage(m)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, msg...)
+		}
+	}
+	if flags&_FlagAddress != 0 {
+		data = append(data, p.Address.SerializeBare()...)
+	}
+	if flags&_FlagPriorityAddress != 0 {
+		data = append(data, p.PriorityAddress.SerializeBare()...)
+	}
+	if flags&_FlagSeqno != 0 {
+		data = tl.AppendInt64(data, *p.Seqno)
+	}
+	if flags&_FlagConfirmSeqno != 0 {
+		data = tl.AppendInt64(data, *p.ConfirmSeqno)
+	}
+	if flags&_FlagRecvAddrListVersion != 0 {
+		data = tl.AppendInt32(data, *p.RecvAddrListVersion)
+	}
+	if flags&_FlagRecvPriorityAddrListVersion != 0 {
+		data = tl.AppendInt32(data, *p.RecvPriorityAddrListVersion)
+	}
+	if flags&_FlagReinitDate != 0 {
+		data = tl.AppendInt32(data, *p.ReinitDate)
+		data = tl.AppendInt32(data, *p.DstReinitDate)
+	}
+	if flags&_FlagSignature != 0 {
+		data = append(data, tl.ToBytes(p.Signature)...)
+	}
+	data = append(data, tl.ToBytes(p.Rand2)...)
+
+	return data, nil
+}
+
+// signatureData - packet serialized without signature, this is what is signed by sender
+func (p *packet) signatureData() ([]byte, error) {
+	sign := p.Signature
+	p.Signature = nil
+	defer func() {
+		p.Signature = sign
+	}()
+
+	return p.Serialize()
+}
+
+func parsePacket(data []byte) (*packet, error) {
+	r := tl.NewReader(data)
+
+	if id := r.Int32(); id != _PacketContents {
+		if r.Err() != nil {
+			return nil, r.Err()
+		}
+		return nil, fmt.Errorf("unexpected packet type %d", id)
+	}
+
+	p := &packet{}
+	p.Rand1 = r.Bytes()
+	flags := uint32(r.Int32())
+
+	if flags&_FlagFrom != 0 {
+		if id := r.Int32(); id != _PublicKey && r.Err() == nil {
+			return nil, fmt.Errorf("unsupported public key type %d", id)
+		}
+		p.From = r.Int256()
+	}
+	if flags&_FlagFromShort != 0 {
+		p.FromIDShort = r.Int256()
+	}
+	if flags&_FlagMessage != 0 {
+		msg, err := parseMessage(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse message: %w", err)
+		}
+		p.Messages = []any{msg}
+	}
+	if flags&_FlagMessages != 0 {
+		num := r.Int32()
+		if num < 0 || int(num) > len(r.Rest()) {
+			return nil, fmt.Errorf("incorrect messages number %d", num)
+		}
+
+		p.messagesVector = true
+		for i := int32(0); i < num; i++ {
+			msg, err := parseMessage(r)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse message %d: %w", i, err)
+			}
+			p.Messages = append(p.Messages, msg)
+		}
+	}
+	if flags&_FlagAddress != 0 {
+		list, err := ParseAddressList(r, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse address list: %w", err)
+		}
+		p.Address = list
+	}
+	if flags&_FlagPriorityAddress != 0 {
+		list, err := ParseAddressList(r, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse priority address list: %w", err)
+		}
+		p.PriorityAddress = list
+	}
+	if flags&_FlagSeqno != 0 {
+		v := r.Int64()
+		p.Seqno = &v
+	}
+	if flags&_FlagConfirmSeqno != 0 {
+		v := r.Int64()
+		p.ConfirmSeqno = &v
+	}
+	if flags&_FlagRecvAddrListVersion != 0 {
+		v := r.Int32()
+		p.RecvAddrListVersion = &v
+	}
+	if flags&_FlagRecvPriorityAddrListVersion != 0 {
+		v := r.Int32()
+		p.RecvPriorityAddrListVersion = &v
+	}
+	if flags&_FlagReinitDate != 0 {
+		reinit, dst := r.Int32(), r.Int32()
+		p.ReinitDate, p.DstReinitDate = &reinit, &dst
+	}
+	if flags&_FlagSignature != 0 {
+		p.Signature = r.Bytes()
+	}
+	p.Rand2 = r.Bytes()
+
+	if r.Err() != nil {
+		return nil, r.Err()
+	}
+	return p, nil
+}
+
+func serializeMessage(msg any) ([]byte, error) {
+	switch m := msg.(type) {
+	case MessageCreateChannel:
+		data := tl.AppendInt32(nil, _MessageCreateChannel)
+		data = append(data, m.Key...)
+		return tl.AppendInt32(data, m.Date), nil
+	case MessageConfirmChannel:
+		data := tl.AppendInt32(nil, _MessageConfirmChannel)
+		data = append(data, m.Key...)
+		data = append(data, m.PeerKey...)
+		return tl.AppendInt32(data, m.Date), nil
+	case MessageCustom:
+		return append(tl.AppendInt32(nil, _MessageCustom), tl.ToBytes(m.Data)...), nil
+	case MessageNop:
+		return tl.AppendInt32(nil, _MessageNop), nil
+	case MessageReinit:
+		return tl.AppendInt32(tl.AppendInt32(nil, _MessageReinit), m.Date), nil
+	case MessageQuery:
+		data := tl.AppendInt32(nil, _MessageQuery)
+		data = append(data, m.ID...)
+		return append(data, tl.ToBytes(m.Data)...), nil
+	case MessageAnswer:
+		data := tl.AppendInt32(nil, _MessageAnswer)
+		data = append(data, m.ID...)
+		return append(data, tl.ToBytes(m.Data)...), nil
+	case MessagePart:
+		data := tl.AppendInt32(nil, _MessagePart)
+		data = append(data, m.Hash...)
+		data = tl.AppendInt32(data, m.TotalSize)
+		data = tl.AppendInt32(data, m.Offset)
+		return append(data, tl.ToBytes(m.Data)...), nil
+	}
+	return nil, fmt.Errorf("unknown message type %T", msg)
+}
+
+func parseMessage(r *tl.Reader) (any, error) {
+	var msg any
+
+	switch id := r.Int32(); id {
+	case _MessageCreateChannel:
+		msg = MessageCreateChannel{Key: r.Int256(), Date: r.Int32()}
+	case _MessageConfirmChannel:
+		msg = MessageConfirmChannel{Key: r.Int256(), PeerKey: r.Int256(), Date: r.Int32()}
+	case _MessageCustom:
+		msg = MessageCustom{Data: r.Bytes()}
+	case _MessageNop:
+		msg = MessageNop{}
+	case _MessageReinit:
+		msg = MessageReinit{Date: r.Int32()}
+	case _MessageQuery:
+		msg = MessageQuery{ID: r.Int256(), Data: r.Bytes()}
+	case _MessageAnswer:
+		msg = MessageAnswer{ID: r.Int256(), Data: r.Bytes()}
+	case _MessagePart:
+		msg = MessagePart{Hash: r.Int256(), TotalSize: r.Int32(), Offset: r.Int32(), Data: r.Bytes()}
+	default:
+		if r.Err() == nil {
+			return nil, fmt.Errorf("unknown message type %d", id)
+		}
+	}
+
+	if r.Err() != nil {
+		return nil, r.Err()
+	}
+	return msg, nil
+}
+
+// Serialize - serializes address list with type id, as it is stored in dht
+func (l *AddressList) Serialize() []byte {
+	return append(tl.AppendInt32(nil, _AddressList), l.SerializeBare()...)
+}
+
+// SerializeBare - serializes address list without type id, as it is embedded into packets and dht nodes
+func (l *AddressList) SerializeBare() []byte {
+	data := tl.AppendInt32(nil, int32(len(l.Addresses)))
+	for _, a := range l.Addresses {
+		data = append(data, a.Serialize()...)
+	}
+	data = tl.AppendInt32(data, l.Version)
+	data = tl.AppendInt32(data, l.ReinitDate)
+	data = tl.AppendInt32(data, l.Priority)
+	return tl.AppendInt32(data, l.ExpireAt)
+}
+
+// ParseAddressList - reads address list, boxed should be true when it is prefixed with type id
+func ParseAddressList(r *tl.Reader, boxed bool) (*AddressList, error) {
+	if boxed {
+		if id := r.Int32(); id != _AddressList && r.Err() == nil {
+			return nil, fmt.Errorf("unexpected address list type %d", id)
+		}
+	}
+
+	num := r.Int32()
+	if num < 0 || int(num) > len(r.Rest()) {
+		return nil, fmt.Errorf("incorrect addresses number %d", num)
+	}
+
+	l := &AddressList{}
+	for i := int32(0); i < num; i++ {
+		a := &Address{}
+		switch id := r.Int32(); id {
+		case _AddressUDP:
+			ip := make(net.IP, 4)
+			binary.BigEndian.PutUint32(ip, uint32(r.Int32()))
+			a.IP = ip
+		case _AddressUDP6:
+			a.IP = net.IP(r.Raw(16))
+		default:
+			if r.Err() == nil {
+				return nil, fmt.Errorf("unsupported address type %d", id)
+			}
+		}
+		a.Port = r.Int32()
+		l.Addresses = append(l.Addresses, a)
+	}
+
+	l.Version = r.Int32()
+	l.ReinitDate = r.Int32()
+	l.Priority = r.Int32()
+	l.ExpireAt = r.Int32()
+
+	if r.Err() != nil {
+		return nil, r.Err()
+	}
+	return l, nil
+}
+
+func (a *Address) Serialize() []byte {
+	if ip := a.IP.To4(); ip != nil {
+		data := tl.AppendInt32(nil, _AddressUDP)
+		data = tl.AppendInt32(data, int32(binary.BigEndian.Uint32(ip)))
+		return tl.AppendInt32(data, a.Port)
+	}
+
+	data := tl.AppendInt32(nil, _AddressUDP6)
+	data = append(data, a.IP.To16()...)
+	return tl.AppendInt32(data, a.Port)
+}
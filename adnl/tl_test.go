@@ -0,0 +1,100 @@
+package adnl
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestPacket_Serialize(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+
+	seqno, confirm := int64(7), int64(5)
+	reinit, dst := int32(1000), int32(0)
+
+	p := &packet{
+		Rand1: []byte{1, 2, 3, 4, 5, 6, 7},
+		From:  pub,
+		Messages: []any{
+			MessageCreateChannel{Key: bytes.Repeat([]byte{1}, 32), Date: 77},
+			MessageQuery{ID: bytes.Repeat([]byte{2}, 32), Data: []byte("query")},
+			MessagePart{Hash: bytes.Repeat([]byte{3}, 32), TotalSize: 3000, Offset: 1024, Data: bytes.Repeat([]byte{4}, 300)},
+			MessageNop{},
+		},
+		Address: &AddressList{
+			Addresses: []*Address{
+				{IP: net.IPv4(1, 2, 3, 4).To4(), Port: 30303},
+				{IP: net.ParseIP("2001:db8::1"), Port: 1},
+			},
+			Version:    3,
+			ReinitDate: 1000,
+		},
+		Seqno:          &seqno,
+		ConfirmSeqno:   &confirm,
+		ReinitDate:     &reinit,
+		DstReinitDate:  &dst,
+		Signature:      bytes.Repeat([]byte{9}, 64),
+		Rand2:          []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+		messagesVector: true,
+	}
+
+	data, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data)%4 != 0 {
+		t.Fatal("packet is not aligned", len(data))
+	}
+
+	parsed, err := parsePacket(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(p, parsed) {
+		t.Fatal("parsed packet not equals")
+	}
+
+	// signature data should not contain signature, but should keep everything else
+	signed, err := parsed.signatureData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Signature == nil || bytes.Contains(signed, p.Signature) || len(signed) != len(data)-68 {
+		t.Fatal("wrong signature data")
+	}
+
+	if _, err = parsePacket(data[:len(data)-4]); err == nil {
+		t.Fatal("truncated packet should not be parsed")
+	}
+}
+
+func TestPacket_SingleMessage(t *testing.T) {
+	p := &packet{
+		Rand1:    []byte{1, 2, 3},
+		Messages: []any{MessageCustom{Data: []byte("hello")}},
+		Rand2:    []byte{},
+	}
+
+	data, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parsePacket(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.flags() != _FlagMessage || parsed.messagesVector {
+		t.Fatal("single message should be serialized as message field")
+	}
+
+	if !reflect.DeepEqual(parsed.Messages, p.Messages) {
+		t.Fatal("wrong message")
+	}
+}
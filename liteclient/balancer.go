@@ -0,0 +1,263 @@
+package liteclient
+
+import (
+	"encoding/binary"
+	"math"
+	mRand "math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	_GetMasterchainInfo int32 = -1984567762
+	_MasterchainInfo    int32 = -2055001983
+	_MasterchainInfoExt int32 = -1462968075
+
+	// _MaxSeqnoLag - node which is behind the best known masterchain seqno by more blocks is not used,
+	// small lag is allowed because seqno of nodes is not updated at the same time
+	_MaxSeqnoLag = 2
+	// _ProbeTimeout - probe without answer during this time is counted as failure
+	_ProbeTimeout = 5 * time.Second
+	// _DefaultProbeInterval - how often nodes are probed, probes are sent together with pings
+	_DefaultProbeInterval = 5 * time.Second
+
+	// _LatencyWeight - weight of new latency sample in moving average
+	_LatencyWeight = 0.2
+	// _ErrorWeight - weight of new request result in moving average of error rate
+	_ErrorWeight = 0.1
+)
+
+// NodeStats - health of lite server connection, score is used by balancer, lower is better.
+// Lagging nodes are excluded from balancing while there are nodes which are not lagging.
+type NodeStats struct {
+	Addr      string
+	ServerKey string
+
+	Latency   time.Duration
+	ErrorRate float64
+	Requests  uint64
+	Failures  uint64
+	InFlight  int
+
+	MasterSeqno uint32
+	SeqnoLag    uint32
+	Lagging     bool
+
+	Score float64
+}
+
+// nodeStats - request results of connection, latency and error rate are moving averages
+type nodeStats struct {
+	mx sync.Mutex
+
+	latency   time.Duration
+	errorRate float64
+	requests  uint64
+	failures  uint64
+	inFlight  int
+
+	seqno   uint32
+	seqnoAt time.Time
+}
+
+// setLatency - sets initial latency, before there are requests
+func (s *nodeStats) setLatency(latency time.Duration) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.latency = latency
+}
+
+func (s *nodeStats) begin() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.inFlight++
+}
+
+// done - records result of request, latency is zero when request was not sent
+func (s *nodeStats) done(latency time.Duration, failed bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.inFlight > 0 {
+		s.inFlight--
+	}
+	s.record(latency, failed)
+}
+
+func (s *nodeStats) record(latency time.Duration, failed bool) {
+	s.requests++
+
+	errSample := 0.0
+	if failed {
+		s.failures++
+		errSample = 1
+	}
+	s.errorRate += (errSample - s.errorRate) * _ErrorWeight
+
+	if latency > 0 {
+		if s.latency == 0 {
+			s.latency = latency
+		} else {
+			s.latency += time.Duration(float64(latency-s.latency) * _LatencyWeight)
+		}
+	}
+}
+
+// cancel - request was cancelled by caller, it says nothing about node
+func (s *nodeStats) cancel() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.inFlight > 0 {
+		s.inFlight--
+	}
+}
+
+func (s *nodeStats) setSeqno(seqno uint32) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.seqno = seqno
+	s.seqnoAt = time.Now()
+}
+
+// seqnoAge - time since node reported its seqno
+func (s *nodeStats) seqnoAge() time.Duration {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.seqnoAt.IsZero() {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Since(s.seqnoAt)
+}
+
+// observe - takes masterchain seqno of the node from its answers to masterchain info requests
+func (s *nodeStats) observe(resp *LiteResponse) {
+	var off int
+	switch resp.TypeID {
+	case _MasterchainInfo:
+		off = 12
+	case _MasterchainInfoExt:
+		// mode, version and capabilities are before block id
+		off = 16 + 12
+	default:
+		return
+	}
+
+	if len(resp.Data) < off+4 {
+		return
+	}
+	s.setSeqno(binary.LittleEndian.Uint32(resp.Data[off:]))
+}
+
+func (s *nodeStats) snapshot() NodeStats {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	return NodeStats{
+		Latency:     s.latency,
+		ErrorRate:   s.errorRate,
+		Requests:    s.requests,
+		Failures:    s.failures,
+		InFlight:    s.inFlight,
+		MasterSeqno: s.seqno,
+		Score:       score(s.latency, s.inFlight, s.errorRate),
+	}
+}
+
+// score - expected cost of request to the node, slow, busy and failing nodes get higher score
+func score(latency time.Duration, inFlight int, errorRate float64) float64 {
+	success := 1 - errorRate
+	if success < 0.01 {
+		success = 0.01
+	}
+	return latency.Seconds() * float64(1+inFlight) / success
+}
+
+// Stats - returns health of active connections, it can be used for monitoring
+func (c *ConnectionPool) Stats() []NodeStats {
+	_, stats := c.nodesStats()
+	return stats
+}
+
+func (c *ConnectionPool) nodesStats() ([]*connection, []NodeStats) {
+	c.nodesMx.RLock()
+	nodes := append([]*connection{}, c.activeNodes...)
+	c.nodesMx.RUnlock()
+
+	var best uint32
+	stats := make([]NodeStats, len(nodes))
+	for i, n := range nodes {
+		stats[i] = n.stats.snapshot()
+		stats[i].Addr = n.addr
+		stats[i].ServerKey = n.serverKey
+
+		if stats[i].MasterSeqno > best {
+			best = stats[i].MasterSeqno
+		}
+	}
+
+	for i := range stats {
+		// seqno of node is unknown until it answers to masterchain info request
+		if stats[i].MasterSeqno > 0 {
+			stats[i].SeqnoLag = best - stats[i].MasterSeqno
+			stats[i].Lagging = stats[i].SeqnoLag > _MaxSeqnoLag
+		}
+	}
+	return nodes, stats
+}
+
+// orderNodes - returns active nodes in order they should be tried. First node is the best of two random
+// not lagging nodes, so load is spread between good nodes and bad ones get less requests.
+// Other nodes follow by score, lagging nodes are the last.
+func (c *ConnectionPool) orderNodes() []*connection {
+	nodes, stats := c.nodesStats()
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	idx := make([]int, len(nodes))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	sort.Slice(idx, func(i, j int) bool {
+		a, b := stats[idx[i]], stats[idx[j]]
+		if a.Lagging != b.Lagging {
+			return !a.Lagging
+		}
+		return a.Score < b.Score
+	})
+
+	healthy := 0
+	for healthy < len(idx) && !stats[idx[healthy]].Lagging {
+		healthy++
+	}
+
+	if healthy >= 2 {
+		x := mRand.Intn(healthy)
+		y := mRand.Intn(healthy - 1)
+		if y >= x {
+			y++
+		}
+
+		// sorted by score, so smaller position is the better node
+		if y < x {
+			x = y
+		}
+
+		chosen := idx[x]
+		copy(idx[1:x+1], idx[:x])
+		idx[0] = chosen
+	}
+
+	res := make([]*connection, len(idx))
+	for i, id := range idx {
+		res[i] = nodes[id]
+	}
+	return res
+}
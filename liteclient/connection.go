@@ -36,7 +36,8 @@ type connection struct {
 
 	reqs chan *LiteRequest
 
-	pool *ConnectionPool
+	pool  *ConnectionPool
+	stats nodeStats
 }
 
 func (c *ConnectionPool) AddConnectionsFromConfig(ctx context.Context, config *GlobalConfig) error {
@@ -115,6 +116,7 @@ func (c *ConnectionPool) AddConnection(ctx context.Context, addr, serverKey stri
 		till = time.Now().Add(60 * time.Second)
 	}
 
+	connectAt := time.Now()
 	conn.tcp, err = net.DialTimeout("tcp", addr, till.Sub(time.Now()))
 	if err != nil {
 		return err
@@ -154,6 +156,9 @@ func (c *ConnectionPool) AddConnection(ctx context.Context, addr, serverKey stri
 			return err
 		}
 
+		// until there are requests, handshake time is the best estimation of latency
+		conn.stats.setLatency(time.Since(connectAt))
+
 		go conn.startPings(5 * time.Second)
 
 		c.nodesMx.Lock()
@@ -276,12 +281,17 @@ func (n *connection) startPings(every time.Duration) {
 
 			break
 		}
+
+		// ping only checks that connection is alive, probe also tells how fast node answers and how fresh its data is
+		if n.pool.needProbe(n) {
+			go n.pool.probe(n)
+		}
 	}
 }
 
 func (n *connection) readSize() (uint32, error) {
 	size := make([]byte, 4)
-	_, err := n.tcp.Read(size)
+	_, err := io.ReadFull(n.tcp, size)
 	if err != nil {
 		return 0, err
 	}
@@ -375,19 +385,7 @@ func (n *connection) handshake(data []byte, ourKey ed25519.PrivateKey, serverKey
 		return err
 	}
 
-	k := []byte{
-		key[0], key[1], key[2], key[3], key[4], key[5], key[6], key[7],
-		key[8], key[9], key[10], key[11], key[12], key[13], key[14], key[15],
-		checksum[16], checksum[17], checksum[18], checksum[19], checksum[20], checksum[21], checksum[22], checksum[23],
-		checksum[24], checksum[25], checksum[26], checksum[27], checksum[28], checksum[29], checksum[30], checksum[31],
-	}
-
-	iv := []byte{
-		checksum[0], checksum[1], checksum[2], checksum[3], key[20], key[21], key[22], key[23],
-		key[24], key[25], key[26], key[27], key[28], key[29], key[30], key[31],
-	}
-
-	ctr, err := newCipherCtr(k, iv)
+	ctr, err := handshakeCipher(key, checksum)
 	if err != nil {
 		return err
 	}
@@ -410,6 +408,23 @@ func (n *connection) handshake(data []byte, ourKey ed25519.PrivateKey, serverKey
 	return nil
 }
 
+// handshakeCipher - builds cipher for handshake packet data from shared key and checksum of the data
+func handshakeCipher(key, checksum []byte) (cipher.Stream, error) {
+	k := []byte{
+		key[0], key[1], key[2], key[3], key[4], key[5], key[6], key[7],
+		key[8], key[9], key[10], key[11], key[12], key[13], key[14], key[15],
+		checksum[16], checksum[17], checksum[18], checksum[19], checksum[20], checksum[21], checksum[22], checksum[23],
+		checksum[24], checksum[25], checksum[26], checksum[27], checksum[28], checksum[29], checksum[30], checksum[31],
+	}
+
+	iv := []byte{
+		checksum[0], checksum[1], checksum[2], checksum[3], key[20], key[21], key[22], key[23],
+		key[24], key[25], key[26], key[27], key[28], key[29], key[30], key[31],
+	}
+
+	return newCipherCtr(k, iv)
+}
+
 func (n *connection) ping(qid uint64) error {
 	data := make([]byte, 12)
 	binary.LittleEndian.PutUint32(data, uint32(TCPPing))
@@ -431,6 +446,16 @@ func (n *connection) queryADNL(qid, payload []byte) error {
 	return n.send(data)
 }
 
+// sendRequest - sends request to lite server, failed send is recorded to stats of the node
+func (n *connection) sendRequest(req *LiteRequest) error {
+	n.stats.begin()
+	if err := n.queryLiteServer(req.QueryID, req.TypeID, req.Data); err != nil {
+		n.stats.done(0, true)
+		return err
+	}
+	return nil
+}
+
 func (n *connection) queryLiteServer(qid []byte, typeID int32, payload []byte) error {
 	data := make([]byte, 4)
 	binary.LittleEndian.PutUint32(data, uint32(LiteServerQuery))
@@ -28,6 +28,26 @@ func keyID(key []byte) ([]byte, error) {
 	return s, nil
 }
 
+// KeyID - computes short id of ed25519 public key, adnl addresses peers and channels by it
+func KeyID(key ed25519.PublicKey) ([]byte, error) {
+	return keyID(key)
+}
+
+// SharedCipher - builds AES-CTR cipher from shared key and checksum of the plain data,
+// adnl encrypts tcp handshake and udp packets this way
+func SharedCipher(key, checksum []byte) (cipher.Stream, error) {
+	if len(key) != 32 || len(checksum) != 32 {
+		return nil, errors.New("key and checksum should be 32 bytes")
+	}
+	return handshakeCipher(key, checksum)
+}
+
+// SharedKey - computes shared secret of our private key and peer's public key (ECDH on ed25519 keys),
+// it is used by adnl and also by other protocols, like encrypted comments
+func SharedKey(ourKey ed25519.PrivateKey, peerKey ed25519.PublicKey) ([]byte, error) {
+	return sharedKey(ourKey, peerKey)
+}
+
 // generate encryption key based on our and server key, ECDH algorithm
 func sharedKey(ourKey ed25519.PrivateKey, serverKey ed25519.PublicKey) ([]byte, error) {
 	comp, err := curve.NewCompressedEdwardsYFromBytes(serverKey)
@@ -13,6 +13,7 @@ const ADNLQuery int32 = -1265895046
 const ADNLQueryResponse int32 = 262964246
 
 const LiteServerQuery int32 = 2039219935
+const LiteServerError int32 = -1146494648
 
 func parseServerResp(data []byte) (typ int32, queryID string, payload []byte, err error) {
 	if len(data) <= 4 {
@@ -6,9 +6,8 @@ import (
 	"encoding/hex"
 	"errors"
 	"io"
-	mRand "math/rand"
+	"sort"
 	"sync"
-	"sync/atomic"
 	"time"
 )
 
@@ -35,15 +34,17 @@ type ConnectionPool struct {
 	reqMx       sync.RWMutex
 	nodesMx     sync.RWMutex
 
-	onDisconnect     func(addr, key string)
-	roundRobinOffset uint64
+	onDisconnect  func(addr, key string)
+	retryPolicy   RetryPolicy
+	probeInterval time.Duration
 }
 
 var ErrNoActiveConnections = errors.New("no active connections")
 
 func NewConnectionPool() *ConnectionPool {
 	c := &ConnectionPool{
-		activeReqs: map[string]*LiteRequest{},
+		activeReqs:    map[string]*LiteRequest{},
+		probeInterval: _DefaultProbeInterval,
 	}
 
 	// default reconnect policy
@@ -64,18 +65,35 @@ func NewConnectionPool() *ConnectionPool {
 func (c *ConnectionPool) StickyContext(ctx context.Context) context.Context {
 	var id uint32
 
-	c.nodesMx.RLock()
-	if len(c.activeNodes) > 0 {
-		// pick random one
-		id = c.activeNodes[mRand.Uint32()%uint32(len(c.activeNodes))].id
+	// pick the node which balancer would use
+	if nodes := c.orderNodes(); len(nodes) > 0 {
+		id = nodes[0].id
 	}
-	c.nodesMx.RUnlock()
 
 	return context.WithValue(ctx, _StickyCtxKey, id)
 }
 
-// Do - builds and executes request to liteserver
+// Do - builds and executes request to liteserver, failed request is resent to other nodes according to retry policy
 func (c *ConnectionPool) Do(ctx context.Context, typeID int32, payload []byte) (*LiteResponse, error) {
+	c.reqMx.RLock()
+	policy := c.retryPolicy
+	c.reqMx.RUnlock()
+
+	if policy.canRetry(typeID) {
+		return c.doWithRetry(ctx, policy, typeID, payload)
+	}
+
+	return c.do(ctx, typeID, payload, func(req *LiteRequest) (*connection, error) {
+		if nodeID, ok := ctx.Value(_StickyCtxKey).(uint32); ok && nodeID > 0 {
+			return c.querySticky(nodeID, req, nil)
+		}
+		return c.queryWithBalancer(req, nil)
+	})
+}
+
+// do - sends request using send func, which returns node the request was sent to, and waits for response.
+// Result of the request is recorded to stats of the node.
+func (c *ConnectionPool) do(ctx context.Context, typeID int32, payload []byte, send func(req *LiteRequest) (*connection, error)) (*LiteResponse, error) {
 	id := make([]byte, 32)
 	if _, err := io.ReadFull(rand.Reader, id); err != nil {
 		return nil, err
@@ -103,16 +121,10 @@ func (c *ConnectionPool) Do(ctx context.Context, typeID int32, payload []byte) (
 		c.reqMx.Unlock()
 	}()
 
-	if nodeID, ok := ctx.Value(_StickyCtxKey).(uint32); ok && nodeID > 0 {
-		err := c.querySticky(nodeID, req)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		err := c.queryWithBalancer(req)
-		if err != nil {
-			return nil, err
-		}
+	sentAt := time.Now()
+	node, err := send(req)
+	if err != nil {
+		return nil, err
 	}
 
 	_, hasDeadline := ctx.Deadline()
@@ -126,12 +138,21 @@ func (c *ConnectionPool) Do(ctx context.Context, typeID int32, payload []byte) (
 	// wait for response
 	select {
 	case resp := <-ch:
+		node.stats.done(time.Since(sentAt), resp.err != nil)
+
 		if resp.err != nil {
 			return nil, resp.err
 		}
 
+		node.stats.observe(resp)
 		return resp, nil
 	case <-ctx.Done():
+		if ctx.Err() == context.Canceled {
+			node.stats.cancel()
+		} else {
+			node.stats.done(time.Since(sentAt), true)
+		}
+
 		if !hasDeadline {
 			return nil, errors.New("liteserver request timeout")
 		}
@@ -139,51 +160,76 @@ func (c *ConnectionPool) Do(ctx context.Context, typeID int32, payload []byte) (
 	}
 }
 
-func (c *ConnectionPool) querySticky(id uint32, req *LiteRequest) error {
+func (c *ConnectionPool) querySticky(id uint32, req *LiteRequest, exclude map[*connection]bool) (*connection, error) {
+	var node *connection
+
 	c.nodesMx.RLock()
-	for _, node := range c.activeNodes {
-		if node.id == id {
-			err := node.queryLiteServer(req.QueryID, req.TypeID, req.Data)
-			if err == nil {
-				return nil
-			}
+	for _, n := range c.activeNodes {
+		if n.id == id {
+			node = n
 			break
 		}
 	}
 	c.nodesMx.RUnlock()
 
+	if node != nil {
+		if err := node.sendRequest(req); err == nil {
+			return node, nil
+		}
+	}
+
 	// fallback if bounded node is not available
-	return c.queryWithBalancer(req)
+	return c.queryWithBalancer(req, exclude)
 }
 
-func (c *ConnectionPool) queryWithBalancer(req *LiteRequest) error {
-	nodeOffset := atomic.AddUint64(&c.roundRobinOffset, 1)
+// queryWithBalancer - sends request to the best node by score, if sending fails, next nodes are tried.
+// Excluded nodes are used only when other nodes are not available.
+func (c *ConnectionPool) queryWithBalancer(req *LiteRequest, exclude map[*connection]bool) (*connection, error) {
+	nodes := c.orderNodes()
 
-	var firstNode *connection
-	for {
-		c.nodesMx.RLock()
-		if len(c.activeNodes) == 0 {
-			c.nodesMx.RUnlock()
-			return ErrNoActiveConnections
-		}
-		reqNode := c.activeNodes[nodeOffset%uint64(len(c.activeNodes))]
-		c.nodesMx.RUnlock()
-
-		if firstNode == nil {
-			firstNode = reqNode
-		} else if reqNode == firstNode {
-			// all nodes were tried, nothing works
-			return ErrNoActiveConnections
-		}
+	// stable sort keeps order of balancer within both groups
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return !exclude[nodes[i]] && exclude[nodes[j]]
+	})
 
-		err := reqNode.queryLiteServer(req.QueryID, req.TypeID, req.Data)
-		if err != nil {
-			nodeOffset++
-			continue
+	for _, node := range nodes {
+		if err := node.sendRequest(req); err == nil {
+			return node, nil
 		}
-
-		return nil
 	}
+
+	// all nodes were tried, nothing works
+	return nil, ErrNoActiveConnections
+}
+
+// SetProbeInterval - sets how often masterchain info is requested from each node to know its seqno and latency,
+// zero disables probes. Probes are sent together with pings, so interval is rounded up to ping period (5 seconds).
+// Node is not probed when it reported its seqno recently, answering masterchain info requests of the user.
+// Without probes, seqno lag of nodes is known only from user requests.
+func (c *ConnectionPool) SetProbeInterval(interval time.Duration) {
+	c.reqMx.Lock()
+	c.probeInterval = interval
+	c.reqMx.Unlock()
+}
+
+// needProbe - probe is needed when node did not report its seqno during the last half of interval,
+// half is used, so seqno reported by the previous probe does not skip the next one
+func (c *ConnectionPool) needProbe(node *connection) bool {
+	c.reqMx.RLock()
+	interval := c.probeInterval
+	c.reqMx.RUnlock()
+
+	return interval > 0 && node.stats.seqnoAge() >= interval/2
+}
+
+// probe - requests masterchain info from the node, to know its seqno and latency even when it is not used
+func (c *ConnectionPool) probe(node *connection) {
+	ctx, cancel := context.WithTimeout(context.Background(), _ProbeTimeout)
+	defer cancel()
+
+	_, _ = c.do(ctx, _GetMasterchainInfo, nil, func(req *LiteRequest) (*connection, error) {
+		return node, node.sendRequest(req)
+	})
 }
 
 func (c *ConnectionPool) SetOnDisconnect(cb OnDisconnectCallback) {
@@ -0,0 +1,226 @@
+package liteclient
+
+import (
+	"context"
+	"encoding/binary"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// masterchainInfo - builds liteServer.masterchainInfo with the seqno, other fields are zero
+func masterchainInfo(seqno uint32) *LiteResponse {
+	data := make([]byte, 4+8+4+32+32+32+4+32+32)
+	binary.LittleEndian.PutUint32(data, uint32(0xFFFFFFFF))
+	binary.LittleEndian.PutUint64(data[4:], 0x8000000000000000)
+	binary.LittleEndian.PutUint32(data[12:], seqno)
+	return &LiteResponse{TypeID: _MasterchainInfo, Data: data}
+}
+
+type testNode struct {
+	addr, key string
+	delay     time.Duration
+	err       error
+	seqno     uint32 // atomic
+	calls     int32  // atomic
+}
+
+func startTestNodes(t *testing.T, nodes ...*testNode) *ConnectionPool {
+	pool := NewConnectionPool()
+	pool.SetOnDisconnect(nil)
+
+	for _, n := range nodes {
+		n := n
+		_, n.addr, n.key = startTestServer(t, func(ctx context.Context, typeID int32, payload []byte) (*LiteResponse, error) {
+			if typeID == _GetMasterchainInfo {
+				return masterchainInfo(atomic.LoadUint32(&n.seqno)), nil
+			}
+
+			atomic.AddInt32(&n.calls, 1)
+			time.Sleep(n.delay)
+			if n.err != nil {
+				return nil, n.err
+			}
+			return &LiteResponse{TypeID: 2, Data: payload}, nil
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := pool.AddConnection(ctx, n.addr, n.key)
+		cancel()
+		if err != nil {
+			t.Fatal("connect err", err)
+		}
+	}
+	return pool
+}
+
+func probeAll(pool *ConnectionPool) {
+	pool.nodesMx.RLock()
+	nodes := append([]*connection{}, pool.activeNodes...)
+	pool.nodesMx.RUnlock()
+
+	for _, n := range nodes {
+		pool.probe(n)
+	}
+}
+
+func TestConnectionPool_BalancerLatency(t *testing.T) {
+	fast := &testNode{}
+	slow := &testNode{delay: 30 * time.Millisecond}
+	pool := startTestNodes(t, fast, slow)
+
+	for i := 0; i < 40; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := pool.Do(ctx, 1, []byte{1, 2, 3, 4})
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if atomic.LoadInt32(&slow.calls) > 5 {
+		t.Fatal("slow node got too many requests", slow.calls, fast.calls)
+	}
+
+	stats := map[string]NodeStats{}
+	for _, st := range pool.Stats() {
+		stats[st.Addr] = st
+	}
+
+	if stats[fast.addr].Requests == 0 || stats[fast.addr].Score <= 0 {
+		t.Fatal("stats are not collected", stats[fast.addr])
+	}
+
+	if stats[slow.addr].Latency <= stats[fast.addr].Latency || stats[slow.addr].Score <= stats[fast.addr].Score {
+		t.Fatal("slow node should have worse stats", stats[slow.addr], stats[fast.addr])
+	}
+}
+
+func TestConnectionPool_BalancerLagging(t *testing.T) {
+	a := &testNode{seqno: 1000}
+	b := &testNode{seqno: 999}
+	lagging := &testNode{seqno: 990}
+	pool := startTestNodes(t, a, b, lagging)
+
+	probeAll(pool)
+
+	for _, st := range pool.Stats() {
+		switch st.Addr {
+		case lagging.addr:
+			if !st.Lagging || st.SeqnoLag != 10 || st.MasterSeqno != 990 {
+				t.Fatal("node should be lagging", st)
+			}
+		default:
+			if st.Lagging {
+				t.Fatal("node should not be lagging", st)
+			}
+		}
+	}
+
+	for i := 0; i < 30; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := pool.Do(ctx, 1, nil)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if atomic.LoadInt32(&lagging.calls) != 0 || atomic.LoadInt32(&a.calls)+atomic.LoadInt32(&b.calls) != 30 {
+		t.Fatal("lagging node should not be used", a.calls, b.calls, lagging.calls)
+	}
+
+	// node caught up
+	atomic.StoreUint32(&lagging.seqno, 1000)
+	probeAll(pool)
+
+	for _, st := range pool.Stats() {
+		if st.Lagging {
+			t.Fatal("no nodes should be lagging", st)
+		}
+	}
+}
+
+func TestConnectionPool_BalancerErrors(t *testing.T) {
+	good := &testNode{}
+	stuck := &testNode{delay: 300 * time.Millisecond}
+	pool := startTestNodes(t, good, stuck)
+
+	// make stuck node look the best one, so balancer has to learn from failures
+	pool.nodesMx.RLock()
+	for _, n := range pool.activeNodes {
+		n.stats.latency = time.Millisecond
+		if n.addr == good.addr {
+			n.stats.latency = 5 * time.Millisecond
+		}
+	}
+	pool.nodesMx.RUnlock()
+
+	failed := 0
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err := pool.Do(ctx, 1, nil)
+		cancel()
+		if err != nil {
+			failed++
+		}
+	}
+
+	if failed == 0 || failed > 3 {
+		t.Fatal("stuck node should be avoided after failures", failed)
+	}
+
+	for _, st := range pool.Stats() {
+		if st.Addr == stuck.addr && (st.Failures == 0 || st.ErrorRate <= 0) {
+			t.Fatal("failures of stuck node are not recorded", st)
+		}
+	}
+}
+
+func TestConnectionPool_NeedProbe(t *testing.T) {
+	pool := NewConnectionPool()
+	node := &connection{}
+
+	if !pool.needProbe(node) {
+		t.Fatal("node with unknown seqno should be probed")
+	}
+
+	node.stats.setSeqno(10)
+	if pool.needProbe(node) {
+		t.Fatal("node which just reported seqno should not be probed")
+	}
+
+	node.stats.seqnoAt = time.Now().Add(-_DefaultProbeInterval)
+	if !pool.needProbe(node) {
+		t.Fatal("node with old seqno should be probed")
+	}
+
+	pool.SetProbeInterval(time.Minute)
+	if pool.needProbe(node) {
+		t.Fatal("node should not be probed more often than interval")
+	}
+
+	pool.SetProbeInterval(0)
+	node.stats.seqnoAt = time.Time{}
+	if pool.needProbe(node) {
+		t.Fatal("probes should be disabled")
+	}
+}
+
+func TestScore(t *testing.T) {
+	if score(10*time.Millisecond, 0, 0) >= score(20*time.Millisecond, 0, 0) {
+		t.Fatal("slower node should have higher score")
+	}
+
+	if score(10*time.Millisecond, 0, 0) >= score(10*time.Millisecond, 3, 0) {
+		t.Fatal("busy node should have higher score")
+	}
+
+	if score(10*time.Millisecond, 0, 0) >= score(10*time.Millisecond, 0, 0.5) {
+		t.Fatal("failing node should have higher score")
+	}
+
+	if s := score(10*time.Millisecond, 0, 1); s <= 0 || s > 10 {
+		t.Fatal("incorrect score of always failing node", s)
+	}
+}
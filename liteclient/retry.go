@@ -0,0 +1,146 @@
+package liteclient
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+const (
+	_SendMessage int32 = 1762317442
+
+	// lite server error codes which mean that other node may answer
+	_ErrorCodeNotReady = 651
+	_ErrorCodeTimeout  = 652
+)
+
+// RetryPolicy - describes when request which was not answered by node is sent to another node.
+// All attempts are done within deadline of request context. Requests with StickyContext
+// are retried on the same node, other node is used only when sticky one is not available.
+// sendMessage is never retried, because message could be already accepted by the node which failed to answer.
+type RetryPolicy struct {
+	// MaxAttempts - total number of attempts including the first one, 1 or less disables retries
+	MaxAttempts int
+	// AttemptTimeout - max time of one attempt, so there is time left for next attempts.
+	// It is applied only when context deadline leaves time for the next attempt,
+	// otherwise attempt can use all remaining time of request context. Zero means no limit.
+	AttemptTimeout time.Duration
+	// Backoff - wait before the second attempt, it is doubled for each next attempt, up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// RetryableQuery - decides if query of this type can be retried, nil means all queries
+	RetryableQuery func(typeID int32) bool
+	// RetryableError - decides if liteServer.error with this code should be retried on another node,
+	// nil means no errors are retried, only timeouts and connection failures
+	RetryableError func(code int32, text string) bool
+}
+
+// DefaultRetryPolicy - retries timeouts and not ready errors 2 times.
+// Retries are disabled in new pools, use SetRetryPolicy to enable them.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		AttemptTimeout: 10 * time.Second,
+		Backoff:        100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		RetryableError: func(code int32, text string) bool {
+			return code == _ErrorCodeNotReady || code == _ErrorCodeTimeout
+		},
+	}
+}
+
+// SetRetryPolicy - sets policy of resending requests to other nodes
+func (c *ConnectionPool) SetRetryPolicy(policy RetryPolicy) {
+	c.reqMx.Lock()
+	c.retryPolicy = policy
+	c.reqMx.Unlock()
+}
+
+func (p *RetryPolicy) canRetry(typeID int32) bool {
+	if p.MaxAttempts <= 1 || typeID == _SendMessage {
+		return false
+	}
+	return p.RetryableQuery == nil || p.RetryableQuery(typeID)
+}
+
+func (p *RetryPolicy) retryableResponse(resp *LiteResponse) bool {
+	if resp.TypeID != LiteServerError || p.RetryableError == nil {
+		return false
+	}
+
+	r := tl.NewReader(resp.Data)
+	code := r.Int32()
+	text := r.Bytes()
+	if r.Err() != nil {
+		return false
+	}
+	return p.RetryableError(code, string(text))
+}
+
+// doWithRetry - sends request to nodes which were not tried yet, or to the sticky node,
+// until it is answered or attempts are over.
+// If the last attempt got retryable lite server error, it is returned as is.
+func (c *ConnectionPool) doWithRetry(ctx context.Context, policy RetryPolicy, typeID int32, payload []byte) (*LiteResponse, error) {
+	_, hasDeadline := ctx.Deadline()
+	if !hasDeadline {
+		// fallback timeout to not stuck forever with background context
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+	}
+
+	tried := map[*connection]bool{}
+	backoff := policy.Backoff
+
+	for attempt := 1; ; attempt++ {
+		last := attempt >= policy.MaxAttempts
+
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if deadline, _ := ctx.Deadline(); policy.AttemptTimeout > 0 && !last &&
+			time.Until(deadline) > policy.AttemptTimeout+backoff {
+			attemptCtx, cancel = context.WithTimeout(ctx, policy.AttemptTimeout)
+		}
+
+		resp, err := c.do(attemptCtx, typeID, payload, func(req *LiteRequest) (*connection, error) {
+			var node *connection
+			var err error
+			if nodeID, ok := ctx.Value(_StickyCtxKey).(uint32); ok && nodeID > 0 {
+				// other node can have different state, so we stay on the sticky one
+				node, err = c.querySticky(nodeID, req, tried)
+			} else {
+				node, err = c.queryWithBalancer(req, tried)
+			}
+
+			if node != nil {
+				tried[node] = true
+			}
+			return node, err
+		})
+		cancel()
+
+		if err == nil && (last || !policy.retryableResponse(resp)) {
+			return resp, nil
+		}
+
+		if err != nil && (last || ctx.Err() != nil) {
+			if ctx.Err() != nil && !hasDeadline {
+				return nil, errors.New("liteserver request timeout")
+			}
+			return nil, err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			// no time for next attempt, result of the last one is returned
+			return resp, err
+		}
+
+		if backoff *= 2; backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
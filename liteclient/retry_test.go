@@ -0,0 +1,192 @@
+package liteclient
+
+import (
+	"context"
+	"encoding/binary"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// preferNode - makes node look the best one for balancer, so it gets the first attempt
+func preferNode(pool *ConnectionPool, addr string) {
+	pool.nodesMx.RLock()
+	defer pool.nodesMx.RUnlock()
+
+	for _, n := range pool.activeNodes {
+		n.stats.latency = 5 * time.Millisecond
+		if n.addr == addr {
+			n.stats.latency = time.Millisecond
+		}
+	}
+}
+
+func TestConnectionPool_RetryLSError(t *testing.T) {
+	notReady := &testNode{err: ServerError{Code: _ErrorCodeNotReady, Text: "block not ready"}}
+	good := &testNode{}
+	pool := startTestNodes(t, notReady, good)
+	preferNode(pool, notReady.addr)
+	pool.SetRetryPolicy(DefaultRetryPolicy())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := pool.Do(ctx, 1, []byte{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.TypeID != 2 {
+		t.Fatal("should be answered by another node", resp.TypeID)
+	}
+
+	if atomic.LoadInt32(&notReady.calls) != 1 || atomic.LoadInt32(&good.calls) != 1 {
+		t.Fatal("incorrect calls", notReady.calls, good.calls)
+	}
+}
+
+func TestConnectionPool_RetryTimeout(t *testing.T) {
+	stuck := &testNode{delay: time.Second}
+	good := &testNode{}
+	pool := startTestNodes(t, stuck, good)
+	preferNode(pool, stuck.addr)
+
+	pool.SetRetryPolicy(RetryPolicy{
+		MaxAttempts:    2,
+		AttemptTimeout: 100 * time.Millisecond,
+		Backoff:        10 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	tm := time.Now()
+	resp, err := pool.Do(ctx, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.TypeID != 2 || time.Since(tm) >= 500*time.Millisecond {
+		t.Fatal("should be answered by another node within deadline", resp.TypeID, time.Since(tm))
+	}
+}
+
+func TestConnectionPool_RetryAttemptTimeout(t *testing.T) {
+	slow := &testNode{delay: 120 * time.Millisecond}
+	pool := startTestNodes(t, slow)
+
+	pool.SetRetryPolicy(RetryPolicy{
+		MaxAttempts:    3,
+		AttemptTimeout: 100 * time.Millisecond,
+		Backoff:        60 * time.Millisecond,
+	})
+
+	// there is no time for the next attempt, so attempt timeout is not applied
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	resp, err := pool.Do(ctx, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.TypeID != 2 || atomic.LoadInt32(&slow.calls) != 1 {
+		t.Fatal("should be answered by the first attempt", resp.TypeID, slow.calls)
+	}
+}
+
+func TestConnectionPool_RetrySticky(t *testing.T) {
+	lsErr := ServerError{Code: _ErrorCodeNotReady, Text: "block not ready"}
+	a := &testNode{err: lsErr}
+	b := &testNode{err: lsErr}
+	pool := startTestNodes(t, a, b)
+	preferNode(pool, a.addr)
+
+	pool.SetRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     10 * time.Millisecond,
+		RetryableError: func(code int32, text string) bool {
+			return true
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ctx = pool.StickyContext(ctx)
+	if _, err := pool.Do(ctx, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if atomic.LoadInt32(&a.calls) != 3 || atomic.LoadInt32(&b.calls) != 0 {
+		t.Fatal("sticky request should be retried on the same node", a.calls, b.calls)
+	}
+}
+
+func TestConnectionPool_RetryExhausted(t *testing.T) {
+	lsErr := ServerError{Code: _ErrorCodeNotReady, Text: "block not ready"}
+	a := &testNode{err: lsErr}
+	b := &testNode{err: lsErr}
+	pool := startTestNodes(t, a, b)
+
+	pool.SetRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     10 * time.Millisecond,
+		RetryableError: func(code int32, text string) bool {
+			return code == _ErrorCodeNotReady
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := pool.Do(ctx, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// error of the last attempt is returned as is
+	if resp.TypeID != LiteServerError || int32(binary.LittleEndian.Uint32(resp.Data)) != _ErrorCodeNotReady {
+		t.Fatal("should be error response", resp.TypeID)
+	}
+
+	if atomic.LoadInt32(&a.calls) == 0 || atomic.LoadInt32(&b.calls) == 0 || atomic.LoadInt32(&a.calls)+atomic.LoadInt32(&b.calls) != 3 {
+		t.Fatal("all nodes should be tried 3 times in total", a.calls, b.calls)
+	}
+}
+
+func TestConnectionPool_NoRetry(t *testing.T) {
+	a := &testNode{err: ServerError{Code: _ErrorCodeNotReady, Text: "block not ready"}}
+	b := &testNode{err: ServerError{Code: _ErrorCodeNotReady, Text: "block not ready"}}
+	pool := startTestNodes(t, a, b)
+
+	pool.SetRetryPolicy(RetryPolicy{
+		MaxAttempts: 3,
+		RetryableQuery: func(typeID int32) bool {
+			return typeID != 7
+		},
+		RetryableError: func(code int32, text string) bool {
+			return true
+		},
+	})
+
+	for _, typeID := range []int32{_SendMessage, 7} {
+		atomic.StoreInt32(&a.calls, 0)
+		atomic.StoreInt32(&b.calls, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		resp, err := pool.Do(ctx, typeID, nil)
+		cancel()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.TypeID != LiteServerError {
+			t.Fatal("should be error response", resp.TypeID)
+		}
+
+		if calls := atomic.LoadInt32(&a.calls) + atomic.LoadInt32(&b.calls); calls != 1 {
+			t.Fatal("query should not be retried", typeID, calls)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package liteclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+// serverCtxKey - context key of ServerClient in handlers context
+type serverCtxKey struct{}
+
+// _MaxConnQueries - max number of queries of one connection processed at the same time,
+// next queries of this connection are not read until some of them are answered
+const _MaxConnQueries = 64
+
+// _ErrorCodeError - generic error code of lite server, it is sent when handler returns error
+const _ErrorCodeError int32 = 601
+
+var ErrServerClosed = errors.New("server closed")
+
+// QueryHandler - processes query and returns response for it.
+// If error is returned, client receives liteServer.error with its text, use ServerError to set the code.
+type QueryHandler func(ctx context.Context, typeID int32, payload []byte) (*LiteResponse, error)
+
+// OnConnectCallback - called after handshake, connection is dropped if error is returned
+type OnConnectCallback func(client *ServerClient) error
+
+// ServerClient - info about connected client, available in handlers using ClientFromContext
+type ServerClient struct {
+	Addr string
+	Key  ed25519.PublicKey
+}
+
+// ServerError - error with lite server code, it is sent to client as is
+type ServerError struct {
+	Code int32
+	Text string
+}
+
+func (e ServerError) Error() string {
+	return fmt.Sprintf("lite server error, code %d: %s", e.Code, e.Text)
+}
+
+// Server - accepts ADNL TCP connections from lite clients and dispatches their queries to handlers by type id
+type Server struct {
+	key            ed25519.PrivateKey
+	keyID          []byte
+	defaultHandler QueryHandler
+
+	mx        sync.RWMutex
+	handlers  map[int32]QueryHandler
+	onConnect OnConnectCallback
+	listeners map[net.Listener]bool
+	conns     map[*connection]bool
+	closed    bool
+}
+
+// NewServer - creates server with the given key, clients should connect to it using base64 of its public key.
+// Handler is used for queries which have no handler registered with Handle, it can be nil.
+func NewServer(key ed25519.PrivateKey, handler QueryHandler) *Server {
+	kid, _ := keyID(key.Public().(ed25519.PublicKey))
+
+	return &Server{
+		key:            key,
+		keyID:          kid,
+		defaultHandler: handler,
+		handlers:       map[int32]QueryHandler{},
+		listeners:      map[net.Listener]bool{},
+		conns:          map[*connection]bool{},
+	}
+}
+
+// Handle - registers handler for queries with the given TL type id.
+// Queries wrapped into liteServer.query are dispatched by type of the inner query,
+// other ADNL queries are dispatched by their own type, so custom services can be built too.
+func (s *Server) Handle(typeID int32, handler QueryHandler) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if handler == nil {
+		delete(s.handlers, typeID)
+		return
+	}
+	s.handlers[typeID] = handler
+}
+
+// SetOnConnect - sets callback which is called for each new client after handshake,
+// it can be used to reject clients by key or address
+func (s *Server) SetOnConnect(cb OnConnectCallback) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.onConnect = cb
+}
+
+// ClientFromContext - returns client which sent the query, context should be the one passed to handler
+func ClientFromContext(ctx context.Context) *ServerClient {
+	client, _ := ctx.Value(serverCtxKey{}).(*ServerClient)
+	return client
+}
+
+// ListenAndServe - listens on tcp address and serves connections, blocks until server is closed
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ln)
+}
+
+// Serve - accepts connections from listener, blocks until listener fails or server is closed
+func (s *Server) Serve(ln net.Listener) error {
+	s.mx.Lock()
+	if s.closed {
+		s.mx.Unlock()
+		_ = ln.Close()
+		return ErrServerClosed
+	}
+	s.listeners[ln] = true
+	s.mx.Unlock()
+
+	defer func() {
+		s.mx.Lock()
+		delete(s.listeners, ln)
+		s.mx.Unlock()
+
+		_ = ln.Close()
+	}()
+
+	for {
+		tcp, err := ln.Accept()
+		if err != nil {
+			s.mx.Lock()
+			closed := s.closed
+			s.mx.Unlock()
+
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		go s.serveConn(tcp)
+	}
+}
+
+// Close - stops all listeners and drops active connections
+func (s *Server) Close() error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.closed = true
+	for ln := range s.listeners {
+		_ = ln.Close()
+	}
+	for conn := range s.conns {
+		_ = conn.tcp.Close()
+	}
+
+	return nil
+}
+
+func (s *Server) serveConn(tcp net.Conn) {
+	conn := &connection{
+		addr: tcp.RemoteAddr().String(),
+		tcp:  tcp,
+	}
+
+	s.mx.Lock()
+	if s.closed {
+		s.mx.Unlock()
+		_ = tcp.Close()
+		return
+	}
+	s.conns[conn] = true
+	s.mx.Unlock()
+
+	defer func() {
+		_ = tcp.Close()
+
+		s.mx.Lock()
+		delete(s.conns, conn)
+		s.mx.Unlock()
+	}()
+
+	clientKey, err := s.handshake(conn)
+	if err != nil {
+		return
+	}
+
+	client := &ServerClient{
+		Addr: conn.addr,
+		Key:  clientKey,
+	}
+
+	s.mx.RLock()
+	onConnect := s.onConnect
+	s.mx.RUnlock()
+
+	if onConnect != nil {
+		if err = onConnect(client); err != nil {
+			return
+		}
+	}
+
+	// confirm connection with empty packet
+	if err = conn.send(nil); err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), serverCtxKey{}, client))
+	defer cancel()
+
+	sem := make(chan struct{}, _MaxConnQueries)
+
+	for {
+		sz, err := conn.readSize()
+		if err != nil {
+			return
+		}
+
+		// should at least have nonce and checksum
+		if sz < 64 {
+			return
+		}
+
+		data, err := conn.readData(sz)
+		if err != nil {
+			return
+		}
+
+		checksum := data[len(data)-32:]
+		data = data[:len(data)-32]
+
+		if err = validatePacket(data, checksum); err != nil {
+			return
+		}
+
+		// skip nonce
+		data = data[32:]
+
+		if err = s.processPacket(ctx, conn, sem, data); err != nil {
+			return
+		}
+	}
+}
+
+// handshake - reads handshake packet of the client, initializes ciphers and returns key of the client
+func (s *Server) handshake(conn *connection) (ed25519.PublicKey, error) {
+	packet := make([]byte, 256)
+	if _, err := io.ReadFull(conn.tcp, packet); err != nil {
+		return nil, err
+	}
+
+	kid, clientKey, checksum, data := packet[:32], packet[32:64], packet[64:96], packet[96:]
+	if !bytes.Equal(kid, s.keyID) {
+		return nil, errors.New("handshake is for another server key")
+	}
+
+	key, err := sharedKey(s.key, clientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ctr, err := handshakeCipher(key, checksum)
+	if err != nil {
+		return nil, err
+	}
+	ctr.XORKeyStream(data, data)
+
+	hash := sha256.Sum256(data)
+	if !bytes.Equal(hash[:], checksum) {
+		return nil, errors.New("invalid handshake checksum")
+	}
+
+	// ciphers are mirrored, what client reads we write
+	conn.rCrypt, err = newCipherCtr(data[32:64], data[80:96])
+	if err != nil {
+		return nil, err
+	}
+	conn.wCrypt, err = newCipherCtr(data[:32], data[64:80])
+	if err != nil {
+		return nil, err
+	}
+
+	return append(ed25519.PublicKey{}, clientKey...), nil
+}
+
+func (s *Server) processPacket(ctx context.Context, conn *connection, sem chan struct{}, data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("too short packet: %d", len(data))
+	}
+
+	typ := int32(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+
+	switch typ {
+	case TCPPing:
+		if len(data) < 8 {
+			return fmt.Errorf("too short ping packet: %d", len(data))
+		}
+
+		// bypass compiler negative check
+		t := TCPPong
+
+		pong := make([]byte, 4)
+		binary.LittleEndian.PutUint32(pong, uint32(t))
+
+		return conn.send(append(pong, data[:8]...))
+	case ADNLQuery:
+		if len(data) <= 32 {
+			return fmt.Errorf("too short adnl query packet: %d", len(data))
+		}
+
+		qid := append([]byte{}, data[:32]...)
+
+		var query []byte
+		if err := tl.Unmarshal(data[32:], &query); err != nil {
+			return fmt.Errorf("failed to parse adnl query: %w", err)
+		}
+
+		if len(query) < 4 {
+			return fmt.Errorf("too short adnl query: %d", len(query))
+		}
+
+		if int32(binary.LittleEndian.Uint32(query)) == LiteServerQuery {
+			if err := tl.Unmarshal(query[4:], &query); err != nil {
+				return fmt.Errorf("failed to parse lite server query: %w", err)
+			}
+
+			if len(query) < 4 {
+				return fmt.Errorf("too short lite server query: %d", len(query))
+			}
+		}
+
+		// wait for free slot, so one client cannot start unlimited number of handlers
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem }()
+			s.processQuery(ctx, conn, qid, int32(binary.LittleEndian.Uint32(query)), query[4:])
+		}()
+		return nil
+	}
+
+	return fmt.Errorf("unknown packet type %d", typ)
+}
+
+func (s *Server) processQuery(ctx context.Context, conn *connection, qid []byte, typeID int32, payload []byte) {
+	s.mx.RLock()
+	handler := s.handlers[typeID]
+	s.mx.RUnlock()
+
+	if handler == nil {
+		handler = s.defaultHandler
+	}
+
+	var resp *LiteResponse
+	var err error
+	if handler != nil {
+		resp, err = handler(ctx, typeID, payload)
+	} else {
+		err = fmt.Errorf("unsupported query %d", typeID)
+	}
+
+	if err == nil && resp == nil {
+		err = errors.New("no response")
+	}
+
+	if err != nil {
+		lsErr := ServerError{
+			Code: _ErrorCodeError,
+			Text: err.Error(),
+		}
+		errors.As(err, &lsErr)
+
+		code := make([]byte, 4)
+		binary.LittleEndian.PutUint32(code, uint32(lsErr.Code))
+
+		resp = &LiteResponse{
+			TypeID: LiteServerError,
+			Data:   append(code, tl.ToBytes([]byte(lsErr.Text))...),
+		}
+	}
+
+	typData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(typData, uint32(resp.TypeID))
+
+	answer := make([]byte, 4)
+	binary.LittleEndian.PutUint32(answer, uint32(ADNLQueryResponse))
+	answer = append(answer, qid...)
+	answer = append(answer, tl.ToBytes(append(typData, resp.Data...))...)
+
+	if err = conn.send(answer); err != nil {
+		_ = conn.tcp.Close()
+	}
+}
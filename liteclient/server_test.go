@@ -0,0 +1,297 @@
+package liteclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func startTestServer(t *testing.T, handler QueryHandler) (*Server, string, string) {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := NewServer(key, handler)
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	return srv, ln.Addr().String(), base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+}
+
+func TestServer_Query(t *testing.T) {
+	// big payload to check size prefix of tl bytes
+	big := bytes.Repeat([]byte{0xAB}, 1000)
+
+	_, addr, key := startTestServer(t, func(ctx context.Context, typeID int32, payload []byte) (*LiteResponse, error) {
+		switch typeID {
+		case 1:
+			return &LiteResponse{TypeID: 2, Data: append([]byte{}, payload...)}, nil
+		case 3:
+			return &LiteResponse{TypeID: 4, Data: big}, nil
+		}
+		return nil, errors.New("unknown query")
+	})
+
+	pool := NewConnectionPool()
+	pool.SetOnDisconnect(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := pool.AddConnection(ctx, addr, key); err != nil {
+		t.Fatal("connect err", err)
+	}
+
+	resp, err := pool.Do(ctx, 1, []byte{1, 2, 3, 4, 5, 6, 7, 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.TypeID != 2 || !bytes.Equal(resp.Data, []byte{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Fatal("wrong response", resp.TypeID, resp.Data)
+	}
+
+	resp, err = pool.Do(ctx, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.TypeID != 4 || !bytes.Equal(resp.Data, big) {
+		t.Fatal("wrong big response", resp.TypeID, len(resp.Data))
+	}
+
+	resp, err = pool.Do(ctx, 7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.TypeID != LiteServerError || int32(binary.LittleEndian.Uint32(resp.Data)) != _ErrorCodeError {
+		t.Fatal("should be error response", resp.TypeID)
+	}
+}
+
+func TestServer_Handle(t *testing.T) {
+	srv, addr, key := startTestServer(t, nil)
+
+	srv.Handle(1, func(ctx context.Context, typeID int32, payload []byte) (*LiteResponse, error) {
+		client := ClientFromContext(ctx)
+		if client == nil || len(client.Key) != ed25519.PublicKeySize {
+			return nil, errors.New("no client info")
+		}
+		return &LiteResponse{TypeID: 2, Data: client.Key}, nil
+	})
+	srv.Handle(3, func(ctx context.Context, typeID int32, payload []byte) (*LiteResponse, error) {
+		return nil, ServerError{Code: 651, Text: "not ready"}
+	})
+
+	var connected *ServerClient
+	srv.SetOnConnect(func(client *ServerClient) error {
+		connected = client
+		return nil
+	})
+
+	pool := NewConnectionPool()
+	pool.SetOnDisconnect(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := pool.AddConnection(ctx, addr, key); err != nil {
+		t.Fatal("connect err", err)
+	}
+
+	resp, err := pool.Do(ctx, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.TypeID != 2 || connected == nil || !bytes.Equal(resp.Data, connected.Key) {
+		t.Fatal("wrong response", resp.TypeID)
+	}
+
+	resp, err = pool.Do(ctx, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.TypeID != LiteServerError || int32(binary.LittleEndian.Uint32(resp.Data)) != 651 {
+		t.Fatal("should be error response with code", resp.TypeID)
+	}
+
+	// no default handler
+	resp, err = pool.Do(ctx, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.TypeID != LiteServerError || int32(binary.LittleEndian.Uint32(resp.Data)) != _ErrorCodeError {
+		t.Fatal("should be error response", resp.TypeID)
+	}
+}
+
+func TestServer_ConnQueriesLimit(t *testing.T) {
+	var mx sync.Mutex
+	active, maxActive := 0, 0
+	release := make(chan struct{})
+
+	_, addr, key := startTestServer(t, func(ctx context.Context, typeID int32, payload []byte) (*LiteResponse, error) {
+		mx.Lock()
+		if active++; active > maxActive {
+			maxActive = active
+		}
+		mx.Unlock()
+
+		<-release
+
+		mx.Lock()
+		active--
+		mx.Unlock()
+		return &LiteResponse{TypeID: 2}, nil
+	})
+
+	pool := NewConnectionPool()
+	pool.SetOnDisconnect(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := pool.AddConnection(ctx, addr, key); err != nil {
+		t.Fatal("connect err", err)
+	}
+
+	num := _MaxConnQueries + 10
+	errs := make(chan error, num)
+	for i := 0; i < num; i++ {
+		go func() {
+			_, err := pool.Do(ctx, 1, nil)
+			errs <- err
+		}()
+	}
+
+	// wait until all slots are taken
+	for {
+		mx.Lock()
+		n := active
+		mx.Unlock()
+
+		if n == _MaxConnQueries {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("handlers were not started, active", n)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < num; i++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if maxActive != _MaxConnQueries {
+		t.Fatal("wrong max number of concurrent queries", maxActive)
+	}
+}
+
+func TestServer_OnConnectReject(t *testing.T) {
+	srv, addr, key := startTestServer(t, func(ctx context.Context, typeID int32, payload []byte) (*LiteResponse, error) {
+		return nil, errors.New("should not be called")
+	})
+	srv.SetOnConnect(func(client *ServerClient) error {
+		return errors.New("rejected")
+	})
+
+	pool := NewConnectionPool()
+	pool.SetOnDisconnect(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := pool.AddConnection(ctx, addr, key); err == nil {
+		t.Fatal("connection should be rejected")
+	}
+}
+
+func TestServer_WrongKey(t *testing.T) {
+	_, addr, _ := startTestServer(t, func(ctx context.Context, typeID int32, payload []byte) (*LiteResponse, error) {
+		return nil, errors.New("should not be called")
+	})
+
+	otherKey, _, _ := ed25519.GenerateKey(nil)
+
+	pool := NewConnectionPool()
+	pool.SetOnDisconnect(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := pool.AddConnection(ctx, addr, base64.StdEncoding.EncodeToString(otherKey)); err == nil {
+		t.Fatal("connection should fail")
+	}
+}
+
+func TestServer_Close(t *testing.T) {
+	srv, addr, key := startTestServer(t, func(ctx context.Context, typeID int32, payload []byte) (*LiteResponse, error) {
+		return &LiteResponse{TypeID: typeID}, nil
+	})
+
+	pool := NewConnectionPool()
+	pool.SetOnDisconnect(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if err := pool.AddConnection(ctx, addr, key); err != nil {
+		t.Fatal("connect err", err)
+	}
+
+	if err := srv.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// connection should be removed from the pool after disconnect
+	for {
+		reqCtx, reqCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		_, err := pool.Do(reqCtx, 1, nil)
+		reqCancel()
+
+		if errors.Is(err, ErrNoActiveConnections) {
+			break
+		}
+
+		if ctx.Err() != nil {
+			t.Fatal("connection was not closed")
+		}
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = srv.Serve(ln); !errors.Is(err, ErrServerClosed) {
+		t.Fatal("should be closed", err)
+	}
+}
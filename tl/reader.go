@@ -0,0 +1,127 @@
+package tl
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var ErrTooShort = errors.New("too short data")
+
+// Reader - reads tl values one by one. After the first error all reads return zero values,
+// so error can be checked once, after all fields are read.
+type Reader struct {
+	data []byte
+	err  error
+}
+
+func NewReader(data []byte) *Reader {
+	return &Reader{data: data}
+}
+
+// Err - returns first error which happened during reading
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Fail - sets error if there is no error yet, next reads will return zero values
+func (r *Reader) Fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Rest - returns data which is not read yet
+func (r *Reader) Rest() []byte {
+	return r.data
+}
+
+// Raw - reads n bytes as is, result points to the original data
+func (r *Reader) Raw(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.data) < n {
+		r.err = ErrTooShort
+		return nil
+	}
+
+	v := r.data[:n]
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *Reader) Int32() int32 {
+	b := r.Raw(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.LittleEndian.Uint32(b))
+}
+
+func (r *Reader) Int64() int64 {
+	b := r.Raw(8)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.LittleEndian.Uint64(b))
+}
+
+// Int256 - reads 32 bytes into new slice
+func (r *Reader) Int256() []byte {
+	b := r.Raw(32)
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+// Bytes - reads tl bytes with length prefix and padding into new slice
+func (r *Reader) Bytes() []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.data) == 0 {
+		r.err = ErrTooShort
+		return nil
+	}
+
+	ln, offset := int(r.data[0]), 1
+	if ln == 0xFE {
+		if len(r.data) < 4 {
+			r.err = ErrTooShort
+			return nil
+		}
+		ln, offset = int(binary.LittleEndian.Uint32(r.data)>>8), 4
+	} else if ln == 0xFF {
+		r.err = errors.New("invalid bytes prefix")
+		return nil
+	}
+
+	full := offset + ln
+	if full%4 != 0 {
+		full += 4 - full%4
+	}
+
+	if len(r.data) < full {
+		r.err = ErrTooShort
+		return nil
+	}
+
+	v := append([]byte{}, r.data[offset:offset+ln]...)
+	r.data = r.data[full:]
+	return v
+}
+
+// AppendInt32 - appends little endian int32 to data
+func AppendInt32(data []byte, v int32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, uint32(v))
+	return append(data, b...)
+}
+
+// AppendInt64 - appends little endian int64 to data
+func AppendInt64(data []byte, v int64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, uint64(v))
+	return append(data, b...)
+}
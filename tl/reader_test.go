@@ -0,0 +1,43 @@
+package tl
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	data := AppendInt32(nil, -5)
+	data = AppendInt64(data, 1<<40)
+	data = append(data, bytes.Repeat([]byte{7}, 32)...)
+	data = append(data, ToBytes([]byte("hello"))...)
+	data = append(data, 0xAA)
+
+	r := NewReader(data)
+	if v := r.Int32(); v != -5 {
+		t.Fatal("wrong int32", v)
+	}
+	if v := r.Int64(); v != 1<<40 {
+		t.Fatal("wrong int64", v)
+	}
+	if v := r.Int256(); !bytes.Equal(v, bytes.Repeat([]byte{7}, 32)) {
+		t.Fatal("wrong int256", v)
+	}
+	if v := r.Bytes(); string(v) != "hello" {
+		t.Fatal("wrong bytes", v)
+	}
+	if r.Err() != nil {
+		t.Fatal(r.Err())
+	}
+	if !bytes.Equal(r.Rest(), []byte{0xAA}) {
+		t.Fatal("wrong rest", r.Rest())
+	}
+
+	// not enough data, error is kept and next reads return zero values
+	if v := r.Int32(); v != 0 || !errors.Is(r.Err(), ErrTooShort) {
+		t.Fatal("should fail", v, r.Err())
+	}
+	if v := r.Raw(1); v != nil {
+		t.Fatal("read after error should return nil")
+	}
+}
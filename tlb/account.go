@@ -2,6 +2,7 @@ package tlb
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/xssnick/tonutils-go/address"
@@ -27,8 +28,8 @@ type Account struct {
 }
 
 type CurrencyCollection struct {
-	Coins           Coins            `tlb:"."`
-	ExtraCurrencies *cell.Dictionary `tlb:"dict 32"`
+	Coins           Coins           `tlb:"."`
+	ExtraCurrencies ExtraCurrencies `tlb:"."`
 }
 
 type DepthBalanceInfo struct {
@@ -40,6 +41,12 @@ type AccountStorage struct {
 	Status            AccountStatus
 	LastTransactionLT uint64
 	Balance           Coins
+	ExtraCurrencies   ExtraCurrencies
+
+	// State of the contract, it is set only for active account
+	StateInit *StateInit
+	// Hash of the state of frozen account, it is needed to unfreeze it
+	StateHash []byte
 }
 
 type StorageUsed struct {
@@ -51,7 +58,7 @@ type StorageUsed struct {
 type StorageInfo struct {
 	StorageUsed StorageUsed
 	LastPaid    uint32
-	DuePayment  *big.Int
+	DuePayment  *Coins
 }
 
 type AccountState struct {
@@ -154,19 +161,11 @@ func (s *StorageInfo) LoadFromCell(loader *cell.Slice) error {
 		return err
 	}
 
-	isDuePayment, err := loader.LoadUInt(1)
+	duePayment, err := loadMaybeCoins(loader)
 	if err != nil {
 		return err
 	}
 
-	var duePayment *big.Int
-	if isDuePayment == 1 {
-		duePayment, err = loader.LoadBigCoins()
-		if err != nil {
-			return err
-		}
-	}
-
 	s.StorageUsed = used
 	s.DuePayment = duePayment
 	s.LastPaid = uint32(lastPaid)
@@ -185,15 +184,11 @@ func (s *AccountStorage) LoadFromCell(loader *cell.Slice) error {
 		return err
 	}
 
-	extraExists, err := loader.LoadBoolBit()
-	if err != nil {
+	var extra ExtraCurrencies
+	if err = extra.LoadFromCell(loader); err != nil {
 		return err
 	}
 
-	if extraExists {
-		return errors.New("extra currency info is not supported for AccountStorage")
-	}
-
 	isStatusActive, err := loader.LoadBoolBit()
 	if err != nil {
 		return err
@@ -201,6 +196,12 @@ func (s *AccountStorage) LoadFromCell(loader *cell.Slice) error {
 
 	if isStatusActive {
 		s.Status = AccountStatusActive
+
+		var state StateInit
+		if err = LoadFromCell(&state, loader); err != nil {
+			return fmt.Errorf("failed to load state init: %w", err)
+		}
+		s.StateInit = &state
 	} else {
 		isStatusFrozen, err := loader.LoadBoolBit()
 		if err != nil {
@@ -209,6 +210,10 @@ func (s *AccountStorage) LoadFromCell(loader *cell.Slice) error {
 
 		if isStatusFrozen {
 			s.Status = AccountStatusFrozen
+
+			if s.StateHash, err = loader.LoadSlice(256); err != nil {
+				return fmt.Errorf("failed to load state hash: %w", err)
+			}
 		} else {
 			s.Status = AccountStatusUninit
 		}
@@ -216,6 +221,98 @@ func (s *AccountStorage) LoadFromCell(loader *cell.Slice) error {
 
 	s.LastTransactionLT = lastTransaction
 	s.Balance = FromNanoTON(coins)
+	s.ExtraCurrencies = extra
 
 	return nil
 }
+
+func (a *AccountState) ToCell() (*cell.Cell, error) {
+	if !a.IsValid {
+		return cell.BeginCell().MustStoreBoolBit(false).EndCell(), nil
+	}
+
+	b := cell.BeginCell().MustStoreBoolBit(true)
+	if err := b.StoreAddr(a.Address); err != nil {
+		return nil, fmt.Errorf("failed to store address: %w", err)
+	}
+
+	if err := a.StorageInfo.store(b); err != nil {
+		return nil, fmt.Errorf("failed to store storage info: %w", err)
+	}
+
+	if err := a.AccountStorage.store(b); err != nil {
+		return nil, fmt.Errorf("failed to store account storage: %w", err)
+	}
+
+	return b.EndCell(), nil
+}
+
+func (s *StorageInfo) store(b *cell.Builder) error {
+	for _, v := range []uint64{s.StorageUsed.CellsUsed, s.StorageUsed.BitsUsed, s.StorageUsed.PublicCellsUsed} {
+		if err := b.StoreVarUInt(new(big.Int).SetUint64(v), 7); err != nil {
+			return err
+		}
+	}
+
+	if err := b.StoreUInt(uint64(s.LastPaid), 32); err != nil {
+		return err
+	}
+
+	if s.DuePayment == nil {
+		return b.StoreBoolBit(false)
+	}
+
+	if err := b.StoreBoolBit(true); err != nil {
+		return err
+	}
+	return b.StoreBigCoins(s.DuePayment.NanoTON())
+}
+
+func (s *AccountStorage) store(b *cell.Builder) error {
+	if err := b.StoreUInt(s.LastTransactionLT, 64); err != nil {
+		return err
+	}
+
+	if err := b.StoreBigCoins(s.Balance.NanoTON()); err != nil {
+		return err
+	}
+
+	extra, err := s.ExtraCurrencies.ToDict()
+	if err != nil {
+		return err
+	}
+
+	if err = b.StoreDict(extra); err != nil {
+		return err
+	}
+
+	switch s.Status {
+	case AccountStatusActive:
+		if s.StateInit == nil {
+			return errors.New("state init is required for active account")
+		}
+
+		state, err := s.StateInit.ToCell()
+		if err != nil {
+			return fmt.Errorf("failed to serialize state init: %w", err)
+		}
+
+		// state init is stored in the same cell
+		if err = b.StoreBoolBit(true); err != nil {
+			return err
+		}
+		return b.StoreBuilder(state.ToBuilder())
+	case AccountStatusFrozen:
+		if len(s.StateHash) != 32 {
+			return errors.New("state hash of frozen account should be 32 bytes")
+		}
+		if err := b.StoreUInt(0b01, 2); err != nil {
+			return err
+		}
+		return b.StoreSlice(s.StateHash, 256)
+	case AccountStatusUninit:
+		return b.StoreUInt(0b00, 2)
+	}
+
+	return fmt.Errorf("status %s cannot be stored in account", s.Status)
+}
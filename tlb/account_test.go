@@ -1,9 +1,12 @@
 package tlb
 
 import (
+	"bytes"
 	"encoding/hex"
+	"math/big"
 	"testing"
 
+	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
@@ -46,4 +49,129 @@ func TestAccountState_LoadFromCell(t *testing.T) {
 		t.Fatal("LastTransactionLT incorrect", as.LastTransactionLT)
 		return
 	}
+
+	if as.StateInit == nil || as.StateInit.Code == nil || as.StateInit.Data == nil {
+		t.Fatal("state init not loaded")
+		return
+	}
+
+	// round trip
+	c, err := as.ToCell()
+	if err != nil {
+		t.Fatal(err)
+		return
+	}
+
+	if !bytes.Equal(c.Hash(), acc.Hash()) {
+		t.Fatal("serialized account state not eq")
+		return
+	}
+}
+
+func TestAccountState_ToCell(t *testing.T) {
+	addr := address.MustParseAddr("EQDEGeK4o7bNgazTln27r0RC4YcOmerzIni3gUpsyqxfgMWk")
+	due := MustFromTON("0.5")
+
+	extra := ExtraCurrencies{7: big.NewInt(100)}
+
+	lib := cell.NewDict(256)
+	libCode := cell.BeginCell().MustStoreUInt(0xAA, 8).EndCell()
+	_ = lib.Set(cell.BeginCell().MustStoreSlice(libCode.Hash(), 256).EndCell(), cell.BeginCell().MustStoreBoolBit(true).MustStoreRef(libCode).EndCell())
+
+	states := []AccountState{
+		{IsValid: false},
+		{
+			IsValid: true,
+			Address: addr,
+			StorageInfo: StorageInfo{
+				StorageUsed: StorageUsed{CellsUsed: 1, BitsUsed: 100, PublicCellsUsed: 0},
+				LastPaid:    1000,
+				DuePayment:  &due,
+			},
+			AccountStorage: AccountStorage{
+				Status:            AccountStatusFrozen,
+				LastTransactionLT: 777,
+				Balance:           MustFromTON("0"),
+				StateHash:         bytes.Repeat([]byte{0x11}, 32),
+			},
+		},
+		{
+			IsValid: true,
+			Address: addr,
+			AccountStorage: AccountStorage{
+				Status:          AccountStatusUninit,
+				Balance:         MustFromTON("1.5"),
+				ExtraCurrencies: extra,
+			},
+		},
+		{
+			IsValid: true,
+			Address: addr,
+			AccountStorage: AccountStorage{
+				Status:  AccountStatusActive,
+				Balance: MustFromTON("2"),
+				StateInit: &StateInit{
+					Depth:    5,
+					TickTock: &TickTock{Tick: true},
+					Code:     cell.BeginCell().MustStoreUInt(1, 8).EndCell(),
+					Lib:      lib,
+				},
+			},
+		},
+	}
+
+	for i, st := range states {
+		c, err := st.ToCell()
+		if err != nil {
+			t.Fatal(i, err)
+		}
+
+		var loaded AccountState
+		if err = loaded.LoadFromCell(c.BeginParse()); err != nil {
+			t.Fatal(i, err)
+		}
+
+		if loaded.IsValid != st.IsValid || loaded.Status != st.Status {
+			t.Fatal(i, "status not eq", loaded.Status)
+		}
+
+		if !st.IsValid {
+			continue
+		}
+
+		if loaded.Balance.NanoTON().Cmp(st.Balance.NanoTON()) != 0 || loaded.LastTransactionLT != st.LastTransactionLT ||
+			loaded.StorageInfo.LastPaid != st.StorageInfo.LastPaid || loaded.StorageInfo.StorageUsed != st.StorageInfo.StorageUsed {
+			t.Fatal(i, "fields not eq")
+		}
+
+		if (loaded.StorageInfo.DuePayment == nil) != (st.StorageInfo.DuePayment == nil) ||
+			(st.StorageInfo.DuePayment != nil && loaded.StorageInfo.DuePayment.NanoTON().Cmp(due.NanoTON()) != 0) {
+			t.Fatal(i, "due payment not eq")
+		}
+
+		if !bytes.Equal(loaded.StateHash, st.StateHash) {
+			t.Fatal(i, "state hash not eq")
+		}
+
+		if !loaded.ExtraCurrencies.Equal(st.ExtraCurrencies) {
+			t.Fatal(i, "extra currencies not loaded")
+		}
+
+		if st.StateInit != nil {
+			s := loaded.StateInit
+			if s == nil || s.Depth != 5 || s.TickTock == nil || !s.TickTock.Tick || s.TickTock.Tock ||
+				s.Data != nil || !bytes.Equal(s.Code.Hash(), st.StateInit.Code.Hash()) || len(s.Lib.All()) != 1 {
+				t.Fatal(i, "state init not eq")
+			}
+		}
+
+		c2, err := loaded.ToCell()
+		if err != nil {
+			t.Fatal(i, err)
+		}
+
+		if !bytes.Equal(c.Hash(), c2.Hash()) {
+			t.Fatal(i, "round trip not eq")
+		}
+	}
 }
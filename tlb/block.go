@@ -50,6 +50,27 @@ func (b *BlockInfo) Serialize() []byte {
 	return data
 }
 
+// BlockHeader - first fields of block info, it is enough to know time and lt range of the block
+type BlockHeader struct {
+	_             Magic      `tlb:"#9bc7a987"`
+	Version       uint32     `tlb:"## 32"`
+	NotMaster     bool       `tlb:"bool"`
+	AfterMerge    bool       `tlb:"bool"`
+	BeforeSplit   bool       `tlb:"bool"`
+	AfterSplit    bool       `tlb:"bool"`
+	WantSplit     bool       `tlb:"bool"`
+	WantMerge     bool       `tlb:"bool"`
+	KeyBlock      bool       `tlb:"bool"`
+	VertSeqnoIncr bool       `tlb:"bool"`
+	Flags         uint8      `tlb:"## 8"`
+	SeqNo         uint32     `tlb:"## 32"`
+	VertSeqNo     uint32     `tlb:"## 32"`
+	Shard         ShardIdent `tlb:"."`
+	GenUtime      uint32     `tlb:"## 32"`
+	StartLT       uint64     `tlb:"## 64"`
+	EndLT         uint64     `tlb:"## 64"`
+}
+
 type StateUpdate struct {
 	Old ShardState `tlb:"^"`
 	New ShardState `tlb:"^"`
@@ -0,0 +1,389 @@
+package tlb
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// StoragePrices - config param 18, prices are in nanoTON/65536 per bit or cell for second
+type StoragePrices struct {
+	_           Magic  `tlb:"#cc"`
+	UTimeSince  uint32 `tlb:"## 32"`
+	BitPrice    uint64 `tlb:"## 64"`
+	CellPrice   uint64 `tlb:"## 64"`
+	MCBitPrice  uint64 `tlb:"## 64"`
+	MCCellPrice uint64 `tlb:"## 64"`
+}
+
+// GasLimitsPrices - config params 20 (masterchain) and 21 (basechain), gas price is in nanoTON/65536.
+// Flat part is zero when it is not set in config.
+type GasLimitsPrices struct {
+	FlatGasLimit    uint64
+	FlatGasPrice    uint64
+	GasPrice        uint64
+	GasLimit        uint64
+	SpecialGasLimit uint64
+	GasCredit       uint64
+	BlockGasLimit   uint64
+	FreezeDueLimit  uint64
+	DeleteDueLimit  uint64
+}
+
+// MsgForwardPrices - config params 24 (masterchain) and 25 (basechain), prices are in nanoTON/65536
+type MsgForwardPrices struct {
+	_              Magic  `tlb:"#ea"`
+	LumpPrice      uint64 `tlb:"## 64"`
+	BitPrice       uint64 `tlb:"## 64"`
+	CellPrice      uint64 `tlb:"## 64"`
+	IHRPriceFactor uint32 `tlb:"## 32"`
+	FirstFrac      uint16 `tlb:"## 16"`
+	NextFrac       uint16 `tlb:"## 16"`
+}
+
+// LoadStoragePrices - parses config param 18, result is sorted by UTimeSince
+func LoadStoragePrices(param *cell.Cell) ([]StoragePrices, error) {
+	dict, err := param.BeginParse().ToDict(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load storage prices dict: %w", err)
+	}
+
+	var prices []StoragePrices
+	for _, kv := range dict.All() {
+		var p StoragePrices
+		if err = LoadFromCell(&p, kv.Value.BeginParse()); err != nil {
+			return nil, fmt.Errorf("failed to load storage prices: %w", err)
+		}
+		prices = append(prices, p)
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].UTimeSince < prices[j].UTimeSince
+	})
+
+	return prices, nil
+}
+
+func (g *GasLimitsPrices) LoadFromCell(loader *cell.Slice) error {
+	tag, err := loader.LoadUInt(8)
+	if err != nil {
+		return fmt.Errorf("failed to load tag: %w", err)
+	}
+
+	if tag == 0xd1 {
+		if g.FlatGasLimit, err = loader.LoadUInt(64); err != nil {
+			return fmt.Errorf("failed to load flat gas limit: %w", err)
+		}
+		if g.FlatGasPrice, err = loader.LoadUInt(64); err != nil {
+			return fmt.Errorf("failed to load flat gas price: %w", err)
+		}
+
+		if tag, err = loader.LoadUInt(8); err != nil {
+			return fmt.Errorf("failed to load tag: %w", err)
+		}
+	}
+
+	var fields []*uint64
+	switch tag {
+	case 0xdd:
+		fields = []*uint64{&g.GasPrice, &g.GasLimit, &g.GasCredit, &g.BlockGasLimit, &g.FreezeDueLimit, &g.DeleteDueLimit}
+	case 0xde:
+		fields = []*uint64{&g.GasPrice, &g.GasLimit, &g.SpecialGasLimit, &g.GasCredit, &g.BlockGasLimit, &g.FreezeDueLimit, &g.DeleteDueLimit}
+	default:
+		return errors.New("unknown gas prices tag")
+	}
+
+	for _, f := range fields {
+		if *f, err = loader.LoadUInt(64); err != nil {
+			return fmt.Errorf("failed to load gas prices: %w", err)
+		}
+	}
+
+	if tag == 0xdd {
+		g.SpecialGasLimit = g.GasLimit
+	}
+
+	return nil
+}
+
+// CalcGasFee - calculates compute fee for the given gas amount
+func (g *GasLimitsPrices) CalcGasFee(gasUsed uint64) Coins {
+	if gasUsed <= g.FlatGasLimit {
+		return FromNanoTONU(g.FlatGasPrice)
+	}
+
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(g.GasPrice), new(big.Int).SetUint64(gasUsed-g.FlatGasLimit))
+	fee = shiftCeil(fee)
+
+	return FromNanoTON(fee.Add(fee, new(big.Int).SetUint64(g.FlatGasPrice)))
+}
+
+// CalcFwdFee - calculates forward fee for the message, cells and bits should be counted without root cell
+func (m *MsgForwardPrices) CalcFwdFee(cells, bits uint64) Coins {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(m.BitPrice), new(big.Int).SetUint64(bits))
+	fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(m.CellPrice), new(big.Int).SetUint64(cells)))
+	fee = shiftCeil(fee)
+
+	return FromNanoTON(fee.Add(fee, new(big.Int).SetUint64(m.LumpPrice)))
+}
+
+// CalcStorageFee - calculates storage fee for the account of the given size for the period in seconds
+func (s *StoragePrices) CalcStorageFee(cells, bits uint64, seconds uint32, masterchain bool) Coins {
+	bitPrice, cellPrice := s.BitPrice, s.CellPrice
+	if masterchain {
+		bitPrice, cellPrice = s.MCBitPrice, s.MCCellPrice
+	}
+
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(bitPrice), new(big.Int).SetUint64(bits))
+	fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(cellPrice), new(big.Int).SetUint64(cells)))
+	fee.Mul(fee, new(big.Int).SetUint64(uint64(seconds)))
+
+	return FromNanoTON(shiftCeil(fee))
+}
+
+// shiftCeil - divides by 65536 and rounds up, like node does for prices
+func shiftCeil(v *big.Int) *big.Int {
+	v.Add(v, big.NewInt(0xffff))
+	return v.Rsh(v, 16)
+}
+
+// WorkchainDescr - description of workchain from config param 12
+type WorkchainDescr struct {
+	EnabledSince      uint32
+	ActualMinSplit    uint8
+	MinSplit          uint8
+	MaxSplit          uint8
+	Basic             bool
+	Active            bool
+	AcceptMsgs        bool
+	ZeroStateRootHash []byte
+	ZeroStateFileHash []byte
+	Version           uint32
+
+	// only for basic workchains
+	VMVersion int32
+	VMMode    uint64
+}
+
+// ElectionsTimings - config param 15, all values are in seconds
+type ElectionsTimings struct {
+	ValidatorsElectedFor uint32 `tlb:"## 32"`
+	ElectionsStartBefore uint32 `tlb:"## 32"`
+	ElectionsEndBefore   uint32 `tlb:"## 32"`
+	StakeHeldFor         uint32 `tlb:"## 32"`
+}
+
+// StakeLimits - config param 17
+type StakeLimits struct {
+	MinStake       Coins  `tlb:"."`
+	MaxStake       Coins  `tlb:"."`
+	MinTotalStake  Coins  `tlb:"."`
+	MaxStakeFactor uint32 `tlb:"## 32"`
+}
+
+// ValidatorSet - config params 32 (previous), 34 (current) and 36 (next) validators
+type ValidatorSet struct {
+	UTimeSince uint32
+	UTimeUntil uint32
+	Total      uint16
+	Main       uint16
+	// Sum of weights of all validators, calculated for old format
+	TotalWeight uint64
+	// Sorted by index in the set
+	List []ValidatorDescr
+}
+
+type ValidatorDescr struct {
+	PublicKey []byte
+	Weight    uint64
+	// Can be nil, if validator has no adnl address in config
+	ADNLAddr []byte
+}
+
+func (w *WorkchainDescr) LoadFromCell(loader *cell.Slice) error {
+	tag, err := loader.LoadUInt(8)
+	if err != nil {
+		return fmt.Errorf("failed to load tag: %w", err)
+	}
+
+	if tag != 0xa6 && tag != 0xa7 {
+		return errors.New("unknown workchain descr tag")
+	}
+
+	enabledSince, err := loader.LoadUInt(32)
+	if err != nil {
+		return fmt.Errorf("failed to load enabled since: %w", err)
+	}
+	w.EnabledSince = uint32(enabledSince)
+
+	for _, v := range []*uint8{&w.ActualMinSplit, &w.MinSplit, &w.MaxSplit} {
+		x, err := loader.LoadUInt(8)
+		if err != nil {
+			return fmt.Errorf("failed to load split params: %w", err)
+		}
+		*v = uint8(x)
+	}
+
+	for _, v := range []*bool{&w.Basic, &w.Active, &w.AcceptMsgs} {
+		if *v, err = loader.LoadBoolBit(); err != nil {
+			return fmt.Errorf("failed to load flags: %w", err)
+		}
+	}
+
+	// flags, should be zero
+	if _, err = loader.LoadUInt(13); err != nil {
+		return fmt.Errorf("failed to load flags: %w", err)
+	}
+
+	if w.ZeroStateRootHash, err = loader.LoadSlice(256); err != nil {
+		return fmt.Errorf("failed to load zero state root hash: %w", err)
+	}
+
+	if w.ZeroStateFileHash, err = loader.LoadSlice(256); err != nil {
+		return fmt.Errorf("failed to load zero state file hash: %w", err)
+	}
+
+	version, err := loader.LoadUInt(32)
+	if err != nil {
+		return fmt.Errorf("failed to load version: %w", err)
+	}
+	w.Version = uint32(version)
+
+	if w.Basic {
+		if format, err := loader.LoadUInt(4); err != nil || format != 1 {
+			return errors.New("invalid basic workchain format")
+		}
+
+		vmVersion, err := loader.LoadInt(32)
+		if err != nil {
+			return fmt.Errorf("failed to load vm version: %w", err)
+		}
+		w.VMVersion = int32(vmVersion)
+
+		if w.VMMode, err = loader.LoadUInt(64); err != nil {
+			return fmt.Errorf("failed to load vm mode: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (v *ValidatorSet) LoadFromCell(loader *cell.Slice) error {
+	tag, err := loader.LoadUInt(8)
+	if err != nil {
+		return fmt.Errorf("failed to load tag: %w", err)
+	}
+
+	if tag != 0x11 && tag != 0x12 {
+		return errors.New("unknown validator set tag")
+	}
+
+	since, err := loader.LoadUInt(32)
+	if err != nil {
+		return fmt.Errorf("failed to load utime since: %w", err)
+	}
+
+	until, err := loader.LoadUInt(32)
+	if err != nil {
+		return fmt.Errorf("failed to load utime until: %w", err)
+	}
+
+	total, err := loader.LoadUInt(16)
+	if err != nil {
+		return fmt.Errorf("failed to load total: %w", err)
+	}
+
+	main, err := loader.LoadUInt(16)
+	if err != nil {
+		return fmt.Errorf("failed to load main: %w", err)
+	}
+
+	v.UTimeSince, v.UTimeUntil, v.Total, v.Main = uint32(since), uint32(until), uint16(total), uint16(main)
+
+	var list *cell.Dictionary
+	if tag == 0x12 {
+		if v.TotalWeight, err = loader.LoadUInt(64); err != nil {
+			return fmt.Errorf("failed to load total weight: %w", err)
+		}
+
+		if list, err = loader.LoadDict(16); err != nil {
+			return fmt.Errorf("failed to load validators list: %w", err)
+		}
+	} else {
+		// not empty hashmap, stored in the same cell
+		if list, err = loader.ToDict(16); err != nil {
+			return fmt.Errorf("failed to load validators list: %w", err)
+		}
+	}
+
+	type indexed struct {
+		idx uint64
+		val ValidatorDescr
+	}
+
+	var validators []indexed
+	var weight uint64
+	for _, kv := range list.All() {
+		idx, err := kv.Key.BeginParse().LoadUInt(16)
+		if err != nil {
+			return fmt.Errorf("failed to load validator index: %w", err)
+		}
+
+		var descr ValidatorDescr
+		if err = descr.LoadFromCell(kv.Value.BeginParse()); err != nil {
+			return fmt.Errorf("failed to load validator %d: %w", idx, err)
+		}
+		weight += descr.Weight
+
+		validators = append(validators, indexed{idx, descr})
+	}
+
+	sort.Slice(validators, func(i, j int) bool {
+		return validators[i].idx < validators[j].idx
+	})
+
+	v.List = make([]ValidatorDescr, 0, len(validators))
+	for _, val := range validators {
+		v.List = append(v.List, val.val)
+	}
+
+	if tag == 0x11 {
+		v.TotalWeight = weight
+	}
+
+	return nil
+}
+
+func (v *ValidatorDescr) LoadFromCell(loader *cell.Slice) error {
+	tag, err := loader.LoadUInt(8)
+	if err != nil {
+		return fmt.Errorf("failed to load tag: %w", err)
+	}
+
+	if tag != 0x53 && tag != 0x73 {
+		return errors.New("unknown validator descr tag")
+	}
+
+	if keyTag, err := loader.LoadUInt(32); err != nil || keyTag != 0x8e81278a {
+		return errors.New("unknown public key type")
+	}
+
+	if v.PublicKey, err = loader.LoadSlice(256); err != nil {
+		return fmt.Errorf("failed to load public key: %w", err)
+	}
+
+	if v.Weight, err = loader.LoadUInt(64); err != nil {
+		return fmt.Errorf("failed to load weight: %w", err)
+	}
+
+	if tag == 0x73 {
+		if v.ADNLAddr, err = loader.LoadSlice(256); err != nil {
+			return fmt.Errorf("failed to load adnl address: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package tlb
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestGasLimitsPrices(t *testing.T) {
+	c := cell.BeginCell().
+		MustStoreUInt(0xd1, 8).MustStoreUInt(100, 64).MustStoreUInt(40000, 64).
+		MustStoreUInt(0xde, 8).
+		MustStoreUInt(26214400, 64).MustStoreUInt(1000000, 64).MustStoreUInt(1000000, 64).
+		MustStoreUInt(10000, 64).MustStoreUInt(10000000, 64).MustStoreUInt(100000000, 64).MustStoreUInt(1000000000, 64).
+		EndCell()
+
+	var g GasLimitsPrices
+	if err := g.LoadFromCell(c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	if g.FlatGasLimit != 100 || g.FlatGasPrice != 40000 || g.GasPrice != 26214400 ||
+		g.GasCredit != 10000 || g.DeleteDueLimit != 1000000000 {
+		t.Fatal("incorrect gas prices", g)
+	}
+
+	if fee := g.CalcGasFee(50); fee.NanoTON().Uint64() != 40000 {
+		t.Fatal("incorrect flat fee", fee.NanoTON())
+	}
+
+	if fee := g.CalcGasFee(3000); fee.NanoTON().Uint64() != 40000+2900*400 {
+		t.Fatal("incorrect fee", fee.NanoTON())
+	}
+
+	err := g.LoadFromCell(cell.BeginCell().MustStoreUInt(0xaa, 8).EndCell().BeginParse())
+	if err == nil {
+		t.Fatal("unknown tag should fail")
+	}
+}
+
+func TestMsgForwardPrices(t *testing.T) {
+	c := cell.BeginCell().MustStoreUInt(0xea, 8).
+		MustStoreUInt(400000, 64).MustStoreUInt(26214400, 64).MustStoreUInt(2621440000, 64).
+		MustStoreUInt(98304, 32).MustStoreUInt(21845, 16).MustStoreUInt(21845, 16).
+		EndCell()
+
+	var m MsgForwardPrices
+	if err := LoadFromCell(&m, c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	if fee := m.CalcFwdFee(1, 100); fee.NanoTON().Uint64() != 400000+100*400+40000 {
+		t.Fatal("incorrect fee", fee.NanoTON())
+	}
+
+	// rounding up
+	m.BitPrice = 1
+	if fee := m.CalcFwdFee(0, 1); fee.NanoTON().Uint64() != 400001 {
+		t.Fatal("incorrect fee rounding", fee.NanoTON())
+	}
+}
+
+func TestLoadStoragePrices(t *testing.T) {
+	dict := cell.NewDict(32)
+	for i, since := range []uint64{1000, 0} {
+		err := dict.SetIntKey(big.NewInt(int64(i)), cell.BeginCell().MustStoreUInt(0xcc, 8).
+			MustStoreUInt(since, 32).MustStoreUInt(1, 64).MustStoreUInt(500, 64).
+			MustStoreUInt(1000, 64).MustStoreUInt(500000, 64).EndCell())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prices, err := LoadStoragePrices(dict.MustToCell())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(prices) != 2 || prices[0].UTimeSince != 0 || prices[1].UTimeSince != 1000 {
+		t.Fatal("incorrect prices", prices)
+	}
+
+	// (1000*1 + 10*500) * 65536 / 65536
+	if fee := prices[1].CalcStorageFee(10, 1000, 65536, false); fee.NanoTON().Uint64() != 6000 {
+		t.Fatal("incorrect fee", fee.NanoTON())
+	}
+
+	if fee := prices[1].CalcStorageFee(10, 1000, 65536, true); fee.NanoTON().Uint64() != 1000*1000+10*500000 {
+		t.Fatal("incorrect mc fee", fee.NanoTON())
+	}
+}
+
+func testValidatorDescr(withAddr bool, key byte, weight uint64) *cell.Cell {
+	b := cell.BeginCell()
+	if withAddr {
+		b.MustStoreUInt(0x73, 8)
+	} else {
+		b.MustStoreUInt(0x53, 8)
+	}
+
+	b.MustStoreUInt(0x8e81278a, 32).MustStoreSlice(bytes.Repeat([]byte{key}, 32), 256).MustStoreUInt(weight, 64)
+	if withAddr {
+		b.MustStoreSlice(bytes.Repeat([]byte{key + 1}, 32), 256)
+	}
+	return b.EndCell()
+}
+
+func TestValidatorSet_LoadFromCell(t *testing.T) {
+	list := cell.NewDict(16)
+	for i := 0; i < 3; i++ {
+		if err := list.SetIntKey(big.NewInt(int64(i)), testValidatorDescr(i == 1, byte(i*10), uint64(100+i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	listCell := list.MustToCell()
+
+	ext := cell.BeginCell().MustStoreUInt(0x12, 8).MustStoreUInt(1000, 32).MustStoreUInt(2000, 32).
+		MustStoreUInt(3, 16).MustStoreUInt(2, 16).MustStoreUInt(500, 64).MustStoreDict(list).EndCell()
+
+	var set ValidatorSet
+	if err := set.LoadFromCell(ext.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	if set.UTimeSince != 1000 || set.UTimeUntil != 2000 || set.Total != 3 || set.Main != 2 || set.TotalWeight != 500 || len(set.List) != 3 {
+		t.Fatal("incorrect validator set", set)
+	}
+
+	for i, v := range set.List {
+		if v.PublicKey[0] != byte(i*10) || v.Weight != uint64(100+i) || (v.ADNLAddr != nil) != (i == 1) {
+			t.Fatal("incorrect validator", i)
+		}
+	}
+
+	// old format keeps not empty hashmap in the same cell
+	old := cell.BeginCell().MustStoreUInt(0x11, 8).MustStoreUInt(1000, 32).MustStoreUInt(2000, 32).
+		MustStoreUInt(3, 16).MustStoreUInt(2, 16).MustStoreBuilder(listCell.ToBuilder()).EndCell()
+
+	set = ValidatorSet{}
+	if err := set.LoadFromCell(old.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	if set.TotalWeight != 303 || len(set.List) != 3 {
+		t.Fatal("incorrect validator set", set)
+	}
+}
+
+func TestWorkchainDescr_LoadFromCell(t *testing.T) {
+	c := cell.BeginCell().MustStoreUInt(0xa6, 8).MustStoreUInt(1573821854, 32).
+		MustStoreUInt(0, 8).MustStoreUInt(0, 8).MustStoreUInt(8, 8).
+		MustStoreBoolBit(true).MustStoreBoolBit(true).MustStoreBoolBit(true).MustStoreUInt(0, 13).
+		MustStoreSlice(make([]byte, 32), 256).MustStoreSlice(make([]byte, 32), 256).
+		MustStoreUInt(0, 32).
+		MustStoreUInt(1, 4).MustStoreInt(-1, 32).MustStoreUInt(0, 64).
+		EndCell()
+
+	var w WorkchainDescr
+	if err := w.LoadFromCell(c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.EnabledSince != 1573821854 || w.MaxSplit != 8 || !w.Basic || !w.Active || !w.AcceptMsgs || w.VMVersion != -1 {
+		t.Fatal("incorrect workchain", w)
+	}
+}
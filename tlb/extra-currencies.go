@@ -0,0 +1,178 @@
+package tlb
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var ErrNotEnoughExtraCurrency = errors.New("not enough extra currency")
+
+// ExtraCurrencies - amounts of extra currencies by their ids, ExtraCurrencyCollection in TL-B.
+// Amount of each currency is VarUInteger 32, zero amounts are not stored.
+type ExtraCurrencies map[uint32]*big.Int
+
+func (e *ExtraCurrencies) LoadFromCell(loader *cell.Slice) error {
+	dict, err := loader.LoadDict(32)
+	if err != nil {
+		return fmt.Errorf("failed to load extra currencies dict: %w", err)
+	}
+
+	*e = nil
+	for _, kv := range dict.All() {
+		id, err := kv.Key.BeginParse().LoadUInt(32)
+		if err != nil {
+			return fmt.Errorf("failed to load currency id: %w", err)
+		}
+
+		amount, err := kv.Value.BeginParse().LoadVarUInt(32)
+		if err != nil {
+			return fmt.Errorf("failed to load amount of currency %d: %w", id, err)
+		}
+
+		if *e == nil {
+			*e = ExtraCurrencies{}
+		}
+		(*e)[uint32(id)] = amount
+	}
+
+	return nil
+}
+
+// ToDict - serializes currencies to dictionary, nil is returned when there are no currencies
+func (e ExtraCurrencies) ToDict() (*cell.Dictionary, error) {
+	if e.IsEmpty() {
+		return nil, nil
+	}
+
+	dict := cell.NewDict(32)
+	for id, amount := range e {
+		if amount == nil || amount.Sign() == 0 {
+			continue
+		}
+
+		if amount.Sign() < 0 {
+			return nil, fmt.Errorf("amount of currency %d is negative", id)
+		}
+
+		value := cell.BeginCell()
+		if err := value.StoreVarUInt(amount, 32); err != nil {
+			return nil, fmt.Errorf("failed to store amount of currency %d: %w", id, err)
+		}
+
+		if err := dict.Set(cell.BeginCell().MustStoreUInt(uint64(id), 32).EndCell(), value.EndCell()); err != nil {
+			return nil, err
+		}
+	}
+
+	return dict, nil
+}
+
+// ToCell - serializes currencies as HashmapE, it should be stored inline
+func (e ExtraCurrencies) ToCell() (*cell.Cell, error) {
+	dict, err := e.ToDict()
+	if err != nil {
+		return nil, err
+	}
+
+	b := cell.BeginCell()
+	if err = b.StoreDict(dict); err != nil {
+		return nil, err
+	}
+	return b.EndCell(), nil
+}
+
+// Get - returns amount of currency, zero if there is no such currency
+func (e ExtraCurrencies) Get(id uint32) *big.Int {
+	if v := e[id]; v != nil {
+		return new(big.Int).Set(v)
+	}
+	return big.NewInt(0)
+}
+
+// IsEmpty - true if there is no currency with non-zero amount
+func (e ExtraCurrencies) IsEmpty() bool {
+	for _, v := range e {
+		if v != nil && v.Sign() != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IDs - returns ids of currencies with non-zero amount, sorted
+func (e ExtraCurrencies) IDs() []uint32 {
+	var ids []uint32
+	for id, v := range e {
+		if v != nil && v.Sign() != 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// Add - returns new collection which is the sum of both
+func (e ExtraCurrencies) Add(other ExtraCurrencies) ExtraCurrencies {
+	res := e.Copy()
+	for id, v := range other {
+		if v == nil || v.Sign() == 0 {
+			continue
+		}
+		res[id] = new(big.Int).Add(res.Get(id), v)
+	}
+	return res
+}
+
+// Sub - returns new collection which is the difference,
+// ErrNotEnoughExtraCurrency is returned if any amount would become negative
+func (e ExtraCurrencies) Sub(other ExtraCurrencies) (ExtraCurrencies, error) {
+	res := e.Copy()
+	for id, v := range other {
+		if v == nil || v.Sign() == 0 {
+			continue
+		}
+
+		left := new(big.Int).Sub(res.Get(id), v)
+		if left.Sign() < 0 {
+			return nil, fmt.Errorf("%w: id %d", ErrNotEnoughExtraCurrency, id)
+		}
+
+		if left.Sign() == 0 {
+			delete(res, id)
+			continue
+		}
+		res[id] = left
+	}
+	return res, nil
+}
+
+// Equal - compares non-zero amounts of both collections
+func (e ExtraCurrencies) Equal(other ExtraCurrencies) bool {
+	ids := e.IDs()
+	if len(ids) != len(other.IDs()) {
+		return false
+	}
+
+	for _, id := range ids {
+		if e[id].Cmp(other.Get(id)) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Copy - deep copy, zero amounts are dropped
+func (e ExtraCurrencies) Copy() ExtraCurrencies {
+	res := ExtraCurrencies{}
+	for _, id := range e.IDs() {
+		res[id] = new(big.Int).Set(e[id])
+	}
+	return res
+}
@@ -0,0 +1,99 @@
+package tlb
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestExtraCurrencies_LoadFromCell(t *testing.T) {
+	extra := ExtraCurrencies{
+		5:          big.NewInt(1),
+		239:        big.NewInt(0),
+		0xFFFFFFFF: new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 248), big.NewInt(1)),
+	}
+
+	c, err := extra.ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded ExtraCurrencies
+	if err = loaded.LoadFromCell(c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loaded) != 2 || !loaded.Equal(extra) {
+		t.Fatal("not eq", loaded)
+	}
+
+	// zero amounts are not stored, so it is an empty dict
+	c, err = (ExtraCurrencies{1: big.NewInt(0)}).ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded = nil
+	if err = loaded.LoadFromCell(c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded != nil {
+		t.Fatal("should be nil")
+	}
+
+	tooBig := ExtraCurrencies{1: new(big.Int).Lsh(big.NewInt(1), 248)}
+	if _, err = tooBig.ToDict(); err == nil {
+		t.Fatal("should be error")
+	}
+
+	if _, err = (ExtraCurrencies{1: big.NewInt(-1)}).ToDict(); err == nil {
+		t.Fatal("should be error")
+	}
+}
+
+func TestExtraCurrencies_Arithmetic(t *testing.T) {
+	a := ExtraCurrencies{1: big.NewInt(100), 2: big.NewInt(5)}
+	b := ExtraCurrencies{2: big.NewInt(5), 3: big.NewInt(7)}
+
+	sum := a.Add(b)
+	if !sum.Equal(ExtraCurrencies{1: big.NewInt(100), 2: big.NewInt(10), 3: big.NewInt(7)}) {
+		t.Fatal("wrong sum", sum)
+	}
+
+	// source should not be changed
+	if a.Get(2).Int64() != 5 || len(a) != 2 {
+		t.Fatal("source changed")
+	}
+
+	diff, err := sum.Sub(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !diff.Equal(a) {
+		t.Fatal("wrong diff", diff)
+	}
+
+	diff, err = a.Sub(ExtraCurrencies{2: big.NewInt(5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := diff[2]; ok || diff.Get(2).Sign() != 0 {
+		t.Fatal("zero currency should be removed")
+	}
+
+	if _, err = a.Sub(b); !errors.Is(err, ErrNotEnoughExtraCurrency) {
+		t.Fatal("should be not enough", err)
+	}
+
+	if !(ExtraCurrencies{}).IsEmpty() || !(ExtraCurrencies{4: big.NewInt(0)}).Equal(nil) || a.IsEmpty() {
+		t.Fatal("wrong empty check")
+	}
+
+	ids := sum.IDs()
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+		t.Fatal("wrong ids", ids)
+	}
+}
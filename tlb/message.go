@@ -35,7 +35,7 @@ type InternalMessage struct {
 	SrcAddr         *address.Address `tlb:"addr"`
 	DstAddr         *address.Address `tlb:"addr"`
 	Amount          Coins            `tlb:"."`
-	ExtraCurrencies *cell.Dictionary `tlb:"dict 32"`
+	ExtraCurrencies ExtraCurrencies  `tlb:"."`
 	IHRFee          Coins            `tlb:"."`
 	FwdFee          Coins            `tlb:"."`
 	CreatedLT       uint64           `tlb:"## 64"`
@@ -187,11 +187,11 @@ func (m *InternalMessage) ToCell() (*cell.Cell, error) {
 	b.MustStoreAddr(m.DstAddr)
 	b.MustStoreBigCoins(m.Amount.NanoTON())
 
-	if m.ExtraCurrencies != nil {
-		return nil, errors.New("extra currencies serialization is not supported yet")
+	extra, err := m.ExtraCurrencies.ToDict()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize extra currencies: %w", err)
 	}
-
-	b.MustStoreBoolBit(m.ExtraCurrencies != nil)
+	b.MustStoreDict(extra)
 
 	b.MustStoreBigCoins(m.IHRFee.NanoTON())
 	b.MustStoreBigCoins(m.FwdFee.NanoTON())
@@ -1,6 +1,7 @@
 package tlb
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/xssnick/tonutils-go/address"
@@ -19,6 +20,10 @@ func TestInternalMessage_ToCell(t *testing.T) {
 		SrcAddr:     src,
 		DstAddr:     dst,
 		Amount:      amount,
+		ExtraCurrencies: ExtraCurrencies{
+			1:   big.NewInt(777),
+			100: new(big.Int).Lsh(big.NewInt(1), 200),
+		},
 		StateInit: &StateInit{
 			Data: cell.BeginCell().EndCell(),
 			Code: cell.BeginCell().EndCell(),
@@ -48,4 +53,8 @@ func TestInternalMessage_ToCell(t *testing.T) {
 	if intMsg.Amount.NanoTON().Uint64() != intMsg2.Amount.NanoTON().Uint64() {
 		t.Fatal("not eq ton", intMsg.Amount.NanoTON(), intMsg2.Amount.NanoTON())
 	}
+
+	if !intMsg.ExtraCurrencies.Equal(intMsg2.ExtraCurrencies) {
+		t.Fatal("not eq extra currencies", intMsg2.ExtraCurrencies)
+	}
 }
@@ -16,6 +16,7 @@ type ShardState struct {
 }
 
 type ShardIdent struct {
+	_           Magic  `tlb:"$00"`
 	PrefixBits  int8   `tlb:"## 6"`
 	WorkchainID int32  `tlb:"## 32"`
 	ShardPrefix uint64 `tlb:"## 64"`
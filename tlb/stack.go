@@ -74,54 +74,8 @@ func (s *Stack) ToCell() (*cell.Cell, error) {
 		b := cell.BeginCell()
 		b.MustStoreRef(next.EndCell())
 
-		switch v := unwrap[i].value.(type) {
-		case nil:
-			b.MustStoreUInt(0x00, 8)
-		case int, int8, int16, int32, int64, uint8, uint16, uint32:
-			b.MustStoreUInt(0x01, 8)
-
-			// cast to int64
-			val := reflect.ValueOf(v).Convert(reflect.TypeOf(int64(0))).Interface().(int64)
-			b.MustStoreInt(val, 64)
-		case uint, uint64, *big.Int:
-			// https://github.com/ton-blockchain/ton/blob/24dc184a2ea67f9c47042b4104bbb4d82289fac1/crypto/vm/stack.cpp#L739
-			b.MustStoreUInt(0x0200/2, 15)
-
-			var bi *big.Int
-			switch vv := v.(type) {
-			case uint64:
-				bi = new(big.Int).SetUint64(vv)
-			case uint:
-				bi = new(big.Int).SetUint64(uint64(vv))
-			case *big.Int:
-				bi = vv
-			}
-
-			b.MustStoreBigInt(bi, 257)
-		case StackNaN, *StackNaN:
-			b.MustStoreSlice([]byte{0x02, 0xFF}, 16)
-		case *cell.Cell:
-			b.MustStoreUInt(0x03, 8)
-			b.MustStoreRef(v)
-		case *cell.Slice:
-			b.MustStoreUInt(0x04, 8)
-
-			// start data offset
-			b.MustStoreUInt(0, 10)
-			// end data offset
-			b.MustStoreUInt(uint64(v.BitsLeft()), 10)
-
-			// start refs offset
-			b.MustStoreUInt(0, 3)
-			// end refs offset
-			b.MustStoreUInt(uint64(v.RefsNum()), 3)
-
-			b.MustStoreRef(v.MustToCell())
-		case *cell.Builder:
-			b.MustStoreUInt(0x05, 8)
-			b.MustStoreRef(v.EndCell())
-		default:
-			return nil, fmt.Errorf("unknown type at %d pos in stack", i)
+		if err := storeStackValue(b, unwrap[i].value); err != nil {
+			return nil, fmt.Errorf("failed to store value at %d pos in stack: %w", i, err)
 		}
 
 		next = b
@@ -130,6 +84,104 @@ func (s *Stack) ToCell() (*cell.Cell, error) {
 	return root.MustStoreBuilder(next).EndCell(), nil
 }
 
+func storeStackValue(b *cell.Builder, value any) error {
+	switch v := value.(type) {
+	case nil:
+		b.MustStoreUInt(0x00, 8)
+	case int, int8, int16, int32, int64, uint8, uint16, uint32:
+		b.MustStoreUInt(0x01, 8)
+
+		// cast to int64
+		val := reflect.ValueOf(v).Convert(reflect.TypeOf(int64(0))).Interface().(int64)
+		b.MustStoreInt(val, 64)
+	case uint, uint64, *big.Int:
+		// https://github.com/ton-blockchain/ton/blob/24dc184a2ea67f9c47042b4104bbb4d82289fac1/crypto/vm/stack.cpp#L739
+		b.MustStoreUInt(0x0200/2, 15)
+
+		var bi *big.Int
+		switch vv := v.(type) {
+		case uint64:
+			bi = new(big.Int).SetUint64(vv)
+		case uint:
+			bi = new(big.Int).SetUint64(uint64(vv))
+		case *big.Int:
+			bi = vv
+		}
+
+		b.MustStoreBigInt(bi, 257)
+	case StackNaN, *StackNaN:
+		b.MustStoreSlice([]byte{0x02, 0xFF}, 16)
+	case *cell.Cell:
+		b.MustStoreUInt(0x03, 8)
+		b.MustStoreRef(v)
+	case *cell.Slice:
+		b.MustStoreUInt(0x04, 8)
+
+		// start data offset
+		b.MustStoreUInt(0, 10)
+		// end data offset
+		b.MustStoreUInt(uint64(v.BitsLeft()), 10)
+
+		// start refs offset
+		b.MustStoreUInt(0, 3)
+		// end refs offset
+		b.MustStoreUInt(uint64(v.RefsNum()), 3)
+
+		b.MustStoreRef(v.MustToCell())
+	case *cell.Builder:
+		b.MustStoreUInt(0x05, 8)
+		b.MustStoreRef(v.EndCell())
+	case []any:
+		if len(v) > 255 {
+			return errors.New("too big tuple, max 255 elements are supported")
+		}
+
+		b.MustStoreUInt(0x07, 8)
+		b.MustStoreUInt(uint64(len(v)), 16)
+
+		if err := storeTuple(b, v); err != nil {
+			return fmt.Errorf("failed to store tuple: %w", err)
+		}
+	default:
+		return errors.New("unknown type")
+	}
+
+	return nil
+}
+
+// storeTuple - serializes VmTuple, each element is stored in its own ref,
+// last element goes to the tail and all previous are packed in the head
+func storeTuple(b *cell.Builder, tuple []any) error {
+	if len(tuple) == 0 {
+		return nil
+	}
+
+	head := tuple[:len(tuple)-1]
+	switch len(head) {
+	case 0:
+	case 1:
+		c := cell.BeginCell()
+		if err := storeStackValue(c, head[0]); err != nil {
+			return err
+		}
+		b.MustStoreRef(c.EndCell())
+	default:
+		c := cell.BeginCell()
+		if err := storeTuple(c, head); err != nil {
+			return err
+		}
+		b.MustStoreRef(c.EndCell())
+	}
+
+	c := cell.BeginCell()
+	if err := storeStackValue(c, tuple[len(tuple)-1]); err != nil {
+		return err
+	}
+	b.MustStoreRef(c.EndCell())
+
+	return nil
+}
+
 func (s *Stack) LoadFromCell(loader *cell.Slice) error {
 	depth, err := loader.LoadUInt(24)
 	if err != nil {
@@ -146,129 +198,197 @@ func (s *Stack) LoadFromCell(loader *cell.Slice) error {
 			return fmt.Errorf("failed to load stack next ref, err: %w", err)
 		}
 
-		typ, err := next.LoadUInt(8)
+		val, err := loadStackValue(next)
 		if err != nil {
-			return fmt.Errorf("failed to load stack value type, err: %w", err)
+			return err
 		}
+		s.Push(val)
 
-		switch typ {
-		case 0x00:
-			s.Push(nil)
-		case 0x01:
-			val, err := next.LoadInt(64)
-			if err != nil {
-				return fmt.Errorf("failed to load tiny int stack value, err: %w", err)
-			}
-			s.Push(val)
-		case 0x02:
-			subTyp, err := next.LoadUInt(8)
+		next = ref
+	}
+
+	return nil
+}
+
+func loadStackValue(loader *cell.Slice) (any, error) {
+	typ, err := loader.LoadUInt(8)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stack value type, err: %w", err)
+	}
+
+	switch typ {
+	case 0x00:
+		return nil, nil
+	case 0x01:
+		val, err := loader.LoadInt(64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tiny int stack value, err: %w", err)
+		}
+		return val, nil
+	case 0x02:
+		subTyp, err := loader.LoadUInt(8)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load stack value sub type, err: %w", err)
+		}
+
+		switch subTyp {
+		case 0xFF:
+			return StackNaN{}, nil
+		default:
+			bInt, err := loader.LoadBigUInt(256)
 			if err != nil {
-				return fmt.Errorf("failed to load stack value sub type, err: %w", err)
+				return nil, fmt.Errorf("failed to load stack value big int, err: %w", err)
 			}
 
-			switch subTyp {
-			case 0xFF:
-				s.Push(StackNaN{})
-			default:
-				bInt, err := next.LoadBigUInt(256)
-				if err != nil {
-					return fmt.Errorf("failed to load stack value big int, err: %w", err)
-				}
-
-				// 1st bit of int257 indicates sign, it is loaded in type
-				if subTyp > 0 {
-					bInt.Mul(bInt, big.NewInt(-1))
-				}
-
-				s.Push(bInt)
+			// 1st bit of int257 indicates sign, it is loaded in type
+			if subTyp > 0 {
+				bInt.Mul(bInt, big.NewInt(-1))
 			}
-		case 0x03:
-			val, err := next.LoadRef()
+
+			return bInt, nil
+		}
+	case 0x03:
+		val, err := loader.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cell stack value, err: %w", err)
+		}
+		return val.MustToCell(), nil
+	case 0x04:
+		start, err := loader.LoadUInt(10)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load slice stack value's start, err: %w", err)
+		}
+		end, err := loader.LoadUInt(10)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load slice stack value's end, err: %w", err)
+		}
+		if start > end {
+			return nil, fmt.Errorf("start index > end index")
+		}
+
+		startRef, err := loader.LoadUInt(3)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load slice stack value's start ref, err: %w", err)
+		}
+		endRef, err := loader.LoadUInt(3)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load slice stack value's end ref, err: %w", err)
+		}
+		if startRef > endRef {
+			return nil, fmt.Errorf("start ref index > end ref index")
+		}
+
+		val, err := loader.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cell stack value, err: %w", err)
+		}
+
+		cl := cell.BeginCell()
+
+		if start > 0 {
+			_, err = val.LoadSlice(uint(start))
 			if err != nil {
-				return fmt.Errorf("failed to load cell stack value, err: %w", err)
+				return nil, fmt.Errorf("load prefix err: %w", err)
 			}
-			s.Push(val.MustToCell())
-		case 0x04:
-			start, err := next.LoadUInt(10)
+		}
+
+		if end > 0 {
+			sz := uint(end - start)
+			data, err := val.LoadSlice(sz)
 			if err != nil {
-				return fmt.Errorf("failed to load slice stack value's start, err: %w", err)
+				return nil, fmt.Errorf("load prefix err: %w", err)
 			}
-			end, err := next.LoadUInt(10)
+
+			err = cl.StoreSlice(data, sz)
 			if err != nil {
-				return fmt.Errorf("failed to load slice stack value's end, err: %w", err)
-			}
-			if start > end {
-				return fmt.Errorf("start index > end index")
+				return nil, fmt.Errorf("store slice err: %w", err)
 			}
+		}
 
-			startRef, err := next.LoadUInt(3)
+		for x := uint64(0); x < startRef; x++ {
+			_, err := val.LoadRef()
 			if err != nil {
-				return fmt.Errorf("failed to load slice stack value's start ref, err: %w", err)
+				return nil, fmt.Errorf("failed to load slice stack value's ref, err: %w", err)
 			}
-			endRef, err := next.LoadUInt(3)
+		}
+
+		for x := uint64(0); x < endRef-startRef; x++ {
+			sliceRef, err := val.LoadRef()
 			if err != nil {
-				return fmt.Errorf("failed to load slice stack value's end ref, err: %w", err)
-			}
-			if startRef > endRef {
-				return fmt.Errorf("start ref index > end ref index")
+				return nil, fmt.Errorf("failed to load slice stack value's ref, err: %w", err)
 			}
 
-			val, err := next.LoadRef()
+			err = cl.StoreRef(sliceRef.MustToCell())
 			if err != nil {
-				return fmt.Errorf("failed to load cell stack value, err: %w", err)
+				return nil, fmt.Errorf("failed to store slice stack value's ref, err: %w", err)
 			}
+		}
+		return cl.EndCell().BeginParse(), nil
+	case 0x05:
+		val, err := loader.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cell stack value, err: %w", err)
+		}
+		return val.MustToCell().ToBuilder(), nil
+	case 0x07:
+		ln, err := loader.LoadUInt(16)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tuple stack value's length, err: %w", err)
+		}
 
-			cl := cell.BeginCell()
+		tuple, err := loadTuple(loader, ln)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tuple stack value, err: %w", err)
+		}
+		return tuple, nil
+	}
 
-			if start > 0 {
-				_, err = val.LoadSlice(uint(start))
-				if err != nil {
-					return fmt.Errorf("load prefix err: %w", err)
-				}
-			}
+	return nil, fmt.Errorf("unknown stack value type %x", typ)
+}
 
-			if end > 0 {
-				sz := uint(end - start)
-				data, err := val.LoadSlice(sz)
-				if err != nil {
-					return fmt.Errorf("load prefix err: %w", err)
-				}
-
-				err = cl.StoreSlice(data, sz)
-				if err != nil {
-					return fmt.Errorf("store slice err: %w", err)
-				}
-			}
+// loadTuple - parses VmTuple of given length,
+// last element is stored in the tail ref and all previous are in the head
+func loadTuple(loader *cell.Slice, ln uint64) ([]any, error) {
+	tuple := make([]any, 0, ln)
+	if ln == 0 {
+		return tuple, nil
+	}
 
-			for x := uint64(0); x < startRef; x++ {
-				_, err := val.LoadRef()
-				if err != nil {
-					return fmt.Errorf("failed to load slice stack value's ref, err: %w", err)
-				}
-			}
+	switch ln - 1 {
+	case 0:
+	case 1:
+		ref, err := loader.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tuple head ref, err: %w", err)
+		}
 
-			for x := uint64(0); x < endRef-startRef; x++ {
-				sliceRef, err := val.LoadRef()
-				if err != nil {
-					return fmt.Errorf("failed to load slice stack value's ref, err: %w", err)
-				}
+		val, err := loadStackValue(ref)
+		if err != nil {
+			return nil, err
+		}
+		tuple = append(tuple, val)
+	default:
+		ref, err := loader.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tuple head ref, err: %w", err)
+		}
 
-				err = cl.StoreRef(sliceRef.MustToCell())
-				if err != nil {
-					return fmt.Errorf("failed to store slice stack value's ref, err: %w", err)
-				}
-			}
-			s.Push(cl.EndCell().BeginParse())
-		case 0x05:
-			val, err := next.LoadRef()
-			if err != nil {
-				return fmt.Errorf("failed to load cell stack value, err: %w", err)
-			}
-			s.Push(val.MustToCell().ToBuilder())
+		head, err := loadTuple(ref, ln-1)
+		if err != nil {
+			return nil, err
 		}
+		tuple = append(tuple, head...)
+	}
 
-		next = ref
+	ref, err := loader.LoadRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tuple tail ref, err: %w", err)
 	}
 
-	return nil
+	val, err := loadStackValue(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(tuple, val), nil
 }
@@ -67,3 +67,58 @@ func TestStack_ToCell(t *testing.T) {
 		t.Fatal("big val err", err)
 	}
 }
+
+func TestStack_Tuple(t *testing.T) {
+	ref := cell.BeginCell().MustStoreUInt(0xAA, 8).EndCell()
+
+	s := NewStack()
+	s.Push([]any{int64(7), []any{}, []any{int64(-1), ref}, nil, big.NewInt(99), []any{int64(5), int64(6), int64(7)}})
+
+	c, err := s.ToCell()
+	if err != nil {
+		t.Fatal("failed to cell", err)
+	}
+
+	var s2 Stack
+	err = s2.LoadFromCell(c.BeginParse())
+	if err != nil {
+		t.Fatal("failed from cell", err)
+	}
+
+	c2, err := s2.ToCell()
+	if err != nil {
+		t.Fatal("failed to cell", err)
+	}
+
+	if !bytes.Equal(c2.Hash(), c.Hash()) {
+		t.Fatal("rebuild not same")
+	}
+
+	v, err := s2.Pop()
+	if err != nil {
+		t.Fatal("pop err", err)
+	}
+
+	tuple := v.([]any)
+	if len(tuple) != 6 {
+		t.Fatal("tuple len incorrect", len(tuple))
+	}
+	if tuple[0].(int64) != 7 {
+		t.Fatal("tuple 0 incorrect")
+	}
+	if len(tuple[1].([]any)) != 0 {
+		t.Fatal("tuple 1 incorrect")
+	}
+	if pair := tuple[2].([]any); pair[0].(int64) != -1 || !bytes.Equal(pair[1].(*cell.Cell).Hash(), ref.Hash()) {
+		t.Fatal("tuple 2 incorrect")
+	}
+	if tuple[3] != nil {
+		t.Fatal("tuple 3 incorrect")
+	}
+	if tuple[4].(*big.Int).Uint64() != 99 {
+		t.Fatal("tuple 4 incorrect")
+	}
+	if triple := tuple[5].([]any); triple[0].(int64) != 5 || triple[1].(int64) != 6 || triple[2].(int64) != 7 {
+		t.Fatal("tuple 5 incorrect")
+	}
+}
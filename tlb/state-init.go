@@ -2,6 +2,7 @@ package tlb
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
@@ -13,37 +14,36 @@ type TickTock struct {
 
 type StateInit struct {
 	Depth    uint64           `tlb:"maybe ## 5"`
-	TickTock *TickTock        `tlb:"maybe ^"`
+	TickTock *TickTock        `tlb:"maybe ."`
 	Code     *cell.Cell       `tlb:"maybe ^"`
 	Data     *cell.Cell       `tlb:"maybe ^"`
 	Lib      *cell.Dictionary `tlb:"dict 256"`
 }
 
 func (m *StateInit) ToCell() (*cell.Cell, error) {
-	var flags byte
 	state := cell.BeginCell()
 
-	if m.Lib != nil {
-		return nil, errors.New("lib serialization is currently not supported")
+	if m.Depth > 31 {
+		return nil, errors.New("split depth should be less than 32")
 	}
 
 	if m.Depth != 0 {
-		return nil, errors.New("depth serialization is currently not supported")
+		state.MustStoreBoolBit(true).MustStoreUInt(m.Depth, 5)
+	} else {
+		state.MustStoreBoolBit(false)
 	}
 
 	if m.TickTock != nil {
-		return nil, errors.New("ticktock serialization is currently not supported")
+		state.MustStoreBoolBit(true).MustStoreBoolBit(m.TickTock.Tick).MustStoreBoolBit(m.TickTock.Tock)
+	} else {
+		state.MustStoreBoolBit(false)
 	}
 
-	if m.Code != nil {
-		flags |= 1 << 2
-		state.MustStoreRef(m.Code)
-	}
+	state.MustStoreMaybeRef(m.Code).MustStoreMaybeRef(m.Data)
 
-	if m.Data != nil {
-		flags |= 1 << 1
-		state.MustStoreRef(m.Data)
+	if err := state.StoreDict(m.Lib); err != nil {
+		return nil, fmt.Errorf("failed to store libraries: %w", err)
 	}
 
-	return state.MustStoreUInt(uint64(flags), 5).EndCell(), nil
+	return state.EndCell(), nil
 }
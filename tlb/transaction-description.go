@@ -0,0 +1,524 @@
+package tlb
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var ErrNotOrdinaryTransaction = errors.New("transaction is not ordinary")
+
+type AccStatusChange uint8
+
+const (
+	AccStatusChangeUnchanged AccStatusChange = iota
+	AccStatusChangeFrozen
+	AccStatusChangeDeleted
+)
+
+type ComputeSkipReason uint8
+
+const (
+	ComputeSkipReasonNoState ComputeSkipReason = iota
+	ComputeSkipReasonBadState
+	ComputeSkipReasonNoGas
+	ComputeSkipReasonSuspended
+)
+
+type BouncePhaseType uint8
+
+const (
+	BouncePhaseNegFunds BouncePhaseType = iota
+	BouncePhaseNoFunds
+	BouncePhaseOk
+)
+
+// TransactionDescriptionOrdinary - description of ordinary transaction, which is created by incoming message
+type TransactionDescriptionOrdinary struct {
+	CreditFirst  bool
+	StoragePhase *StoragePhase
+	CreditPhase  *CreditPhase
+	ComputePhase ComputePhase
+	ActionPhase  *ActionPhase
+	Aborted      bool
+	BouncePhase  *BouncePhase
+	Destroyed    bool
+}
+
+type StoragePhase struct {
+	StorageFeesCollected Coins
+	StorageFeesDue       *Coins
+	StatusChange         AccStatusChange
+}
+
+type CreditPhase struct {
+	DueFeesCollected *Coins
+	Credit           CurrencyCollection
+}
+
+type ComputePhase struct {
+	Skipped    bool
+	SkipReason ComputeSkipReason
+
+	// fields below are filled only when phase is not skipped
+	Success          bool
+	MsgStateUsed     bool
+	AccountActivated bool
+	GasFees          Coins
+	GasUsed          *big.Int
+	GasLimit         *big.Int
+	GasCredit        *big.Int
+	Mode             int8
+	ExitCode         int32
+	ExitArg          *int32
+	VMSteps          uint32
+	VMInitStateHash  []byte
+	VMFinalStateHash []byte
+}
+
+type ActionPhase struct {
+	Success         bool
+	Valid           bool
+	NoFunds         bool
+	StatusChange    AccStatusChange
+	TotalFwdFees    *Coins
+	TotalActionFees *Coins
+	ResultCode      int32
+	ResultArg       *int32
+	TotalActions    uint16
+	SpecActions     uint16
+	SkippedActions  uint16
+	MessagesCreated uint16
+	ActionListHash  []byte
+	TotalMsgSize    StorageUsedShort
+}
+
+type BouncePhase struct {
+	Type BouncePhaseType
+
+	// for no funds and ok types
+	MsgSize StorageUsedShort
+	// for no funds type
+	ReqFwdFees Coins
+	// for ok type
+	MsgFees Coins
+	FwdFees Coins
+}
+
+type StorageUsedShort struct {
+	Cells *big.Int
+	Bits  *big.Int
+}
+
+// ParseDescription - parses description of ordinary transaction,
+// returns ErrNotOrdinaryTransaction for other types, like tick-tock or split.
+func (t *Transaction) ParseDescription() (*TransactionDescriptionOrdinary, error) {
+	if t.Description == nil {
+		return nil, errors.New("transaction has no description")
+	}
+
+	var desc TransactionDescriptionOrdinary
+	if err := desc.LoadFromCell(t.Description.BeginParse()); err != nil {
+		return nil, err
+	}
+	return &desc, nil
+}
+
+func (d *TransactionDescriptionOrdinary) LoadFromCell(loader *cell.Slice) error {
+	typ, err := loader.LoadUInt(4)
+	if err != nil {
+		return fmt.Errorf("failed to load description type: %w", err)
+	}
+
+	if typ != 0b0000 {
+		return ErrNotOrdinaryTransaction
+	}
+
+	var desc TransactionDescriptionOrdinary
+
+	if desc.CreditFirst, err = loader.LoadBoolBit(); err != nil {
+		return fmt.Errorf("failed to load credit first: %w", err)
+	}
+
+	has, err := loader.LoadBoolBit()
+	if err != nil {
+		return fmt.Errorf("failed to load storage phase bit: %w", err)
+	}
+	if has {
+		desc.StoragePhase = &StoragePhase{}
+		if err = desc.StoragePhase.LoadFromCell(loader); err != nil {
+			return fmt.Errorf("failed to load storage phase: %w", err)
+		}
+	}
+
+	has, err = loader.LoadBoolBit()
+	if err != nil {
+		return fmt.Errorf("failed to load credit phase bit: %w", err)
+	}
+	if has {
+		desc.CreditPhase = &CreditPhase{}
+		if err = desc.CreditPhase.LoadFromCell(loader); err != nil {
+			return fmt.Errorf("failed to load credit phase: %w", err)
+		}
+	}
+
+	if err = desc.ComputePhase.LoadFromCell(loader); err != nil {
+		return fmt.Errorf("failed to load compute phase: %w", err)
+	}
+
+	action, err := loader.LoadMaybeRef()
+	if err != nil {
+		return fmt.Errorf("failed to load action phase ref: %w", err)
+	}
+	if action != nil {
+		desc.ActionPhase = &ActionPhase{}
+		if err = desc.ActionPhase.LoadFromCell(action); err != nil {
+			return fmt.Errorf("failed to load action phase: %w", err)
+		}
+	}
+
+	if desc.Aborted, err = loader.LoadBoolBit(); err != nil {
+		return fmt.Errorf("failed to load aborted: %w", err)
+	}
+
+	has, err = loader.LoadBoolBit()
+	if err != nil {
+		return fmt.Errorf("failed to load bounce phase bit: %w", err)
+	}
+	if has {
+		desc.BouncePhase = &BouncePhase{}
+		if err = desc.BouncePhase.LoadFromCell(loader); err != nil {
+			return fmt.Errorf("failed to load bounce phase: %w", err)
+		}
+	}
+
+	if desc.Destroyed, err = loader.LoadBoolBit(); err != nil {
+		return fmt.Errorf("failed to load destroyed: %w", err)
+	}
+
+	*d = desc
+	return nil
+}
+
+func (s *StoragePhase) LoadFromCell(loader *cell.Slice) error {
+	collected, err := loader.LoadBigCoins()
+	if err != nil {
+		return err
+	}
+
+	due, err := loadMaybeCoins(loader)
+	if err != nil {
+		return err
+	}
+
+	change, err := loadAccStatusChange(loader)
+	if err != nil {
+		return err
+	}
+
+	*s = StoragePhase{
+		StorageFeesCollected: FromNanoTON(collected),
+		StorageFeesDue:       due,
+		StatusChange:         change,
+	}
+	return nil
+}
+
+func (c *CreditPhase) LoadFromCell(loader *cell.Slice) error {
+	due, err := loadMaybeCoins(loader)
+	if err != nil {
+		return err
+	}
+
+	var credit CurrencyCollection
+	if err = LoadFromCell(&credit, loader); err != nil {
+		return err
+	}
+
+	*c = CreditPhase{
+		DueFeesCollected: due,
+		Credit:           credit,
+	}
+	return nil
+}
+
+func (c *ComputePhase) LoadFromCell(loader *cell.Slice) error {
+	isVM, err := loader.LoadBoolBit()
+	if err != nil {
+		return err
+	}
+
+	if !isVM {
+		reason, err := loader.LoadUInt(2)
+		if err != nil {
+			return err
+		}
+
+		if reason == 0b11 {
+			// cskip_suspended$110
+			if _, err = loader.LoadUInt(1); err != nil {
+				return err
+			}
+		}
+
+		*c = ComputePhase{
+			Skipped:    true,
+			SkipReason: ComputeSkipReason(reason),
+		}
+		return nil
+	}
+
+	var ph ComputePhase
+	if ph.Success, err = loader.LoadBoolBit(); err != nil {
+		return err
+	}
+	if ph.MsgStateUsed, err = loader.LoadBoolBit(); err != nil {
+		return err
+	}
+	if ph.AccountActivated, err = loader.LoadBoolBit(); err != nil {
+		return err
+	}
+
+	fees, err := loader.LoadBigCoins()
+	if err != nil {
+		return err
+	}
+	ph.GasFees = FromNanoTON(fees)
+
+	details, err := loader.LoadRef()
+	if err != nil {
+		return err
+	}
+
+	if ph.GasUsed, err = details.LoadVarUInt(7); err != nil {
+		return err
+	}
+	if ph.GasLimit, err = details.LoadVarUInt(7); err != nil {
+		return err
+	}
+
+	has, err := details.LoadBoolBit()
+	if err != nil {
+		return err
+	}
+	if has {
+		if ph.GasCredit, err = details.LoadVarUInt(3); err != nil {
+			return err
+		}
+	}
+
+	mode, err := details.LoadInt(8)
+	if err != nil {
+		return err
+	}
+	ph.Mode = int8(mode)
+
+	code, err := details.LoadInt(32)
+	if err != nil {
+		return err
+	}
+	ph.ExitCode = int32(code)
+
+	if ph.ExitArg, err = loadMaybeInt32(details); err != nil {
+		return err
+	}
+
+	steps, err := details.LoadUInt(32)
+	if err != nil {
+		return err
+	}
+	ph.VMSteps = uint32(steps)
+
+	if ph.VMInitStateHash, err = details.LoadSlice(256); err != nil {
+		return err
+	}
+	if ph.VMFinalStateHash, err = details.LoadSlice(256); err != nil {
+		return err
+	}
+
+	*c = ph
+	return nil
+}
+
+func (a *ActionPhase) LoadFromCell(loader *cell.Slice) error {
+	var ph ActionPhase
+	var err error
+
+	if ph.Success, err = loader.LoadBoolBit(); err != nil {
+		return err
+	}
+	if ph.Valid, err = loader.LoadBoolBit(); err != nil {
+		return err
+	}
+	if ph.NoFunds, err = loader.LoadBoolBit(); err != nil {
+		return err
+	}
+	if ph.StatusChange, err = loadAccStatusChange(loader); err != nil {
+		return err
+	}
+	if ph.TotalFwdFees, err = loadMaybeCoins(loader); err != nil {
+		return err
+	}
+	if ph.TotalActionFees, err = loadMaybeCoins(loader); err != nil {
+		return err
+	}
+
+	code, err := loader.LoadInt(32)
+	if err != nil {
+		return err
+	}
+	ph.ResultCode = int32(code)
+
+	if ph.ResultArg, err = loadMaybeInt32(loader); err != nil {
+		return err
+	}
+
+	for _, v := range []*uint16{&ph.TotalActions, &ph.SpecActions, &ph.SkippedActions, &ph.MessagesCreated} {
+		n, err := loader.LoadUInt(16)
+		if err != nil {
+			return err
+		}
+		*v = uint16(n)
+	}
+
+	if ph.ActionListHash, err = loader.LoadSlice(256); err != nil {
+		return err
+	}
+	if err = ph.TotalMsgSize.LoadFromCell(loader); err != nil {
+		return err
+	}
+
+	*a = ph
+	return nil
+}
+
+func (b *BouncePhase) LoadFromCell(loader *cell.Slice) error {
+	isOk, err := loader.LoadBoolBit()
+	if err != nil {
+		return err
+	}
+
+	var ph BouncePhase
+	if isOk {
+		ph.Type = BouncePhaseOk
+		if err = ph.MsgSize.LoadFromCell(loader); err != nil {
+			return err
+		}
+
+		msgFees, err := loader.LoadBigCoins()
+		if err != nil {
+			return err
+		}
+		fwdFees, err := loader.LoadBigCoins()
+		if err != nil {
+			return err
+		}
+		ph.MsgFees, ph.FwdFees = FromNanoTON(msgFees), FromNanoTON(fwdFees)
+
+		*b = ph
+		return nil
+	}
+
+	noFunds, err := loader.LoadBoolBit()
+	if err != nil {
+		return err
+	}
+
+	if !noFunds {
+		*b = BouncePhase{Type: BouncePhaseNegFunds}
+		return nil
+	}
+
+	ph.Type = BouncePhaseNoFunds
+	if err = ph.MsgSize.LoadFromCell(loader); err != nil {
+		return err
+	}
+
+	reqFees, err := loader.LoadBigCoins()
+	if err != nil {
+		return err
+	}
+	ph.ReqFwdFees = FromNanoTON(reqFees)
+
+	*b = ph
+	return nil
+}
+
+func (s *StorageUsedShort) LoadFromCell(loader *cell.Slice) error {
+	cells, err := loader.LoadVarUInt(7)
+	if err != nil {
+		return err
+	}
+
+	bits, err := loader.LoadVarUInt(7)
+	if err != nil {
+		return err
+	}
+
+	*s = StorageUsedShort{
+		Cells: cells,
+		Bits:  bits,
+	}
+	return nil
+}
+
+func loadAccStatusChange(loader *cell.Slice) (AccStatusChange, error) {
+	changed, err := loader.LoadBoolBit()
+	if err != nil {
+		return 0, err
+	}
+
+	if !changed {
+		return AccStatusChangeUnchanged, nil
+	}
+
+	deleted, err := loader.LoadBoolBit()
+	if err != nil {
+		return 0, err
+	}
+
+	if deleted {
+		return AccStatusChangeDeleted, nil
+	}
+	return AccStatusChangeFrozen, nil
+}
+
+func loadMaybeCoins(loader *cell.Slice) (*Coins, error) {
+	has, err := loader.LoadBoolBit()
+	if err != nil {
+		return nil, err
+	}
+
+	if !has {
+		return nil, nil
+	}
+
+	val, err := loader.LoadBigCoins()
+	if err != nil {
+		return nil, err
+	}
+
+	coins := FromNanoTON(val)
+	return &coins, nil
+}
+
+func loadMaybeInt32(loader *cell.Slice) (*int32, error) {
+	has, err := loader.LoadBoolBit()
+	if err != nil {
+		return nil, err
+	}
+
+	if !has {
+		return nil, nil
+	}
+
+	val, err := loader.LoadInt(32)
+	if err != nil {
+		return nil, err
+	}
+
+	v := int32(val)
+	return &v, nil
+}
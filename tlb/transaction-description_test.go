@@ -0,0 +1,95 @@
+package tlb
+
+import (
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestTransaction_ParseDescription(t *testing.T) {
+	computeDetails := cell.BeginCell().
+		MustStoreUInt(1, 3).MustStoreUInt(200, 8). // gas used
+		MustStoreUInt(1, 3).MustStoreUInt(250, 8). // gas limit
+		MustStoreBoolBit(false).                   // gas credit
+		MustStoreInt(0, 8).                        // mode
+		MustStoreInt(33, 32).                      // exit code
+		MustStoreBoolBit(false).                   // exit arg
+		MustStoreUInt(55, 32).                     // vm steps
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreSlice(make([]byte, 32), 256).
+		EndCell()
+
+	action := cell.BeginCell().
+		MustStoreBoolBit(true).MustStoreBoolBit(true).MustStoreBoolBit(false).
+		MustStoreUInt(0, 1).                         // status unchanged
+		MustStoreBoolBit(true).MustStoreCoins(1000). // fwd fees
+		MustStoreBoolBit(false).                     // action fees
+		MustStoreInt(0, 32).
+		MustStoreBoolBit(true).MustStoreInt(-5, 32). // result arg
+		MustStoreUInt(2, 16).MustStoreUInt(0, 16).MustStoreUInt(0, 16).MustStoreUInt(2, 16).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreUInt(1, 3).MustStoreUInt(3, 8). // cells
+		MustStoreUInt(1, 3).MustStoreUInt(9, 8). // bits
+		EndCell()
+
+	desc := cell.BeginCell().
+		MustStoreUInt(0b0000, 4).
+		MustStoreBoolBit(false). // credit first
+		MustStoreBoolBit(true).  // storage phase
+		MustStoreCoins(7).MustStoreBoolBit(false).MustStoreUInt(0b10, 2).
+		MustStoreBoolBit(false). // credit phase
+		MustStoreBoolBit(true).  // compute vm
+		MustStoreBoolBit(false).MustStoreBoolBit(false).MustStoreBoolBit(true).
+		MustStoreCoins(123).
+		MustStoreRef(computeDetails).
+		MustStoreMaybeRef(action).
+		MustStoreBoolBit(true).  // aborted
+		MustStoreBoolBit(true).  // bounce phase
+		MustStoreBoolBit(false). // not ok
+		MustStoreBoolBit(true).  // no funds
+		MustStoreUInt(1, 3).MustStoreUInt(1, 8).
+		MustStoreUInt(1, 3).MustStoreUInt(2, 8).
+		MustStoreCoins(77).
+		MustStoreBoolBit(false). // destroyed
+		EndCell()
+
+	tx := &Transaction{Description: desc}
+	d, err := tx.ParseDescription()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.StoragePhase == nil || d.StoragePhase.StorageFeesCollected.NanoTON().Uint64() != 7 ||
+		d.StoragePhase.StatusChange != AccStatusChangeFrozen {
+		t.Fatal("incorrect storage phase")
+	}
+
+	if d.CreditPhase != nil {
+		t.Fatal("credit phase should be nil")
+	}
+
+	ph := d.ComputePhase
+	if ph.Skipped || ph.Success || !ph.AccountActivated || ph.GasFees.NanoTON().Uint64() != 123 ||
+		ph.GasUsed.Uint64() != 200 || ph.GasLimit.Uint64() != 250 || ph.ExitCode != 33 || ph.VMSteps != 55 {
+		t.Fatal("incorrect compute phase")
+	}
+
+	act := d.ActionPhase
+	if act == nil || !act.Success || act.TotalFwdFees.NanoTON().Uint64() != 1000 || act.TotalActionFees != nil ||
+		*act.ResultArg != -5 || act.MessagesCreated != 2 || act.TotalMsgSize.Bits.Uint64() != 9 {
+		t.Fatal("incorrect action phase")
+	}
+
+	if !d.Aborted || d.Destroyed {
+		t.Fatal("incorrect flags")
+	}
+
+	if d.BouncePhase == nil || d.BouncePhase.Type != BouncePhaseNoFunds || d.BouncePhase.ReqFwdFees.NanoTON().Uint64() != 77 {
+		t.Fatal("incorrect bounce phase")
+	}
+
+	tx.Description = cell.BeginCell().MustStoreUInt(0b0010, 4).EndCell()
+	if _, err = tx.ParseDescription(); err != ErrNotOrdinaryTransaction {
+		t.Fatal("should be not ordinary", err)
+	}
+}
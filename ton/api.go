@@ -24,6 +24,8 @@ const (
 	_GetAllShardsInfo      int32 = 1960050027
 	_ListBlockTransactions int32 = -1375942694
 	_LookupBlock           int32 = -87492834
+	_GetConfigAll          int32 = -1860491593
+	_GetConfigParams       int32 = -1627878045
 )
 
 // responses
@@ -37,6 +39,7 @@ const (
 	_BlockTransactions int32 = -1114854101
 	_BlockHeader       int32 = 1965916697
 	_AllShardsInfo     int32 = 160425773
+	_ConfigInfo        int32 = -1367660753
 
 	_BoolTrue  int32 = -1720552011
 	_BoolFalse int32 = -1132882121
@@ -78,9 +81,9 @@ func loadBytes(data []byte) (loaded []byte, buffer []byte) {
 		offset = 4
 	}
 
-	// bytes length should be dividable by 4, add additional offset to buffer if it is not
+	// bytes length with its prefix should be dividable by 4, add additional offset to buffer if it is not
 	bufSz := ln
-	if add := ln % 4; add != 0 {
+	if add := (offset + ln) % 4; add != 0 {
 		bufSz += 4 - add
 	}
 
@@ -0,0 +1,61 @@
+package ton
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLoadBytes(t *testing.T) {
+	long := bytes.Repeat([]byte{0xAB}, 300)
+
+	tests := []struct {
+		name string
+		data []byte
+		val  []byte
+		rest []byte
+	}{
+		{
+			// 1 byte prefix + 3 bytes of value are already aligned, so no padding
+			name: "short aligned",
+			data: []byte{3, 1, 2, 3, 0xEE, 0xEE, 0xEE, 0xEE},
+			val:  []byte{1, 2, 3},
+			rest: []byte{0xEE, 0xEE, 0xEE, 0xEE},
+		},
+		{
+			name: "short padded",
+			data: []byte{1, 7, 0, 0, 0xEE, 0xEE, 0xEE, 0xEE},
+			val:  []byte{7},
+			rest: []byte{0xEE, 0xEE, 0xEE, 0xEE},
+		},
+		{
+			name: "short with 4 bytes value",
+			data: []byte{4, 1, 2, 3, 4, 0, 0, 0, 0xEE, 0xEE, 0xEE, 0xEE},
+			val:  []byte{1, 2, 3, 4},
+			rest: []byte{0xEE, 0xEE, 0xEE, 0xEE},
+		},
+		{
+			name: "long",
+			data: append(append([]byte{0xFE, 0x2C, 0x01, 0x00}, long...), 0xEE, 0xEE, 0xEE, 0xEE),
+			val:  long,
+			rest: []byte{0xEE, 0xEE, 0xEE, 0xEE},
+		},
+		{
+			name: "last",
+			data: []byte{2, 1, 2, 0},
+			val:  []byte{1, 2},
+			rest: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			val, rest := loadBytes(test.data)
+			if !bytes.Equal(val, test.val) {
+				t.Fatal("wrong value", val)
+			}
+			if !bytes.Equal(rest, test.rest) {
+				t.Fatal("wrong rest", rest)
+			}
+		})
+	}
+}
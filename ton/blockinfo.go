@@ -13,6 +13,16 @@ import (
 
 var ErrBlockNotFound = errors.New("block not found")
 
+// modes of liteServer.lookupBlock, which field is used to find the block
+const (
+	_LookupModeSeqno uint32 = 1
+	_LookupModeLT    uint32 = 2
+	_LookupModeUtime uint32 = 4
+)
+
+// _MasterchainShard - masterchain has only one shard with prefix 1000...0
+const _MasterchainShard int64 = -9223372036854775808
+
 // CurrentMasterchainInfo - cached version of GetMasterchainInfo to not do it in parallel many times
 func (c *APIClient) CurrentMasterchainInfo(ctx context.Context) (_ *tlb.BlockInfo, err error) {
 	c.curMasterLock.RLock()
@@ -74,15 +84,88 @@ func (c *APIClient) GetMasterchainInfo(ctx context.Context) (*tlb.BlockInfo, err
 
 // LookupBlock - find block information by seqno, shard and chain
 func (c *APIClient) LookupBlock(ctx context.Context, workchain int32, shard int64, seqno uint32) (*tlb.BlockInfo, error) {
+	b, _, err := c.lookupBlock(ctx, _LookupModeSeqno, workchain, shard, seqno, 0, 0)
+	return b, err
+}
+
+// LookupBlockByUtime - finds block of the shard by unix time, generation time of the returned block
+// can be a bit before or after the given time, use GetMasterchainBlockByTime to get the closest one in masterchain
+func (c *APIClient) LookupBlockByUtime(ctx context.Context, workchain int32, shard int64, utime uint32) (*tlb.BlockInfo, error) {
+	b, _, err := c.lookupBlock(ctx, _LookupModeUtime, workchain, shard, 0, 0, utime)
+	return b, err
+}
+
+// LookupBlockByLT - finds block of the shard which contains the given logical time
+func (c *APIClient) LookupBlockByLT(ctx context.Context, workchain int32, shard int64, lt uint64) (*tlb.BlockInfo, error) {
+	b, _, err := c.lookupBlock(ctx, _LookupModeLT, workchain, shard, 0, lt, 0)
+	return b, err
+}
+
+// GetMasterchainBlockByTime - finds masterchain block which generation time is the closest to the given unix time
+func (c *APIClient) GetMasterchainBlockByTime(ctx context.Context, utime uint32) (*tlb.BlockInfo, error) {
+	block, header, err := c.lookupBlockHeader(ctx, _LookupModeUtime, -1, _MasterchainShard, 0, utime)
+	if err != nil {
+		return nil, err
+	}
+
+	// check neighbour block from the other side of the time, maybe it is closer
+	neighbour := block.SeqNo + 1
+	if header.GenUtime > utime {
+		if block.SeqNo == 0 {
+			return block, nil
+		}
+		neighbour = block.SeqNo - 1
+	}
+
+	nBlock, nHeader, err := c.lookupBlockHeader(ctx, _LookupModeSeqno, -1, _MasterchainShard, neighbour, 0)
+	if err != nil {
+		if errors.Is(err, ErrBlockNotFound) {
+			return block, nil
+		}
+		return nil, err
+	}
+
+	if timeDiff(nHeader.GenUtime, utime) < timeDiff(header.GenUtime, utime) {
+		return nBlock, nil
+	}
+	return block, nil
+}
+
+func (c *APIClient) lookupBlockHeader(ctx context.Context, mode uint32, workchain int32, shard int64, seqno, utime uint32) (*tlb.BlockInfo, *tlb.BlockHeader, error) {
+	block, proof, err := c.lookupBlock(ctx, mode, workchain, shard, seqno, 0, utime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header, err := parseBlockHeaderProof(proof)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return block, header, nil
+}
+
+func (c *APIClient) lookupBlock(ctx context.Context, mode uint32, workchain int32, shard int64, seqno uint32, lt uint64, utime uint32) (*tlb.BlockInfo, []byte, error) {
 	data := make([]byte, 20)
-	binary.LittleEndian.PutUint32(data, 1)
+	binary.LittleEndian.PutUint32(data, mode)
 	binary.LittleEndian.PutUint32(data[4:], uint32(workchain))
 	binary.LittleEndian.PutUint64(data[8:], uint64(shard))
 	binary.LittleEndian.PutUint32(data[16:], seqno)
 
+	switch mode {
+	case _LookupModeLT:
+		ltData := make([]byte, 8)
+		binary.LittleEndian.PutUint64(ltData, lt)
+		data = append(data, ltData...)
+	case _LookupModeUtime:
+		utimeData := make([]byte, 4)
+		binary.LittleEndian.PutUint32(utimeData, utime)
+		data = append(data, utimeData...)
+	}
+
 	resp, err := c.client.Do(ctx, _LookupBlock, data)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	switch resp.TypeID {
@@ -90,26 +173,72 @@ func (c *APIClient) LookupBlock(ctx context.Context, workchain int32, shard int6
 		b := new(tlb.BlockInfo)
 		resp.Data, err = b.Load(resp.Data)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		return b, nil
+		if len(resp.Data) < 4 {
+			return nil, nil, errors.New("not enough length")
+		}
+
+		// mode
+		resp.Data = resp.Data[4:]
+
+		var proof []byte
+		proof, resp.Data = loadBytes(resp.Data)
+
+		return b, proof, nil
 	case _LSError:
 		var lsErr LSError
 		resp.Data, err = lsErr.Load(resp.Data)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		// 651 = block not found code
 		if lsErr.Code == 651 {
-			return nil, ErrBlockNotFound
+			return nil, nil, ErrBlockNotFound
 		}
 
-		return nil, lsErr
+		return nil, nil, lsErr
 	}
 
-	return nil, errors.New("unknown response type")
+	return nil, nil, errors.New("unknown response type")
+}
+
+// parseBlockHeaderProof - loads block info from merkle proof of block header
+func parseBlockHeaderProof(proof []byte) (*tlb.BlockHeader, error) {
+	proofCell, err := cell.FromBOC(proof)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse header proof boc: %w", err)
+	}
+
+	block, err := proofCell.BeginParse().LoadRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load block ref: %w", err)
+	}
+
+	if tag, err := block.LoadUInt(32); err != nil || tag != 0x11ef55aa {
+		return nil, errors.New("invalid block tag")
+	}
+
+	info, err := block.LoadRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load block info ref: %w", err)
+	}
+
+	var header tlb.BlockHeader
+	if err = tlb.LoadFromCell(&header, info); err != nil {
+		return nil, fmt.Errorf("failed to parse block info: %w", err)
+	}
+
+	return &header, nil
+}
+
+func timeDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
 }
 
 // GetBlockData - get block detailed information
@@ -0,0 +1,111 @@
+package ton
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func testBlockHeader(block *tlb.BlockInfo, genUtime uint32) []byte {
+	info := cell.BeginCell().MustStoreUInt(0x9bc7a987, 32).MustStoreUInt(0, 32).
+		MustStoreUInt(0, 8).MustStoreUInt(0, 8).
+		MustStoreUInt(uint64(block.SeqNo), 32).MustStoreUInt(0, 32).
+		MustStoreUInt(0, 2).MustStoreUInt(0, 6).MustStoreInt(int64(block.Workchain), 32).MustStoreUInt(uint64(block.Shard), 64).
+		MustStoreUInt(uint64(genUtime), 32).MustStoreUInt(uint64(block.SeqNo)*1000, 64).MustStoreUInt(uint64(block.SeqNo)*1000+999, 64).
+		EndCell()
+
+	blockCell := cell.BeginCell().MustStoreUInt(0x11ef55aa, 32).MustStoreInt(-239, 32).MustStoreRef(info).EndCell()
+	proof := cell.BeginCell().MustStoreUInt(3, 8).MustStoreRef(blockCell).EndCell()
+
+	data := block.Serialize()
+	data = append(data, make([]byte, 4)...)
+	data = append(data, tl.ToBytes(proof.ToBOCWithFlags(false))...)
+	return data
+}
+
+func TestAPIClient_LookupBlockByTime(t *testing.T) {
+	// blocks are generated every 5 seconds, lite server returns first block after the time
+	genUtime := func(seqno uint32) uint32 {
+		return 1000 + seqno*5
+	}
+
+	var lastMode uint32
+	api := NewAPIClient(mockClient{do: func(ctx context.Context, typeID int32, payload []byte) (*liteclient.LiteResponse, error) {
+		if typeID != _LookupBlock {
+			t.Fatal("incorrect request type")
+		}
+
+		lastMode = binary.LittleEndian.Uint32(payload)
+		block := &tlb.BlockInfo{
+			Workchain: int32(binary.LittleEndian.Uint32(payload[4:])),
+			Shard:     int64(binary.LittleEndian.Uint64(payload[8:])),
+			RootHash:  make([]byte, 32),
+			FileHash:  make([]byte, 32),
+		}
+
+		switch lastMode {
+		case _LookupModeSeqno:
+			if len(payload) != 20 {
+				t.Fatal("incorrect payload size")
+			}
+			block.SeqNo = binary.LittleEndian.Uint32(payload[16:])
+		case _LookupModeLT:
+			if len(payload) != 28 {
+				t.Fatal("incorrect payload size")
+			}
+			block.SeqNo = uint32(binary.LittleEndian.Uint64(payload[20:]) / 1000)
+		case _LookupModeUtime:
+			if len(payload) != 24 {
+				t.Fatal("incorrect payload size")
+			}
+			utime := binary.LittleEndian.Uint32(payload[20:])
+			block.SeqNo = (utime - 1000 + 4) / 5
+		default:
+			t.Fatal("unknown mode", lastMode)
+		}
+
+		if block.SeqNo > 100 {
+			return &liteclient.LiteResponse{TypeID: _LSError, Data: append([]byte{0x8b, 0x02, 0, 0}, tl.ToBytes([]byte("not found"))...)}, nil
+		}
+
+		return &liteclient.LiteResponse{TypeID: _BlockHeader, Data: testBlockHeader(block, genUtime(block.SeqNo))}, nil
+	}})
+
+	b, err := api.LookupBlockByLT(context.Background(), 0, _MasterchainShard, 7500)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lastMode != _LookupModeLT || b.SeqNo != 7 || b.Workchain != 0 {
+		t.Fatal("incorrect block", b.SeqNo)
+	}
+
+	b, err = api.LookupBlockByUtime(context.Background(), 0, _MasterchainShard, 1012)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lastMode != _LookupModeUtime || b.SeqNo != 3 {
+		t.Fatal("incorrect block", b.SeqNo)
+	}
+
+	for utime, seqno := range map[uint32]uint32{1012: 2, 1014: 3, 1015: 3, 1000: 0, 1500: 100} {
+		b, err = api.GetMasterchainBlockByTime(context.Background(), utime)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if b.SeqNo != seqno || b.Workchain != -1 {
+			t.Fatal("incorrect closest block", utime, b.SeqNo)
+		}
+	}
+
+	if _, err = api.LookupBlock(context.Background(), -1, _MasterchainShard, 101); err != ErrBlockNotFound {
+		t.Fatal("block should be not found", err)
+	}
+}
@@ -0,0 +1,281 @@
+package dns
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var ErrNoSuchRecord = errors.New("no such dns record")
+
+const (
+	_CategoryNextResolver = 0xba93
+	_CategoryContractAddr = 0x9fd3
+	_CategoryADNLSite     = 0xad01
+	_CategoryStorageSite  = 0x7473
+
+	// _ExitCodeNotInitialized - get method exit code when contract is not deployed, -256
+	_ExitCodeNotInitialized = 0xFFFFFF00
+	// _MaxResolveSteps - domain can be delegated to the next resolver only so many times
+	_MaxResolveSteps = 16
+)
+
+// TonApi - methods of ton.APIClient which are needed for dns resolve
+type TonApi interface {
+	CurrentMasterchainInfo(ctx context.Context) (*tlb.BlockInfo, error)
+	RunGetMethod(ctx context.Context, blockInfo *tlb.BlockInfo, addr *address.Address, method string, params ...any) ([]interface{}, error)
+	GetConfigParams(ctx context.Context, block *tlb.BlockInfo, params ...int32) (*ton.BlockchainConfig, error)
+}
+
+// Domain - resolved domain with its records, keys of records are sha256 of record names
+type Domain struct {
+	Name    string
+	Records *cell.Dictionary
+}
+
+// Client - resolves .ton domains starting from root dns contract
+type Client struct {
+	root *address.Address
+	api  TonApi
+}
+
+// RootContractAddr - gets address of root dns contract from config param 4
+func RootContractAddr(ctx context.Context, api TonApi) (*address.Address, error) {
+	b, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	cfg, err := api.GetConfigParams(ctx, b, 4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config param: %w", err)
+	}
+
+	data := cfg.Get(4)
+	if data == nil {
+		return nil, errors.New("root dns address is not in config")
+	}
+
+	hash, err := data.BeginParse().LoadSlice(256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load root dns address: %w", err)
+	}
+	return address.NewAddress(0, 255, hash), nil
+}
+
+func NewDNSClient(api TonApi, root *address.Address) *Client {
+	return &Client{
+		root: root,
+		api:  api,
+	}
+}
+
+// Resolve - resolves domain at the current block, like site.ton or sub.site.ton
+func (c *Client) Resolve(ctx context.Context, domain string) (*Domain, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.ResolveAtBlock(ctx, domain, b)
+}
+
+// ResolveAtBlock - resolves domain at the given block
+func (c *Client) ResolveAtBlock(ctx context.Context, domain string, b *tlb.BlockInfo) (*Domain, error) {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	if domain == "" {
+		return nil, errors.New("empty domain")
+	}
+
+	// name is passed to contract in reversed order with zero byte after each part: ton\0site\0
+	parts := strings.Split(domain, ".")
+	var name []byte
+	for i := len(parts) - 1; i >= 0; i-- {
+		if parts[i] == "" || strings.IndexByte(parts[i], 0) >= 0 {
+			return nil, fmt.Errorf("invalid domain %s", domain)
+		}
+		name = append(append(name, parts[i]...), 0)
+	}
+
+	if len(name) > 126 {
+		return nil, errors.New("too long domain")
+	}
+
+	contract := c.root
+	for i := 0; i < _MaxResolveSteps; i++ {
+		resolved, data, err := c.dnsResolve(ctx, b, contract, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if resolved == len(name) {
+			records := cell.NewDict(256)
+			if data != nil {
+				if records, err = data.BeginParse().ToDict(256); err != nil {
+					return nil, fmt.Errorf("failed to load records: %w", err)
+				}
+			}
+			return &Domain{Name: domain, Records: records}, nil
+		}
+
+		if data == nil {
+			return nil, ErrNoSuchRecord
+		}
+
+		// part of the name is resolved, rest is resolved by the next contract
+		s := data.BeginParse()
+		category, err := s.LoadUInt(16)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load category: %w", err)
+		}
+
+		if category != _CategoryNextResolver {
+			return nil, fmt.Errorf("unexpected category of partially resolved domain: %x", category)
+		}
+
+		if contract, err = s.LoadAddr(); err != nil {
+			return nil, fmt.Errorf("failed to load next resolver: %w", err)
+		}
+		name = name[resolved:]
+	}
+	return nil, errors.New("too many resolve steps")
+}
+
+// dnsResolve - calls dnsresolve get method with category 0 to get all records,
+// returns number of resolved bytes of name and result
+func (c *Client) dnsResolve(ctx context.Context, b *tlb.BlockInfo, contract *address.Address, name []byte) (int, *cell.Cell, error) {
+	nameSlice := cell.BeginCell().MustStoreSlice(name, uint(len(name)*8)).EndCell().BeginParse()
+
+	res, err := c.api.RunGetMethod(ctx, b, contract, "dnsresolve", nameSlice, 0)
+	if err != nil {
+		if errors.Is(err, ton.ContractExecError{Code: _ExitCodeNotInitialized}) {
+			return 0, nil, ErrNoSuchRecord
+		}
+		return 0, nil, fmt.Errorf("failed to run dnsresolve method: %w", err)
+	}
+
+	if len(res) < 2 {
+		return 0, nil, errors.New("dnsresolve returned too few values")
+	}
+
+	var bits int64
+	switch x := res[0].(type) {
+	case int64:
+		bits = x
+	case *big.Int:
+		if !x.IsInt64() {
+			return 0, nil, errors.New("resolved bits number is too big")
+		}
+		bits = x.Int64()
+	default:
+		return 0, nil, errors.New("resolved bits number is not int")
+	}
+
+	if bits%8 != 0 || bits < 0 || bits/8 > int64(len(name)) {
+		return 0, nil, fmt.Errorf("incorrect resolved bits number %d", bits)
+	}
+
+	if bits == 0 {
+		return 0, nil, ErrNoSuchRecord
+	}
+
+	switch x := res[1].(type) {
+	case nil:
+		return int(bits / 8), nil, nil
+	case *cell.Cell:
+		return int(bits / 8), x, nil
+	case *cell.Slice:
+		data, err := x.ToCell()
+		if err != nil {
+			return 0, nil, err
+		}
+		return int(bits / 8), data, nil
+	}
+	return 0, nil, errors.New("dnsresolve result is not cell")
+}
+
+// GetRecord - returns record with the name, record is stored in the ref of value
+func (d *Domain) GetRecord(name string) *cell.Cell {
+	h := sha256.Sum256([]byte(name))
+
+	val := d.Records.Get(cell.BeginCell().MustStoreSlice(h[:], 256).EndCell())
+	if val == nil {
+		return nil
+	}
+
+	rec, err := val.BeginParse().LoadRef()
+	if err != nil {
+		return nil
+	}
+
+	c, err := rec.ToCell()
+	if err != nil {
+		return nil
+	}
+	return c
+}
+
+// GetSiteRecord - returns adnl address of the site, or bag id when site is stored in ton storage
+func (d *Domain) GetSiteRecord() (_ []byte, inStorage bool, err error) {
+	rec := d.GetRecord("site")
+	if rec == nil {
+		return nil, false, ErrNoSuchRecord
+	}
+
+	s := rec.BeginParse()
+	category, err := s.LoadUInt(16)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load category: %w", err)
+	}
+
+	switch category {
+	case _CategoryADNLSite, _CategoryStorageSite:
+		id, err := s.LoadSlice(256)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to load site address: %w", err)
+		}
+		return id, category == _CategoryStorageSite, nil
+	}
+	return nil, false, fmt.Errorf("unexpected site record category %x", category)
+}
+
+// GetWalletRecord - returns wallet address which is linked to the domain
+func (d *Domain) GetWalletRecord() (*address.Address, error) {
+	rec := d.GetRecord("wallet")
+	if rec == nil {
+		return nil, ErrNoSuchRecord
+	}
+
+	s := rec.BeginParse()
+	category, err := s.LoadUInt(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load category: %w", err)
+	}
+
+	if category != _CategoryContractAddr {
+		return nil, fmt.Errorf("unexpected wallet record category %x", category)
+	}
+	return s.LoadAddr()
+}
+
+// SiteRecord - builds value of site record, it can be set to domain by its owner
+func SiteRecord(id []byte, inStorage bool) *cell.Cell {
+	category := uint64(_CategoryADNLSite)
+	if inStorage {
+		category = _CategoryStorageSite
+	}
+
+	b := cell.BeginCell().MustStoreUInt(category, 16).MustStoreSlice(id, 256)
+	if !inStorage {
+		// flags, there is no protocols list
+		b.MustStoreUInt(0, 8)
+	}
+	return b.EndCell()
+}
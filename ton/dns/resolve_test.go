@@ -0,0 +1,173 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type mockAPI struct {
+	contracts map[string]func(name []byte) ([]interface{}, error)
+	calls     int
+}
+
+func (m *mockAPI) CurrentMasterchainInfo(ctx context.Context) (*tlb.BlockInfo, error) {
+	return &tlb.BlockInfo{Workchain: -1}, nil
+}
+
+func (m *mockAPI) RunGetMethod(ctx context.Context, blockInfo *tlb.BlockInfo, addr *address.Address, method string, params ...any) ([]interface{}, error) {
+	m.calls++
+	if method != "dnsresolve" || len(params) != 2 {
+		return nil, errors.New("unexpected call")
+	}
+
+	f := m.contracts[addr.String()]
+	if f == nil {
+		return nil, ton.ContractExecError{Code: _ExitCodeNotInitialized}
+	}
+
+	s := params[0].(*cell.Slice)
+	name, err := s.LoadSlice(s.BitsLeft())
+	if err != nil {
+		return nil, err
+	}
+	return f(name)
+}
+
+func (m *mockAPI) GetConfigParams(ctx context.Context, block *tlb.BlockInfo, params ...int32) (*ton.BlockchainConfig, error) {
+	return ton.NewBlockchainConfig(map[int32]*cell.Cell{
+		4: cell.BeginCell().MustStoreSlice(bytes.Repeat([]byte{0xAA}, 32), 256).EndCell(),
+	}), nil
+}
+
+func records(t *testing.T, recs map[string]*cell.Cell) *cell.Cell {
+	d := cell.NewDict(256)
+	for name, rec := range recs {
+		h := sha256.Sum256([]byte(name))
+		err := d.Set(cell.BeginCell().MustStoreSlice(h[:], 256).EndCell(), cell.BeginCell().MustStoreRef(rec).EndCell())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := d.ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClient_Resolve(t *testing.T) {
+	root := address.MustParseAddr("Ef_lZ1T4NCb2mwkme9h2rJfESCE0W34ma9lWp7-_uY3zXDvq")
+	site := address.MustParseAddr("EQBCFwW8uFUh-amdRmNY9NyeDEaeDYXd9ggJGsicpqVcHq7B")
+	wallet := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+	adnlID := bytes.Repeat([]byte{0x11}, 32)
+
+	siteRecords := records(t, map[string]*cell.Cell{
+		"site":   SiteRecord(adnlID, false),
+		"wallet": cell.BeginCell().MustStoreUInt(_CategoryContractAddr, 16).MustStoreAddr(wallet).MustStoreUInt(0, 8).EndCell(),
+	})
+
+	api := &mockAPI{contracts: map[string]func(name []byte) ([]interface{}, error){
+		root.String(): func(name []byte) ([]interface{}, error) {
+			if !bytes.HasPrefix(name, []byte("ton\x00")) {
+				return []interface{}{int64(0), nil}, nil
+			}
+			return []interface{}{int64(32), cell.BeginCell().MustStoreUInt(_CategoryNextResolver, 16).MustStoreAddr(site).EndCell()}, nil
+		},
+		site.String(): func(name []byte) ([]interface{}, error) {
+			switch string(name) {
+			case "example\x00":
+				return []interface{}{int64(len(name) * 8), siteRecords}, nil
+			case "empty\x00":
+				return []interface{}{int64(len(name) * 8), nil}, nil
+			}
+			return []interface{}{int64(0), nil}, nil
+		},
+	}}
+
+	addr, err := RootContractAddr(context.Background(), api)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.String() != "Ef-qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqjjT" {
+		t.Fatal("incorrect root address", addr.String())
+	}
+
+	c := NewDNSClient(api, root)
+
+	domain, err := c.Resolve(context.Background(), "Example.TON")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if api.calls != 2 {
+		t.Fatal("next resolver is not called", api.calls)
+	}
+
+	id, inStorage, err := domain.GetSiteRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inStorage || !bytes.Equal(id, adnlID) {
+		t.Fatal("incorrect site record")
+	}
+
+	w, err := domain.GetWalletRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.String() != wallet.String() {
+		t.Fatal("incorrect wallet", w.String())
+	}
+
+	domain, err = c.Resolve(context.Background(), "empty.ton")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = domain.GetSiteRecord(); !errors.Is(err, ErrNoSuchRecord) {
+		t.Fatal("should be no site record, got", err)
+	}
+
+	for _, name := range []string{"unknown.ton", "example.com"} {
+		if _, err = c.Resolve(context.Background(), name); !errors.Is(err, ErrNoSuchRecord) {
+			t.Fatal("should be not found", name, err)
+		}
+	}
+
+	if _, err = c.Resolve(context.Background(), "bad..ton"); err == nil {
+		t.Fatal("should be invalid domain")
+	}
+
+	// not deployed root contract
+	c = NewDNSClient(api, wallet)
+	if _, err = c.Resolve(context.Background(), "example.ton"); !errors.Is(err, ErrNoSuchRecord) {
+		t.Fatal("should be not found, got", err)
+	}
+}
+
+func TestSiteRecord(t *testing.T) {
+	bag := bytes.Repeat([]byte{0x22}, 32)
+	d := &Domain{Records: cell.NewDict(256)}
+
+	h := sha256.Sum256([]byte("site"))
+	err := d.Records.Set(cell.BeginCell().MustStoreSlice(h[:], 256).EndCell(), cell.BeginCell().MustStoreRef(SiteRecord(bag, true)).EndCell())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, inStorage, err := d.GetSiteRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inStorage || !bytes.Equal(id, bag) {
+		t.Fatal("incorrect storage site record")
+	}
+}
@@ -0,0 +1,343 @@
+package ton
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var ErrConfigParamNotFound = errors.New("config param not found")
+
+// BlockchainConfig - raw config params by their id, typed getters parse the most common of them
+type BlockchainConfig struct {
+	data map[int32]*cell.Cell
+}
+
+// NewBlockchainConfig - creates config from raw params, can be used with config loaded from another source
+func NewBlockchainConfig(params map[int32]*cell.Cell) *BlockchainConfig {
+	return &BlockchainConfig{data: params}
+}
+
+// GetConfigAll - gets all blockchain config params, at the state of masterchain block
+func (c *APIClient) GetConfigAll(ctx context.Context, block *tlb.BlockInfo) (*BlockchainConfig, error) {
+	data := make([]byte, 4)
+	data = append(data, block.Serialize()...)
+
+	resp, err := c.client.Do(ctx, _GetConfigAll, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseConfigInfo(resp.TypeID, resp.Data)
+}
+
+// GetConfigParams - gets blockchain config params with the given ids, at the state of masterchain block
+func (c *APIClient) GetConfigParams(ctx context.Context, block *tlb.BlockInfo, params ...int32) (*BlockchainConfig, error) {
+	data := make([]byte, 4)
+	data = append(data, block.Serialize()...)
+
+	ln := make([]byte, 4)
+	binary.LittleEndian.PutUint32(ln, uint32(len(params)))
+	data = append(data, ln...)
+
+	for _, p := range params {
+		id := make([]byte, 4)
+		binary.LittleEndian.PutUint32(id, uint32(p))
+		data = append(data, id...)
+	}
+
+	resp, err := c.client.Do(ctx, _GetConfigParams, data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := parseConfigInfo(resp.TypeID, resp.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	// lite server may return more params than we asked, so we keep only requested
+	result := &BlockchainConfig{data: map[int32]*cell.Cell{}}
+	for _, p := range params {
+		v, ok := cfg.data[p]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d", ErrConfigParamNotFound, p)
+		}
+		result.data[p] = v
+	}
+
+	return result, nil
+}
+
+func parseConfigInfo(typeID int32, data []byte) (*BlockchainConfig, error) {
+	switch typeID {
+	case _ConfigInfo:
+		// mode
+		data = data[4:]
+
+		b := new(tlb.BlockInfo)
+		data, err := b.Load(data)
+		if err != nil {
+			return nil, err
+		}
+
+		var stateProof []byte
+		stateProof, data = loadBytes(data)
+		_ = stateProof
+
+		var configProof []byte
+		configProof, data = loadBytes(data)
+
+		proof, err := cell.FromBOC(configProof)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config proof boc: %w", err)
+		}
+
+		// ShardStateUnsplit
+		state, err := proof.BeginParse().LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ref ShardStateUnsplit: %w", err)
+		}
+
+		// skip out_msg_queue_info, accounts and ^[...] refs, 4th ref is McStateExtra
+		var extra *cell.Slice
+		for i := 0; i < 4; i++ {
+			extra, err = state.LoadRef()
+			if err != nil {
+				return nil, fmt.Errorf("no mc extra state found: %w", err)
+			}
+		}
+
+		if tag, err := extra.LoadUInt(16); err != nil || tag != 0xcc26 {
+			return nil, errors.New("invalid mc extra state tag")
+		}
+
+		// shard_hashes
+		if _, err = extra.LoadMaybeRef(); err != nil {
+			return nil, fmt.Errorf("failed to load shard hashes: %w", err)
+		}
+
+		// config_addr
+		if _, err = extra.LoadSlice(256); err != nil {
+			return nil, fmt.Errorf("failed to load config address: %w", err)
+		}
+
+		dictRoot, err := extra.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config dict ref: %w", err)
+		}
+
+		// proof contains only requested params, other branches are pruned
+		dict, err := dictRoot.ToDictPartial(32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config dict: %w", err)
+		}
+
+		cfg := &BlockchainConfig{data: map[int32]*cell.Cell{}}
+		for _, kv := range dict.All() {
+			id, err := kv.Key.BeginParse().LoadInt(32)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load config param id: %w", err)
+			}
+
+			v, err := kv.Value.BeginParse().LoadRef()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load config param %d: %w", id, err)
+			}
+
+			cfg.data[int32(id)], err = v.ToCell()
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert config param %d to cell: %w", id, err)
+			}
+		}
+
+		return cfg, nil
+	case _LSError:
+		var lsErr LSError
+		_, err := lsErr.Load(data)
+		if err != nil {
+			return nil, err
+		}
+		return nil, lsErr
+	}
+
+	return nil, errors.New("unknown response type")
+}
+
+// Get - returns raw config param, or nil if it was not loaded
+func (b *BlockchainConfig) Get(id int32) *cell.Cell {
+	return b.data[id]
+}
+
+// All - returns all loaded config params
+func (b *BlockchainConfig) All() map[int32]*cell.Cell {
+	return b.data
+}
+
+// GetStoragePrices - parses config param 18
+func (b *BlockchainConfig) GetStoragePrices() ([]tlb.StoragePrices, error) {
+	param := b.data[18]
+	if param == nil {
+		return nil, fmt.Errorf("%w: 18", ErrConfigParamNotFound)
+	}
+	return tlb.LoadStoragePrices(param)
+}
+
+// GetGasPrices - parses config param 20 for masterchain, or 21 for basechain
+func (b *BlockchainConfig) GetGasPrices(masterchain bool) (*tlb.GasLimitsPrices, error) {
+	id := int32(21)
+	if masterchain {
+		id = 20
+	}
+
+	var prices tlb.GasLimitsPrices
+	if err := b.loadParam(id, &prices); err != nil {
+		return nil, err
+	}
+	return &prices, nil
+}
+
+// GetMsgForwardPrices - parses config param 24 for masterchain, or 25 for basechain
+func (b *BlockchainConfig) GetMsgForwardPrices(masterchain bool) (*tlb.MsgForwardPrices, error) {
+	id := int32(25)
+	if masterchain {
+		id = 24
+	}
+
+	var prices tlb.MsgForwardPrices
+	if err := b.loadParam(id, &prices); err != nil {
+		return nil, err
+	}
+	return &prices, nil
+}
+
+// GetConfigAddress - config param 0, address of config contract in masterchain
+func (b *BlockchainConfig) GetConfigAddress() (*address.Address, error) {
+	return b.loadAddress(0)
+}
+
+// GetElectorAddress - config param 1, address of elector contract in masterchain
+func (b *BlockchainConfig) GetElectorAddress() (*address.Address, error) {
+	return b.loadAddress(1)
+}
+
+// GetMinterAddress - config param 2, address of minter contract in masterchain,
+// if param is not set, config contract is the minter
+func (b *BlockchainConfig) GetMinterAddress() (*address.Address, error) {
+	if b.data[2] == nil && b.data[0] != nil {
+		return b.loadAddress(0)
+	}
+	return b.loadAddress(2)
+}
+
+// GetWorkchains - parses config param 12, workchain descriptions by their ids
+func (b *BlockchainConfig) GetWorkchains() (map[int32]*tlb.WorkchainDescr, error) {
+	param := b.data[12]
+	if param == nil {
+		return nil, fmt.Errorf("%w: 12", ErrConfigParamNotFound)
+	}
+
+	dict, err := param.BeginParse().LoadDict(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workchains dict: %w", err)
+	}
+
+	workchains := map[int32]*tlb.WorkchainDescr{}
+	for _, kv := range dict.All() {
+		id, err := kv.Key.BeginParse().LoadInt(32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load workchain id: %w", err)
+		}
+
+		var descr tlb.WorkchainDescr
+		if err = descr.LoadFromCell(kv.Value.BeginParse()); err != nil {
+			return nil, fmt.Errorf("failed to parse workchain %d: %w", id, err)
+		}
+		workchains[int32(id)] = &descr
+	}
+
+	return workchains, nil
+}
+
+// GetElectionsTimings - parses config param 15
+func (b *BlockchainConfig) GetElectionsTimings() (*tlb.ElectionsTimings, error) {
+	var timings tlb.ElectionsTimings
+	if err := b.loadParam(15, &timings); err != nil {
+		return nil, err
+	}
+	return &timings, nil
+}
+
+// GetStakeLimits - parses config param 17
+func (b *BlockchainConfig) GetStakeLimits() (*tlb.StakeLimits, error) {
+	var limits tlb.StakeLimits
+	if err := b.loadParam(17, &limits); err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
+// GetPrevValidators - parses config param 32, validators of the previous round
+func (b *BlockchainConfig) GetPrevValidators() (*tlb.ValidatorSet, error) {
+	return b.loadValidatorSet(32)
+}
+
+// GetCurrentValidators - parses config param 34, validators of the current round
+func (b *BlockchainConfig) GetCurrentValidators() (*tlb.ValidatorSet, error) {
+	return b.loadValidatorSet(34)
+}
+
+// GetNextValidators - parses config param 36, validators of the next round,
+// it is present only after elections and before the round start
+func (b *BlockchainConfig) GetNextValidators() (*tlb.ValidatorSet, error) {
+	return b.loadValidatorSet(36)
+}
+
+func (b *BlockchainConfig) loadValidatorSet(id int32) (*tlb.ValidatorSet, error) {
+	var set tlb.ValidatorSet
+	if err := b.loadParam(id, &set); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+func (b *BlockchainConfig) loadAddress(id int32) (*address.Address, error) {
+	param := b.data[id]
+	if param == nil {
+		return nil, fmt.Errorf("%w: %d", ErrConfigParamNotFound, id)
+	}
+
+	data, err := param.BeginParse().LoadSlice(256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config param %d: %w", id, err)
+	}
+
+	// masterchain, workchain is stored as byte
+	return address.NewAddress(0, 255, data), nil
+}
+
+func (b *BlockchainConfig) loadParam(id int32, v any) error {
+	param := b.data[id]
+	if param == nil {
+		return fmt.Errorf("%w: %d", ErrConfigParamNotFound, id)
+	}
+
+	var err error
+	if ld, ok := v.(interface {
+		LoadFromCell(loader *cell.Slice) error
+	}); ok {
+		err = ld.LoadFromCell(param.BeginParse())
+	} else {
+		err = tlb.LoadFromCell(v, param.BeginParse())
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to parse config param %d: %w", id, err)
+	}
+	return nil
+}
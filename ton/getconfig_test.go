@@ -0,0 +1,192 @@
+package ton
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type mockClient struct {
+	do func(ctx context.Context, typeID int32, payload []byte) (*liteclient.LiteResponse, error)
+}
+
+func (m mockClient) Do(ctx context.Context, typeID int32, payload []byte) (*liteclient.LiteResponse, error) {
+	return m.do(ctx, typeID, payload)
+}
+
+func testConfigInfo(params map[int32]*cell.Cell) []byte {
+	dict := cell.NewDict(32)
+	for id, p := range params {
+		_ = dict.SetIntKey(big.NewInt(int64(id)), cell.BeginCell().MustStoreRef(p).EndCell())
+	}
+
+	extra := cell.BeginCell().MustStoreUInt(0xcc26, 16).
+		MustStoreMaybeRef(nil).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreRef(dict.MustToCell()).
+		EndCell()
+
+	empty := cell.BeginCell().EndCell()
+	state := cell.BeginCell().MustStoreUInt(0x9023afe2, 32).
+		MustStoreRef(empty).MustStoreRef(empty).MustStoreRef(empty).MustStoreRef(extra).EndCell()
+
+	proof := cell.BeginCell().MustStoreUInt(3, 8).MustStoreRef(state).EndCell()
+
+	block := &tlb.BlockInfo{Workchain: -1, Shard: -9223372036854775808, SeqNo: 100, RootHash: make([]byte, 32), FileHash: make([]byte, 32)}
+
+	data := make([]byte, 4)
+	data = append(data, block.Serialize()...)
+	data = append(data, tl.ToBytes(nil)...)
+	data = append(data, tl.ToBytes(proof.ToBOCWithFlags(false))...)
+	return data
+}
+
+func TestAPIClient_GetConfigParams(t *testing.T) {
+	fwd := cell.BeginCell().MustStoreUInt(0xea, 8).
+		MustStoreUInt(400000, 64).MustStoreUInt(26214400, 64).MustStoreUInt(2621440000, 64).
+		MustStoreUInt(98304, 32).MustStoreUInt(21845, 16).MustStoreUInt(21845, 16).
+		EndCell()
+
+	gas := cell.BeginCell().MustStoreUInt(0xdd, 8).
+		MustStoreUInt(65536000, 64).MustStoreUInt(1000000, 64).MustStoreUInt(10000, 64).
+		MustStoreUInt(10000000, 64).MustStoreUInt(100000000, 64).MustStoreUInt(1000000000, 64).
+		EndCell()
+
+	api := NewAPIClient(mockClient{do: func(ctx context.Context, typeID int32, payload []byte) (*liteclient.LiteResponse, error) {
+		if typeID != _GetConfigParams {
+			t.Fatal("incorrect request type")
+		}
+
+		// mode + block + vector len
+		if n := binary.LittleEndian.Uint32(payload[4+80:]); n != 2 {
+			t.Fatal("incorrect params num", n)
+		}
+
+		return &liteclient.LiteResponse{
+			TypeID: _ConfigInfo,
+			Data:   testConfigInfo(map[int32]*cell.Cell{20: gas, 24: fwd, 34: cell.BeginCell().EndCell()}),
+		}, nil
+	}})
+
+	block := &tlb.BlockInfo{Workchain: -1, RootHash: make([]byte, 32), FileHash: make([]byte, 32)}
+
+	cfg, err := api.GetConfigParams(context.Background(), block, 20, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.All()) != 2 || cfg.Get(34) != nil {
+		t.Fatal("only requested params should be returned")
+	}
+
+	fwdPrices, err := cfg.GetMsgForwardPrices(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fwdPrices.LumpPrice != 400000 || fwdPrices.NextFrac != 21845 {
+		t.Fatal("incorrect fwd prices", fwdPrices)
+	}
+
+	gasPrices, err := cfg.GetGasPrices(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gasPrices.GasPrice != 65536000 || gasPrices.SpecialGasLimit != 1000000 {
+		t.Fatal("incorrect gas prices", gasPrices)
+	}
+
+	if _, err = cfg.GetGasPrices(false); err == nil {
+		t.Fatal("param 21 is not loaded")
+	}
+
+	if _, err = api.GetConfigParams(context.Background(), block, 20, 18); err == nil {
+		t.Fatal("param 18 is not in response")
+	}
+}
+
+func TestAPIClient_GetConfigAll(t *testing.T) {
+	elector := make([]byte, 32)
+	elector[31] = 0x33
+
+	stakes := cell.BeginCell().
+		MustStoreBigCoins(tlb.MustFromTON("10000").NanoTON()).
+		MustStoreBigCoins(tlb.MustFromTON("10000000").NanoTON()).
+		MustStoreBigCoins(tlb.MustFromTON("100000").NanoTON()).
+		MustStoreUInt(196608, 32).EndCell()
+
+	api := NewAPIClient(mockClient{do: func(ctx context.Context, typeID int32, payload []byte) (*liteclient.LiteResponse, error) {
+		if typeID != _GetConfigAll {
+			t.Fatal("incorrect request type")
+		}
+
+		return &liteclient.LiteResponse{
+			TypeID: _ConfigInfo,
+			Data: testConfigInfo(map[int32]*cell.Cell{
+				0:  cell.BeginCell().MustStoreSlice(make([]byte, 32), 256).EndCell(),
+				1:  cell.BeginCell().MustStoreSlice(elector, 256).EndCell(),
+				15: cell.BeginCell().MustStoreUInt(65536, 32).MustStoreUInt(32768, 32).MustStoreUInt(8192, 32).MustStoreUInt(32768, 32).EndCell(),
+				17: stakes,
+			}),
+		}, nil
+	}})
+
+	cfg, err := api.GetConfigAll(context.Background(), &tlb.BlockInfo{RootHash: make([]byte, 32), FileHash: make([]byte, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.All()) != 4 {
+		t.Fatal("incorrect params num")
+	}
+
+	addr, err := cfg.GetElectorAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if addr.Workchain() != 255 || !bytes.Equal(addr.Data(), elector) {
+		t.Fatal("incorrect elector address", addr.String())
+	}
+
+	// no param 2, so config is minter
+	minter, err := cfg.GetMinterAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if minter.Workchain() != 255 || !bytes.Equal(minter.Data(), make([]byte, 32)) {
+		t.Fatal("incorrect minter address", minter.String())
+	}
+
+	timings, err := cfg.GetElectionsTimings()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if timings.ValidatorsElectedFor != 65536 || timings.ElectionsEndBefore != 8192 {
+		t.Fatal("incorrect timings", timings)
+	}
+
+	limits, err := cfg.GetStakeLimits()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limits.MinStake.TON() != "10000" || limits.MaxStakeFactor != 196608 {
+		t.Fatal("incorrect stake limits", limits.MinStake.TON(), limits.MaxStakeFactor)
+	}
+
+	if _, err = cfg.GetCurrentValidators(); !errors.Is(err, ErrConfigParamNotFound) {
+		t.Fatal("param should be not found", err)
+	}
+}
@@ -0,0 +1,266 @@
+package liteproxy
+
+import (
+	"container/list"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+// requests which are handled in a special way
+const (
+	_RunContractGetMethod  int32 = 1556504018
+	_GetAccountState       int32 = 1804144165
+	_SendMessage           int32 = 1762317442
+	_GetTransactions       int32 = 474015649
+	_GetOneTransaction     int32 = -737205014
+	_GetBlock              int32 = 1668796173
+	_GetAllShardsInfo      int32 = 1960050027
+	_ListBlockTransactions int32 = -1375942694
+	_LookupBlock           int32 = -87492834
+	_GetConfigAll          int32 = -1860491593
+	_GetConfigParams       int32 = -1627878045
+)
+
+const _LookupModeSeqno uint32 = 1
+
+const (
+	_DefaultCacheSize       = 10000
+	_DefaultUpstreamTimeout = 10 * time.Second
+)
+
+// blockPinned - queries which contain exact block id (or exact lt and hash), so their result never changes
+var blockPinned = map[int32]bool{
+	_RunContractGetMethod:  true,
+	_GetAccountState:       true,
+	_GetTransactions:       true,
+	_GetOneTransaction:     true,
+	_GetBlock:              true,
+	_GetAllShardsInfo:      true,
+	_ListBlockTransactions: true,
+	_GetConfigAll:          true,
+	_GetConfigParams:       true,
+}
+
+// Stats - counters of the proxy requests
+type Stats struct {
+	// Requests - total number of requests
+	Requests uint64
+	// CacheHits - requests which were served from cache
+	CacheHits uint64
+	// Collapsed - requests which waited for the same in-flight request instead of making own
+	Collapsed uint64
+	// Upstream - requests which were sent to upstream
+	Upstream uint64
+}
+
+type call struct {
+	done chan struct{}
+	resp *liteclient.LiteResponse
+	err  error
+}
+
+type cacheEntry struct {
+	key  [32]byte
+	resp *liteclient.LiteResponse
+}
+
+// Proxy - lite server which serves all its clients using one upstream client.
+// Responses to block-pinned queries are cached, and identical in-flight requests are collapsed into one.
+// Proxy can also be used in-process as ton.LiteClient.
+type Proxy struct {
+	upstream ton.LiteClient
+	srv      *liteclient.Server
+
+	mx              sync.Mutex
+	inflight        map[[32]byte]*call
+	cache           map[[32]byte]*list.Element
+	cacheOrder      *list.List
+	cacheSize       int
+	upstreamTimeout time.Duration
+
+	requests  uint64
+	cacheHits uint64
+	collapsed uint64
+	upstreams uint64
+}
+
+// NewProxy - creates proxy with the given server key, clients should connect to it using base64 of its public key.
+// Upstream is usually liteclient.ConnectionPool connected to real lite servers.
+func NewProxy(key ed25519.PrivateKey, upstream ton.LiteClient) *Proxy {
+	p := &Proxy{
+		upstream:        upstream,
+		inflight:        map[[32]byte]*call{},
+		cache:           map[[32]byte]*list.Element{},
+		cacheOrder:      list.New(),
+		cacheSize:       _DefaultCacheSize,
+		upstreamTimeout: _DefaultUpstreamTimeout,
+	}
+	p.srv = liteclient.NewServer(key, p.handle)
+
+	return p
+}
+
+// SetCacheSize - sets max number of cached responses, least recently used are evicted first.
+// 0 disables caching, in-flight requests are still collapsed.
+func (p *Proxy) SetCacheSize(size int) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.cacheSize = size
+	p.evict()
+}
+
+// SetUpstreamTimeout - sets timeout of requests to upstream.
+// It is not bound to context of the client, because other clients can wait for the same request.
+func (p *Proxy) SetUpstreamTimeout(timeout time.Duration) {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+
+	p.upstreamTimeout = timeout
+}
+
+// Server - underlying lite server, can be used to register own handlers or set connection callback
+func (p *Proxy) Server() *liteclient.Server {
+	return p.srv
+}
+
+// ListenAndServe - listens on tcp address and serves lite clients, blocks until proxy is closed
+func (p *Proxy) ListenAndServe(addr string) error {
+	return p.srv.ListenAndServe(addr)
+}
+
+// Serve - serves lite clients from listener, blocks until listener fails or proxy is closed
+func (p *Proxy) Serve(ln net.Listener) error {
+	return p.srv.Serve(ln)
+}
+
+// Close - stops accepting clients and drops active connections, upstream is not closed
+func (p *Proxy) Close() error {
+	return p.srv.Close()
+}
+
+// Stats - returns counters of the proxy
+func (p *Proxy) Stats() Stats {
+	return Stats{
+		Requests:  atomic.LoadUint64(&p.requests),
+		CacheHits: atomic.LoadUint64(&p.cacheHits),
+		Collapsed: atomic.LoadUint64(&p.collapsed),
+		Upstream:  atomic.LoadUint64(&p.upstreams),
+	}
+}
+
+func (p *Proxy) handle(ctx context.Context, typeID int32, payload []byte) (*liteclient.LiteResponse, error) {
+	return p.Do(ctx, typeID, payload)
+}
+
+// Do - executes request using cache, in-flight requests or upstream
+func (p *Proxy) Do(ctx context.Context, typeID int32, payload []byte) (*liteclient.LiteResponse, error) {
+	atomic.AddUint64(&p.requests, 1)
+
+	// messages are never collapsed or cached, each of them is sent as is
+	if typeID == _SendMessage {
+		atomic.AddUint64(&p.upstreams, 1)
+		return p.upstream.Do(ctx, typeID, payload)
+	}
+
+	key := requestKey(typeID, payload)
+	cacheable := isCacheable(typeID, payload)
+
+	p.mx.Lock()
+	if el := p.cache[key]; el != nil {
+		p.cacheOrder.MoveToFront(el)
+		resp := el.Value.(*cacheEntry).resp
+		p.mx.Unlock()
+
+		atomic.AddUint64(&p.cacheHits, 1)
+		return copyResponse(resp), nil
+	}
+
+	c := p.inflight[key]
+	if c != nil {
+		p.mx.Unlock()
+		atomic.AddUint64(&p.collapsed, 1)
+	} else {
+		c = &call{done: make(chan struct{})}
+		p.inflight[key] = c
+		timeout := p.upstreamTimeout
+		p.mx.Unlock()
+
+		atomic.AddUint64(&p.upstreams, 1)
+		go p.execute(key, c, cacheable, timeout, typeID, payload)
+	}
+
+	select {
+	case <-c.done:
+		if c.err != nil {
+			return nil, c.err
+		}
+		return copyResponse(c.resp), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *Proxy) execute(key [32]byte, c *call, cacheable bool, timeout time.Duration, typeID int32, payload []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	c.resp, c.err = p.upstream.Do(ctx, typeID, payload)
+
+	p.mx.Lock()
+	delete(p.inflight, key)
+	// errors of lite server are not cached, block could be not yet known for the node
+	if cacheable && c.err == nil && c.resp.TypeID != liteclient.LiteServerError && p.cacheSize > 0 {
+		p.cache[key] = p.cacheOrder.PushFront(&cacheEntry{key: key, resp: c.resp})
+		p.evict()
+	}
+	p.mx.Unlock()
+
+	close(c.done)
+}
+
+// evict - removes least recently used responses to fit the cache size, must be called under lock
+func (p *Proxy) evict() {
+	for p.cacheOrder.Len() > p.cacheSize {
+		el := p.cacheOrder.Back()
+		p.cacheOrder.Remove(el)
+		delete(p.cache, el.Value.(*cacheEntry).key)
+	}
+}
+
+func isCacheable(typeID int32, payload []byte) bool {
+	if typeID == _LookupBlock {
+		// lookup by seqno always points to the same block, by lt or utime it can change while chain grows
+		return len(payload) >= 4 && binary.LittleEndian.Uint32(payload)&7 == _LookupModeSeqno
+	}
+	return blockPinned[typeID]
+}
+
+func requestKey(typeID int32, payload []byte) [32]byte {
+	typ := make([]byte, 4)
+	binary.LittleEndian.PutUint32(typ, uint32(typeID))
+
+	h := sha256.New()
+	h.Write(typ)
+	h.Write(payload)
+
+	var key [32]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+func copyResponse(resp *liteclient.LiteResponse) *liteclient.LiteResponse {
+	return &liteclient.LiteResponse{
+		TypeID: resp.TypeID,
+		Data:   append([]byte{}, resp.Data...),
+	}
+}
@@ -0,0 +1,210 @@
+package liteproxy
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/litetest"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var testAddr = address.MustParseAddr("EQBL2_3lMiyywU17g-or8N7v9hDmPCpttzBPE2isF2GTzpK4")
+
+func startProxy(t *testing.T) (*litetest.Chain, *Proxy, *ton.APIClient) {
+	chain := litetest.NewChain()
+	chain.SetAccount(testAddr, &tlb.AccountState{
+		AccountStorage: tlb.AccountStorage{
+			Status:  tlb.AccountStatusActive,
+			Balance: tlb.MustFromTON("1"),
+			StateInit: &tlb.StateInit{
+				Code: cell.BeginCell().EndCell(),
+				Data: cell.BeginCell().EndCell(),
+			},
+		},
+	})
+
+	upstreamSrv, err := litetest.NewServer(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = upstreamSrv.Close()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	upstream, err := upstreamSrv.Connect(ctx)
+	if err != nil {
+		t.Fatal("upstream connect err", err)
+	}
+
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := NewProxy(key, upstream)
+	go func() {
+		_ = proxy.Serve(ln)
+	}()
+	t.Cleanup(func() {
+		_ = proxy.Close()
+	})
+
+	pool := liteclient.NewConnectionPool()
+	pool.SetOnDisconnect(nil)
+
+	err = pool.AddConnection(ctx, ln.Addr().String(), base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)))
+	if err != nil {
+		t.Fatal("proxy connect err", err)
+	}
+
+	return chain, proxy, ton.NewAPIClient(pool)
+}
+
+func TestProxy_Cache(t *testing.T) {
+	chain, proxy, api := startProxy(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	block, err := api.GetMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		acc, err := api.GetAccount(ctx, block, testAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !acc.IsActive {
+			t.Fatal("account should be active")
+		}
+	}
+
+	// masterchain info is not block-pinned, it should not be cached
+	chain.AddBlock()
+
+	last, err := api.GetMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if last.SeqNo != block.SeqNo+1 {
+		t.Fatal("masterchain info should not be cached")
+	}
+
+	// lite server errors are not cached too
+	for i := 0; i < 2; i++ {
+		if _, err = api.LookupBlock(ctx, -1, block.Shard, 100); err == nil {
+			t.Fatal("block should not be found")
+		}
+	}
+
+	stats := proxy.Stats()
+	if stats.Requests != 7 || stats.CacheHits != 2 || stats.Upstream != 5 {
+		t.Fatal("wrong stats", stats)
+	}
+}
+
+func TestProxy_Collapse(t *testing.T) {
+	chain, proxy, api := startProxy(t)
+
+	release := make(chan struct{})
+	calls := 0
+	chain.SetGetMethod(testAddr, "slow", func(args []any) ([]any, error) {
+		calls++
+		<-release
+		return []any{int64(7)}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	block, err := api.GetMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const num = 5
+
+	var wg sync.WaitGroup
+	errs := make(chan error, num)
+	for i := 0; i < num; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := api.RunGetMethod(ctx, block, testAddr, "slow")
+			if err == nil && res[0].(int64) != 7 {
+				t.Error("wrong result", res)
+			}
+			errs <- err
+		}()
+	}
+
+	// wait for all requests to reach the proxy
+	for proxy.Stats().Collapsed != num-1 {
+		if ctx.Err() != nil {
+			t.Fatal("requests were not collapsed", proxy.Stats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(release)
+
+	wg.Wait()
+	close(errs)
+	for err = range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatal("get method should be called once, but called", calls)
+	}
+
+	if _, err = api.RunGetMethod(ctx, block, testAddr, "slow"); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 1 || proxy.Stats().CacheHits != 1 {
+		t.Fatal("result should be cached", proxy.Stats())
+	}
+}
+
+func TestProxy_Evict(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(nil)
+
+	p := NewProxy(key, nil)
+	p.SetCacheSize(2)
+
+	p.mx.Lock()
+	for i := 0; i < 3; i++ {
+		key := requestKey(int32(i), nil)
+		p.cache[key] = p.cacheOrder.PushFront(&cacheEntry{key: key, resp: &liteclient.LiteResponse{}})
+		p.evict()
+	}
+	p.mx.Unlock()
+
+	if len(p.cache) != 2 || p.cache[requestKey(0, nil)] != nil {
+		t.Fatal("oldest entry should be evicted")
+	}
+}
@@ -0,0 +1,411 @@
+package litetest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/sigurn/crc16"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const _GlobalID int32 = -239
+
+// _ShardAll - prefix of the shard which contains all accounts of the workchain
+const _ShardAll int64 = -9223372036854775808
+
+// distance between lt of blocks
+const _BlockLTGap = 1000000
+
+var ErrAccountNotFound = errors.New("account not found")
+
+// GetMethod - emulates get method of the contract, args are in the same order as they were passed to RunGetMethod.
+// Returned values are received by client in the same order, to return non-zero exit code use ton.ContractExecError.
+type GetMethod func(args []any) ([]any, error)
+
+// Block - block of the fake chain
+type Block struct {
+	ID       *tlb.BlockInfo
+	GenUtime uint32
+	StartLT  uint64
+	EndLT    uint64
+
+	data         *cell.Cell
+	shards       *cell.Dictionary
+	transactions []*transaction
+}
+
+type transaction struct {
+	workchain int32
+	accountID []byte
+	lt        uint64
+	hash      []byte
+	cell      *cell.Cell
+	block     *tlb.BlockInfo
+}
+
+type account struct {
+	state        *tlb.AccountState
+	lastTxLT     uint64
+	lastTxHash   []byte
+	transactions []*transaction
+	methods      map[uint64]GetMethod
+}
+
+// Chain - in-memory blockchain which is served by fake lite server.
+// It has masterchain and basechain with one shard, each AddBlock creates block with the same seqno in both.
+// Accounts have only the latest state, it is returned for any existing block.
+type Chain struct {
+	mx sync.RWMutex
+
+	lt       uint64
+	blocks   map[int32][]*Block
+	accounts map[string]*account
+	config   map[int32]*cell.Cell
+
+	messages  []*tlb.ExternalMessage
+	onMessage func(msg *tlb.ExternalMessage) error
+}
+
+// NewChain - creates chain with the first block generated at the current time
+func NewChain() *Chain {
+	c := &Chain{
+		blocks:   map[int32][]*Block{},
+		accounts: map[string]*account{},
+		config:   map[int32]*cell.Cell{},
+	}
+	c.AddBlockAt(uint32(time.Now().Unix()))
+
+	return c
+}
+
+// AddBlock - creates new block in masterchain and basechain, generation time is current time,
+// but not less than time of the previous block
+func (c *Chain) AddBlock() *tlb.BlockInfo {
+	utime := uint32(time.Now().Unix())
+
+	c.mx.RLock()
+	if last := c.lastBlock(-1); utime < last.GenUtime {
+		utime = last.GenUtime
+	}
+	c.mx.RUnlock()
+
+	return c.AddBlockAt(utime)
+}
+
+// AddBlockAt - creates new block in masterchain and basechain with the given generation time, returns masterchain block
+func (c *Chain) AddBlockAt(genUtime uint32) *tlb.BlockInfo {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	var seqno uint32
+	if last := c.lastBlock(-1); last != nil {
+		seqno = last.ID.SeqNo + 1
+	}
+
+	c.lt += _BlockLTGap
+
+	shard := c.newBlock(0, seqno, genUtime, nil)
+
+	shards := cell.NewDict(32)
+	_ = shards.SetIntKey(big.NewInt(0), cell.BeginCell().MustStoreRef(shardDescr(shard)).EndCell())
+
+	master := c.newBlock(-1, seqno, genUtime, shards)
+
+	return master.ID
+}
+
+func (c *Chain) newBlock(workchain int32, seqno, genUtime uint32, shards *cell.Dictionary) *Block {
+	header, err := tlb.ToCell(&tlb.BlockHeader{
+		NotMaster: workchain != -1,
+		SeqNo:     seqno,
+		Shard: tlb.ShardIdent{
+			WorkchainID: workchain,
+			ShardPrefix: 0,
+		},
+		GenUtime: genUtime,
+		StartLT:  c.lt,
+		EndLT:    c.lt + 1,
+	})
+	if err != nil {
+		// all fields are fixed size, so it is not possible
+		panic(err)
+	}
+
+	state := shardState(workchain, seqno, nil)
+
+	extra := cell.BeginCell().
+		MustStoreRef(cell.BeginCell().EndCell()).
+		MustStoreRef(cell.BeginCell().EndCell()).
+		MustStoreRef(cell.BeginCell().EndCell()).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreSlice(make([]byte, 32), 256)
+
+	if shards != nil {
+		extra.MustStoreMaybeRef(cell.BeginCell().MustStoreUInt(0xcca5, 16).
+			MustStoreUInt(0, 1).MustStoreDict(shards).MustStoreDict(nil).EndCell())
+	} else {
+		extra.MustStoreMaybeRef(nil)
+	}
+
+	data := cell.BeginCell().MustStoreUInt(0x11ef55aa, 32).MustStoreInt(int64(_GlobalID), 32).
+		MustStoreRef(header).
+		MustStoreRef(cell.BeginCell().EndCell()).
+		MustStoreRef(cell.BeginCell().MustStoreRef(state).MustStoreRef(state).EndCell()).
+		MustStoreRef(extra.EndCell()).
+		EndCell()
+
+	fileHash := sha256.Sum256(data.ToBOCWithFlags(false))
+
+	b := &Block{
+		ID: &tlb.BlockInfo{
+			Workchain: workchain,
+			Shard:     _ShardAll,
+			SeqNo:     seqno,
+			RootHash:  data.Hash(),
+			FileHash:  fileHash[:],
+		},
+		GenUtime: genUtime,
+		StartLT:  c.lt,
+		EndLT:    c.lt + 1,
+		data:     data,
+		shards:   shards,
+	}
+	c.blocks[workchain] = append(c.blocks[workchain], b)
+
+	return b
+}
+
+// LastBlock - returns the latest block of the workchain, -1 is masterchain and 0 is basechain
+func (c *Chain) LastBlock(workchain int32) *Block {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	return c.lastBlock(workchain)
+}
+
+func (c *Chain) lastBlock(workchain int32) *Block {
+	blocks := c.blocks[workchain]
+	if len(blocks) == 0 {
+		return nil
+	}
+	return blocks[len(blocks)-1]
+}
+
+// SetAccount - sets the current state of the account, nil state removes account
+func (c *Chain) SetAccount(addr *address.Address, state *tlb.AccountState) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	acc := c.account(addr, true)
+	if state != nil {
+		st := *state
+		st.IsValid = true
+		st.Address = addr
+		state = &st
+	}
+	acc.state = state
+}
+
+// SetGetMethod - sets handler of the get method of the account
+func (c *Chain) SetGetMethod(addr *address.Address, name string, method GetMethod) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.account(addr, true).methods[methodID(name)] = method
+}
+
+// SetConfigParam - sets blockchain config param, it is returned by GetConfigParams and GetConfigAll
+func (c *Chain) SetConfigParam(id int32, param *cell.Cell) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if param == nil {
+		delete(c.config, id)
+		return
+	}
+	c.config[id] = param
+}
+
+// OnExternalMessage - sets callback which is called for each external message sent to the chain,
+// if it returns error, message is rejected and client receives it. It can be used to emulate contract,
+// for example to add transaction and update account state.
+func (c *Chain) OnExternalMessage(cb func(msg *tlb.ExternalMessage) error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.onMessage = cb
+}
+
+// ExternalMessages - returns all accepted external messages, in order of receiving
+func (c *Chain) ExternalMessages() []*tlb.ExternalMessage {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	return append([]*tlb.ExternalMessage{}, c.messages...)
+}
+
+// AddTransaction - creates transaction of the account in the latest block of its workchain and returns it.
+// In message and out messages should be serialized messages, in message is optional.
+// Account state is not changed, only its last transaction.
+func (c *Chain) AddTransaction(addr *address.Address, in *cell.Cell, out ...*cell.Cell) (*tlb.Transaction, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	acc := c.account(addr, true)
+
+	wc := workchainID(addr)
+	block := c.lastBlock(wc)
+	if block == nil {
+		return nil, fmt.Errorf("workchain %d is not exists", wc)
+	}
+
+	c.lt++
+
+	status := tlb.AccountStatus(tlb.AccountStatusNonExist)
+	if acc.state != nil {
+		status = acc.state.Status
+	}
+
+	outDict := cell.NewDict(15)
+	for i, msg := range out {
+		if err := outDict.SetIntKey(big.NewInt(int64(i)), cell.BeginCell().MustStoreRef(msg).EndCell()); err != nil {
+			return nil, fmt.Errorf("failed to store out message: %w", err)
+		}
+	}
+
+	io := cell.BeginCell().MustStoreMaybeRef(in).MustStoreDict(outDict).EndCell()
+
+	// ordinary transaction with skipped compute phase, it is enough to be parsed
+	desc := cell.BeginCell().MustStoreUInt(0b0000, 4).MustStoreBoolBit(true).
+		MustStoreUInt(0, 2).MustStoreUInt(0, 3).
+		MustStoreMaybeRef(nil).MustStoreBoolBit(false).MustStoreUInt(0, 1).MustStoreBoolBit(false).
+		EndCell()
+
+	prevHash := acc.lastTxHash
+	if prevHash == nil {
+		prevHash = make([]byte, 32)
+	}
+
+	txCell := cell.BeginCell().MustStoreUInt(0b0111, 4).
+		MustStoreSlice(addr.Data(), 256).
+		MustStoreUInt(c.lt, 64).
+		MustStoreSlice(prevHash, 256).
+		MustStoreUInt(acc.lastTxLT, 64).
+		MustStoreUInt(uint64(block.GenUtime), 32).
+		MustStoreUInt(uint64(len(out)), 15).
+		MustStoreUInt(statusBits(status), 2).
+		MustStoreUInt(statusBits(status), 2).
+		MustStoreRef(io).
+		MustStoreBigCoins(big.NewInt(0)).MustStoreDict(nil).
+		MustStoreRef(cell.BeginCell().MustStoreUInt(0x72, 8).
+			MustStoreSlice(make([]byte, 32), 256).MustStoreSlice(make([]byte, 32), 256).EndCell()).
+		MustStoreRef(desc).
+		EndCell()
+
+	var tx tlb.Transaction
+	if err := tlb.LoadFromCell(&tx, txCell.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse built transaction: %w", err)
+	}
+	tx.Hash = txCell.Hash()
+
+	rec := &transaction{
+		workchain: wc,
+		accountID: addr.Data(),
+		lt:        c.lt,
+		hash:      tx.Hash,
+		cell:      txCell,
+		block:     block.ID,
+	}
+
+	acc.transactions = append(acc.transactions, rec)
+	acc.lastTxLT, acc.lastTxHash = rec.lt, rec.hash
+
+	block.transactions = append(block.transactions, rec)
+	block.EndLT = c.lt + 1
+
+	return &tx, nil
+}
+
+func (c *Chain) account(addr *address.Address, create bool) *account {
+	key := accountKey(workchainID(addr), addr.Data())
+
+	acc := c.accounts[key]
+	if acc == nil && create {
+		acc = &account{
+			methods: map[uint64]GetMethod{},
+		}
+		c.accounts[key] = acc
+	}
+	return acc
+}
+
+func (c *Chain) findBlock(workchain int32, seqno uint32) *Block {
+	for _, b := range c.blocks[workchain] {
+		if b.ID.SeqNo == seqno {
+			return b
+		}
+	}
+	return nil
+}
+
+func accountKey(workchain int32, id []byte) string {
+	return fmt.Sprintf("%d:%s", workchain, hex.EncodeToString(id))
+}
+
+// workchainID - workchain is stored as byte in address, so we restore its sign
+func workchainID(addr *address.Address) int32 {
+	return int32(int8(addr.Workchain()))
+}
+
+// methodID - id of get method by its name, same as lite client calculates
+func methodID(name string) uint64 {
+	return uint64(crc16.Checksum([]byte(name), crc16.MakeTable(crc16.CRC16_XMODEM))) | 0x10000
+}
+
+func statusBits(status tlb.AccountStatus) uint64 {
+	switch status {
+	case tlb.AccountStatusActive:
+		return 0b10
+	case tlb.AccountStatusFrozen:
+		return 0b01
+	case tlb.AccountStatusUninit:
+		return 0b00
+	}
+	return 0b11
+}
+
+func shardDescr(b *Block) *cell.Cell {
+	desc, err := tlb.ToCell(&tlb.ShardDesc{
+		SeqNo:              b.ID.SeqNo,
+		StartLT:            b.StartLT,
+		EndLT:              b.EndLT,
+		RootHash:           b.ID.RootHash,
+		FileHash:           b.ID.FileHash,
+		NextValidatorShard: b.ID.Shard,
+		GenUTime:           b.GenUtime,
+	})
+	if err != nil {
+		// all fields are fixed size, so it is not possible
+		panic(err)
+	}
+
+	// bt_leaf$0
+	return cell.BeginCell().MustStoreUInt(0, 1).MustStoreBuilder(desc.ToBuilder()).EndCell()
+}
+
+// shardState - builds ShardStateUnsplit with the given accounts, only fields which are parsed by client are filled
+func shardState(workchain int32, seqno uint32, accounts *cell.Dictionary) *cell.Cell {
+	return cell.BeginCell().MustStoreUInt(0x9023afe2, 32).MustStoreInt(int64(_GlobalID), 32).
+		MustStoreUInt(0, 2).MustStoreUInt(0, 6).MustStoreInt(int64(workchain), 32).MustStoreInt(_ShardAll, 64).
+		MustStoreUInt(uint64(seqno), 32).
+		MustStoreRef(cell.BeginCell().EndCell()).
+		MustStoreRef(cell.BeginCell().MustStoreDict(accounts).EndCell()).
+		EndCell()
+}
@@ -0,0 +1,755 @@
+package litetest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// requests
+const (
+	_GetMasterchainInfo    int32 = -1984567762
+	_RunContractGetMethod  int32 = 1556504018
+	_GetAccountState       int32 = 1804144165
+	_SendMessage           int32 = 1762317442
+	_GetTransactions       int32 = 474015649
+	_GetOneTransaction     int32 = -737205014
+	_GetBlock              int32 = 1668796173
+	_GetAllShardsInfo      int32 = 1960050027
+	_ListBlockTransactions int32 = -1375942694
+	_LookupBlock           int32 = -87492834
+	_GetConfigAll          int32 = -1860491593
+	_GetConfigParams       int32 = -1627878045
+)
+
+// responses
+const (
+	_MasterchainInfo   int32 = -2055001983
+	_RunQueryResult    int32 = -1550163605
+	_AccountState      int32 = 1887029073
+	_SendMessageResult int32 = 961602967
+	_TransactionsList  int32 = 1864812043
+	_TransactionInfo   int32 = 249490759
+	_BlockData         int32 = -1519063700
+	_BlockTransactions int32 = -1114854101
+	_BlockHeader       int32 = 1965916697
+	_AllShardsInfo     int32 = 160425773
+	_ConfigInfo        int32 = -1367660753
+
+	_BoolTrue  int32 = -1720552011
+	_BoolFalse int32 = -1132882121
+)
+
+// lite server error codes
+const (
+	_ErrorCodeNotReady int32 = 651
+)
+
+// exit code of TVM when get method is not found
+const _ExitCodeMethodNotFound = 11
+
+// Server - fake lite server which serves Chain on local tcp port, it speaks the same protocol as real one,
+// so liteclient.ConnectionPool and ton.APIClient can be tested with it without network.
+// Proofs are not real merkle proofs, they contain only cells which client parses.
+type Server struct {
+	chain *Chain
+	key   ed25519.PrivateKey
+	ln    net.Listener
+	srv   *liteclient.Server
+}
+
+// NewServer - starts serving the chain on random port of localhost, with random key
+func NewServer(chain *Chain) (*Server, error) {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	s := &Server{
+		chain: chain,
+		key:   key,
+		ln:    ln,
+	}
+	s.srv = liteclient.NewServer(key, nil)
+	s.srv.Handle(_GetMasterchainInfo, s.getMasterchainInfo)
+	s.srv.Handle(_LookupBlock, s.lookupBlock)
+	s.srv.Handle(_GetBlock, s.getBlock)
+	s.srv.Handle(_GetAccountState, s.getAccountState)
+	s.srv.Handle(_RunContractGetMethod, s.runGetMethod)
+	s.srv.Handle(_SendMessage, s.sendMessage)
+	s.srv.Handle(_GetTransactions, s.getTransactions)
+	s.srv.Handle(_GetOneTransaction, s.getOneTransaction)
+	s.srv.Handle(_ListBlockTransactions, s.listBlockTransactions)
+	s.srv.Handle(_GetAllShardsInfo, s.getAllShardsInfo)
+	s.srv.Handle(_GetConfigAll, s.getConfig)
+	s.srv.Handle(_GetConfigParams, s.getConfig)
+
+	go func() {
+		_ = s.srv.Serve(ln)
+	}()
+
+	return s, nil
+}
+
+// Addr - tcp address which server listens
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// PublicKey - base64 public key of the server, as it is in the global config
+func (s *Server) PublicKey() string {
+	return base64.StdEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+}
+
+// Config - global config with this server as the only lite server
+func (s *Server) Config() *liteclient.GlobalConfig {
+	return &liteclient.GlobalConfig{
+		Type: "config.global",
+		Liteservers: []liteclient.LiteserverConfig{
+			{
+				IP:   0x7f000001,
+				Port: s.ln.Addr().(*net.TCPAddr).Port,
+				ID: liteclient.ServerID{
+					Type: "pub.ed25519",
+					Key:  s.PublicKey(),
+				},
+			},
+		},
+	}
+}
+
+// Connect - creates connection pool connected to this server, pool does not reconnect after server is closed
+func (s *Server) Connect(ctx context.Context) (*liteclient.ConnectionPool, error) {
+	pool := liteclient.NewConnectionPool()
+	pool.SetOnDisconnect(nil)
+
+	if err := pool.AddConnection(ctx, s.Addr(), s.PublicKey()); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+// Close - stops server and drops all connections
+func (s *Server) Close() error {
+	return s.srv.Close()
+}
+
+func (s *Server) getMasterchainInfo(_ context.Context, _ int32, _ []byte) (*liteclient.LiteResponse, error) {
+	s.chain.mx.RLock()
+	defer s.chain.mx.RUnlock()
+
+	last := s.chain.lastBlock(-1)
+	first := s.chain.blocks[-1][0]
+
+	wc := make([]byte, 4)
+	binary.LittleEndian.PutUint32(wc, uint32(first.ID.Workchain))
+
+	data := last.ID.Serialize()
+	data = append(data, last.ID.RootHash...) // state root hash
+	data = append(data, wc...)
+	data = append(data, first.ID.RootHash...)
+	data = append(data, first.ID.FileHash...)
+
+	return &liteclient.LiteResponse{TypeID: _MasterchainInfo, Data: data}, nil
+}
+
+func (s *Server) lookupBlock(_ context.Context, _ int32, payload []byte) (*liteclient.LiteResponse, error) {
+	if len(payload) < 20 {
+		return nil, errors.New("too short request")
+	}
+
+	mode := binary.LittleEndian.Uint32(payload)
+	wc := int32(binary.LittleEndian.Uint32(payload[4:]))
+	seqno := binary.LittleEndian.Uint32(payload[16:])
+	payload = payload[20:]
+
+	var lt uint64
+	var utime uint32
+	switch {
+	case mode&2 != 0:
+		if len(payload) < 8 {
+			return nil, errors.New("too short request")
+		}
+		lt = binary.LittleEndian.Uint64(payload)
+	case mode&4 != 0:
+		if len(payload) < 4 {
+			return nil, errors.New("too short request")
+		}
+		utime = binary.LittleEndian.Uint32(payload)
+	}
+
+	s.chain.mx.RLock()
+	defer s.chain.mx.RUnlock()
+
+	var block *Block
+	for _, b := range s.chain.blocks[wc] {
+		switch {
+		case mode&1 != 0:
+			if b.ID.SeqNo == seqno {
+				block = b
+			}
+		case mode&2 != 0:
+			// first block which ends after lt
+			if b.EndLT > lt {
+				block = b
+			}
+		case mode&4 != 0:
+			// first block generated at or after the time
+			if b.GenUtime >= utime {
+				block = b
+			}
+		}
+
+		if block != nil {
+			break
+		}
+	}
+
+	if block == nil {
+		return nil, liteclient.ServerError{Code: _ErrorCodeNotReady, Text: "block is not found"}
+	}
+
+	modeData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(modeData, mode)
+
+	data := block.ID.Serialize()
+	data = append(data, modeData...)
+	data = append(data, tl.ToBytes(proofCell(block.data).ToBOCWithFlags(false))...)
+
+	return &liteclient.LiteResponse{TypeID: _BlockHeader, Data: data}, nil
+}
+
+func (s *Server) getBlock(_ context.Context, _ int32, payload []byte) (*liteclient.LiteResponse, error) {
+	id := new(tlb.BlockInfo)
+	if _, err := id.Load(payload); err != nil {
+		return nil, err
+	}
+
+	s.chain.mx.RLock()
+	defer s.chain.mx.RUnlock()
+
+	block := s.chain.blockByID(id)
+	if block == nil {
+		return nil, liteclient.ServerError{Code: _ErrorCodeNotReady, Text: "block is not found"}
+	}
+
+	data := block.ID.Serialize()
+	data = append(data, tl.ToBytes(block.data.ToBOCWithFlags(false))...)
+
+	return &liteclient.LiteResponse{TypeID: _BlockData, Data: data}, nil
+}
+
+func (s *Server) getAccountState(_ context.Context, _ int32, payload []byte) (*liteclient.LiteResponse, error) {
+	id := new(tlb.BlockInfo)
+	payload, err := id.Load(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) < 36 {
+		return nil, errors.New("too short request")
+	}
+	wc, accID := int32(int8(binary.LittleEndian.Uint32(payload))), payload[4:36]
+
+	s.chain.mx.RLock()
+	defer s.chain.mx.RUnlock()
+
+	block := s.chain.blockByID(id)
+	if block == nil {
+		return nil, liteclient.ServerError{Code: _ErrorCodeNotReady, Text: "block is not found"}
+	}
+
+	shardBlock := s.chain.findBlock(wc, id.SeqNo)
+	if shardBlock == nil {
+		return nil, fmt.Errorf("workchain %d is not exists", wc)
+	}
+
+	var state []byte
+	accounts := cell.NewDict(256)
+
+	acc := s.chain.accounts[accountKey(wc, accID)]
+	if acc != nil && acc.state != nil {
+		stateCell, err := acc.state.ToCell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize account state: %w", err)
+		}
+		state = stateCell.ToBOCWithFlags(false)
+
+		lastHash := acc.lastTxHash
+		if lastHash == nil {
+			lastHash = make([]byte, 32)
+		}
+
+		// depth balance info and shard account
+		value := cell.BeginCell().MustStoreUInt(0, 5).
+			MustStoreBigCoins(acc.state.Balance.NanoTON()).MustStoreDict(nil).
+			MustStoreRef(stateCell).
+			MustStoreSlice(lastHash, 256).
+			MustStoreUInt(acc.lastTxLT, 64).
+			EndCell()
+
+		if err = accounts.Set(cell.BeginCell().MustStoreSlice(accID, 256).EndCell(), value); err != nil {
+			return nil, fmt.Errorf("failed to store account: %w", err)
+		}
+	}
+
+	proof := proofCell(shardState(wc, shardBlock.ID.SeqNo, accounts))
+
+	data := block.ID.Serialize()
+	data = append(data, shardBlock.ID.Serialize()...)
+	data = append(data, tl.ToBytes(nil)...)
+	data = append(data, tl.ToBytes(proof.ToBOCWithFlags(false))...)
+	data = append(data, tl.ToBytes(state)...)
+
+	return &liteclient.LiteResponse{TypeID: _AccountState, Data: data}, nil
+}
+
+func (s *Server) runGetMethod(_ context.Context, _ int32, payload []byte) (*liteclient.LiteResponse, error) {
+	if len(payload) < 4 {
+		return nil, errors.New("too short request")
+	}
+	mode := binary.LittleEndian.Uint32(payload)
+
+	id := new(tlb.BlockInfo)
+	payload, err := id.Load(payload[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) < 44 {
+		return nil, errors.New("too short request")
+	}
+	wc, accID := int32(int8(binary.LittleEndian.Uint32(payload))), payload[4:36]
+	method := binary.LittleEndian.Uint64(payload[36:])
+
+	var params []byte
+	if err = tl.Unmarshal(payload[44:], &params); err != nil {
+		return nil, fmt.Errorf("failed to parse params: %w", err)
+	}
+
+	paramsCell, err := cell.FromBOC(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse params boc: %w", err)
+	}
+
+	var stack tlb.Stack
+	if err = stack.LoadFromCell(paramsCell.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse params stack: %w", err)
+	}
+
+	var args []any
+	for stack.Depth() > 0 {
+		v, _ := stack.Pop()
+		args = append(args, v)
+	}
+
+	s.chain.mx.RLock()
+	block := s.chain.blockByID(id)
+	shardBlock := s.chain.findBlock(wc, id.SeqNo)
+
+	var handler GetMethod
+	active := false
+	if acc := s.chain.accounts[accountKey(wc, accID)]; acc != nil {
+		handler = acc.methods[method]
+		active = acc.state != nil && acc.state.Status == tlb.AccountStatusActive
+	}
+	s.chain.mx.RUnlock()
+
+	if block == nil || shardBlock == nil {
+		return nil, liteclient.ServerError{Code: _ErrorCodeNotReady, Text: "block is not found"}
+	}
+
+	if !active {
+		return nil, errors.New("account is not active")
+	}
+
+	var exitCode uint32
+	var result []any
+	if handler == nil {
+		exitCode = _ExitCodeMethodNotFound
+	} else {
+		result, err = handler(args)
+		if err != nil {
+			var execErr ton.ContractExecError
+			if !errors.As(err, &execErr) {
+				return nil, err
+			}
+			exitCode = execErr.Code
+		}
+	}
+
+	// client pops values from the top, so first value should be on top
+	var resStack tlb.Stack
+	for i := len(result) - 1; i >= 0; i-- {
+		resStack.Push(result[i])
+	}
+
+	resCell, err := resStack.ToCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize result: %w", err)
+	}
+
+	modeData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(modeData, mode)
+
+	data := append(modeData, block.ID.Serialize()...)
+	data = append(data, shardBlock.ID.Serialize()...)
+
+	// proofs are not supported, we send them empty if requested
+	if mode&1 != 0 {
+		data = append(data, tl.ToBytes(nil)...)
+		data = append(data, tl.ToBytes(nil)...)
+	}
+	if mode&2 != 0 {
+		data = append(data, tl.ToBytes(nil)...)
+	}
+	if mode&8 != 0 {
+		data = append(data, tl.ToBytes(nil)...)
+	}
+	if mode&16 != 0 {
+		data = append(data, tl.ToBytes(nil)...)
+	}
+
+	exitData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(exitData, exitCode)
+	data = append(data, exitData...)
+
+	if mode&4 != 0 {
+		data = append(data, tl.ToBytes(resCell.ToBOCWithFlags(false))...)
+	}
+
+	return &liteclient.LiteResponse{TypeID: _RunQueryResult, Data: data}, nil
+}
+
+func (s *Server) sendMessage(_ context.Context, _ int32, payload []byte) (*liteclient.LiteResponse, error) {
+	var boc []byte
+	if err := tl.Unmarshal(payload, &boc); err != nil {
+		return nil, fmt.Errorf("failed to parse message bytes: %w", err)
+	}
+
+	msgCell, err := cell.FromBOC(boc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message boc: %w", err)
+	}
+
+	var msg tlb.Message
+	if err = msg.LoadFromCell(msgCell.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	if msg.MsgType != tlb.MsgTypeExternalIn {
+		return nil, errors.New("only external in messages can be sent")
+	}
+	ext := msg.AsExternalIn()
+
+	s.chain.mx.RLock()
+	cb := s.chain.onMessage
+	s.chain.mx.RUnlock()
+
+	if cb != nil {
+		if err = cb(ext); err != nil {
+			return nil, err
+		}
+	}
+
+	s.chain.mx.Lock()
+	s.chain.messages = append(s.chain.messages, ext)
+	s.chain.mx.Unlock()
+
+	status := make([]byte, 4)
+	binary.LittleEndian.PutUint32(status, 1)
+
+	return &liteclient.LiteResponse{TypeID: _SendMessageResult, Data: status}, nil
+}
+
+func (s *Server) getTransactions(_ context.Context, _ int32, payload []byte) (*liteclient.LiteResponse, error) {
+	if len(payload) < 4+36+8+32 {
+		return nil, errors.New("too short request")
+	}
+
+	count := binary.LittleEndian.Uint32(payload)
+	wc, accID := int32(int8(binary.LittleEndian.Uint32(payload[4:]))), payload[8:40]
+	lt := binary.LittleEndian.Uint64(payload[40:])
+	hash := payload[48:80]
+
+	s.chain.mx.RLock()
+	defer s.chain.mx.RUnlock()
+
+	acc := s.chain.accounts[accountKey(wc, accID)]
+	if acc == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	pos := -1
+	for i, tx := range acc.transactions {
+		if tx.lt == lt && bytes.Equal(tx.hash, hash) {
+			pos = i
+			break
+		}
+	}
+
+	if pos < 0 {
+		return nil, fmt.Errorf("cannot locate transaction %d", lt)
+	}
+
+	from := pos + 1 - int(count)
+	if from < 0 {
+		from = 0
+	}
+	list := acc.transactions[from : pos+1]
+
+	vecLn := make([]byte, 4)
+	binary.LittleEndian.PutUint32(vecLn, uint32(len(list)))
+
+	data := vecLn
+	cells := make([]*cell.Cell, 0, len(list))
+	for _, tx := range list {
+		data = append(data, tx.block.Serialize()...)
+		cells = append(cells, tx.cell)
+	}
+	data = append(data, tl.ToBytes(cell.ToBOCMultiRoot(cells, false))...)
+
+	return &liteclient.LiteResponse{TypeID: _TransactionsList, Data: data}, nil
+}
+
+func (s *Server) getOneTransaction(_ context.Context, _ int32, payload []byte) (*liteclient.LiteResponse, error) {
+	id := new(tlb.BlockInfo)
+	payload, err := id.Load(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) < 44 {
+		return nil, errors.New("too short request")
+	}
+	wc, accID := int32(int8(binary.LittleEndian.Uint32(payload))), payload[4:36]
+	lt := binary.LittleEndian.Uint64(payload[36:])
+
+	s.chain.mx.RLock()
+	defer s.chain.mx.RUnlock()
+
+	acc := s.chain.accounts[accountKey(wc, accID)]
+	if acc == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	for _, tx := range acc.transactions {
+		if tx.lt == lt {
+			data := tx.block.Serialize()
+			data = append(data, tl.ToBytes(nil)...)
+			data = append(data, tl.ToBytes(tx.cell.ToBOCWithFlags(false))...)
+
+			return &liteclient.LiteResponse{TypeID: _TransactionInfo, Data: data}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot locate transaction %d", lt)
+}
+
+func (s *Server) listBlockTransactions(_ context.Context, _ int32, payload []byte) (*liteclient.LiteResponse, error) {
+	id := new(tlb.BlockInfo)
+	payload, err := id.Load(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) < 8 {
+		return nil, errors.New("too short request")
+	}
+	mode := binary.LittleEndian.Uint32(payload)
+	count := binary.LittleEndian.Uint32(payload[4:])
+
+	var afterAcc []byte
+	var afterLT uint64
+	hasAfter := mode&(1<<7) != 0
+	if hasAfter {
+		if len(payload) < 8+40 {
+			return nil, errors.New("too short request")
+		}
+		afterAcc, afterLT = payload[8:40], binary.LittleEndian.Uint64(payload[40:])
+	}
+
+	s.chain.mx.RLock()
+	defer s.chain.mx.RUnlock()
+
+	block := s.chain.blockByID(id)
+	if block == nil {
+		return nil, liteclient.ServerError{Code: _ErrorCodeNotReady, Text: "block is not found"}
+	}
+
+	txs := append([]*transaction{}, block.transactions...)
+	sort.Slice(txs, func(i, j int) bool {
+		if c := bytes.Compare(txs[i].accountID, txs[j].accountID); c != 0 {
+			return c < 0
+		}
+		return txs[i].lt < txs[j].lt
+	})
+
+	var list []*transaction
+	for _, tx := range txs {
+		if hasAfter {
+			c := bytes.Compare(tx.accountID, afterAcc)
+			if c < 0 || (c == 0 && tx.lt <= afterLT) {
+				continue
+			}
+		}
+		list = append(list, tx)
+	}
+
+	incomplete := _BoolFalse
+	if uint32(len(list)) > count {
+		list = list[:count]
+		incomplete = _BoolTrue
+	}
+
+	itemMode := mode & 0b111
+
+	data := make([]byte, 12)
+	binary.LittleEndian.PutUint32(data, count)
+	binary.LittleEndian.PutUint32(data[4:], uint32(incomplete))
+	binary.LittleEndian.PutUint32(data[8:], uint32(len(list)))
+	data = append(id.Serialize(), data...)
+
+	for _, tx := range list {
+		item := make([]byte, 4)
+		binary.LittleEndian.PutUint32(item, itemMode)
+
+		if itemMode&0b1 != 0 {
+			item = append(item, tx.accountID...)
+		}
+		if itemMode&0b10 != 0 {
+			ltData := make([]byte, 8)
+			binary.LittleEndian.PutUint64(ltData, tx.lt)
+			item = append(item, ltData...)
+		}
+		if itemMode&0b100 != 0 {
+			item = append(item, tx.hash...)
+		}
+		data = append(data, item...)
+	}
+	data = append(data, tl.ToBytes(nil)...)
+
+	return &liteclient.LiteResponse{TypeID: _BlockTransactions, Data: data}, nil
+}
+
+func (s *Server) getAllShardsInfo(_ context.Context, _ int32, payload []byte) (*liteclient.LiteResponse, error) {
+	id := new(tlb.BlockInfo)
+	if _, err := id.Load(payload); err != nil {
+		return nil, err
+	}
+
+	s.chain.mx.RLock()
+	defer s.chain.mx.RUnlock()
+
+	block := s.chain.blockByID(id)
+	if block == nil || block.shards == nil {
+		return nil, liteclient.ServerError{Code: _ErrorCodeNotReady, Text: "masterchain block is not found"}
+	}
+
+	data := block.ID.Serialize()
+	data = append(data, tl.ToBytes(nil)...)
+	data = append(data, tl.ToBytes(cell.BeginCell().MustStoreDict(block.shards).EndCell().ToBOCWithFlags(false))...)
+
+	return &liteclient.LiteResponse{TypeID: _AllShardsInfo, Data: data}, nil
+}
+
+func (s *Server) getConfig(_ context.Context, typeID int32, payload []byte) (*liteclient.LiteResponse, error) {
+	if len(payload) < 4 {
+		return nil, errors.New("too short request")
+	}
+	mode := binary.LittleEndian.Uint32(payload)
+
+	id := new(tlb.BlockInfo)
+	payload, err := id.Load(payload[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	var only map[int32]bool
+	if typeID == _GetConfigParams {
+		if len(payload) < 4 {
+			return nil, errors.New("too short request")
+		}
+
+		num := int(binary.LittleEndian.Uint32(payload))
+		payload = payload[4:]
+		if len(payload) < num*4 {
+			return nil, errors.New("too short request")
+		}
+
+		only = map[int32]bool{}
+		for i := 0; i < num; i++ {
+			only[int32(binary.LittleEndian.Uint32(payload[i*4:]))] = true
+		}
+	}
+
+	s.chain.mx.RLock()
+	defer s.chain.mx.RUnlock()
+
+	block := s.chain.blockByID(id)
+	if block == nil || block.shards == nil {
+		return nil, liteclient.ServerError{Code: _ErrorCodeNotReady, Text: "masterchain block is not found"}
+	}
+
+	params := cell.NewDict(32)
+	for pid, p := range s.chain.config {
+		if only != nil && !only[pid] {
+			continue
+		}
+
+		if err = params.SetIntKey(big.NewInt(int64(pid)), cell.BeginCell().MustStoreRef(p).EndCell()); err != nil {
+			return nil, fmt.Errorf("failed to store config param %d: %w", pid, err)
+		}
+	}
+
+	// McStateExtra with config, as 4th ref of masterchain state
+	extra := cell.BeginCell().MustStoreUInt(0xcc26, 16).
+		MustStoreMaybeRef(nil).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreDict(params).
+		EndCell()
+
+	empty := cell.BeginCell().EndCell()
+	state := cell.BeginCell().MustStoreUInt(0x9023afe2, 32).
+		MustStoreRef(empty).MustStoreRef(empty).MustStoreRef(empty).MustStoreRef(extra).EndCell()
+
+	modeData := make([]byte, 4)
+	binary.LittleEndian.PutUint32(modeData, mode)
+
+	data := append(modeData, block.ID.Serialize()...)
+	data = append(data, tl.ToBytes(nil)...)
+	data = append(data, tl.ToBytes(proofCell(state).ToBOCWithFlags(false))...)
+
+	return &liteclient.LiteResponse{TypeID: _ConfigInfo, Data: data}, nil
+}
+
+func (c *Chain) blockByID(id *tlb.BlockInfo) *Block {
+	b := c.findBlock(id.Workchain, id.SeqNo)
+	if b == nil || !bytes.Equal(b.ID.RootHash, id.RootHash) {
+		return nil
+	}
+	return b
+}
+
+// proofCell - imitation of merkle proof, which has the same structure for the client
+func proofCell(c *cell.Cell) *cell.Cell {
+	return cell.BeginCell().MustStoreUInt(3, 8).MustStoreRef(c).EndCell()
+}
@@ -0,0 +1,335 @@
+package litetest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func startChain(t *testing.T) (*Chain, *ton.APIClient) {
+	chain := NewChain()
+
+	srv, err := NewServer(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	pool, err := srv.Connect(ctx)
+	if err != nil {
+		t.Fatal("connect err", err)
+	}
+
+	return chain, ton.NewAPIClient(pool)
+}
+
+func TestServer_Blocks(t *testing.T) {
+	chain, api := startChain(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := chain.LastBlock(-1).GenUtime
+	chain.AddBlockAt(start + 10)
+	last := chain.AddBlockAt(start + 20)
+
+	master, err := api.GetMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if master.SeqNo != 2 || master.Workchain != -1 || !bytes.Equal(master.RootHash, last.RootHash) {
+		t.Fatal("wrong masterchain info", master.SeqNo)
+	}
+
+	b, err := api.LookupBlock(ctx, -1, master.Shard, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.SeqNo != 1 {
+		t.Fatal("wrong block", b.SeqNo)
+	}
+
+	if _, err = api.LookupBlock(ctx, -1, master.Shard, 5); !errors.Is(err, ton.ErrBlockNotFound) {
+		t.Fatal("should be not found", err)
+	}
+
+	b, err = api.GetMasterchainBlockByTime(ctx, start+12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.SeqNo != 1 {
+		t.Fatal("wrong block by time", b.SeqNo)
+	}
+
+	data, err := api.GetBlockData(ctx, master)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.Extra == nil || data.Extra.Custom == nil || data.StateUpdate.New.Seqno != 2 {
+		t.Fatal("wrong block data")
+	}
+
+	shards, err := api.GetBlockShardsInfo(ctx, master)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(shards) != 1 || shards[0].Workchain != 0 || shards[0].SeqNo != 2 ||
+		!bytes.Equal(shards[0].RootHash, chain.LastBlock(0).ID.RootHash) {
+		t.Fatal("wrong shards", shards)
+	}
+}
+
+func TestServer_Account(t *testing.T) {
+	chain, api := startChain(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addr := address.MustParseAddr("EQBL2_3lMiyywU17g-or8N7v9hDmPCpttzBPE2isF2GTzpK4")
+	code := cell.BeginCell().MustStoreUInt(0xC0DE, 16).EndCell()
+
+	chain.SetAccount(addr, &tlb.AccountState{
+		AccountStorage: tlb.AccountStorage{
+			Status:  tlb.AccountStatusActive,
+			Balance: tlb.MustFromTON("3.5"),
+			StateInit: &tlb.StateInit{
+				Code: code,
+				Data: cell.BeginCell().EndCell(),
+			},
+		},
+	})
+
+	chain.SetGetMethod(addr, "sum", func(args []any) ([]any, error) {
+		// small integers are decoded as int64, order of args is kept
+		if len(args) != 3 || args[0].(int64) != 1 || args[2].(int64) != 3 {
+			return nil, errors.New("wrong args")
+		}
+
+		var sum int64
+		for _, a := range args {
+			sum += a.(int64)
+		}
+		return []any{sum, big.NewInt(int64(len(args)))}, nil
+	})
+	chain.SetGetMethod(addr, "fail", func(args []any) ([]any, error) {
+		return nil, ton.ContractExecError{Code: 77}
+	})
+
+	var txs []*tlb.Transaction
+	for i := 0; i < 3; i++ {
+		tx, err := chain.AddTransaction(addr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, tx)
+	}
+
+	block, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acc, err := api.GetAccount(ctx, block, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !acc.IsActive || acc.State.Balance.NanoTON().Cmp(tlb.MustFromTON("3.5").NanoTON()) != 0 ||
+		!bytes.Equal(acc.Code.Hash(), code.Hash()) {
+		t.Fatal("wrong account")
+	}
+
+	if acc.LastTxLT != txs[2].LT || !bytes.Equal(acc.LastTxHash, txs[2].Hash) {
+		t.Fatal("wrong last tx")
+	}
+
+	missing, err := api.GetAccount(ctx, block, address.MustParseAddr("EQB3P0cDOtkFDdxB77YX-F2DGkrIszmZkmyauMnsP1gg0pJG"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if missing.IsActive {
+		t.Fatal("account should not exist")
+	}
+
+	res, err := api.RunGetMethod(ctx, block, addr, "sum", 1, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res) != 2 || res[0].(int64) != 6 || res[1].(*big.Int).Int64() != 3 {
+		t.Fatal("wrong get method result", res)
+	}
+
+	if _, err = api.RunGetMethod(ctx, block, addr, "fail"); !errors.Is(err, ton.ContractExecError{Code: 77}) {
+		t.Fatal("should be exec error", err)
+	}
+
+	if _, err = api.RunGetMethod(ctx, block, addr, "unknown"); !errors.Is(err, ton.ContractExecError{Code: 11}) {
+		t.Fatal("should be method not found", err)
+	}
+
+	list, err := api.ListTransactions(ctx, addr, 2, acc.LastTxLT, acc.LastTxHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 2 || list[0].LT != txs[1].LT || list[1].LT != txs[2].LT || list[1].PrevTxLT != txs[1].LT {
+		t.Fatal("wrong transactions list")
+	}
+
+	tx, err := api.GetTransaction(ctx, block, addr, txs[0].LT)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(tx.Hash, txs[0].Hash) {
+		t.Fatal("wrong transaction")
+	}
+
+	if _, err = tx.ParseDescription(); err != nil {
+		t.Fatal("description should be parsed", err)
+	}
+
+	ids, incomplete, err := api.GetBlockTransactions(ctx, chain.LastBlock(0).ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !incomplete || len(ids) != 2 || ids[0].LT != txs[0].LT {
+		t.Fatal("wrong block transactions", incomplete, len(ids))
+	}
+
+	ids, incomplete, err = api.GetBlockTransactions(ctx, chain.LastBlock(0).ID, 2, ids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if incomplete || len(ids) != 1 || !bytes.Equal(ids[0].Hash, txs[2].Hash) {
+		t.Fatal("wrong block transactions after", incomplete, len(ids))
+	}
+}
+
+func TestServer_Config(t *testing.T) {
+	chain, api := startChain(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	elector := bytes.Repeat([]byte{0x33}, 32)
+	chain.SetConfigParam(1, cell.BeginCell().MustStoreSlice(elector, 256).EndCell())
+	chain.SetConfigParam(7, cell.BeginCell().MustStoreUInt(7, 8).EndCell())
+
+	block, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := api.GetConfigParams(ctx, block, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := cfg.GetElectorAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(addr.Data(), elector) || cfg.Get(7) != nil {
+		t.Fatal("wrong config params")
+	}
+
+	cfg, err = api.GetConfigAll(ctx, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.All()) != 2 {
+		t.Fatal("wrong config all", len(cfg.All()))
+	}
+}
+
+func TestServer_Wallet(t *testing.T) {
+	chain, api := startChain(t)
+
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, 32))
+
+	w, err := wallet.FromPrivateKey(api, key, wallet.V3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := wallet.GetStateInit(key.Public().(ed25519.PublicKey), wallet.V3, wallet.DefaultSubwallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain.SetAccount(w.Address(), &tlb.AccountState{
+		AccountStorage: tlb.AccountStorage{
+			Status:    tlb.AccountStatusActive,
+			Balance:   tlb.MustFromTON("10"),
+			StateInit: state,
+		},
+	})
+
+	seqno := int64(5)
+	chain.SetGetMethod(w.Address(), "seqno", func(args []any) ([]any, error) {
+		return []any{seqno}, nil
+	})
+
+	// emulate wallet contract, which accepts message and increases seqno
+	chain.OnExternalMessage(func(msg *tlb.ExternalMessage) error {
+		// signature, subwallet and valid until are skipped
+		body := msg.Body.BeginParse()
+		body.MustLoadSlice(512 + 32 + 32)
+		if got := int64(body.MustLoadUInt(32)); got != seqno {
+			return errors.New("wrong seqno")
+		}
+
+		msgCell, err := msg.ToCell()
+		if err != nil {
+			return err
+		}
+
+		if _, err = chain.AddTransaction(msg.DstAddr, msgCell); err != nil {
+			return err
+		}
+
+		seqno++
+		chain.AddBlock()
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	to := address.MustParseAddr("EQB3P0cDOtkFDdxB77YX-F2DGkrIszmZkmyauMnsP1gg0pJG")
+	if err = w.Transfer(ctx, to, tlb.MustFromTON("1.5"), "hello", true); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := chain.ExternalMessages()
+	if len(msgs) != 1 || seqno != 6 {
+		t.Fatal("message was not processed", len(msgs))
+	}
+}
@@ -0,0 +1,30 @@
+package nft
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestContentOffchain_ContentCell(t *testing.T) {
+	uri := "https://example.com/nft/" + strings.Repeat("abcdefghij", 20) + "/meta.json"
+
+	c, err := (&ContentOffchain{URI: uri}).ContentCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// hash of long uri which is split to snake cells should not change
+	if hex.EncodeToString(c.Hash()) != "1219834200c0bbeca955d05c39eecdd859ba81d77da3afb7ce52c55ab1bbdfe8" {
+		t.Fatal("incorrect content cell hash", hex.EncodeToString(c.Hash()))
+	}
+
+	content, err := ContentFromCell(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if off, ok := content.(*ContentOffchain); !ok || off.URI != uri {
+		t.Fatal("incorrect parsed content", content)
+	}
+}
@@ -0,0 +1,60 @@
+package ton
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+var ErrShardNotFound = errors.New("shard for address is not found")
+
+// GetAddressShard - finds the latest shard block which holds account, at the state of masterchain block.
+// For masterchain addresses master block itself is returned.
+func (c *APIClient) GetAddressShard(ctx context.Context, master *tlb.BlockInfo, addr *address.Address) (*tlb.BlockInfo, error) {
+	if addrWorkchain(addr) == master.Workchain {
+		return master, nil
+	}
+
+	shards, err := c.GetBlockShardsInfo(ctx, master)
+	if err != nil {
+		return nil, err
+	}
+
+	return FindAddressShard(shards, addr)
+}
+
+// FindAddressShard - selects shard which holds account from the list of shards, returned by GetBlockShardsInfo.
+// It can be used to group many addresses by shards, without additional requests.
+func FindAddressShard(shards []*tlb.BlockInfo, addr *address.Address) (*tlb.BlockInfo, error) {
+	for _, shard := range shards {
+		if IsAddressInShard(addr, shard.Workchain, shard.Shard) {
+			return shard, nil
+		}
+	}
+	return nil, ErrShardNotFound
+}
+
+// IsAddressInShard - checks that account id prefix matches shard prefix.
+// Shard id is a prefix with the tag bit 1 after it, for example 0x8000000000000000 is the whole workchain.
+func IsAddressInShard(addr *address.Address, workchain int32, shard int64) bool {
+	if addrWorkchain(addr) != workchain {
+		return false
+	}
+
+	prefix := binary.BigEndian.Uint64(addr.Data())
+	id := uint64(shard)
+
+	// all bits before the lowest 1 bit are the prefix
+	tag := id & -id
+	mask := ^(tag<<1 - 1)
+
+	return (prefix^id)&mask == 0
+}
+
+// addrWorkchain - workchain is stored as byte in address, so we restore its sign
+func addrWorkchain(addr *address.Address) int32 {
+	return int32(int8(addr.Workchain()))
+}
@@ -0,0 +1,86 @@
+package ton
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+func testAddr(wc byte, first byte) *address.Address {
+	data := make([]byte, 32)
+	data[0] = first
+	return address.NewAddress(0, wc, data)
+}
+
+func TestIsAddressInShard(t *testing.T) {
+	tests := []struct {
+		first byte
+		shard uint64
+		in    bool
+	}{
+		{0x00, 0x8000000000000000, true},
+		{0xff, 0x8000000000000000, true},
+		{0x7f, 0x4000000000000000, true},
+		{0x80, 0x4000000000000000, false},
+		{0x80, 0xc000000000000000, true},
+		{0x5f, 0x6000000000000000, true},
+		{0x5f, 0x2000000000000000, false},
+		{0xa1, 0xa000000000000000, true},
+		{0xa1, 0xb800000000000000, false},
+		{0xa1, 0xa400000000000000, true},
+	}
+
+	for _, tt := range tests {
+		if IsAddressInShard(testAddr(0, tt.first), 0, int64(tt.shard)) != tt.in {
+			t.Fatalf("incorrect result for %x in shard %x", tt.first, tt.shard)
+		}
+	}
+
+	if IsAddressInShard(testAddr(0, 0), -1, _MasterchainShard) {
+		t.Fatal("workchain should not match")
+	}
+
+	if !IsAddressInShard(testAddr(255, 0x12), -1, _MasterchainShard) {
+		t.Fatal("masterchain address should match")
+	}
+}
+
+func TestFindAddressShard(t *testing.T) {
+	var shards []*tlb.BlockInfo
+	for i, s := range []uint64{0x2000000000000000, 0x6000000000000000, 0xa000000000000000, 0xe000000000000000} {
+		shards = append(shards, &tlb.BlockInfo{Workchain: 0, Shard: int64(s), SeqNo: uint32(i)})
+	}
+
+	for first, seqno := range map[byte]uint32{0x00: 0, 0x3f: 0, 0x40: 1, 0x9a: 2, 0xff: 3} {
+		shard, err := FindAddressShard(shards, testAddr(0, first))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if shard.SeqNo != seqno {
+			t.Fatalf("incorrect shard for %x: %d", first, shard.SeqNo)
+		}
+	}
+
+	if _, err := FindAddressShard(shards, testAddr(1, 0)); err != ErrShardNotFound {
+		t.Fatal("shard should be not found", err)
+	}
+
+	api := NewAPIClient(mockClient{do: func(ctx context.Context, typeID int32, payload []byte) (*liteclient.LiteResponse, error) {
+		t.Fatal("should be no requests for masterchain address")
+		return nil, nil
+	}})
+
+	master := &tlb.BlockInfo{Workchain: -1, Shard: _MasterchainShard, SeqNo: 777}
+	shard, err := api.GetAddressShard(context.Background(), master, testAddr(255, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if shard != master {
+		t.Fatal("master block should be returned")
+	}
+}
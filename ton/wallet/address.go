@@ -19,6 +19,10 @@ func AddressFromPubKey(key ed25519.PublicKey, ver Version, subwallet uint32) (*a
 		return nil, fmt.Errorf("failed to get state: %w", err)
 	}
 
+	return addressFromStateInit(state)
+}
+
+func addressFromStateInit(state *tlb.StateInit) (*address.Address, error) {
 	stateCell, err := state.ToCell()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get state cell: %w", err)
@@ -37,7 +41,12 @@ func GetStateInit(pubKey ed25519.PublicKey, ver Version, subWallet uint32) (*tlb
 
 	var data *cell.Cell
 	switch ver {
-	case V3:
+	case V1R1, V1R2, V1R3, V2R1, V2R2:
+		data = cell.BeginCell().
+			MustStoreUInt(0, 32). // seqno
+			MustStoreSlice(pubKey, 256).
+			EndCell()
+	case V3R1, V3R2:
 		data = cell.BeginCell().
 			MustStoreUInt(0, 32).                 // seqno
 			MustStoreUInt(uint64(subWallet), 32). // sub wallet
@@ -57,8 +66,11 @@ func GetStateInit(pubKey ed25519.PublicKey, ver Version, subWallet uint32) (*tlb
 			MustStoreSlice(pubKey, 256).
 			MustStoreDict(nil). // old queries
 			EndCell()
+	case Lockup:
+		// without restrictions, use GetLockupStateInit to set them
+		return GetLockupStateInit(pubKey, subWallet, nil)
 	default:
-		return nil, errors.New("wallet version is not supported")
+		return nil, ErrUnsupportedWalletVersion
 	}
 
 	return &tlb.StateInit{
@@ -71,12 +83,26 @@ func getCode(ver Version) (*cell.Cell, error) {
 	var codeHex string
 
 	switch ver {
-	case V3:
-		codeHex = _V3CodeHex
+	case V1R1:
+		codeHex = _V1R1CodeHex
+	case V1R2:
+		codeHex = _V1R2CodeHex
+	case V1R3:
+		codeHex = _V1R3CodeHex
+	case V2R1:
+		codeHex = _V2R1CodeHex
+	case V2R2:
+		codeHex = _V2R2CodeHex
+	case V3R1:
+		codeHex = _V3R1CodeHex
+	case V3R2:
+		codeHex = _V3R2CodeHex
 	case V4R2:
 		codeHex = _V4R2CodeHex
 	case HighloadV2R2:
 		codeHex = _HighloadV2R2CodeHex
+	case Lockup:
+		codeHex = _LockupCodeHex
 	default:
 		return nil, errors.New("cannot get code: unknown version")
 	}
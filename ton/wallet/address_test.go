@@ -1,8 +1,14 @@
 package wallet
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/hex"
+	"math/big"
 	"testing"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
 func TestAddressFromPubKey(t *testing.T) {
@@ -17,3 +23,75 @@ func TestAddressFromPubKey(t *testing.T) {
 		t.Fatal("v3 not match")
 	}
 }
+
+func TestGetCode(t *testing.T) {
+	// well-known hashes of wallet contracts code
+	hashes := map[Version]string{
+		V1R1: "oM/CxIruFqJx8s/AtzgtgXVs7LEBfQd/qqs7tgL2how=",
+		V1R2: "1JAvzJ+tdGmPqONTIgpo2g3PcuMryy657gQhfBfTBiw=",
+		V1R3: "WHzHie/xyE9G7DeX5F/ICaFP9a4k8eDHpqmcydyQYf8=",
+		V2R1: "XJpeaMEI4YchoHxC+ZVr+zmtd+xtYktgxXbsiO7mUyk=",
+		V2R2: "/pUw0yQ4Uwg+8u8LTCkIwKv2+hwx6iQ6rKpb+MfXU/E=",
+		V3R1: "thBBpYp5gLlG6PueGY48kE0keZ/6NldOpCUcQaVm9YE=",
+		V3R2: "hNr6RJ+Ypph3ibojI1gHK8D3bcRSQAKl0JGLmnXS1Zk=",
+		V4R2: "/rX/aCDi/w2Ug+fg1iyBfYRniftK5YDIeIZtlZ2r1cA=",
+	}
+
+	for ver, hash := range hashes {
+		code, err := getCode(ver)
+		if err != nil {
+			t.Fatal(ver, err)
+		}
+
+		if base64.StdEncoding.EncodeToString(code.Hash()) != hash {
+			t.Fatal("code hash not match for", ver)
+		}
+	}
+}
+
+func TestGetLockupStateInit(t *testing.T) {
+	pkey, _ := hex.DecodeString("dcc39550bb494f4b493e7efe1aa18ea31470f33a2553c568cb74a17ed56790c1")
+
+	state, err := GetStateInit(pkey, Lockup, DefaultSubwallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locked := cell.NewDict(32)
+	if err = locked.SetIntKey(big.NewInt(1700000000), cell.BeginCell().MustStoreCoins(500).EndCell()); err != nil {
+		t.Fatal(err)
+	}
+
+	stateCfg, err := GetLockupStateInit(pkey, DefaultSubwallet, &LockupConfig{
+		ConfigPublicKey:  pkey,
+		TotalLockedValue: tlb.FromNanoTONU(500),
+		Locked:           locked,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(state.Data.Hash(), stateCfg.Data.Hash()) {
+		t.Fatal("config is not used")
+	}
+
+	p := stateCfg.Data.BeginParse()
+	if p.MustLoadUInt(32) != 0 || p.MustLoadUInt(32) != DefaultSubwallet {
+		t.Fatal("seqno or subwallet incorrect")
+	}
+	if !bytes.Equal(p.MustLoadSlice(256), pkey) || !bytes.Equal(p.MustLoadSlice(256), pkey) {
+		t.Fatal("keys incorrect")
+	}
+	if p.MustLoadMaybeRef() != nil {
+		t.Fatal("allowed destinations should be empty")
+	}
+	if p.MustLoadBigCoins().Uint64() != 500 {
+		t.Fatal("total locked incorrect")
+	}
+	if len(p.MustLoadDict(32).All()) != 1 {
+		t.Fatal("locked dict incorrect")
+	}
+	if p.MustLoadBigCoins().Uint64() != 0 || len(p.MustLoadDict(32).All()) != 0 {
+		t.Fatal("restricted incorrect")
+	}
+}
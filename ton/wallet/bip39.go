@@ -0,0 +1,213 @@
+package wallet
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// DefaultBIP39Path - derivation path of TON (coin type 607 in SLIP-0044), used by most of BIP39 wallets
+const DefaultBIP39Path = "m/44'/607'/0'"
+
+const _HardenedOffset = 0x80000000
+
+var ErrBIP39InvalidChecksum = errors.New("invalid bip39 mnemonic checksum")
+
+var bip39Words struct {
+	once  sync.Once
+	list  []string
+	index map[string]int
+}
+
+// bip39WordList - english wordlist, it is the same as TON uses, and is sorted alphabetically in BIP39
+func bip39WordList() ([]string, map[string]int) {
+	bip39Words.once.Do(func() {
+		list := make([]string, 0, len(words))
+		for w := range words {
+			list = append(list, w)
+		}
+		sort.Strings(list)
+
+		index := make(map[string]int, len(list))
+		for i, w := range list {
+			index[w] = i
+		}
+
+		bip39Words.list, bip39Words.index = list, index
+	})
+	return bip39Words.list, bip39Words.index
+}
+
+// NewBIP39Seed - generates 24 words BIP39 mnemonic from 256 bits of random entropy
+func NewBIP39Seed() ([]string, error) {
+	entropy := make([]byte, 32)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, fmt.Errorf("failed to generate entropy: %w", err)
+	}
+
+	return BIP39EntropyToMnemonic(entropy)
+}
+
+// BIP39EntropyToMnemonic - converts entropy of 16-32 bytes (multiple of 4) to mnemonic with checksum
+func BIP39EntropyToMnemonic(entropy []byte) ([]string, error) {
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return nil, fmt.Errorf("invalid entropy size %d", len(entropy))
+	}
+
+	list, _ := bip39WordList()
+
+	hash := sha256.Sum256(entropy)
+	data := append(append([]byte{}, entropy...), hash[0])
+
+	num := len(entropy) * 8 * 33 / 32 / 11
+	mnemonic := make([]string, num)
+	for i := 0; i < num; i++ {
+		mnemonic[i] = list[readBits(data, i*11, 11)]
+	}
+	return mnemonic, nil
+}
+
+// BIP39MnemonicToEntropy - validates mnemonic words and checksum, and returns entropy
+func BIP39MnemonicToEntropy(mnemonic []string) ([]byte, error) {
+	if len(mnemonic) < 12 || len(mnemonic) > 24 || len(mnemonic)%3 != 0 {
+		return nil, fmt.Errorf("invalid bip39 mnemonic size %d", len(mnemonic))
+	}
+
+	_, index := bip39WordList()
+
+	data := make([]byte, (len(mnemonic)*11+7)/8)
+	for i, w := range mnemonic {
+		idx, ok := index[w]
+		if !ok {
+			return nil, fmt.Errorf("unknown word '%s' in mnemonic", w)
+		}
+		writeBits(data, i*11, 11, idx)
+	}
+
+	bits := len(mnemonic) * 11
+	checksumBits := bits / 33
+	entropy := data[:(bits-checksumBits)/8]
+
+	hash := sha256.Sum256(entropy)
+	if readBits(data, len(entropy)*8, checksumBits) != readBits(hash[:], 0, checksumBits) {
+		return nil, ErrBIP39InvalidChecksum
+	}
+
+	return append([]byte{}, entropy...), nil
+}
+
+// ValidateBIP39Seed - checks that all words are from the wordlist and checksum is correct
+func ValidateBIP39Seed(mnemonic []string) error {
+	_, err := BIP39MnemonicToEntropy(mnemonic)
+	return err
+}
+
+// BIP39SeedToBytes - converts valid mnemonic to 64 bytes seed, passphrase can be empty.
+// Passphrase is used as is, without NFKD normalization, so it should be ASCII for compatibility.
+func BIP39SeedToBytes(mnemonic []string, passphrase string) ([]byte, error) {
+	if err := ValidateBIP39Seed(mnemonic); err != nil {
+		return nil, err
+	}
+
+	return pbkdf2.Key([]byte(strings.Join(mnemonic, " ")), []byte("mnemonic"+passphrase), 2048, 64, sha512.New), nil
+}
+
+// BIP39SeedToPrivateKey - derives wallet private key from BIP39 mnemonic using SLIP-0010 path,
+// if path is empty, DefaultBIP39Path is used.
+func BIP39SeedToPrivateKey(mnemonic []string, passphrase, path string) (ed25519.PrivateKey, error) {
+	seed, err := BIP39SeedToBytes(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		path = DefaultBIP39Path
+	}
+	return DerivePrivateKey(seed, path)
+}
+
+// FromBIP39Seed - initializes wallet with key derived from BIP39 mnemonic, see BIP39SeedToPrivateKey
+func FromBIP39Seed(api TonAPI, mnemonic []string, passphrase, path string, version Version) (*Wallet, error) {
+	key, err := BIP39SeedToPrivateKey(mnemonic, passphrase, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromPrivateKey(api, key, version)
+}
+
+// DerivePrivateKey - derives ed25519 key from seed by SLIP-0010 path, like m/44'/607'/0'.
+// Only hardened indexes are supported by ed25519.
+func DerivePrivateKey(seed []byte, path string) (ed25519.PrivateKey, error) {
+	indexes, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chain := sum[:32], sum[32:]
+
+	for _, idx := range indexes {
+		data := make([]byte, 37)
+		copy(data[1:], key)
+		binary.BigEndian.PutUint32(data[33:], idx)
+
+		mac = hmac.New(sha512.New, chain)
+		mac.Write(data)
+		sum = mac.Sum(nil)
+		key, chain = sum[:32], sum[32:]
+	}
+
+	return ed25519.NewKeyFromSeed(key), nil
+}
+
+func parseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("derivation path should start with 'm', got '%s'", path)
+	}
+
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, p := range parts[1:] {
+		if !strings.HasSuffix(p, "'") && !strings.HasSuffix(p, "h") && !strings.HasSuffix(p, "H") {
+			return nil, fmt.Errorf("only hardened derivation is supported for ed25519, '%s' is not hardened", p)
+		}
+
+		idx, err := strconv.ParseUint(p[:len(p)-1], 10, 32)
+		if err != nil || idx >= _HardenedOffset {
+			return nil, fmt.Errorf("invalid path index '%s'", p)
+		}
+		indexes = append(indexes, uint32(idx)+_HardenedOffset)
+	}
+	return indexes, nil
+}
+
+func readBits(data []byte, offset, sz int) int {
+	v := 0
+	for i := offset; i < offset+sz; i++ {
+		v = v<<1 | int(data[i/8]>>(7-i%8)&1)
+	}
+	return v
+}
+
+func writeBits(data []byte, offset, sz, v int) {
+	for i := 0; i < sz; i++ {
+		if v>>(sz-1-i)&1 == 1 {
+			pos := offset + i
+			data[pos/8] |= 1 << (7 - pos%8)
+		}
+	}
+}
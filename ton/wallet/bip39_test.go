@@ -0,0 +1,125 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestBIP39_Vectors(t *testing.T) {
+	tests := []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+	}{
+		{
+			entropy:  "00000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			entropy:  "0000000000000000000000000000000000000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+			seed:     "bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd3097170af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8",
+		},
+	}
+
+	for _, tt := range tests {
+		entropy, _ := hex.DecodeString(tt.entropy)
+
+		mnemonic, err := BIP39EntropyToMnemonic(entropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Join(mnemonic, " ") != tt.mnemonic {
+			t.Fatal("mnemonic not match", mnemonic)
+		}
+
+		restored, err := BIP39MnemonicToEntropy(mnemonic)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(restored) != tt.entropy {
+			t.Fatal("entropy not match")
+		}
+
+		seed, err := BIP39SeedToBytes(mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(seed) != tt.seed {
+			t.Fatal("seed not match", hex.EncodeToString(seed))
+		}
+	}
+}
+
+func TestBIP39_Validate(t *testing.T) {
+	mnemonic, err := NewBIP39Seed()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mnemonic) != 24 {
+		t.Fatal("should be 24 words")
+	}
+
+	if err = ValidateBIP39Seed(mnemonic); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ValidateBIP39Seed(strings.Split("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", " ")); err != ErrBIP39InvalidChecksum {
+		t.Fatal("checksum should be invalid", err)
+	}
+
+	mnemonic[3] = "wat"
+	if err = ValidateBIP39Seed(mnemonic); err == nil {
+		t.Fatal("unknown word should be rejected")
+	}
+
+	if err = ValidateBIP39Seed(mnemonic[:11]); err == nil {
+		t.Fatal("wrong size should be rejected")
+	}
+}
+
+func TestDerivePrivateKey(t *testing.T) {
+	// SLIP-0010 test vector 1 for ed25519
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	tests := map[string]string{
+		"m":                         "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7",
+		"m/0'":                      "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3",
+		"m/0'/1'":                   "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2",
+		"m/0H/1H/2H/2H/1000000000H": "8f94d394a8e8fd6b1bc2f3f49f5c47e385281d5c17e65324b0f62483e37e8793",
+	}
+
+	for path, want := range tests {
+		key, err := DerivePrivateKey(seed, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hex.EncodeToString(key.Seed()) != want {
+			t.Fatal("key not match for", path, hex.EncodeToString(key.Seed()))
+		}
+	}
+
+	for _, path := range []string{"", "m/0", "m/0'/x'", "44'/607'"} {
+		if _, err := DerivePrivateKey(seed, path); err == nil {
+			t.Fatal("path should be invalid", path)
+		}
+	}
+
+	mnemonic, _ := NewBIP39Seed()
+	w, err := FromBIP39Seed(nil, mnemonic, "", "", V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := BIP39SeedToPrivateKey(mnemonic, "", DefaultBIP39Path)
+	if !key.Equal(w.PrivateKey()) {
+		t.Fatal("default path not used")
+	}
+}
@@ -0,0 +1,231 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const EncryptedCommentOpcode = 0x2167da4b
+
+var ErrNotEncryptedComment = errors.New("message body is not an encrypted comment")
+
+// CreateCommentCell - builds message body with plain text comment
+func CreateCommentCell(text string) (*cell.Cell, error) {
+	// comment ident
+	root := cell.BeginCell().MustStoreUInt(0, 32)
+
+	if err := root.StoreStringSnake(text); err != nil {
+		return nil, fmt.Errorf("failed to build comment: %w", err)
+	}
+
+	return root.EndCell(), nil
+}
+
+// CreateEncryptedCommentCell - builds message body with comment encrypted using shared secret of
+// sender's and receiver's wallet keys, only they can decrypt it.
+// Format is compatible with other TON wallets: https://docs.ton.org/develop/smart-contracts/guidelines/internal-messages#messages-with-encrypted-comments
+func CreateEncryptedCommentCell(text string, senderAddr *address.Address, ourKey ed25519.PrivateKey, theirKey ed25519.PublicKey) (*cell.Cell, error) {
+	if len(theirKey) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid receiver public key")
+	}
+
+	sharedKey, err := liteclient.SharedKey(ourKey, theirKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute shared key: %w", err)
+	}
+
+	data := []byte(text)
+
+	// random prefix of 16-31 bytes, to make data size multiple of 16, first byte is prefix size
+	pfxSz := 16
+	if len(data)%16 != 0 {
+		pfxSz += 16 - (len(data) % 16)
+	}
+
+	pfx := make([]byte, pfxSz)
+	if _, err = rand.Read(pfx); err != nil {
+		return nil, fmt.Errorf("failed to generate random prefix: %w", err)
+	}
+	pfx[0] = byte(pfxSz)
+	data = append(pfx, data...)
+
+	h := hmac.New(sha512.New, commentSalt(senderAddr))
+	h.Write(data)
+	msgKey := h.Sum(nil)[:16]
+
+	c, iv, err := commentCipher(sharedKey, msgKey)
+	if err != nil {
+		return nil, err
+	}
+	cipher.NewCBCEncrypter(c, iv).CryptBlocks(data, data)
+
+	// receiver uses it to find sender's public key
+	xorKey := make([]byte, 32)
+	ourPub := ourKey.Public().(ed25519.PublicKey)
+	for i := range xorKey {
+		xorKey[i] = ourPub[i] ^ theirKey[i]
+	}
+
+	root := cell.BeginCell().MustStoreUInt(EncryptedCommentOpcode, 32).
+		MustStoreSlice(xorKey, 256).
+		MustStoreSlice(msgKey, 128)
+
+	if err = root.StoreBinarySnake(data); err != nil {
+		return nil, fmt.Errorf("failed to build comment: %w", err)
+	}
+
+	return root.EndCell(), nil
+}
+
+// DecryptCommentCell - decrypts comment from message body, sender is address of the wallet which sent the message,
+// theirKey is the public key of the other side, it can be sender's or receiver's key,
+// so the same function can be used to decrypt both incoming and outgoing comments.
+func DecryptCommentCell(body *cell.Cell, sender *address.Address, ourKey ed25519.PrivateKey, theirKey ed25519.PublicKey) (string, error) {
+	if len(theirKey) != ed25519.PublicKeySize {
+		return "", errors.New("invalid public key")
+	}
+
+	p := body.BeginParse()
+
+	op, err := p.LoadUInt(32)
+	if err != nil || op != EncryptedCommentOpcode {
+		return "", ErrNotEncryptedComment
+	}
+
+	xorKey, err := p.LoadSlice(256)
+	if err != nil {
+		return "", fmt.Errorf("failed to load xor key: %w", err)
+	}
+
+	ourPub := ourKey.Public().(ed25519.PublicKey)
+	for i := range xorKey {
+		xorKey[i] ^= theirKey[i]
+	}
+
+	if !bytes.Equal(xorKey, ourPub) {
+		return "", errors.New("comment was encrypted not for the given keys")
+	}
+
+	msgKey, err := p.LoadSlice(128)
+	if err != nil {
+		return "", fmt.Errorf("failed to load msg key: %w", err)
+	}
+
+	data, err := p.LoadBinarySnake()
+	if err != nil {
+		return "", fmt.Errorf("failed to load encrypted data: %w", err)
+	}
+
+	if len(data) < 16 || len(data)%16 != 0 {
+		return "", errors.New("invalid encrypted data size")
+	}
+
+	sharedKey, err := liteclient.SharedKey(ourKey, theirKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute shared key: %w", err)
+	}
+
+	c, iv, err := commentCipher(sharedKey, msgKey)
+	if err != nil {
+		return "", err
+	}
+	cipher.NewCBCDecrypter(c, iv).CryptBlocks(data, data)
+
+	h := hmac.New(sha512.New, commentSalt(sender))
+	h.Write(data)
+	if !hmac.Equal(msgKey, h.Sum(nil)[:16]) {
+		return "", errors.New("incorrect msg key, comment is corrupted or sender is wrong")
+	}
+
+	if data[0] < 16 || int(data[0]) > len(data) {
+		return "", fmt.Errorf("invalid prefix size %d", data[0])
+	}
+
+	return string(data[data[0]:]), nil
+}
+
+// IsEncryptedComment - checks op of the message body
+func IsEncryptedComment(body *cell.Cell) bool {
+	if body == nil {
+		return false
+	}
+
+	op, err := body.BeginParse().LoadUInt(32)
+	return err == nil && op == EncryptedCommentOpcode
+}
+
+// TransferWithEncryptedComment - same as Transfer, but comment is encrypted,
+// receiver contract should have get_public_key method, like all wallets have.
+func (w *Wallet) TransferWithEncryptedComment(ctx context.Context, to *address.Address, amount tlb.Coins, comment string, waitConfirmation ...bool) error {
+	block, err := w.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block: %w", err)
+	}
+
+	key, err := GetPublicKey(ctx, w.api, block, to)
+	if err != nil {
+		return fmt.Errorf("failed to get receiver public key: %w", err)
+	}
+
+	body, err := CreateEncryptedCommentCell(comment, w.addr, w.key, key)
+	if err != nil {
+		return err
+	}
+
+	return w.Send(ctx, &Message{
+		Mode: 1,
+		InternalMessage: &tlb.InternalMessage{
+			IHRDisabled: true,
+			Bounce:      true,
+			DstAddr:     to,
+			Amount:      amount,
+			Body:        body,
+		},
+	}, waitConfirmation...)
+}
+
+// DecryptComment - decrypts comment of the message which was received by the wallet,
+// public key of the sender is taken from sender's contract using get_public_key method.
+func (w *Wallet) DecryptComment(ctx context.Context, block *tlb.BlockInfo, msg *tlb.InternalMessage) (string, error) {
+	if !IsEncryptedComment(msg.Body) {
+		return "", ErrNotEncryptedComment
+	}
+
+	key, err := GetPublicKey(ctx, w.api, block, msg.SrcAddr)
+	if err != nil {
+		return "", fmt.Errorf("failed to get sender public key: %w", err)
+	}
+
+	return DecryptCommentCell(msg.Body, msg.SrcAddr, w.key, key)
+}
+
+func commentCipher(sharedKey, msgKey []byte) (cipher.Block, []byte, error) {
+	h := hmac.New(sha512.New, sharedKey)
+	h.Write(msgKey)
+	x := h.Sum(nil)
+
+	c, err := aes.NewCipher(x[:32])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to init cipher: %w", err)
+	}
+
+	return c, x[32:48], nil
+}
+
+// commentSalt - sender address in bounceable mainnet user-friendly format, regardless of flags of the passed address
+func commentSalt(addr *address.Address) []byte {
+	return []byte(address.NewAddress(0, byte(addr.Workchain()), addr.Data()).String())
+}
@@ -0,0 +1,111 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+func TestCreateEncryptedCommentCell(t *testing.T) {
+	sender := address.MustParseAddr("EQC9bWZd29foipyPOGWlVNVCQzpGAjvi1rGWF7EbNcSVClpA")
+
+	for i := 0; i < 40; i++ {
+		pub1, priv1, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub2, priv2, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		msg := strings.Repeat("x", i*7)
+
+		c, err := CreateEncryptedCommentCell(msg, sender, priv1, pub2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !IsEncryptedComment(c) {
+			t.Fatal("should be encrypted comment")
+		}
+
+		// receiver side
+		data, err := DecryptCommentCell(c, sender, priv2, pub1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data != msg {
+			t.Fatal("incorrect result")
+		}
+
+		// sender side
+		data, err = DecryptCommentCell(c, sender, priv1, pub2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data != msg {
+			t.Fatal("incorrect result for sender")
+		}
+
+		if _, err = DecryptCommentCell(c, address.MustParseAddr("EQDnYZIpTwo9RN_84KZX3qIkLVIUJSo8d1yz1vMlKAp2uRtK"), priv2, pub1); err == nil {
+			t.Fatal("should fail with wrong sender")
+		}
+
+		if _, err = DecryptCommentCell(c, sender, priv2, pub2); err == nil {
+			t.Fatal("should fail with wrong key")
+		}
+	}
+
+	plain, err := CreateCommentCell("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, priv, _ := ed25519.GenerateKey(nil)
+	if _, err = DecryptCommentCell(plain, sender, priv, make([]byte, 32)); err != ErrNotEncryptedComment {
+		t.Fatal("should be not encrypted", err)
+	}
+}
+
+func TestWallet_DecryptComment(t *testing.T) {
+	m := &MockAPI{}
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	senderKey := ed25519.NewKeyFromSeed([]byte("abcdefghijabcdefghijabcdefghijab"))
+	sender := address.MustParseAddr("EQC9bWZd29foipyPOGWlVNVCQzpGAjvi1rGWF7EbNcSVClpA")
+
+	w, err := FromPrivateKey(m, pkey, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.runGetMethod = func(ctx context.Context, blockInfo *tlb.BlockInfo, addr *address.Address, method string, params ...interface{}) ([]interface{}, error) {
+		if method != "get_public_key" || addr.String() != sender.String() {
+			t.Fatal("incorrect get method call")
+		}
+		return []interface{}{new(big.Int).SetBytes(senderKey.Public().(ed25519.PublicKey))}, nil
+	}
+
+	body, err := CreateEncryptedCommentCell("deposit 12345", sender, senderKey, pkey.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comment, err := w.DecryptComment(context.Background(), &tlb.BlockInfo{}, &tlb.InternalMessage{
+		SrcAddr: sender,
+		DstAddr: w.Address(),
+		Body:    body,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if comment != "deposit 12345" {
+		t.Fatal("incorrect comment", comment)
+	}
+}
@@ -0,0 +1,226 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var ErrUnknownWalletVersion = errors.New("code of the contract is not a known wallet code")
+var ErrKeyNotMatch = errors.New("public key of the wallet is not match with the private key")
+
+// versions which are checked by GetVersionByCode and ScanVersions
+var knownVersions = []Version{V1R1, V1R2, V1R3, V2R1, V2R2, V3R1, V3R2, V4R2, HighloadV2R2, Lockup}
+
+// Data - parsed persistent data of wallet contract
+type Data struct {
+	Seqno uint32
+	// Zero for V1 and V2 wallets, they have no subwallet
+	SubwalletID uint32
+	PublicKey   ed25519.PublicKey
+
+	// Only for lockup wallet
+	Lockup *LockupConfig
+}
+
+// GetVersionByCode - detects wallet version by hash of the contract code
+func GetVersionByCode(code *cell.Cell) (Version, error) {
+	if code == nil {
+		return 0, ErrUnknownWalletVersion
+	}
+
+	hash := code.Hash()
+	for _, ver := range knownVersions {
+		verCode, err := getCode(ver)
+		if err != nil {
+			return 0, err
+		}
+
+		if bytes.Equal(verCode.Hash(), hash) {
+			return ver, nil
+		}
+	}
+
+	return 0, ErrUnknownWalletVersion
+}
+
+// ParseData - parses data cell of wallet contract with the given version
+func ParseData(ver Version, data *cell.Cell) (*Data, error) {
+	if data == nil {
+		return nil, errors.New("data is nil")
+	}
+
+	p := data.BeginParse()
+
+	var res Data
+	var err error
+
+	switch ver {
+	case V1R1, V1R2, V1R3, V2R1, V2R2:
+		res.Seqno, err = loadUint32(p)
+	case V3R1, V3R2, V4R2, Lockup:
+		if res.Seqno, err = loadUint32(p); err == nil {
+			res.SubwalletID, err = loadUint32(p)
+		}
+	case HighloadV2R2:
+		if res.SubwalletID, err = loadUint32(p); err == nil {
+			// last cleaned query
+			_, err = p.LoadUInt(64)
+		}
+	default:
+		return nil, ErrUnsupportedWalletVersion
+	}
+
+	if err == nil {
+		res.PublicKey, err = p.LoadSlice(256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse wallet data: %w", err)
+	}
+
+	if ver == Lockup {
+		res.Lockup, err = parseLockupConfig(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse lockup config: %w", err)
+		}
+	}
+
+	return &res, nil
+}
+
+// FromAccount - detects version of the wallet deployed on account, and initializes wallet
+// with its address and subwallet id. Returns ErrKeyNotMatch if wallet belongs to another key.
+func FromAccount(api TonAPI, key ed25519.PrivateKey, acc *tlb.Account) (*Wallet, error) {
+	if !acc.IsActive || acc.State == nil || acc.State.Status != tlb.AccountStatusActive {
+		return nil, errors.New("account is not active")
+	}
+
+	ver, err := GetVersionByCode(acc.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ParseData(ver, acc.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(data.PublicKey, key.Public().(ed25519.PublicKey)) {
+		return nil, ErrKeyNotMatch
+	}
+
+	w := &Wallet{
+		api:       api,
+		key:       key,
+		addr:      acc.State.Address,
+		ver:       ver,
+		subwallet: data.SubwalletID,
+	}
+
+	w.spec, err = getSpec(w)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec, ok := w.spec.(*SpecLockup); ok {
+		spec.config = data.Lockup
+	}
+
+	return w, nil
+}
+
+// ScannedWallet - wallet of some version, which was found on chain for the key
+type ScannedWallet struct {
+	Wallet   *Wallet
+	IsActive bool
+	Balance  tlb.Coins
+}
+
+// ScanVersions - checks addresses of all known wallet versions with default subwallet for the key,
+// and returns wallets which have balance or deployed contract. Use SeedToPrivateKey to scan for a seed phrase.
+// Lockup wallets are not checked, because their address depends on restrictions set by creator.
+func ScanVersions(ctx context.Context, api TonAPI, block *tlb.BlockInfo, key ed25519.PrivateKey) ([]*ScannedWallet, error) {
+	var res []*ScannedWallet
+	for _, ver := range knownVersions {
+		if ver == Lockup {
+			continue
+		}
+
+		w, err := FromPrivateKey(api, key, ver)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init wallet %s: %w", ver, err)
+		}
+
+		acc, err := api.GetAccount(ctx, block, w.addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account of wallet %s: %w", ver, err)
+		}
+
+		if acc.State == nil {
+			continue
+		}
+
+		if !acc.IsActive && acc.State.Balance.NanoTON().Sign() == 0 {
+			continue
+		}
+
+		res = append(res, &ScannedWallet{
+			Wallet:   w,
+			IsActive: acc.IsActive && acc.State.Status == tlb.AccountStatusActive,
+			Balance:  acc.State.Balance,
+		})
+	}
+
+	return res, nil
+}
+
+func parseLockupConfig(p *cell.Slice) (*LockupConfig, error) {
+	var cfg LockupConfig
+	var err error
+
+	if cfg.ConfigPublicKey, err = p.LoadSlice(256); err != nil {
+		return nil, err
+	}
+
+	allowed, err := p.LoadMaybeRef()
+	if err != nil {
+		return nil, err
+	}
+	if allowed != nil {
+		if cfg.AllowedDestinations, err = allowed.ToCell(); err != nil {
+			return nil, err
+		}
+	}
+
+	locked, err := p.LoadBigCoins()
+	if err != nil {
+		return nil, err
+	}
+	cfg.TotalLockedValue = tlb.FromNanoTON(locked)
+
+	if cfg.Locked, err = p.LoadDict(32); err != nil {
+		return nil, err
+	}
+
+	restricted, err := p.LoadBigCoins()
+	if err != nil {
+		return nil, err
+	}
+	cfg.TotalRestrictedValue = tlb.FromNanoTON(restricted)
+
+	if cfg.Restricted, err = p.LoadDict(32); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func loadUint32(p *cell.Slice) (uint32, error) {
+	v, err := p.LoadUInt(32)
+	return uint32(v), err
+}
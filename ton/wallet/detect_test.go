@@ -0,0 +1,162 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+func activeAccount(t *testing.T, key ed25519.PublicKey, ver Version, subwallet uint32) *tlb.Account {
+	state, err := GetStateInit(key, ver, subwallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := AddressFromPubKey(key, ver, subwallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &tlb.Account{
+		IsActive: true,
+		State: &tlb.AccountState{
+			IsValid: true,
+			Address: addr,
+			AccountStorage: tlb.AccountStorage{
+				Status:  tlb.AccountStatusActive,
+				Balance: tlb.MustFromTON("1"),
+			},
+		},
+		Code: state.Code,
+		Data: state.Data,
+	}
+}
+
+func TestFromAccount(t *testing.T) {
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	for _, ver := range knownVersions {
+		acc := activeAccount(t, pkey.Public().(ed25519.PublicKey), ver, 777)
+
+		w, err := FromAccount(nil, pkey, acc)
+		if err != nil {
+			t.Fatal(ver, err)
+		}
+
+		if w.ver != ver {
+			t.Fatal("incorrect version detected", w.ver, ver)
+		}
+
+		if w.Address().String() != acc.State.Address.String() {
+			t.Fatal("incorrect address", ver)
+		}
+
+		switch ver {
+		case V1R1, V1R2, V1R3, V2R1, V2R2:
+			if w.subwallet != 0 {
+				t.Fatal("subwallet should be zero", ver)
+			}
+		default:
+			if w.subwallet != 777 {
+				t.Fatal("incorrect subwallet", ver)
+			}
+		}
+	}
+
+	otherKey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, 32))
+	if _, err := FromAccount(nil, otherKey, activeAccount(t, pkey.Public().(ed25519.PublicKey), V3, 1)); err != ErrKeyNotMatch {
+		t.Fatal("key should not match", err)
+	}
+
+	acc := activeAccount(t, pkey.Public().(ed25519.PublicKey), V3, 1)
+	acc.Code = acc.Data
+	if _, err := FromAccount(nil, pkey, acc); err != ErrUnknownWalletVersion {
+		t.Fatal("version should be unknown", err)
+	}
+}
+
+func TestParseData_Lockup(t *testing.T) {
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	pub := pkey.Public().(ed25519.PublicKey)
+
+	state, err := GetLockupStateInit(pub, 5, &LockupConfig{
+		ConfigPublicKey:      pub,
+		TotalRestrictedValue: tlb.MustFromTON("3"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ParseData(Lockup, state.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data.SubwalletID != 5 || !bytes.Equal(data.PublicKey, pub) {
+		t.Fatal("incorrect data")
+	}
+
+	if !bytes.Equal(data.Lockup.ConfigPublicKey, pub) || data.Lockup.TotalRestrictedValue.NanoTON().Uint64() != 3000000000 {
+		t.Fatal("incorrect lockup config")
+	}
+
+	// config should give the same state after parsing
+	state2, err := GetLockupStateInit(pub, 5, data.Lockup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(state.Data.Hash(), state2.Data.Hash()) {
+		t.Fatal("state not match")
+	}
+}
+
+func TestScanVersions(t *testing.T) {
+	m := &MockAPI{}
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	pub := pkey.Public().(ed25519.PublicKey)
+
+	v2Addr, _ := AddressFromPubKey(pub, V2R2, DefaultSubwallet)
+	v4Addr, _ := AddressFromPubKey(pub, V4R2, DefaultSubwallet)
+
+	m.getAccount = func(ctx context.Context, block *tlb.BlockInfo, addr *address.Address) (*tlb.Account, error) {
+		switch addr.String() {
+		case v2Addr.String():
+			return activeAccount(t, pub, V2R2, DefaultSubwallet), nil
+		case v4Addr.String():
+			// not deployed, but has funds
+			return &tlb.Account{
+				State: &tlb.AccountState{
+					IsValid: true,
+					Address: addr,
+					AccountStorage: tlb.AccountStorage{
+						Status:  tlb.AccountStatusUninit,
+						Balance: tlb.MustFromTON("2"),
+					},
+				},
+			}, nil
+		}
+		return &tlb.Account{}, nil
+	}
+
+	list, err := ScanVersions(context.Background(), m, &tlb.BlockInfo{}, pkey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 2 {
+		t.Fatal("incorrect number of wallets", len(list))
+	}
+
+	if list[0].Wallet.ver != V2R2 || !list[0].IsActive || list[0].Balance.NanoTON().Uint64() != 1000000000 {
+		t.Fatal("incorrect v2 wallet")
+	}
+
+	if list[1].Wallet.ver != V4R2 || list[1].IsActive || list[1].Balance.NanoTON().Uint64() != 2000000000 {
+		t.Fatal("incorrect v4 wallet")
+	}
+}
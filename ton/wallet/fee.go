@@ -0,0 +1,272 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// default gas consumption of wallet contracts for processing of external message with one out message.
+// These are rough approximations, not measured values, they are used only when wallet
+// has no processed external messages yet, otherwise gas used by the last of them is taken.
+var walletGasUsage = map[Version]uint64{
+	V1R1: 2500,
+	V1R2: 2500,
+	V1R3: 2500,
+	V2R1: 2800,
+	V2R2: 2800,
+	V3R1: 3000,
+	V3R2: 3000,
+	V4R2: 3400,
+}
+
+// approximate gas consumption for each additional out message
+const _GasPerMessage = 700
+
+// _GasScanTx - how many last transactions of wallet are checked to find processed external message
+const _GasScanTx = 10
+
+var ErrUnknownGasUsage = errors.New("gas usage of wallet is unknown, it has no processed external messages and no default for its version")
+
+// ConfigAPI - source of blockchain config, implemented by ton.APIClient
+type ConfigAPI interface {
+	GetConfigParams(ctx context.Context, block *tlb.BlockInfo, params ...int32) (*ton.BlockchainConfig, error)
+}
+
+// FeeEstimate - estimated fees which wallet will pay for processing of external message.
+// Real fees can differ, compute fee depends on how contract processes the message,
+// and prices in config can change before the message is processed.
+type FeeEstimate struct {
+	// Fee for import of external message to blockchain
+	ImportFee tlb.Coins
+	// Storage fee, accumulated since last payment, it is charged in the transaction
+	StorageFee tlb.Coins
+	// Fee for execution of wallet contract, it is calculated for Gas
+	ComputeFee tlb.Coins
+	// Gas which wallet contract is expected to use, see GasMeasured
+	Gas uint64
+	// GasMeasured - true if Gas is based on the last processed external message of the wallet,
+	// otherwise it is default approximation for wallet version
+	GasMeasured bool
+	// Forward fees of each internal message, in the same order as messages
+	ForwardFees []tlb.Coins
+
+	// Sum of all fees, amounts of messages are not included
+	Total tlb.Coins
+}
+
+// FeeEstimator - estimates fees of wallet messages using actual blockchain config
+type FeeEstimator struct {
+	api ConfigAPI
+}
+
+func NewFeeEstimator(api ConfigAPI) *FeeEstimator {
+	return &FeeEstimator{
+		api: api,
+	}
+}
+
+// Estimate - builds external message of the wallet with the given messages, same as SendMany would do,
+// and estimates fees for it. Message is not sent.
+// Gas is taken from the last processed external message of the wallet, if it is not found,
+// default approximation for wallet version is used, ErrUnknownGasUsage is returned if there is none.
+func (e *FeeEstimator) Estimate(ctx context.Context, w *Wallet, messages []*Message) (*FeeEstimate, error) {
+	build, err := w.messagesBuilder(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := w.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	acc, err := w.api.GetAccount(ctx, block, w.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	// workchain is stored as byte, so masterchain is 255
+	masterchain := w.addr.Workchain() == 255
+
+	gasParam, fwdParam := int32(21), int32(25)
+	if masterchain {
+		gasParam, fwdParam = 20, 24
+	}
+
+	cfg, err := e.api.GetConfigParams(ctx, block, 18, gasParam, fwdParam)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+
+	storagePrices, err := cfg.GetStoragePrices()
+	if err != nil {
+		return nil, err
+	}
+
+	gasPrices, err := cfg.GetGasPrices(masterchain)
+	if err != nil {
+		return nil, err
+	}
+
+	fwdPrices, err := cfg.GetMsgForwardPrices(masterchain)
+	if err != nil {
+		return nil, err
+	}
+
+	var stateInit *tlb.StateInit
+	initialized := acc.IsActive && acc.State.Status == tlb.AccountStatusActive
+	if !initialized {
+		stateInit, err = w.getStateInit()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get state init: %w", err)
+		}
+	}
+
+	body, err := build(ctx, initialized, block)
+	if err != nil {
+		return nil, fmt.Errorf("build message err: %w", err)
+	}
+
+	ext, err := (&tlb.ExternalMessage{
+		DstAddr:   w.addr,
+		StateInit: stateInit,
+		Body:      body,
+	}).ToCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize external message: %w", err)
+	}
+
+	res := &FeeEstimate{}
+	res.ImportFee = fwdPrices.CalcFwdFee(msgStats(ext))
+
+	gas, outMessages, err := lastExternalGas(ctx, w, acc)
+	if err != nil {
+		return nil, err
+	}
+
+	if gas > 0 {
+		res.GasMeasured = true
+	} else if gas, outMessages = walletGasUsage[w.ver], 1; gas == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGasUsage, w.ver)
+	}
+
+	if len(messages) > outMessages {
+		gas += uint64(len(messages)-outMessages) * _GasPerMessage
+	}
+	res.Gas = gas
+	res.ComputeFee = gasPrices.CalcGasFee(gas)
+
+	if acc.IsActive && acc.State != nil {
+		res.StorageFee = calcStorageFee(storagePrices, acc.State, masterchain)
+	}
+
+	total := new(big.Int).Add(res.ImportFee.NanoTON(), res.ComputeFee.NanoTON())
+	total.Add(total, res.StorageFee.NanoTON())
+
+	for _, m := range messages {
+		msg, err := m.InternalMessage.ToCell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize internal message: %w", err)
+		}
+
+		fee := fwdPrices.CalcFwdFee(msgStats(msg))
+		res.ForwardFees = append(res.ForwardFees, fee)
+		total.Add(total, fee.NanoTON())
+	}
+	res.Total = tlb.FromNanoTON(total)
+
+	return res, nil
+}
+
+// lastExternalGas - gas used by wallet for the last successfully processed external message
+// among its recent transactions, and number of messages it sent. Zero gas if it is not found.
+func lastExternalGas(ctx context.Context, w *Wallet, acc *tlb.Account) (uint64, int, error) {
+	if !acc.IsActive || acc.LastTxLT == 0 {
+		return 0, 0, nil
+	}
+
+	list, err := w.api.ListTransactions(ctx, w.addr, _GasScanTx, acc.LastTxLT, acc.LastTxHash)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list transactions: %w", err)
+	}
+
+	// the oldest one is first
+	for i := len(list) - 1; i >= 0; i-- {
+		tx := list[i]
+		if tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeExternalIn {
+			continue
+		}
+
+		desc, err := tx.ParseDescription()
+		if err != nil {
+			continue
+		}
+
+		ph := desc.ComputePhase
+		if ph.Skipped || !ph.Success || ph.GasUsed == nil || !ph.GasUsed.IsUint64() {
+			continue
+		}
+		return ph.GasUsed.Uint64(), int(tx.OutMsgCount), nil
+	}
+	return 0, 0, nil
+}
+
+// calcStorageFee - storage fee since last payment, using the latest prices for the whole period
+func calcStorageFee(prices []tlb.StoragePrices, state *tlb.AccountState, masterchain bool) tlb.Coins {
+	now := uint32(timeNow().Unix())
+	if len(prices) == 0 || state.StorageInfo.LastPaid >= now {
+		return tlb.FromNanoTONU(0)
+	}
+
+	var price *tlb.StoragePrices
+	for i := range prices {
+		if prices[i].UTimeSince <= now {
+			price = &prices[i]
+		}
+	}
+
+	if price == nil {
+		return tlb.FromNanoTONU(0)
+	}
+
+	used := state.StorageInfo.StorageUsed
+	fee := price.CalcStorageFee(used.CellsUsed, used.BitsUsed, now-state.StorageInfo.LastPaid, masterchain).NanoTON()
+
+	if state.StorageInfo.DuePayment != nil {
+		fee.Add(fee, state.StorageInfo.DuePayment.NanoTON())
+	}
+
+	return tlb.FromNanoTON(fee)
+}
+
+// msgStats - counts unique cells and bits of message, without root cell, as node does for forward fees
+func msgStats(msg *cell.Cell) (cells, bits uint64) {
+	seen := map[string]bool{}
+
+	var walk func(s *cell.Slice)
+	walk = func(s *cell.Slice) {
+		for s.RefsNum() > 0 {
+			ref := s.MustLoadRef()
+
+			c := ref.MustToCell()
+			key := string(c.Hash())
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			cells++
+			bits += uint64(c.BitsSize())
+			walk(ref)
+		}
+	}
+	walk(msg.BeginParse())
+
+	return cells, bits
+}
@@ -0,0 +1,170 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type mockConfigAPI struct {
+	params []int32
+}
+
+func (m *mockConfigAPI) GetConfigParams(ctx context.Context, block *tlb.BlockInfo, params ...int32) (*ton.BlockchainConfig, error) {
+	m.params = params
+
+	storage := cell.NewDict(32)
+	_ = storage.SetIntKey(big.NewInt(0), cell.BeginCell().MustStoreUInt(0xcc, 8).
+		MustStoreUInt(0, 32).MustStoreUInt(1, 64).MustStoreUInt(500, 64).
+		MustStoreUInt(1000, 64).MustStoreUInt(500000, 64).EndCell())
+
+	gas := cell.BeginCell().
+		MustStoreUInt(0xd1, 8).MustStoreUInt(100, 64).MustStoreUInt(40000, 64).
+		MustStoreUInt(0xdd, 8).
+		MustStoreUInt(26214400, 64).MustStoreUInt(1000000, 64).MustStoreUInt(10000, 64).
+		MustStoreUInt(10000000, 64).MustStoreUInt(100000000, 64).MustStoreUInt(1000000000, 64).
+		EndCell()
+
+	fwd := cell.BeginCell().MustStoreUInt(0xea, 8).
+		MustStoreUInt(400000, 64).MustStoreUInt(26214400, 64).MustStoreUInt(2621440000, 64).
+		MustStoreUInt(98304, 32).MustStoreUInt(21845, 16).MustStoreUInt(21845, 16).
+		EndCell()
+
+	return ton.NewBlockchainConfig(map[int32]*cell.Cell{18: storage.MustToCell(), 21: gas, 25: fwd}), nil
+}
+
+func TestFeeEstimator_Estimate(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	var acc *tlb.Account
+	m := &MockAPI{
+		getBlockInfo: func(ctx context.Context) (*tlb.BlockInfo, error) {
+			return &tlb.BlockInfo{}, nil
+		},
+		getAccount: func(ctx context.Context, block *tlb.BlockInfo, addr *address.Address) (*tlb.Account, error) {
+			return acc, nil
+		},
+		runGetMethod: func(ctx context.Context, blockInfo *tlb.BlockInfo, addr *address.Address, method string, params ...interface{}) ([]interface{}, error) {
+			return []interface{}{int64(3)}, nil
+		},
+	}
+
+	w, err := FromPrivateKey(m, pkey, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := SimpleMessage(w.Address(), tlb.MustFromTON("1"), cell.BeginCell().MustStoreUInt(0, 32).EndCell())
+	msgs := []*Message{msg, msg}
+
+	cfg := &mockConfigAPI{}
+	e := NewFeeEstimator(cfg)
+
+	acc = &tlb.Account{IsActive: false}
+	notDeployed, err := e.Estimate(context.Background(), w, msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.params) != 3 || cfg.params[0] != 18 || cfg.params[1] != 21 || cfg.params[2] != 25 {
+		t.Fatal("incorrect params requested", cfg.params)
+	}
+
+	// 40000 flat + (3400+700-100) * 400
+	if notDeployed.ComputeFee.NanoTON().Uint64() != 40000+4000*400 {
+		t.Fatal("incorrect compute fee", notDeployed.ComputeFee.NanoTON())
+	}
+
+	if notDeployed.StorageFee.NanoTON().Sign() != 0 {
+		t.Fatal("storage fee should be zero")
+	}
+
+	if len(notDeployed.ForwardFees) != 2 || notDeployed.ForwardFees[0].NanoTON().Cmp(notDeployed.ForwardFees[1].NanoTON()) != 0 {
+		t.Fatal("incorrect forward fees")
+	}
+
+	// small body is stored in the root cell, so only lump price is paid
+	if notDeployed.ForwardFees[0].NanoTON().Uint64() != 400000 {
+		t.Fatal("incorrect forward fee", notDeployed.ForwardFees[0].NanoTON())
+	}
+
+	acc = activeAccount(t, pkey.Public().(ed25519.PublicKey), V4R2, DefaultSubwallet)
+	acc.State.StorageInfo = tlb.StorageInfo{
+		StorageUsed: tlb.StorageUsed{BitsUsed: 1000, CellsUsed: 10},
+		LastPaid:    1000000 - 65536,
+	}
+
+	deployed, err := e.Estimate(context.Background(), w, msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deployed.StorageFee.NanoTON().Uint64() != 6000 {
+		t.Fatal("incorrect storage fee", deployed.StorageFee.NanoTON())
+	}
+
+	// state init is not sent for deployed wallet
+	if deployed.ImportFee.NanoTON().Cmp(notDeployed.ImportFee.NanoTON()) >= 0 {
+		t.Fatal("import fee with state init should be bigger")
+	}
+
+	total := new(big.Int).Add(deployed.ImportFee.NanoTON(), deployed.ComputeFee.NanoTON())
+	total.Add(total, deployed.StorageFee.NanoTON())
+	for _, f := range deployed.ForwardFees {
+		total.Add(total, f.NanoTON())
+	}
+
+	if total.Cmp(deployed.Total.NanoTON()) != 0 {
+		t.Fatal("incorrect total")
+	}
+
+	if deployed.GasMeasured || deployed.Gas != 4100 {
+		t.Fatal("default gas should be used without history", deployed.Gas)
+	}
+
+	// gas is taken from the last external message, 100 is used in test description
+	acc.LastTxLT = 10
+	acc.LastTxHash = make([]byte, 32)
+	m.listTransactions = func(ctx context.Context, addr *address.Address, limit uint32, lt uint64, txHash []byte) ([]*tlb.Transaction, error) {
+		ext := &tlb.Transaction{LT: 10, OutMsgCount: 1, Description: testTxDescription(0, true)}
+		ext.IO.In = &tlb.Message{MsgType: tlb.MsgTypeExternalIn, Msg: &tlb.ExternalMessage{DstAddr: w.Address()}}
+
+		internal := &tlb.Transaction{LT: 9, Description: testTxDescription(0, true)}
+		internal.IO.In = &tlb.Message{MsgType: tlb.MsgTypeInternal, Msg: &tlb.InternalMessage{DstAddr: w.Address()}}
+
+		return []*tlb.Transaction{internal, ext}, nil
+	}
+
+	measured, err := e.Estimate(context.Background(), w, msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 40000 flat + (100+700-100) * 400
+	if !measured.GasMeasured || measured.Gas != 800 || measured.ComputeFee.NanoTON().Uint64() != 40000+700*400 {
+		t.Fatal("incorrect measured compute fee", measured.Gas, measured.ComputeFee.NanoTON())
+	}
+
+	// no history and no default
+	acc = &tlb.Account{IsActive: false}
+	lockup, err := FromPrivateKey(m, pkey, Lockup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = e.Estimate(context.Background(), lockup, msgs); !errors.Is(err, ErrUnknownGasUsage) {
+		t.Fatal("should be unknown gas usage error, got", err)
+	}
+}
@@ -0,0 +1,329 @@
+package wallet
+
+import (
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const _KeystoreVersion = 1
+
+// limits of kdf params, keystore file is not trusted, so it should not make us
+// allocate too much memory or compute key for a very long time
+const (
+	_MaxKDFMemory  = 1 << 30 // bytes
+	_MaxScryptN    = 1 << 20
+	_MaxScryptP    = 16
+	_MaxArgonTime  = 10
+	_MaxKDFThreads = 16
+)
+
+type KeystoreKind string
+
+const (
+	KeystoreKindSeed KeystoreKind = "seed"
+	KeystoreKindKey  KeystoreKind = "key"
+)
+
+type KDFType string
+
+const (
+	KDFScrypt   KDFType = "scrypt"
+	KDFArgon2id KDFType = "argon2id"
+)
+
+var ErrKeystoreWrongPassword = errors.New("wrong keystore password or data is corrupted")
+
+// KDFParams - parameters of the function which derives encryption key from password
+type KDFParams struct {
+	Type KDFType `json:"type"`
+	Salt []byte  `json:"salt"`
+
+	// scrypt params
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+
+	// argon2id params
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+}
+
+// DefaultScryptParams and DefaultArgon2idParams are used for new keystores, salt is generated for each one.
+var (
+	DefaultScryptParams   = KDFParams{Type: KDFScrypt, N: 1 << 17, R: 8, P: 1}
+	DefaultArgon2idParams = KDFParams{Type: KDFArgon2id, Time: 3, Memory: 64 * 1024, Threads: 4}
+)
+
+// Keystore - seed phrase or private key encrypted with XChaCha20-Poly1305, using key derived from password.
+// It can be serialized to json and stored in a file.
+type Keystore struct {
+	Version    int          `json:"version"`
+	Kind       KeystoreKind `json:"kind"`
+	KDF        KDFParams    `json:"kdf"`
+	Nonce      []byte       `json:"nonce"`
+	Ciphertext []byte       `json:"ciphertext"`
+}
+
+type keystoreSecret struct {
+	Seed         []string `json:"seed,omitempty"`
+	SeedPassword string   `json:"seed_password,omitempty"`
+	Key          []byte   `json:"key,omitempty"`
+}
+
+// NewSeedKeystore - encrypts seed phrase with password, seedPassword is the optional password of the mnemonic itself.
+// If kdf is nil, DefaultArgon2idParams are used.
+func NewSeedKeystore(seed []string, seedPassword, password string, kdf *KDFParams) (*Keystore, error) {
+	// check that seed is correct, to not store garbage
+	if _, err := SeedToPrivateKey(seed, seedPassword); err != nil {
+		return nil, fmt.Errorf("invalid seed: %w", err)
+	}
+
+	return newKeystore(KeystoreKindSeed, &keystoreSecret{
+		Seed:         seed,
+		SeedPassword: seedPassword,
+	}, password, kdf)
+}
+
+// NewKeyKeystore - encrypts private key with password. If kdf is nil, DefaultArgon2idParams are used.
+func NewKeyKeystore(key ed25519.PrivateKey, password string, kdf *KDFParams) (*Keystore, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid private key size")
+	}
+
+	return newKeystore(KeystoreKindKey, &keystoreSecret{
+		Key: key.Seed(),
+	}, password, kdf)
+}
+
+// ImportBackup - creates keystore from standard TON wallet backup, which is 24 words mnemonic
+func ImportBackup(words []string, seedPassword, password string) (*Keystore, error) {
+	return NewSeedKeystore(words, seedPassword, password, nil)
+}
+
+// LoadKeystore - parses keystore from json
+func LoadKeystore(data []byte) (*Keystore, error) {
+	var ks Keystore
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, fmt.Errorf("failed to parse keystore: %w", err)
+	}
+
+	if ks.Version != _KeystoreVersion {
+		return nil, fmt.Errorf("unsupported keystore version %d", ks.Version)
+	}
+
+	if ks.Kind != KeystoreKindSeed && ks.Kind != KeystoreKindKey {
+		return nil, fmt.Errorf("unknown keystore kind '%s'", ks.Kind)
+	}
+
+	if err := ks.KDF.validate(); err != nil {
+		return nil, err
+	}
+
+	return &ks, nil
+}
+
+// LoadKeystoreFile - reads keystore from json file
+func LoadKeystoreFile(path string) (*Keystore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore file: %w", err)
+	}
+
+	return LoadKeystore(data)
+}
+
+// SaveFile - writes keystore as json to file, which is readable only by owner
+func (k *Keystore) SaveFile(path string) error {
+	data, err := json.MarshalIndent(k, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize keystore: %w", err)
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+// UnlockKey - decrypts keystore and returns private key, for seed keystore key is derived from the seed
+func (k *Keystore) UnlockKey(password string) (ed25519.PrivateKey, error) {
+	secret, err := k.decrypt(password)
+	if err != nil {
+		return nil, err
+	}
+
+	if k.Kind == KeystoreKindSeed {
+		return SeedToPrivateKey(secret.Seed, secret.SeedPassword)
+	}
+
+	if len(secret.Key) != ed25519.SeedSize {
+		return nil, errors.New("invalid key size in keystore")
+	}
+	return ed25519.NewKeyFromSeed(secret.Key), nil
+}
+
+// ExportBackup - decrypts seed keystore and returns mnemonic words and its password,
+// which can be imported to any TON wallet
+func (k *Keystore) ExportBackup(password string) (words []string, seedPassword string, err error) {
+	if k.Kind != KeystoreKindSeed {
+		return nil, "", errors.New("keystore has no seed phrase, only private key")
+	}
+
+	secret, err := k.decrypt(password)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return secret.Seed, secret.SeedPassword, nil
+}
+
+// ChangePassword - re-encrypts keystore with the new password and the fresh salt and nonce
+func (k *Keystore) ChangePassword(oldPassword, newPassword string) error {
+	secret, err := k.decrypt(oldPassword)
+	if err != nil {
+		return err
+	}
+
+	params := k.KDF
+	ks, err := newKeystore(k.Kind, secret, newPassword, &params)
+	if err != nil {
+		return err
+	}
+
+	*k = *ks
+	return nil
+}
+
+// FromKeystore - unlocks keystore and initializes wallet with its key
+func FromKeystore(api TonAPI, ks *Keystore, password string, version Version) (*Wallet, error) {
+	key, err := ks.UnlockKey(password)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromPrivateKey(api, key, version)
+}
+
+func newKeystore(kind KeystoreKind, secret *keystoreSecret, password string, kdf *KDFParams) (*Keystore, error) {
+	params := DefaultArgon2idParams
+	if kdf != nil {
+		params = *kdf
+	}
+
+	params.Salt = make([]byte, 32)
+	if _, err := rand.Read(params.Salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	ks := &Keystore{
+		Version: _KeystoreVersion,
+		Kind:    kind,
+		KDF:     params,
+		Nonce:   make([]byte, chacha20poly1305.NonceSizeX),
+	}
+
+	if _, err := rand.Read(ks.Nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	aead, err := ks.cipher(password)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize secret: %w", err)
+	}
+
+	ks.Ciphertext = aead.Seal(nil, ks.Nonce, data, ks.additionalData())
+
+	return ks, nil
+}
+
+func (k *Keystore) decrypt(password string) (*keystoreSecret, error) {
+	aead, err := k.cipher(password)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(k.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce size")
+	}
+
+	data, err := aead.Open(nil, k.Nonce, k.Ciphertext, k.additionalData())
+	if err != nil {
+		return nil, ErrKeystoreWrongPassword
+	}
+
+	var secret keystoreSecret
+	if err = json.Unmarshal(data, &secret); err != nil {
+		return nil, fmt.Errorf("failed to parse secret: %w", err)
+	}
+
+	return &secret, nil
+}
+
+// validate - checks that params are in limits, scrypt and argon2id need about 128*N*R and Memory*1024 bytes
+func (p *KDFParams) validate() error {
+	switch p.Type {
+	case KDFScrypt:
+		if p.N <= 1 || p.N > _MaxScryptN || p.N&(p.N-1) != 0 {
+			return fmt.Errorf("invalid scrypt N %d, it should be power of 2 up to %d", p.N, _MaxScryptN)
+		}
+		if p.R <= 0 || 128*uint64(p.N)*uint64(p.R) > _MaxKDFMemory {
+			return fmt.Errorf("invalid scrypt R %d", p.R)
+		}
+		if p.P <= 0 || p.P > _MaxScryptP {
+			return fmt.Errorf("invalid scrypt P %d", p.P)
+		}
+	case KDFArgon2id:
+		if p.Time == 0 || p.Time > _MaxArgonTime {
+			return fmt.Errorf("invalid argon2id time %d", p.Time)
+		}
+		if p.Threads == 0 || p.Threads > _MaxKDFThreads {
+			return fmt.Errorf("invalid argon2id threads %d", p.Threads)
+		}
+		if p.Memory == 0 || uint64(p.Memory)*1024 > _MaxKDFMemory {
+			return fmt.Errorf("invalid argon2id memory %d", p.Memory)
+		}
+	default:
+		return fmt.Errorf("unsupported kdf '%s'", p.Type)
+	}
+	return nil
+}
+
+func (k *Keystore) cipher(password string) (cipher.AEAD, error) {
+	if err := k.KDF.validate(); err != nil {
+		return nil, err
+	}
+
+	var key []byte
+	var err error
+
+	switch k.KDF.Type {
+	case KDFScrypt:
+		key, err = scrypt.Key([]byte(password), k.KDF.Salt, k.KDF.N, k.KDF.R, k.KDF.P, chacha20poly1305.KeySize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+	case KDFArgon2id:
+		key = argon2.IDKey([]byte(password), k.KDF.Salt, k.KDF.Time, k.KDF.Memory, k.KDF.Threads, chacha20poly1305.KeySize)
+	default:
+		return nil, fmt.Errorf("unsupported kdf '%s'", k.KDF.Type)
+	}
+
+	return chacha20poly1305.NewX(key)
+}
+
+// additionalData - header fields which are authenticated together with ciphertext
+func (k *Keystore) additionalData() []byte {
+	return []byte(fmt.Sprintf("%d:%s:%s", k.Version, k.Kind, k.KDF.Type))
+}
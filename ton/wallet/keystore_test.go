@@ -0,0 +1,145 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testScrypt = &KDFParams{Type: KDFScrypt, N: 1024, R: 8, P: 1}
+var testArgon2id = &KDFParams{Type: KDFArgon2id, Time: 1, Memory: 1024, Threads: 1}
+
+func TestKeystore_Seed(t *testing.T) {
+	seed := NewSeed()
+
+	key, err := SeedToPrivateKey(seed, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kdf := range []*KDFParams{testScrypt, testArgon2id} {
+		ks, err := NewSeedKeystore(seed, "", "pass", kdf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join(t.TempDir(), "wallet.json")
+		if err = ks.SaveFile(path); err != nil {
+			t.Fatal(err)
+		}
+
+		ks, err = LoadKeystoreFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = ks.UnlockKey("wrong"); err != ErrKeystoreWrongPassword {
+			t.Fatal("should be wrong password", err)
+		}
+
+		unlocked, err := ks.UnlockKey("pass")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(unlocked, key) {
+			t.Fatal("key not match")
+		}
+
+		if err = ks.ChangePassword("pass", "new pass"); err != nil {
+			t.Fatal(err)
+		}
+
+		if ks.KDF.Type != kdf.Type {
+			t.Fatal("kdf changed")
+		}
+
+		if _, err = ks.UnlockKey("pass"); err != ErrKeystoreWrongPassword {
+			t.Fatal("old password should not work", err)
+		}
+
+		words, seedPass, err := ks.ExportBackup("new pass")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Join(words, " ") != strings.Join(seed, " ") || seedPass != "" {
+			t.Fatal("backup not match")
+		}
+
+		w, err := FromKeystore(nil, ks, "new pass", V4R2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(w.PrivateKey(), key) {
+			t.Fatal("wallet key not match")
+		}
+	}
+
+	if _, err = NewSeedKeystore(append([]string{"abandn"}, seed[1:]...), "", "pass", testScrypt); err == nil {
+		t.Fatal("invalid seed should not be accepted")
+	}
+}
+
+func TestKeystore_Key(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := NewKeyKeystore(key, "pass", testArgon2id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlocked, err := ks.UnlockKey("pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(unlocked, key) {
+		t.Fatal("key not match")
+	}
+
+	if _, _, err = ks.ExportBackup("pass"); err == nil {
+		t.Fatal("key keystore should have no backup words")
+	}
+
+	// header is authenticated
+	ks.Kind = KeystoreKindSeed
+	if _, err = ks.UnlockKey("pass"); err != ErrKeystoreWrongPassword {
+		t.Fatal("modified keystore should not be decrypted", err)
+	}
+
+	if _, err = LoadKeystore([]byte(`{"version":2,"kind":"key"}`)); err == nil {
+		t.Fatal("unknown version should not be loaded")
+	}
+}
+
+func TestLoadKeystore_HostileKDF(t *testing.T) {
+	for _, kdf := range []string{
+		`{"type":"argon2id","time":1,"memory":4294967295,"threads":1}`,
+		`{"type":"argon2id","time":1000000,"memory":1024,"threads":1}`,
+		`{"type":"argon2id","time":1,"memory":1024,"threads":255}`,
+		`{"type":"argon2id","time":1,"memory":0,"threads":1}`,
+		`{"type":"scrypt","n":1073741824,"r":8,"p":1}`,
+		`{"type":"scrypt","n":1000,"r":8,"p":1}`,
+		`{"type":"scrypt","n":1048576,"r":1024,"p":1}`,
+		`{"type":"scrypt","n":1024,"r":8,"p":1000}`,
+		`{"type":"pbkdf2"}`,
+	} {
+		data := `{"version":1,"kind":"key","kdf":` + kdf + `}`
+		if _, err := LoadKeystore([]byte(data)); err == nil {
+			t.Fatal("keystore with hostile kdf should not be loaded", kdf)
+		}
+	}
+
+	for _, kdf := range []KDFParams{DefaultScryptParams, DefaultArgon2idParams, *testScrypt, *testArgon2id} {
+		if err := kdf.validate(); err != nil {
+			t.Fatal("valid params are rejected", kdf.Type, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// https://github.com/toncenter/tonweb/blob/master/src/contract/wallet/WalletSources.md#lockup-wallet
+const _LockupCodeHex = "B5EE9C7241021E01000261000114FF00F4A413F4BCF2C80B010201200203020148040501F2F28308D71820D31FD31FD31F802403F823BB13F2F2F003802251A9BA1AF2F4802351B7BA1BF2F4801F0BF9015410C5F9101AF2F4F8005057F823F0065098F823F0062071289320D74A8E8BD30731D4511BDB3C12B001E8309229A0DF72FB02069320D74A96D307D402FB00E8D103A4476814154330F004ED541D0202CD0607020120131402012008090201200F100201200A0B002D5ED44D0D31FD31FD3FFD3FFF404FA00F404FA00F404D1803F7007434C0C05C6C2497C0F83E900C0871C02497C0F80074C7C87040A497C1383C00D46D3C00608420BABE7114AC2F6C2497C338200A208420BABE7106EE86BCBD20084AE0840EE6B2802FBCBD01E0C235C62008087E4055040DBE4404BCBD34C7E00A60840DCEAA7D04EE84BCBD34C034C7CC0078C3C412040DD78CA00C0D0E00130875D27D2A1BE95B0C60000C1039480AF00500161037410AF0050810575056001010244300F004ED540201201112004548E1E228020F4966FA520933023BB9131E2209835FA00D113A14013926C21E2B3E6308003502323287C5F287C572FFC4F2FFFD00007E80BD00007E80BD00326000431448A814C4E0083D039BE865BE803444E800A44C38B21400FE809004E0083D10C06002012015160015BDE9F780188242F847800C02012017180201481B1C002DB5187E006D88868A82609E00C6207E00C63F04EDE20B30020158191A0017ADCE76A268699F98EB85FFC00017AC78F6A268698F98EB858FC00011B325FB513435C2C7E00017B1D1BE08E0804230FB50F620002801D0D3030178B0925B7FE0FA4031FA403001F001A80EDAA4"
+
+// LockupConfig - restrictions of lockup wallet, they are set by the party which creates the wallet
+// and together with public key and subwallet id they define address of the wallet.
+type LockupConfig struct {
+	// Key of the party which can add locked and restricted funds
+	ConfigPublicKey ed25519.PublicKey
+
+	// Root of PfxHashmapE 267 ^Cell with addresses where restricted funds can be sent, nil if empty
+	AllowedDestinations *cell.Cell
+
+	TotalLockedValue tlb.Coins
+	// Unix time (32 bits) -> Coins, which will be unlocked at this time
+	Locked *cell.Dictionary
+
+	TotalRestrictedValue tlb.Coins
+	// Unix time (32 bits) -> Coins, which will be unrestricted at this time
+	Restricted *cell.Dictionary
+}
+
+// SpecLockup - lockup wallet uses the same external message format as v3 wallet,
+// but contract reserves locked funds, and restricted funds can be sent only to allowed destinations.
+type SpecLockup struct {
+	SpecV3
+
+	config *LockupConfig
+}
+
+// FromPrivateKeyLockup - initializes lockup wallet with restrictions from config,
+// config can be nil for wallet without restrictions.
+func FromPrivateKeyLockup(api TonAPI, key ed25519.PrivateKey, subwallet uint32, config *LockupConfig) (*Wallet, error) {
+	state, err := GetLockupStateInit(key.Public().(ed25519.PublicKey), subwallet, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state: %w", err)
+	}
+
+	addr, err := addressFromStateInit(state)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Wallet{
+		api:       api,
+		key:       key,
+		addr:      addr,
+		ver:       Lockup,
+		subwallet: subwallet,
+	}
+
+	w.spec, err = getSpec(w)
+	if err != nil {
+		return nil, err
+	}
+	w.spec.(*SpecLockup).config = config
+
+	return w, nil
+}
+
+func GetLockupStateInit(pubKey ed25519.PublicKey, subWallet uint32, config *LockupConfig) (*tlb.StateInit, error) {
+	code, err := getCode(Lockup)
+	if err != nil {
+		return nil, err
+	}
+
+	if config == nil {
+		config = &LockupConfig{}
+	}
+
+	configKey := config.ConfigPublicKey
+	if configKey == nil {
+		configKey = make([]byte, 32)
+	}
+
+	if len(configKey) != 32 {
+		return nil, errors.New("config public key should be 32 bytes")
+	}
+
+	data := cell.BeginCell().
+		MustStoreUInt(0, 32). // seqno
+		MustStoreUInt(uint64(subWallet), 32).
+		MustStoreSlice(pubKey, 256).
+		MustStoreSlice(configKey, 256).
+		MustStoreMaybeRef(config.AllowedDestinations).
+		MustStoreBigCoins(config.TotalLockedValue.NanoTON())
+
+	if err = data.StoreDict(config.Locked); err != nil {
+		return nil, fmt.Errorf("failed to store locked dict: %w", err)
+	}
+
+	data.MustStoreBigCoins(config.TotalRestrictedValue.NanoTON())
+
+	if err = data.StoreDict(config.Restricted); err != nil {
+		return nil, fmt.Errorf("failed to store restricted dict: %w", err)
+	}
+
+	return &tlb.StateInit{
+		Data: data.EndCell(),
+		Code: code,
+	}, nil
+}
+
+// GetBalances - returns full balance of the wallet and amounts which are still restricted and locked at the block time
+func (s *SpecLockup) GetBalances(ctx context.Context, block *tlb.BlockInfo) (balance, restricted, locked tlb.Coins, err error) {
+	res, err := s.wallet.api.RunGetMethod(ctx, block, s.wallet.addr, "get_balances")
+	if err != nil {
+		return tlb.Coins{}, tlb.Coins{}, tlb.Coins{}, fmt.Errorf("failed to run get_balances method: %w", err)
+	}
+
+	if len(res) < 3 {
+		return tlb.Coins{}, tlb.Coins{}, tlb.Coins{}, errors.New("incorrect result of get_balances")
+	}
+
+	var vals [3]tlb.Coins
+	for i := range vals {
+		switch x := res[i].(type) {
+		case int64:
+			vals[i] = tlb.FromNanoTON(big.NewInt(x))
+		case *big.Int:
+			vals[i] = tlb.FromNanoTON(x)
+		default:
+			return tlb.Coins{}, tlb.Coins{}, tlb.Coins{}, fmt.Errorf("get_balances value %d is not an integer", i)
+		}
+	}
+
+	return vals[0], vals[1], vals[2], nil
+}
@@ -0,0 +1,200 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var ErrPluginsNotSupported = errors.New("plugins are supported only by v4 wallets")
+var ErrNotPluginRequest = errors.New("message is not a plugin request")
+
+// PluginRequestFunds - message which installed plugin sends to wallet to request payment,
+// for example subscription plugin uses it to charge the next period.
+// Wallet will send requested amount back to plugin with 0xf06c7567 op.
+type PluginRequestFunds struct {
+	_       tlb.Magic              `tlb:"#706c7567"`
+	QueryID uint64                 `tlb:"## 64"`
+	Amount  tlb.CurrencyCollection `tlb:"."`
+}
+
+// PluginRequestRemove - message which installed plugin sends to wallet to remove itself from the plugins list.
+// Wallet will confirm it with 0xe4737472 op.
+type PluginRequestRemove struct {
+	_       tlb.Magic `tlb:"#64737472"`
+	QueryID uint64    `tlb:"## 64"`
+}
+
+// InstallPlugin - adds already deployed contract to the list of wallet's plugins,
+// amount will be sent to plugin with install notification
+func (w *Wallet) InstallPlugin(ctx context.Context, plugin *address.Address, amount tlb.Coins, queryID uint64, waitConfirmation ...bool) error {
+	spec, ok := w.spec.(*SpecV4R2)
+	if !ok {
+		return ErrPluginsNotSupported
+	}
+
+	return w.sendBody(ctx, func(ctx context.Context, isInitialized bool, block *tlb.BlockInfo) (*cell.Cell, error) {
+		return spec.BuildInstallPluginMessage(ctx, isInitialized, block, plugin, amount, queryID)
+	}, waitConfirmation...)
+}
+
+// RemovePlugin - removes contract from the list of wallet's plugins,
+// after it plugin cannot request funds anymore
+func (w *Wallet) RemovePlugin(ctx context.Context, plugin *address.Address, amount tlb.Coins, queryID uint64, waitConfirmation ...bool) error {
+	spec, ok := w.spec.(*SpecV4R2)
+	if !ok {
+		return ErrPluginsNotSupported
+	}
+
+	return w.sendBody(ctx, func(ctx context.Context, isInitialized bool, block *tlb.BlockInfo) (*cell.Cell, error) {
+		return spec.BuildRemovePluginMessage(ctx, isInitialized, block, plugin, amount, queryID)
+	}, waitConfirmation...)
+}
+
+// DeployAndInstallPlugin - deploys plugin contract using the given state init and body,
+// and adds it to the list of wallet's plugins in the same transaction. Returns address of the plugin.
+func (w *Wallet) DeployAndInstallPlugin(ctx context.Context, workchain int8, balance tlb.Coins, stateInit *tlb.StateInit, body *cell.Cell, waitConfirmation ...bool) (*address.Address, error) {
+	spec, ok := w.spec.(*SpecV4R2)
+	if !ok {
+		return nil, ErrPluginsNotSupported
+	}
+
+	stateCell, err := stateInit.ToCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert plugin state init to cell: %w", err)
+	}
+
+	err = w.sendBody(ctx, func(ctx context.Context, isInitialized bool, block *tlb.BlockInfo) (*cell.Cell, error) {
+		return spec.BuildDeployAndInstallPluginMessage(ctx, isInitialized, block, workchain, balance, stateInit, body)
+	}, waitConfirmation...)
+	if err != nil {
+		return nil, err
+	}
+
+	return address.NewAddress(0, byte(workchain), stateCell.Hash()), nil
+}
+
+// GetPluginList - returns addresses of all plugins installed in the wallet
+func (w *Wallet) GetPluginList(ctx context.Context, block *tlb.BlockInfo) ([]*address.Address, error) {
+	if _, ok := w.spec.(*SpecV4R2); !ok {
+		return nil, ErrPluginsNotSupported
+	}
+
+	res, err := w.api.RunGetMethod(ctx, block, w.addr, "get_plugin_list")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run get_plugin_list method: %w", err)
+	}
+
+	if len(res) == 0 {
+		return nil, errors.New("empty result of get_plugin_list")
+	}
+
+	var list []*address.Address
+
+	// result is a lisp-style list: [[wc, addr_hash], [[wc, addr_hash], [..., null]]]
+	next := res[0]
+	for next != nil {
+		pair, ok := next.([]any)
+		if !ok || len(pair) != 2 {
+			return nil, errors.New("plugins list element is not a pair")
+		}
+
+		item, ok := pair[0].([]any)
+		if !ok || len(item) != 2 {
+			return nil, errors.New("plugin is not a pair of workchain and address")
+		}
+
+		wc, ok := item[0].(int64)
+		if !ok {
+			return nil, errors.New("plugin workchain is not an integer")
+		}
+
+		hash, err := stackUint256(item[1])
+		if err != nil {
+			return nil, fmt.Errorf("incorrect plugin address hash: %w", err)
+		}
+
+		list = append(list, address.NewAddress(0, byte(wc), hash))
+		next = pair[1]
+	}
+
+	return list, nil
+}
+
+// IsPluginInstalled - checks that contract is in the list of wallet's plugins
+func (w *Wallet) IsPluginInstalled(ctx context.Context, block *tlb.BlockInfo, plugin *address.Address) (bool, error) {
+	if _, ok := w.spec.(*SpecV4R2); !ok {
+		return false, ErrPluginsNotSupported
+	}
+
+	// contract stores workchain as signed 8 bit int, so masterchain should be passed as -1
+	res, err := w.api.RunGetMethod(ctx, block, w.addr, "is_plugin_installed",
+		int64(int8(plugin.Workchain())), new(big.Int).SetBytes(plugin.Data()))
+	if err != nil {
+		return false, fmt.Errorf("failed to run is_plugin_installed method: %w", err)
+	}
+
+	if len(res) == 0 {
+		return false, errors.New("empty result of is_plugin_installed")
+	}
+
+	installed, ok := res[0].(int64)
+	if !ok {
+		return false, errors.New("result of is_plugin_installed is not an integer")
+	}
+
+	return installed != 0, nil
+}
+
+// ParsePluginRequest - parses body of internal message which plugin sent to the wallet,
+// returns *PluginRequestFunds or *PluginRequestRemove, or ErrNotPluginRequest if message is something else.
+func ParsePluginRequest(msg *tlb.InternalMessage) (any, error) {
+	if msg.Body == nil {
+		return nil, ErrNotPluginRequest
+	}
+
+	op, err := msg.Body.BeginParse().LoadUInt(32)
+	if err != nil {
+		return nil, ErrNotPluginRequest
+	}
+
+	switch op {
+	case 0x706c7567:
+		var req PluginRequestFunds
+		if err = tlb.LoadFromCell(&req, msg.Body.BeginParse()); err != nil {
+			return nil, fmt.Errorf("failed to parse funds request: %w", err)
+		}
+		return &req, nil
+	case 0x64737472:
+		var req PluginRequestRemove
+		if err = tlb.LoadFromCell(&req, msg.Body.BeginParse()); err != nil {
+			return nil, fmt.Errorf("failed to parse remove request: %w", err)
+		}
+		return &req, nil
+	}
+
+	return nil, ErrNotPluginRequest
+}
+
+func stackUint256(v any) ([]byte, error) {
+	var val *big.Int
+	switch x := v.(type) {
+	case int64:
+		val = big.NewInt(x)
+	case *big.Int:
+		val = x
+	default:
+		return nil, errors.New("value is not an integer")
+	}
+
+	if val.Sign() < 0 || val.BitLen() > 256 {
+		return nil, errors.New("value is not uint256")
+	}
+
+	return val.FillBytes(make([]byte, 32)), nil
+}
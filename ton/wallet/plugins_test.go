@@ -0,0 +1,220 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestWallet_InstallRemovePlugin(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	m := &MockAPI{}
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	plugin := address.MustParseAddr("EQBL2_3lMiyywU17g-or8N7v9hDmPCpttzBPE2isF2GTzpK4")
+
+	w, err := FromPrivateKey(m, pkey, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.getBlockInfo = func(ctx context.Context) (*tlb.BlockInfo, error) {
+		return &tlb.BlockInfo{SeqNo: 2}, nil
+	}
+	m.getAccount = func(ctx context.Context, block *tlb.BlockInfo, addr *address.Address) (*tlb.Account, error) {
+		return &tlb.Account{
+			IsActive: true,
+			State: &tlb.AccountState{
+				IsValid: true,
+				AccountStorage: tlb.AccountStorage{
+					Status: tlb.AccountStatusActive,
+				},
+			},
+		}, nil
+	}
+	m.runGetMethod = func(ctx context.Context, blockInfo *tlb.BlockInfo, addr *address.Address, method string, params ...interface{}) ([]interface{}, error) {
+		return []interface{}{int64(5)}, nil
+	}
+
+	for _, op := range []uint64{_V4OpInstallPlugin, _V4OpRemovePlugin} {
+		m.sendExternalMessage = func(ctx context.Context, msg *tlb.ExternalMessage) error {
+			p := msg.Body.BeginParse()
+			sign := p.MustLoadSlice(512)
+
+			if !ed25519.Verify(pkey.Public().(ed25519.PublicKey), p.MustToCell().Hash(), sign) {
+				t.Fatal("sign incorrect")
+			}
+
+			if p.MustLoadUInt(32) != DefaultSubwallet {
+				t.Fatal("subwallet id incorrect")
+			}
+			if p.MustLoadUInt(32) != uint64(timeNow().Add(60*3*time.Second).UTC().Unix()) {
+				t.Fatal("expire incorrect")
+			}
+			if p.MustLoadUInt(32) != 5 {
+				t.Fatal("seqno incorrect")
+			}
+			if p.MustLoadUInt(8) != op {
+				t.Fatal("op incorrect")
+			}
+			if p.MustLoadInt(8) != 0 {
+				t.Fatal("workchain incorrect")
+			}
+			if !bytes.Equal(p.MustLoadSlice(256), plugin.Data()) {
+				t.Fatal("plugin addr incorrect")
+			}
+			if p.MustLoadBigCoins().Uint64() != 50000000 {
+				t.Fatal("amount incorrect")
+			}
+			if p.MustLoadUInt(64) != 777 {
+				t.Fatal("query id incorrect")
+			}
+			return nil
+		}
+
+		if op == _V4OpInstallPlugin {
+			err = w.InstallPlugin(context.Background(), plugin, tlb.MustFromTON("0.05"), 777)
+		} else {
+			err = w.RemovePlugin(context.Background(), plugin, tlb.MustFromTON("0.05"), 777)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w3, err := FromPrivateKey(m, pkey, V3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = w3.InstallPlugin(context.Background(), plugin, tlb.MustFromTON("0.05"), 777); err != ErrPluginsNotSupported {
+		t.Fatal("should be not supported", err)
+	}
+}
+
+func TestWallet_GetPluginList(t *testing.T) {
+	m := &MockAPI{}
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	w, err := FromPrivateKey(m, pkey, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash1 := bytes.Repeat([]byte{0xAB}, 32)
+	hash2 := bytes.Repeat([]byte{0x01}, 32)
+
+	m.runGetMethod = func(ctx context.Context, blockInfo *tlb.BlockInfo, addr *address.Address, method string, params ...interface{}) ([]interface{}, error) {
+		if method != "get_plugin_list" {
+			t.Fatal("incorrect method")
+		}
+
+		return []interface{}{
+			[]any{[]any{int64(0), new(big.Int).SetBytes(hash1)},
+				[]any{[]any{int64(-1), new(big.Int).SetBytes(hash2)}, nil}},
+		}, nil
+	}
+
+	list, err := w.GetPluginList(context.Background(), &tlb.BlockInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 2 {
+		t.Fatal("incorrect list len", len(list))
+	}
+
+	if list[0].String() != address.NewAddress(0, 0, hash1).String() {
+		t.Fatal("incorrect plugin 1")
+	}
+
+	if list[1].String() != address.NewAddress(0, 255, hash2).String() {
+		t.Fatal("incorrect plugin 2")
+	}
+}
+
+func TestWallet_IsPluginInstalled(t *testing.T) {
+	m := &MockAPI{}
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+
+	w, err := FromPrivateKey(m, pkey, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := bytes.Repeat([]byte{0xAB}, 32)
+
+	m.runGetMethod = func(ctx context.Context, blockInfo *tlb.BlockInfo, addr *address.Address, method string, params ...interface{}) ([]interface{}, error) {
+		if method != "is_plugin_installed" || len(params) != 2 {
+			t.Fatal("incorrect method")
+		}
+
+		// int8 range is checked by contract
+		if params[0].(int64) != -1 || params[1].(*big.Int).Cmp(new(big.Int).SetBytes(hash)) != 0 {
+			t.Fatal("incorrect params", params)
+		}
+		return []interface{}{int64(-1)}, nil
+	}
+
+	installed, err := w.IsPluginInstalled(context.Background(), &tlb.BlockInfo{}, address.NewAddress(0, 255, hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !installed {
+		t.Fatal("should be installed")
+	}
+}
+
+func TestParsePluginRequest(t *testing.T) {
+	body, err := tlb.ToCell(PluginRequestFunds{
+		QueryID: 123,
+		Amount: tlb.CurrencyCollection{
+			Coins: tlb.MustFromTON("1.5"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := ParsePluginRequest(&tlb.InternalMessage{Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	funds, ok := req.(*PluginRequestFunds)
+	if !ok {
+		t.Fatal("not funds request")
+	}
+
+	if funds.QueryID != 123 || funds.Amount.Coins.NanoTON().Uint64() != 1500000000 {
+		t.Fatal("incorrect funds request")
+	}
+
+	req, err = ParsePluginRequest(&tlb.InternalMessage{
+		Body: cell.BeginCell().MustStoreUInt(0x64737472, 32).MustStoreUInt(5, 64).EndCell(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rm, ok := req.(*PluginRequestRemove); !ok || rm.QueryID != 5 {
+		t.Fatal("incorrect remove request")
+	}
+
+	_, err = ParsePluginRequest(&tlb.InternalMessage{
+		Body: cell.BeginCell().MustStoreUInt(0, 32).MustStoreStringSnake("hello").EndCell(),
+	})
+	if err != ErrNotPluginRequest {
+		t.Fatal("should be not plugin request", err)
+	}
+}
@@ -0,0 +1,153 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+const _TonProofPrefix = "ton-proof-item-v2/"
+const _TonConnectPrefix = "ton-connect"
+
+// _ProofClockSkew - how much proof timestamp can be ahead of our clock
+const _ProofClockSkew = time.Minute
+
+var (
+	ErrProofExpired          = errors.New("proof is expired")
+	ErrProofFromFuture       = errors.New("proof timestamp is in the future")
+	ErrProofDomainNotAllowed = errors.New("proof domain is not allowed")
+	ErrProofStateNotMatch    = errors.New("state init is not match with address")
+	ErrProofInvalidSignature = errors.New("proof signature is invalid")
+)
+
+// Proof - ton_proof of TON Connect, which wallet signs to prove ownership of the address
+// https://github.com/ton-connect/docs/blob/main/requests-responses.md#address-proof-signature-ton_proof
+type Proof struct {
+	Timestamp uint64
+	Domain    string
+	Payload   string
+	Signature []byte
+
+	// Optional, wallet sends it in TON Connect response, it is needed for not yet deployed wallets
+	StateInit *tlb.StateInit
+}
+
+// ProofVerifier - checks ton_proof on the backend side
+type ProofVerifier struct {
+	api            TonAPI
+	ttl            time.Duration
+	allowedDomains []string
+}
+
+// NewProofVerifier - creates verifier which accepts proofs not older than ttl, signed for one of allowed domains.
+// If no domains passed, any domain is accepted.
+func NewProofVerifier(api TonAPI, ttl time.Duration, allowedDomains ...string) *ProofVerifier {
+	return &ProofVerifier{
+		api:            api,
+		ttl:            ttl,
+		allowedDomains: allowedDomains,
+	}
+}
+
+// Verify - checks that proof is signed by the owner of the address.
+// Public key is taken from the state init if wallet version is known, otherwise from get_public_key method of the contract.
+func (v *ProofVerifier) Verify(ctx context.Context, addr *address.Address, proof *Proof) error {
+	// otherwise proof could be replayed until its time comes
+	if proof.Timestamp > uint64(timeNow().Add(_ProofClockSkew).Unix()) {
+		return ErrProofFromFuture
+	}
+
+	if v.ttl > 0 && timeNow().Sub(time.Unix(int64(proof.Timestamp), 0)) > v.ttl {
+		return ErrProofExpired
+	}
+
+	if len(v.allowedDomains) > 0 {
+		allowed := false
+		for _, domain := range v.allowedDomains {
+			if domain == proof.Domain {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return ErrProofDomainNotAllowed
+		}
+	}
+
+	var key ed25519.PublicKey
+	if proof.StateInit != nil {
+		stateCell, err := proof.StateInit.ToCell()
+		if err != nil {
+			return fmt.Errorf("failed to convert state init to cell: %w", err)
+		}
+
+		if !bytes.Equal(stateCell.Hash(), addr.Data()) {
+			return ErrProofStateNotMatch
+		}
+
+		if ver, err := GetVersionByCode(proof.StateInit.Code); err == nil {
+			data, err := ParseData(ver, proof.StateInit.Data)
+			if err != nil {
+				return fmt.Errorf("failed to parse wallet data: %w", err)
+			}
+			key = data.PublicKey
+		}
+	}
+
+	if key == nil {
+		block, err := v.api.CurrentMasterchainInfo(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get block: %w", err)
+		}
+
+		key, err = GetPublicKey(ctx, v.api, block, addr)
+		if err != nil {
+			return fmt.Errorf("failed to get public key: %w", err)
+		}
+	}
+
+	if !ed25519.Verify(key, proof.SignedHash(addr), proof.Signature) {
+		return ErrProofInvalidSignature
+	}
+
+	return nil
+}
+
+// SignedHash - builds hash of the proof message, which is signed by the wallet
+func (p *Proof) SignedHash(addr *address.Address) []byte {
+	// workchain is stored as byte in address, so we restore its sign
+	wc := make([]byte, 4)
+	binary.BigEndian.PutUint32(wc, uint32(int32(int8(addr.Workchain()))))
+
+	domainLen := make([]byte, 4)
+	binary.LittleEndian.PutUint32(domainLen, uint32(len(p.Domain)))
+
+	ts := make([]byte, 8)
+	binary.LittleEndian.PutUint64(ts, p.Timestamp)
+
+	var msg []byte
+	msg = append(msg, _TonProofPrefix...)
+	msg = append(msg, wc...)
+	msg = append(msg, addr.Data()...)
+	msg = append(msg, domainLen...)
+	msg = append(msg, p.Domain...)
+	msg = append(msg, ts...)
+	msg = append(msg, p.Payload...)
+
+	msgHash := sha256.Sum256(msg)
+
+	full := append([]byte{0xff, 0xff}, _TonConnectPrefix...)
+	full = append(full, msgHash[:]...)
+
+	hash := sha256.Sum256(full)
+	return hash[:]
+}
@@ -0,0 +1,108 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+func TestProofVerifier_Verify(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1000000, 0)
+	}
+
+	m := &MockAPI{}
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	pub := pkey.Public().(ed25519.PublicKey)
+
+	state, err := GetStateInit(pub, V4R2, DefaultSubwallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr, err := AddressFromPubKey(pub, V4R2, DefaultSubwallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newProof := func() *Proof {
+		p := &Proof{
+			Timestamp: uint64(timeNow().Unix() - 10),
+			Domain:    "example.com",
+			Payload:   "some-nonce",
+			StateInit: state,
+		}
+		p.Signature = ed25519.Sign(pkey, p.SignedHash(addr))
+		return p
+	}
+
+	v := NewProofVerifier(m, time.Minute, "example.com")
+
+	if err = v.Verify(context.Background(), addr, newProof()); err != nil {
+		t.Fatal(err)
+	}
+
+	p := newProof()
+	p.Payload = "other-nonce"
+	if err = v.Verify(context.Background(), addr, p); err != ErrProofInvalidSignature {
+		t.Fatal("signature should be invalid", err)
+	}
+
+	p = newProof()
+	p.Timestamp -= 120
+	p.Signature = ed25519.Sign(pkey, p.SignedHash(addr))
+	if err = v.Verify(context.Background(), addr, p); err != ErrProofExpired {
+		t.Fatal("should be expired", err)
+	}
+
+	p = newProof()
+	p.Timestamp += 365 * 24 * 3600
+	p.Signature = ed25519.Sign(pkey, p.SignedHash(addr))
+	if err = v.Verify(context.Background(), addr, p); err != ErrProofFromFuture {
+		t.Fatal("proof from future should be rejected", err)
+	}
+
+	// small clock difference is allowed
+	p = newProof()
+	p.Timestamp += 30
+	p.Signature = ed25519.Sign(pkey, p.SignedHash(addr))
+	if err = v.Verify(context.Background(), addr, p); err != nil {
+		t.Fatal(err)
+	}
+
+	p = newProof()
+	p.Domain = "evil.com"
+	p.Signature = ed25519.Sign(pkey, p.SignedHash(addr))
+	if err = v.Verify(context.Background(), addr, p); err != ErrProofDomainNotAllowed {
+		t.Fatal("domain should be not allowed", err)
+	}
+
+	otherAddr, _ := AddressFromPubKey(pub, V3, DefaultSubwallet)
+	p = newProof()
+	p.Signature = ed25519.Sign(pkey, p.SignedHash(otherAddr))
+	if err = v.Verify(context.Background(), otherAddr, p); err != ErrProofStateNotMatch {
+		t.Fatal("state should not match", err)
+	}
+
+	// without state init key is taken from contract
+	m.getBlockInfo = func(ctx context.Context) (*tlb.BlockInfo, error) {
+		return &tlb.BlockInfo{}, nil
+	}
+	m.runGetMethod = func(ctx context.Context, blockInfo *tlb.BlockInfo, a *address.Address, method string, params ...interface{}) ([]interface{}, error) {
+		if method != "get_public_key" || a.String() != addr.String() {
+			t.Fatal("incorrect get method call")
+		}
+		return []interface{}{new(big.Int).SetBytes(pub)}, nil
+	}
+
+	p = newProof()
+	p.StateInit = nil
+	if err = v.Verify(context.Background(), addr, p); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -2,6 +2,7 @@ package wallet
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
@@ -24,3 +25,27 @@ type SpecRegular struct {
 func (s *SpecRegular) SetMessagesTTL(ttl uint32) {
 	s.messagesTTL = ttl
 }
+
+// fetchSeqno - returns seqno for the next message, it is 0 for not yet initialized wallet
+func (s *SpecRegular) fetchSeqno(ctx context.Context, isInitialized bool, block *tlb.BlockInfo) (uint64, error) {
+	if !isInitialized {
+		return 0, nil
+	}
+
+	resp, err := s.wallet.api.RunGetMethod(ctx, block, s.wallet.addr, "seqno")
+	if err != nil {
+		return 0, fmt.Errorf("get seqno err: %w", err)
+	}
+
+	iSeq, ok := resp[0].(int64)
+	if !ok {
+		return 0, fmt.Errorf("seqno is not an integer")
+	}
+	return uint64(iSeq), nil
+}
+
+// sign - signs payload with wallet's key and builds external message body from it
+func (s *SpecRegular) sign(payload *cell.Builder) *cell.Cell {
+	sign := payload.EndCell().Sign(s.wallet.key)
+	return cell.BeginCell().MustStoreSlice(sign, 512).MustStoreBuilder(payload).EndCell()
+}
@@ -0,0 +1,184 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// SendResult - outcome of the transaction in which wallet processed sent external message
+type SendResult struct {
+	Transaction *tlb.Transaction
+	Description *tlb.TransactionDescriptionOrdinary
+
+	// Compute phase of wallet contract, exit code is non-zero when contract has thrown an error
+	ComputePhase tlb.ComputePhase
+	// Action phase, nil if compute phase was not successful
+	ActionPhase *tlb.ActionPhase
+
+	// Internal messages which were sent by wallet in this transaction
+	OutMessages []*tlb.InternalMessage
+
+	// True if compute and action phases were successful and transaction was not aborted,
+	// it means that out messages were sent. It says nothing about bounces: they are processed
+	// in later transactions and are not included in the result, use FindBounced to check them.
+	Success bool
+}
+
+func parseSendResult(tx *tlb.Transaction) (*SendResult, error) {
+	desc, err := tx.ParseDescription()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse transaction description: %w", err)
+	}
+
+	res := &SendResult{
+		Transaction:  tx,
+		Description:  desc,
+		ComputePhase: desc.ComputePhase,
+		ActionPhase:  desc.ActionPhase,
+	}
+
+	res.Success = !desc.Aborted && !desc.ComputePhase.Skipped && desc.ComputePhase.Success &&
+		desc.ActionPhase != nil && desc.ActionPhase.Success
+
+	for _, m := range tx.IO.Out {
+		if m.MsgType == tlb.MsgTypeInternal {
+			res.OutMessages = append(res.OutMessages, m.AsInternal())
+		}
+	}
+
+	return res, nil
+}
+
+// _MaxBounceScanTx - max number of wallet transactions after the send which are checked for bounces
+const _MaxBounceScanTx = 100
+
+var ErrTooManyTransactions = errors.New("too many transactions after the send to look for bounces")
+
+// FindBounced - looks for bounced messages which came back to wallet in transactions after the given send result.
+// Bounce arrives in a separate transaction, so it may be not processed yet right after the send,
+// in this case call it again later. Bounce is matched by its body, which contains the beginning of the sent body.
+// If wallet has more than 100 transactions after the send, ErrTooManyTransactions is returned.
+func (w *Wallet) FindBounced(ctx context.Context, res *SendResult) ([]*tlb.InternalMessage, error) {
+	var waiting []*tlb.InternalMessage
+	for _, out := range res.OutMessages {
+		if out.Bounce {
+			waiting = append(waiting, out)
+		}
+	}
+
+	if len(waiting) == 0 {
+		return nil, nil
+	}
+
+	block, err := w.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+
+	acc, err := w.api.GetAccount(ctx, block, w.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	// collect transactions after the send, newest first
+	var txs []*tlb.Transaction
+	lastLt, lastHash := acc.LastTxLT, acc.LastTxHash
+	for lastLt > res.Transaction.LT {
+		if len(txs) >= _MaxBounceScanTx {
+			return nil, ErrTooManyTransactions
+		}
+
+		txList, err := w.api.ListTransactions(ctx, w.addr, 10, lastLt, lastHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list transactions: %w", err)
+		}
+
+		if len(txList) == 0 {
+			break
+		}
+
+		// the oldest one is first
+		for i := len(txList) - 1; i >= 0; i-- {
+			if txList[i].LT > res.Transaction.LT {
+				txs = append(txs, txList[i])
+			}
+		}
+
+		lastLt, lastHash = txList[0].PrevTxLT, txList[0].PrevTxHash
+	}
+
+	var bounced []*tlb.InternalMessage
+	for i := len(txs) - 1; i >= 0 && len(waiting) > 0; i-- {
+		tx := txs[i]
+		if tx.IO.In == nil || tx.IO.In.MsgType != tlb.MsgTypeInternal {
+			continue
+		}
+
+		in := tx.IO.In.AsInternal()
+		if !in.Bounced {
+			continue
+		}
+
+		for j, out := range waiting {
+			if isBounceOf(in, out) {
+				bounced = append(bounced, in)
+				// each sent message can bounce only once
+				waiting = append(waiting[:j], waiting[j+1:]...)
+				break
+			}
+		}
+	}
+
+	return bounced, nil
+}
+
+// isBounceOf - checks that bounced message came from the destination of sent message after it,
+// and its body is 0xFFFFFFFF followed by the first 256 bits of the sent message body.
+func isBounceOf(in, out *tlb.InternalMessage) bool {
+	if in.CreatedLT <= out.CreatedLT || in.SrcAddr == nil || out.DstAddr == nil ||
+		in.SrcAddr.String() != out.DstAddr.String() || in.Body == nil {
+		return false
+	}
+
+	body := in.Body.BeginParse()
+	if prefix, err := body.LoadUInt(32); err != nil || prefix != 0xFFFFFFFF {
+		return false
+	}
+
+	orig := cell.BeginCell().EndCell().BeginParse()
+	if out.Body != nil {
+		orig = out.Body.BeginParse()
+	}
+
+	left := orig.BitsLeft()
+	if left > 256 {
+		left = 256
+	}
+
+	if body.BitsLeft() < left {
+		return false
+	}
+
+	for left > 0 {
+		sz := left
+		if sz > 64 {
+			sz = 64
+		}
+
+		a, err := body.LoadUInt(sz)
+		if err != nil {
+			return false
+		}
+
+		b, err := orig.LoadUInt(sz)
+		if err != nil || a != b {
+			return false
+		}
+		left -= sz
+	}
+	return true
+}
@@ -0,0 +1,213 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func testTxDescription(exitCode int64, actionSuccess bool) *cell.Cell {
+	computeDetails := cell.BeginCell().
+		MustStoreUInt(1, 3).MustStoreUInt(100, 8). // gas used
+		MustStoreUInt(1, 3).MustStoreUInt(100, 8). // gas limit
+		MustStoreBoolBit(false).
+		MustStoreInt(0, 8).
+		MustStoreInt(exitCode, 32).
+		MustStoreBoolBit(false).
+		MustStoreUInt(10, 32).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreSlice(make([]byte, 32), 256).
+		EndCell()
+
+	action := cell.BeginCell().
+		MustStoreBoolBit(actionSuccess).MustStoreBoolBit(true).MustStoreBoolBit(false).
+		MustStoreUInt(0, 1).
+		MustStoreBoolBit(false).MustStoreBoolBit(false).
+		MustStoreInt(0, 32).MustStoreBoolBit(false).
+		MustStoreUInt(1, 16).MustStoreUInt(0, 16).MustStoreUInt(0, 16).MustStoreUInt(1, 16).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreUInt(0, 3).MustStoreUInt(0, 3).
+		EndCell()
+
+	return cell.BeginCell().
+		MustStoreUInt(0, 4).
+		MustStoreBoolBit(false).
+		MustStoreBoolBit(false).
+		MustStoreBoolBit(false).
+		MustStoreBoolBit(true).
+		MustStoreBoolBit(exitCode == 0).MustStoreBoolBit(false).MustStoreBoolBit(false).
+		MustStoreCoins(1000).
+		MustStoreRef(computeDetails).
+		MustStoreMaybeRef(action).
+		MustStoreBoolBit(false).
+		MustStoreBoolBit(false).
+		MustStoreBoolBit(false).
+		EndCell()
+}
+
+func TestWallet_SendManyWaitTransaction(t *testing.T) {
+	m := &MockAPI{}
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	dst := address.MustParseAddr("EQBL2_3lMiyywU17g-or8N7v9hDmPCpttzBPE2isF2GTzpK4")
+
+	w, err := FromPrivateKey(m, pkey, V3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seqno := uint32(1)
+	m.getBlockInfo = func(ctx context.Context) (*tlb.BlockInfo, error) {
+		seqno++
+		return &tlb.BlockInfo{SeqNo: seqno}, nil
+	}
+
+	lastLT := uint64(10)
+	m.getAccount = func(ctx context.Context, block *tlb.BlockInfo, addr *address.Address) (*tlb.Account, error) {
+		return &tlb.Account{
+			IsActive: true,
+			State: &tlb.AccountState{
+				IsValid: true,
+				AccountStorage: tlb.AccountStorage{
+					Status: tlb.AccountStatusActive,
+				},
+			},
+			LastTxLT:   lastLT,
+			LastTxHash: make([]byte, 32),
+		}, nil
+	}
+	m.runGetMethod = func(ctx context.Context, blockInfo *tlb.BlockInfo, addr *address.Address, method string, params ...interface{}) ([]interface{}, error) {
+		return []interface{}{int64(1)}, nil
+	}
+	m.sendExternalMessage = func(ctx context.Context, msg *tlb.ExternalMessage) error {
+		m.extMsgSent = msg
+		lastLT = 20
+		return nil
+	}
+
+	outBody := cell.BeginCell().MustStoreUInt(0x1234, 32).MustStoreUInt(777, 64).EndCell()
+	outMsg := &tlb.InternalMessage{
+		Bounce:    true,
+		SrcAddr:   w.Address(),
+		DstAddr:   dst,
+		Amount:    tlb.MustFromTON("1"),
+		Body:      outBody,
+		CreatedLT: 21,
+	}
+
+	m.listTransactions = func(ctx context.Context, addr *address.Address, limit uint32, lt uint64, txHash []byte) ([]*tlb.Transaction, error) {
+		tx := &tlb.Transaction{
+			LT:          20,
+			PrevTxLT:    10,
+			PrevTxHash:  make([]byte, 32),
+			Description: testTxDescription(0, true),
+		}
+		tx.IO.In = &tlb.Message{MsgType: tlb.MsgTypeExternalIn, Msg: m.extMsgSent}
+		tx.IO.Out = []*tlb.Message{{MsgType: tlb.MsgTypeInternal, Msg: outMsg}}
+
+		if lt == 20 {
+			return []*tlb.Transaction{tx}, nil
+		}
+
+		// bounce of the sent message
+		bounceTx := &tlb.Transaction{
+			LT:       30,
+			PrevTxLT: 20,
+		}
+		bounceTx.IO.In = &tlb.Message{MsgType: tlb.MsgTypeInternal, Msg: &tlb.InternalMessage{
+			Bounced:   true,
+			SrcAddr:   dst,
+			DstAddr:   w.Address(),
+			Body:      cell.BeginCell().MustStoreUInt(0xFFFFFFFF, 32).MustStoreBuilder(outBody.ToBuilder()).EndCell(),
+			CreatedLT: 25,
+		}}
+
+		// bounce of another message to the same contract
+		otherTx := &tlb.Transaction{
+			LT:       40,
+			PrevTxLT: 30,
+		}
+		otherTx.IO.In = &tlb.Message{MsgType: tlb.MsgTypeInternal, Msg: &tlb.InternalMessage{
+			Bounced:   true,
+			SrcAddr:   dst,
+			DstAddr:   w.Address(),
+			Body:      cell.BeginCell().MustStoreUInt(0xFFFFFFFF, 32).MustStoreUInt(0x1234, 32).MustStoreUInt(778, 64).EndCell(),
+			CreatedLT: 35,
+		}}
+		return []*tlb.Transaction{tx, bounceTx, otherTx}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := w.SendWaitTransaction(ctx, SimpleMessage(dst, tlb.MustFromTON("1"), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !res.Success || res.ComputePhase.ExitCode != 0 || res.Transaction.LT != 20 {
+		t.Fatal("incorrect result")
+	}
+
+	if len(res.OutMessages) != 1 || res.OutMessages[0].DstAddr.String() != dst.String() {
+		t.Fatal("incorrect out messages")
+	}
+
+	lastLT = 40
+	bounced, err := w.FindBounced(ctx, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bounced) != 1 || bounced[0].CreatedLT != 25 {
+		t.Fatal("bounce not found")
+	}
+
+	res, err = parseSendResult(&tlb.Transaction{Description: testTxDescription(0, false)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Success {
+		t.Fatal("should be not successful")
+	}
+}
+
+func TestIsBounceOf(t *testing.T) {
+	dst := address.MustParseAddr("EQBL2_3lMiyywU17g-or8N7v9hDmPCpttzBPE2isF2GTzpK4")
+
+	body := cell.BeginCell().MustStoreSlice(make([]byte, 40), 320).MustStoreRef(cell.BeginCell().EndCell()).EndCell()
+	out := &tlb.InternalMessage{Bounce: true, DstAddr: dst, Body: body, CreatedLT: 10}
+
+	// bounced body has only first 256 bits of the original one
+	in := &tlb.InternalMessage{
+		Bounced:   true,
+		SrcAddr:   dst,
+		Body:      cell.BeginCell().MustStoreUInt(0xFFFFFFFF, 32).MustStoreSlice(make([]byte, 32), 256).EndCell(),
+		CreatedLT: 11,
+	}
+
+	if !isBounceOf(in, out) {
+		t.Fatal("should be bounce")
+	}
+
+	in.CreatedLT = 9
+	if isBounceOf(in, out) {
+		t.Fatal("bounce can not be created before message")
+	}
+
+	in.CreatedLT = 11
+	in.Body = cell.BeginCell().MustStoreUInt(0xFFFFFFFF, 32).MustStoreSlice(make([]byte, 31), 248).MustStoreUInt(1, 8).EndCell()
+	if isBounceOf(in, out) {
+		t.Fatal("body not match")
+	}
+
+	in.Body = cell.BeginCell().MustStoreUInt(0, 32).MustStoreSlice(make([]byte, 32), 256).EndCell()
+	if isBounceOf(in, out) {
+		t.Fatal("no bounce prefix")
+	}
+}
@@ -8,11 +8,33 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
 
 	"golang.org/x/crypto/pbkdf2"
 )
 
+var (
+	ErrInvalidSeed           = errors.New("invalid seed")
+	ErrSeedPasswordRequired  = errors.New("seed is protected with password")
+	ErrSeedPasswordNotNeeded = errors.New("seed is not protected with password")
+)
+
+// UnknownWordError - seed contains word which is not in the wordlist
+type UnknownWordError struct {
+	Word     string
+	Position int
+	// Similar words from the wordlist, can be empty
+	Suggestions []string
+}
+
+func (e *UnknownWordError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("unknown word '%s' in seed at position %d", e.Word, e.Position+1)
+	}
+	return fmt.Sprintf("unknown word '%s' in seed at position %d, maybe you mean: %s", e.Word, e.Position+1, strings.Join(e.Suggestions, ", "))
+}
+
 const (
 	_Iterations   = 100000
 	_Salt         = "TON default seed"
@@ -34,71 +56,193 @@ func NewSeedWithPassword(password string) []string {
 	for {
 		seed := make([]string, 24)
 		for i := 0; i < 24; i++ {
-			for {
-				x, err := rand.Int(rand.Reader, big.NewInt(int64(len(seed))))
-				if err != nil {
-					continue
-				}
-
-				seed[i] = wordsArr[x.Uint64()]
-				break
-			}
+			seed[i] = randomWord(wordsArr)
 		}
 
-		mac := hmac.New(sha512.New, []byte(strings.Join(seed, " ")))
-		mac.Write([]byte(password))
-		hash := mac.Sum(nil)
+		// cheap check first, most of the seeds are rejected by it
+		if len(password) > 0 && !isPasswordSeed(seedHash(seed, "")) {
+			continue
+		}
 
-		if len(password) > 0 {
-			p := pbkdf2.Key(hash, []byte(_PasswordSalt), 1, 1, sha512.New)
-			if p[0] != 1 {
-				continue
-			}
-		} else {
-			p := pbkdf2.Key(hash, []byte(_BasicSalt), _Iterations/256, 1, sha512.New)
-			if p[0] != 0 {
-				continue
-			}
+		if checkSeedPassword(seed, password) != nil {
+			continue
 		}
 
 		return seed
 	}
 }
 
+// randomWord - picks word from the whole list with crypto random
+func randomWord(list []string) string {
+	for {
+		x, err := rand.Int(rand.Reader, big.NewInt(int64(len(list))))
+		if err != nil {
+			continue
+		}
+		return list[x.Uint64()]
+	}
+}
+
 func FromSeed(api TonAPI, seed []string, version Version) (*Wallet, error) {
 	return FromSeedWithPassword(api, seed, "", version)
 }
 
 func FromSeedWithPassword(api TonAPI, seed []string, password string, version Version) (*Wallet, error) {
-	// validate seed
+	key, err := SeedToPrivateKey(seed, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromPrivateKey(api, key, version)
+}
+
+// SeedToPrivateKey - derives wallet private key from seed phrase, password can be empty.
+// Seeds with password are checked with TON rules, same as ValidateSeed does. Seeds generated with password
+// by older versions of this library used another rule, they are still accepted, so keys of existing wallets
+// can be derived. Legacy rule is weak, it accepts 1 of 256 wrong passwords, use ValidateSeed for strict check.
+func SeedToPrivateKey(seed []string, password string) (ed25519.PrivateKey, error) {
+	if err := ValidateSeed(seed, password); err != nil {
+		if !isLegacyPasswordSeed(seed, password, err) {
+			return nil, err
+		}
+	}
+
+	k := pbkdf2.Key(seedHash(seed, password), []byte(_Salt), _Iterations, 32, sha512.New)
+
+	return ed25519.NewKeyFromSeed(k), nil
+}
+
+// ValidateSeed - checks that seed phrase is a valid TON mnemonic for the given password, password can be empty.
+// Returns *UnknownWordError for misspelled words, ErrSeedPasswordRequired if seed is protected with password,
+// but it is not passed, ErrSeedPasswordNotNeeded if seed has no password, but it is passed,
+// and ErrInvalidSeed if checksum is not match, or password is wrong.
+// Password seeds generated by older versions of this library can fail this check, see SeedToPrivateKey.
+func ValidateSeed(seed []string, password string) error {
 	if len(seed) < 12 {
-		return nil, fmt.Errorf("seed should have at least 12 words")
+		return fmt.Errorf("seed should have at least 12 words")
 	}
-	for _, s := range seed {
+	for i, s := range seed {
 		if !words[s] {
-			return nil, fmt.Errorf("unknown word '%s' in seed", s)
+			return &UnknownWordError{
+				Word:        s,
+				Position:    i,
+				Suggestions: SuggestSeedWords(s),
+			}
 		}
 	}
 
-	mac := hmac.New(sha512.New, []byte(strings.Join(seed, " ")))
-	mac.Write([]byte(password))
-	hash := mac.Sum(nil)
+	return checkSeedPassword(seed, password)
+}
+
+// checkSeedPassword - checks seed with the same rules as TON wallets use, seed needs password
+// when it is marked as password seed and it is not basic, and it is valid with password
+// when hash with this password is basic.
+func checkSeedPassword(seed []string, password string) error {
+	noPassHash := seedHash(seed, "")
+	passwordNeeded := isPasswordSeed(noPassHash) && !isBasicSeed(noPassHash)
 
 	if len(password) > 0 {
-		p := pbkdf2.Key(hash, []byte(_PasswordSalt), 1, 1, sha512.New)
-		if p[0] != 1 {
-			return nil, errors.New("invalid seed")
+		if !passwordNeeded {
+			if isBasicSeed(noPassHash) {
+				return ErrSeedPasswordNotNeeded
+			}
+			return ErrInvalidSeed
+		}
+
+		if !isBasicSeed(seedHash(seed, password)) {
+			return ErrInvalidSeed
 		}
-	} else {
-		p := pbkdf2.Key(hash, []byte(_BasicSalt), _Iterations/256, 1, sha512.New)
-		if p[0] != 0 {
-			return nil, errors.New("invalid seed")
+		return nil
+	}
+
+	if passwordNeeded {
+		return ErrSeedPasswordRequired
+	}
+
+	if !isBasicSeed(noPassHash) {
+		return ErrInvalidSeed
+	}
+	return nil
+}
+
+// isLegacyPasswordSeed - checks seed with password by the rule which was used before TON rules,
+// seed was accepted when hash with password is marked as password seed
+func isLegacyPasswordSeed(seed []string, password string, err error) bool {
+	if len(password) == 0 || (err != ErrInvalidSeed && err != ErrSeedPasswordNotNeeded) {
+		return false
+	}
+	return isPasswordSeed(seedHash(seed, password))
+}
+
+// SuggestSeedWords - returns up to 5 words from wordlist which are the most similar to the given one
+func SuggestSeedWords(word string) []string {
+	type candidate struct {
+		word string
+		dist int
+	}
+
+	var list []candidate
+	for w := range words {
+		dist := levenshtein(word, w)
+		if dist <= 2 || (len(word) >= 3 && strings.HasPrefix(w, word)) {
+			list = append(list, candidate{w, dist})
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].dist == list[j].dist {
+			return list[i].word < list[j].word
 		}
+		return list[i].dist < list[j].dist
+	})
+
+	var res []string
+	for i := 0; i < len(list) && i < 5; i++ {
+		res = append(res, list[i].word)
 	}
+	return res
+}
 
-	k := pbkdf2.Key(hash, []byte(_Salt), _Iterations, 32, sha512.New)
+func seedHash(seed []string, password string) []byte {
+	mac := hmac.New(sha512.New, []byte(strings.Join(seed, " ")))
+	mac.Write([]byte(password))
+	return mac.Sum(nil)
+}
+
+func isBasicSeed(hash []byte) bool {
+	return pbkdf2.Key(hash, []byte(_BasicSalt), _Iterations/256, 1, sha512.New)[0] == 0
+}
+
+func isPasswordSeed(hash []byte) bool {
+	return pbkdf2.Key(hash, []byte(_PasswordSalt), 1, 1, sha512.New)[0] == 1
+}
 
-	return FromPrivateKey(api, ed25519.NewKeyFromSeed(k), version)
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
 }
 
 var words = map[string]bool{
@@ -1,6 +1,12 @@
 package wallet
 
 import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -11,7 +17,7 @@ func TestNewSeedWithPassword(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, err = FromSeedWithPassword(nil, seed, "1234", V3)
+	_, err = FromSeedWithPassword(nil, seed, wrongPassword(seed), V3)
 	if err == nil {
 		t.Fatal("should be invalid")
 	}
@@ -40,8 +46,131 @@ func TestNewSeedWithPassword(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, err = FromSeedWithPassword(nil, seedNoPass, "123", V3)
+	_, err = FromSeedWithPassword(nil, seedNoPass, wrongPassword(seedNoPass), V3)
 	if err == nil {
 		t.Fatal("should be invalid")
 	}
 }
+
+// wrongPassword - both TON and legacy rules accept 1 of 256 wrong passwords, so we pick the one which they reject
+func wrongPassword(seed []string) string {
+	for i := 1234; ; i++ {
+		pass := strconv.Itoa(i)
+		if hash := seedHash(seed, pass); !isPasswordSeed(hash) && !isBasicSeed(hash) {
+			return pass
+		}
+	}
+}
+
+func TestSeedToPrivateKey_Legacy(t *testing.T) {
+	// generated by NewSeedWithPassword("secret") of previous version, it does not satisfy TON rules
+	seed := strings.Split("page bar forest trend page bar collect miracle ivory blue around collect "+
+		"welcome present trend page around accident debate collect bar month blue blue", " ")
+
+	if err := ValidateSeed(seed, "secret"); err != ErrInvalidSeed {
+		t.Fatal("should be invalid with TON rules", err)
+	}
+
+	key, err := SeedToPrivateKey(seed, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex.EncodeToString(key.Public().(ed25519.PublicKey)) != "404d74b890a1de4529700c01bdffd06a2bca079812c4c13d09e6e89572a5bd97" {
+		t.Fatal("wrong key")
+	}
+
+	if _, err = SeedToPrivateKey(seed, wrongPassword(seed)); err != ErrInvalidSeed {
+		t.Fatal("wrong password should be rejected", err)
+	}
+}
+
+func TestValidateSeed(t *testing.T) {
+	seed := NewSeed()
+	if err := ValidateSeed(seed, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ValidateSeed(seed, "123"); err != ErrSeedPasswordNotNeeded {
+		t.Fatal("should be not needed", err)
+	}
+
+	seedPass := NewSeedWithPassword("123")
+	if err := ValidateSeed(seedPass, "123"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ValidateSeed(seedPass, wrongPassword(seedPass)); err != ErrInvalidSeed {
+		t.Fatal("should be invalid", err)
+	}
+
+	if err := ValidateSeed(seedPass, ""); !errors.Is(err, ErrSeedPasswordRequired) {
+		t.Fatal("should require password", err)
+	}
+
+	wrong := append([]string{}, seed...)
+	wrong[5] = "abandn"
+
+	err := ValidateSeed(wrong, "")
+	unkErr, ok := err.(*UnknownWordError)
+	if !ok {
+		t.Fatal("should be unknown word", err)
+	}
+
+	if unkErr.Position != 5 || unkErr.Word != "abandn" || len(unkErr.Suggestions) == 0 || unkErr.Suggestions[0] != "abandon" {
+		t.Fatal("incorrect unknown word error", unkErr.Position, unkErr.Suggestions)
+	}
+
+	// find seed which is neither basic nor password protected
+	for {
+		wrong = NewSeed()
+		wrong[0], wrong[1] = wrong[1], wrong[0]
+
+		if h := seedHash(wrong, ""); !isBasicSeed(h) && !isPasswordSeed(h) {
+			break
+		}
+	}
+
+	if err = ValidateSeed(wrong, ""); err != ErrInvalidSeed {
+		t.Fatal("should be invalid", err)
+	}
+}
+
+func TestSuggestSeedWords(t *testing.T) {
+	if s := SuggestSeedWords("zoo"); len(s) == 0 || s[0] != "zoo" {
+		t.Fatal("exact word should be first", s)
+	}
+
+	if s := SuggestSeedWords("abstrct"); len(s) == 0 || s[0] != "abstract" {
+		t.Fatal("incorrect suggestion", s)
+	}
+
+	if s := SuggestSeedWords("qqqqqqqqqq"); len(s) != 0 {
+		t.Fatal("should be no suggestions", s)
+	}
+}
+
+func TestRandomWord(t *testing.T) {
+	list := make([]string, 0, len(words))
+	for w := range words {
+		list = append(list, w)
+	}
+	sort.Strings(list)
+
+	index := map[string]int{}
+	for i, w := range list {
+		index[w] = i
+	}
+
+	// words should be picked from the whole list, not only from its first 24 entries
+	maxIndex := 0
+	for i := 0; i < 200; i++ {
+		if x := index[randomWord(list)]; x > maxIndex {
+			maxIndex = x
+		}
+	}
+
+	if maxIndex < 24 {
+		t.Fatal("words are picked only from the beginning of the list", maxIndex)
+	}
+}
@@ -0,0 +1,44 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// https://github.com/toncenter/tonweb/blob/master/src/contract/wallet/WalletSources.md#v1-wallet
+const _V1R1CodeHex = "B5EE9C72410101010044000084FF0020DDA4F260810200D71820D70B1FED44D0D31FD3FFD15112BAF2A122F901541044F910F2A2F80001D31F3120D74A96D307D402FB00DED1A4C8CB1FCBFFC9ED5441FDF089"
+const _V1R2CodeHex = "B5EE9C724101010100530000A2FF0020DD2082014C97BA9730ED44D0D70B1FE0A4F260810200D71820D70B1FED44D0D31FD3FFD15112BAF2A122F901541044F910F2A2F80001D31F3120D74A96D307D402FB00DED1A4C8CB1FCBFFC9ED54D0E2786F"
+const _V1R3CodeHex = "B5EE9C7241010101005F0000BAFF0020DD2082014C97BA218201339CBAB19C71B0ED44D0D31FD70BFFE304E0A4F260810200D71820D70B1FED44D0D31FD3FFD15112BAF2A122F901541044F910F2A2F80001D31F3120D74A96D307D402FB00DED1A4C8CB1FCBFFC9ED54B5B86E42"
+
+// SpecV1 - simple wallet without subwallet id and expiration time, can send only one message at a time
+type SpecV1 struct {
+	SpecRegular
+}
+
+func (s *SpecV1) BuildMessage(ctx context.Context, isInitialized bool, block *tlb.BlockInfo, messages []*Message) (*cell.Cell, error) {
+	if len(messages) > 1 {
+		return nil, errors.New("for this type of wallet max 1 message can be sent in the same time")
+	}
+
+	seq, err := s.fetchSeqno(ctx, isInitialized, block)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := cell.BeginCell().MustStoreUInt(seq, 32)
+
+	for i, message := range messages {
+		intMsg, err := message.InternalMessage.ToCell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert internal message %d to cell: %w", i, err)
+		}
+
+		payload.MustStoreUInt(uint64(message.Mode), 8).MustStoreRef(intMsg)
+	}
+
+	return s.sign(payload), nil
+}
@@ -0,0 +1,45 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// https://github.com/toncenter/tonweb/blob/master/src/contract/wallet/WalletSources.md#v2-wallet
+const _V2R1CodeHex = "B5EE9C724101010100570000AAFF0020DD2082014C97BA9730ED44D0D70B1FE0A4F2608308D71820D31FD31F01F823BBF263ED44D0D31FD3FFD15131BAF2A103F901541042F910F2A2F800029320D74A96D307D402FB00E8D1A4C8CB1FCBFFC9ED54A1370BB6"
+const _V2R2CodeHex = "B5EE9C724101010100630000C2FF0020DD2082014C97BA218201339CBAB19C71B0ED44D0D31FD70BFFE304E0A4F2608308D71820D31FD31F01F823BBF263ED44D0D31FD3FFD15131BAF2A103F901541042F910F2A2F800029320D74A96D307D402FB00E8D1A4C8CB1FCBFFC9ED54044CD7A1"
+
+// SpecV2 - wallet without subwallet id, messages have expiration time
+type SpecV2 struct {
+	SpecRegular
+}
+
+func (s *SpecV2) BuildMessage(ctx context.Context, isInitialized bool, block *tlb.BlockInfo, messages []*Message) (*cell.Cell, error) {
+	if len(messages) > 4 {
+		return nil, errors.New("for this type of wallet max 4 messages can be sent in the same time")
+	}
+
+	seq, err := s.fetchSeqno(ctx, isInitialized, block)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := cell.BeginCell().MustStoreUInt(seq, 32).
+		MustStoreUInt(uint64(timeNow().Add(time.Duration(s.messagesTTL)*time.Second).UTC().Unix()), 32)
+
+	for i, message := range messages {
+		intMsg, err := message.InternalMessage.ToCell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert internal message %d to cell: %w", i, err)
+		}
+
+		payload.MustStoreUInt(uint64(message.Mode), 8).MustStoreRef(intMsg)
+	}
+
+	return s.sign(payload), nil
+}
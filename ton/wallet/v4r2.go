@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
@@ -13,6 +14,14 @@ import (
 // https://github.com/toncenter/tonweb/blob/master/src/contract/wallet/WalletSources.md#revision-2-3
 const _V4R2CodeHex = "B5EE9C72410214010002D4000114FF00F4A413F4BCF2C80B010201200203020148040504F8F28308D71820D31FD31FD31F02F823BBF264ED44D0D31FD31FD3FFF404D15143BAF2A15151BAF2A205F901541064F910F2A3F80024A4C8CB1F5240CB1F5230CBFF5210F400C9ED54F80F01D30721C0009F6C519320D74A96D307D402FB00E830E021C001E30021C002E30001C0039130E30D03A4C8CB1F12CB1FCBFF1011121302E6D001D0D3032171B0925F04E022D749C120925F04E002D31F218210706C7567BD22821064737472BDB0925F05E003FA403020FA4401C8CA07CBFFC9D0ED44D0810140D721F404305C810108F40A6FA131B3925F07E005D33FC8258210706C7567BA923830E30D03821064737472BA925F06E30D06070201200809007801FA00F40430F8276F2230500AA121BEF2E0508210706C7567831EB17080185004CB0526CF1658FA0219F400CB6917CB1F5260CB3F20C98040FB0006008A5004810108F45930ED44D0810140D720C801CF16F400C9ED540172B08E23821064737472831EB17080185005CB055003CF1623FA0213CB6ACB1FCB3FC98040FB00925F03E20201200A0B0059BD242B6F6A2684080A06B90FA0218470D4080847A4937D29910CE6903E9FF9837812801B7810148987159F31840201580C0D0011B8C97ED44D0D70B1F8003DB29DFB513420405035C87D010C00B23281F2FFF274006040423D029BE84C600201200E0F0019ADCE76A26840206B90EB85FFC00019AF1DF6A26840106B90EB858FC0006ED207FA00D4D422F90005C8CA0715CBFFC9D077748018C8CB05CB0222CF165005FA0214CB6B12CCCCC973FB00C84014810108F451F2A7020070810108D718FA00D33FC8542047810108F451F2A782106E6F746570748018C8CB05CB025006CF165004FA0214CB6A12CB1FCB3FC973FB0002006C810108D718FA00D33F305224810108F459F2A782106473747270748018C8CB05CB025005CF165003FA0213CB6ACB1F12CB3FC973FB00000AF400C9ED54696225E5"
 
+// ops of external messages which are supported by v4 wallet contract
+const (
+	_V4OpSimpleSend             = 0
+	_V4OpDeployAndInstallPlugin = 1
+	_V4OpInstallPlugin          = 2
+	_V4OpRemovePlugin           = 3
+)
+
 type SpecV4R2 struct {
 	SpecRegular
 }
@@ -22,26 +31,11 @@ func (s *SpecV4R2) BuildMessage(ctx context.Context, isInitialized bool, block *
 		return nil, errors.New("for this type of wallet max 4 messages can be sent in the same time")
 	}
 
-	var seq uint64
-
-	if isInitialized {
-		resp, err := s.wallet.api.RunGetMethod(ctx, block, s.wallet.addr, "seqno")
-		if err != nil {
-			return nil, fmt.Errorf("get seqno err: %w", err)
-		}
-
-		iSeq, ok := resp[0].(int64)
-		if !ok {
-			return nil, fmt.Errorf("seqno is not an integer")
-		}
-		seq = uint64(iSeq)
+	payload, err := s.beginPayload(ctx, isInitialized, block, _V4OpSimpleSend)
+	if err != nil {
+		return nil, err
 	}
 
-	payload := cell.BeginCell().MustStoreUInt(uint64(s.wallet.subwallet), 32).
-		MustStoreUInt(uint64(timeNow().Add(time.Duration(s.messagesTTL)*time.Second).UTC().Unix()), 32).
-		MustStoreUInt(seq, 32).
-		MustStoreInt(0, 8) // op
-
 	for i, message := range messages {
 		intMsg, err := message.InternalMessage.ToCell()
 		if err != nil {
@@ -51,10 +45,79 @@ func (s *SpecV4R2) BuildMessage(ctx context.Context, isInitialized bool, block *
 		payload.MustStoreUInt(uint64(message.Mode), 8).MustStoreRef(intMsg)
 	}
 
-	sign := payload.EndCell().Sign(s.wallet.key)
-	msg := cell.BeginCell().MustStoreSlice(sign, 512).MustStoreBuilder(payload).EndCell()
+	return s.sign(payload), nil
+}
+
+// BuildDeployAndInstallPluginMessage - builds message which deploys plugin contract
+// from the given state init to workchain and adds it to the list of wallet's plugins.
+// Balance will be sent to plugin together with body.
+func (s *SpecV4R2) BuildDeployAndInstallPluginMessage(ctx context.Context, isInitialized bool, block *tlb.BlockInfo,
+	workchain int8, balance tlb.Coins, stateInit *tlb.StateInit, body *cell.Cell) (*cell.Cell, error) {
+	stateCell, err := stateInit.ToCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert plugin state init to cell: %w", err)
+	}
+
+	if body == nil {
+		body = cell.BeginCell().EndCell()
+	}
+
+	payload, err := s.beginPayload(ctx, isInitialized, block, _V4OpDeployAndInstallPlugin)
+	if err != nil {
+		return nil, err
+	}
+
+	payload.MustStoreInt(int64(workchain), 8).
+		MustStoreBigCoins(balance.NanoTON()).
+		MustStoreRef(stateCell).
+		MustStoreRef(body)
 
-	return msg, nil
+	return s.sign(payload), nil
 }
 
-// TODO: implement plugins
+// BuildInstallPluginMessage - builds message which adds already deployed contract to the list of wallet's plugins,
+// amount will be sent to plugin with 'note' op (0x6e6f7465) and query id.
+func (s *SpecV4R2) BuildInstallPluginMessage(ctx context.Context, isInitialized bool, block *tlb.BlockInfo,
+	plugin *address.Address, amount tlb.Coins, queryID uint64) (*cell.Cell, error) {
+	return s.buildPluginMessage(ctx, isInitialized, block, _V4OpInstallPlugin, plugin, amount, queryID)
+}
+
+// BuildRemovePluginMessage - builds message which removes contract from the list of wallet's plugins,
+// amount will be sent to plugin with 'dstr' op (0x64737472) and query id.
+func (s *SpecV4R2) BuildRemovePluginMessage(ctx context.Context, isInitialized bool, block *tlb.BlockInfo,
+	plugin *address.Address, amount tlb.Coins, queryID uint64) (*cell.Cell, error) {
+	return s.buildPluginMessage(ctx, isInitialized, block, _V4OpRemovePlugin, plugin, amount, queryID)
+}
+
+func (s *SpecV4R2) buildPluginMessage(ctx context.Context, isInitialized bool, block *tlb.BlockInfo,
+	op uint64, plugin *address.Address, amount tlb.Coins, queryID uint64) (*cell.Cell, error) {
+	if plugin == nil || plugin.Type() != address.StdAddress {
+		return nil, errors.New("plugin address should be standard address")
+	}
+
+	payload, err := s.beginPayload(ctx, isInitialized, block, op)
+	if err != nil {
+		return nil, err
+	}
+
+	payload.MustStoreInt(int64(plugin.Workchain()), 8).
+		MustStoreSlice(plugin.Data(), 256).
+		MustStoreBigCoins(amount.NanoTON()).
+		MustStoreUInt(queryID, 64)
+
+	return s.sign(payload), nil
+}
+
+func (s *SpecV4R2) beginPayload(ctx context.Context, isInitialized bool, block *tlb.BlockInfo, op uint64) (*cell.Builder, error) {
+	seq, err := s.fetchSeqno(ctx, isInitialized, block)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := cell.BeginCell().MustStoreUInt(uint64(s.wallet.subwallet), 32).
+		MustStoreUInt(uint64(timeNow().Add(time.Duration(s.messagesTTL)*time.Second).UTC().Unix()), 32).
+		MustStoreUInt(seq, 32).
+		MustStoreUInt(op, 8)
+
+	return payload, nil
+}
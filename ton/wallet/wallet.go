@@ -17,15 +17,40 @@ import (
 type Version int
 
 const (
-	V3           Version = 3
+	V1R1         Version = 11
+	V1R2         Version = 12
+	V1R3         Version = 13
+	V2R1         Version = 21
+	V2R2         Version = 22
+	V3R1         Version = 31
+	V3R2         Version = 3 // value of V3 before other v3 versions were added, so stored versions are read correctly
+	V3                   = V3R2
 	V4R2         Version = 42
 	HighloadV2R2 Version = 122
+	Lockup       Version = 200
 )
 
+func (v Version) String() string {
+	switch v {
+	case V3R2:
+		return "V3R2"
+	case HighloadV2R2:
+		return "highload V2R2"
+	case Lockup:
+		return "lockup"
+	}
+
+	if v > 10 && v < 100 {
+		return fmt.Sprintf("V%dR%d", v/10, v%10)
+	}
+	return fmt.Sprintf("unknown (%d)", int(v))
+}
+
 // defining some funcs this way to mock for tests
 var randUint32 = rand.Uint32
 var timeNow = time.Now
 
+var ErrUnsupportedWalletVersion = errors.New("wallet version is not supported")
 var ErrTxWasNotConfirmed = errors.New("transaction was not confirmed in a given deadline, but it may still be confirmed later")
 
 type TonAPI interface {
@@ -84,12 +109,18 @@ func getSpec(w *Wallet) (any, error) {
 	}
 
 	switch w.ver {
-	case V3:
+	case V1R1, V1R2, V1R3:
+		return &SpecV1{regular}, nil
+	case V2R1, V2R2:
+		return &SpecV2{regular}, nil
+	case V3R1, V3R2:
 		return &SpecV3{regular}, nil
 	case V4R2:
 		return &SpecV4R2{regular}, nil
 	case HighloadV2R2:
 		return &SpecHighloadV2R2{regular}, nil
+	case Lockup:
+		return &SpecLockup{SpecV3: SpecV3{regular}}, nil
 	}
 
 	return nil, errors.New("cannot init spec: unknown version")
@@ -104,6 +135,10 @@ func (w *Wallet) PrivateKey() ed25519.PrivateKey {
 }
 
 func (w *Wallet) GetSubwallet(subwallet uint32) (*Wallet, error) {
+	if spec, ok := w.spec.(*SpecLockup); ok {
+		return FromPrivateKeyLockup(w.api, w.key, subwallet, spec.config)
+	}
+
 	addr, err := AddressFromPubKey(w.key.Public().(ed25519.PublicKey), w.ver, subwallet)
 	if err != nil {
 		return nil, err
@@ -138,6 +173,39 @@ func (w *Wallet) GetBalance(ctx context.Context, block *tlb.BlockInfo) (tlb.Coin
 	return acc.State.Balance, nil
 }
 
+// GetExtraCurrenciesBalance - returns amounts of extra currencies on the wallet, nil if there are none
+func (w *Wallet) GetExtraCurrenciesBalance(ctx context.Context, block *tlb.BlockInfo) (tlb.ExtraCurrencies, error) {
+	acc, err := w.api.GetAccount(ctx, block, w.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	if !acc.IsActive {
+		return nil, nil
+	}
+
+	return acc.State.ExtraCurrencies, nil
+}
+
+// GetPublicKey - returns public key of the contract using get_public_key method, all standard wallets have it
+func GetPublicKey(ctx context.Context, api TonAPI, block *tlb.BlockInfo, addr *address.Address) (ed25519.PublicKey, error) {
+	res, err := api.RunGetMethod(ctx, block, addr, "get_public_key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run get_public_key method: %w", err)
+	}
+
+	if len(res) == 0 {
+		return nil, errors.New("empty result of get_public_key")
+	}
+
+	key, err := stackUint256(res[0])
+	if err != nil {
+		return nil, fmt.Errorf("incorrect public key: %w", err)
+	}
+
+	return key, nil
+}
+
 func (w *Wallet) GetSpec() any {
 	return w.spec
 }
@@ -147,42 +215,88 @@ func (w *Wallet) Send(ctx context.Context, message *Message, waitConfirmation ..
 }
 
 func (w *Wallet) SendMany(ctx context.Context, messages []*Message, waitConfirmation ...bool) error {
+	build, err := w.messagesBuilder(messages)
+	if err != nil {
+		return err
+	}
+
+	return w.sendBody(ctx, build, waitConfirmation...)
+}
+
+// SendWaitTransaction - sends message and waits for its transaction, see SendManyWaitTransaction
+func (w *Wallet) SendWaitTransaction(ctx context.Context, message *Message) (*SendResult, error) {
+	return w.SendManyWaitTransaction(ctx, []*Message{message})
+}
+
+// SendManyWaitTransaction - sends messages and waits till wallet processes them,
+// returns transaction with its outcome. Transaction can land on chain, but fail, so check SendResult.Success.
+func (w *Wallet) SendManyWaitTransaction(ctx context.Context, messages []*Message) (*SendResult, error) {
+	build, err := w.messagesBuilder(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := w.sendBodyTx(ctx, build, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSendResult(tx)
+}
+
+func (w *Wallet) messagesBuilder(messages []*Message) (bodyBuilder, error) {
+	var build bodyBuilder
+	switch w.ver {
+	case V1R1, V1R2, V1R3, V2R1, V2R2, V3R1, V3R2, V4R2, Lockup:
+		build = func(ctx context.Context, isInitialized bool, block *tlb.BlockInfo) (*cell.Cell, error) {
+			return w.spec.(RegularBuilder).BuildMessage(ctx, isInitialized, block, messages)
+		}
+	case HighloadV2R2:
+		build = func(ctx context.Context, _ bool, _ *tlb.BlockInfo) (*cell.Cell, error) {
+			return w.spec.(*SpecHighloadV2R2).BuildMessage(ctx, randUint32(), messages)
+		}
+	default:
+		return nil, fmt.Errorf("send is not yet supported for wallet with this version")
+	}
+
+	return build, nil
+}
+
+// bodyBuilder - builds signed external message body for the wallet contract
+type bodyBuilder func(ctx context.Context, isInitialized bool, block *tlb.BlockInfo) (*cell.Cell, error)
+
+func (w *Wallet) sendBody(ctx context.Context, build bodyBuilder, waitConfirmation ...bool) error {
+	_, err := w.sendBodyTx(ctx, build, len(waitConfirmation) > 0 && waitConfirmation[0])
+	return err
+}
+
+// sendBodyTx - sends external message to the wallet, if wait is true, waits and returns its transaction
+func (w *Wallet) sendBodyTx(ctx context.Context, build bodyBuilder, wait bool) (*tlb.Transaction, error) {
 	var stateInit *tlb.StateInit
 
 	block, err := w.api.CurrentMasterchainInfo(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get block: %w", err)
+		return nil, fmt.Errorf("failed to get block: %w", err)
 	}
 
 	acc, err := w.api.GetAccount(ctx, block, w.addr)
 	if err != nil {
-		return fmt.Errorf("failed to get account state: %w", err)
+		return nil, fmt.Errorf("failed to get account state: %w", err)
 	}
 
 	initialized := true
 	if !acc.IsActive || acc.State.Status != tlb.AccountStatusActive {
 		initialized = false
 
-		stateInit, err = GetStateInit(w.key.Public().(ed25519.PublicKey), w.ver, w.subwallet)
+		stateInit, err = w.getStateInit()
 		if err != nil {
-			return fmt.Errorf("failed to get state init: %w", err)
+			return nil, fmt.Errorf("failed to get state init: %w", err)
 		}
 	}
 
-	var msg *cell.Cell
-	switch w.ver {
-	case V3, V4R2:
-		msg, err = w.spec.(RegularBuilder).BuildMessage(ctx, initialized, block, messages)
-		if err != nil {
-			return fmt.Errorf("build message err: %w", err)
-		}
-	case HighloadV2R2:
-		msg, err = w.spec.(*SpecHighloadV2R2).BuildMessage(ctx, randUint32(), messages)
-		if err != nil {
-			return fmt.Errorf("build message err: %w", err)
-		}
-	default:
-		return fmt.Errorf("send is not yet supported for wallet with this version")
+	msg, err := build(ctx, initialized, block)
+	if err != nil {
+		return nil, fmt.Errorf("build message err: %w", err)
 	}
 
 	err = w.api.SendExternalMessage(ctx, &tlb.ExternalMessage{
@@ -191,17 +305,24 @@ func (w *Wallet) SendMany(ctx context.Context, messages []*Message, waitConfirma
 		Body:      msg,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+		return nil, fmt.Errorf("failed to send message: %w", err)
 	}
 
-	if len(waitConfirmation) > 0 && waitConfirmation[0] {
+	if wait {
 		return w.waitConfirmation(ctx, block, acc, stateInit, msg)
 	}
 
-	return nil
+	return nil, nil
+}
+
+func (w *Wallet) getStateInit() (*tlb.StateInit, error) {
+	if spec, ok := w.spec.(*SpecLockup); ok {
+		return GetLockupStateInit(w.key.Public().(ed25519.PublicKey), w.subwallet, spec.config)
+	}
+	return GetStateInit(w.key.Public().(ed25519.PublicKey), w.ver, w.subwallet)
 }
 
-func (w *Wallet) waitConfirmation(ctx context.Context, block *tlb.BlockInfo, acc *tlb.Account, stateInit *tlb.StateInit, msg *cell.Cell) error {
+func (w *Wallet) waitConfirmation(ctx context.Context, block *tlb.BlockInfo, acc *tlb.Account, stateInit *tlb.StateInit, msg *cell.Cell) (*tlb.Transaction, error) {
 	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
 		// fallback timeout to not stuck forever with background context
 		var cancel context.CancelFunc
@@ -274,7 +395,7 @@ func (w *Wallet) waitConfirmation(ctx context.Context, block *tlb.BlockInfo, acc
 						continue
 					}
 
-					return nil
+					return transaction, nil
 				}
 			}
 
@@ -285,7 +406,7 @@ func (w *Wallet) waitConfirmation(ctx context.Context, block *tlb.BlockInfo, acc
 		acc = accNew
 	}
 
-	return ErrTxWasNotConfirmed
+	return nil, ErrTxWasNotConfirmed
 }
 
 // TransferNoBounce - can be used to transfer TON to not yet initialized contract/wallet
@@ -299,17 +420,29 @@ func (w *Wallet) Transfer(ctx context.Context, to *address.Address, amount tlb.C
 	return w.transfer(ctx, to, amount, comment, true, waitConfirmation...)
 }
 
-func (w *Wallet) transfer(ctx context.Context, to *address.Address, amount tlb.Coins, comment string, bounce bool, waitConfirmation ...bool) error {
+// TransferWithExtraCurrencies - safe transfer of TON together with extra currencies, amount can be zero
+// if only extra currencies are needed to be sent, but receiver may require some TON for processing.
+func (w *Wallet) TransferWithExtraCurrencies(ctx context.Context, to *address.Address, amount tlb.Coins, extra tlb.ExtraCurrencies, comment string, waitConfirmation ...bool) error {
 	var body *cell.Cell
 	if comment != "" {
-		// comment ident
-		root := cell.BeginCell().MustStoreUInt(0, 32)
-
-		if err := root.StoreStringSnake(comment); err != nil {
-			return fmt.Errorf("failed to build comment: %w", err)
+		var err error
+		body, err = CreateCommentCell(comment)
+		if err != nil {
+			return err
 		}
+	}
+
+	return w.Send(ctx, SimpleMessage(to, amount, body).WithExtraCurrencies(extra), waitConfirmation...)
+}
 
-		body = root.EndCell()
+func (w *Wallet) transfer(ctx context.Context, to *address.Address, amount tlb.Coins, comment string, bounce bool, waitConfirmation ...bool) error {
+	var body *cell.Cell
+	if comment != "" {
+		var err error
+		body, err = CreateCommentCell(comment)
+		if err != nil {
+			return err
+		}
 	}
 
 	return w.Send(ctx, &Message{
@@ -366,3 +499,9 @@ func SimpleMessage(to *address.Address, amount tlb.Coins, payload *cell.Cell) *M
 		},
 	}
 }
+
+// WithExtraCurrencies - attaches extra currencies to the message, they are sent together with TON amount
+func (m *Message) WithExtraCurrencies(extra tlb.ExtraCurrencies) *Message {
+	m.InternalMessage.ExtraCurrencies = extra.Copy()
+	return m
+}
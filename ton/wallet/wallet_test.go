@@ -91,12 +91,16 @@ func TestWallet_Send(t *testing.T) {
 
 	// TODO: SendWait, SendWaitErr
 	cases := map[Version][]int{
+		V1R3:         {OK, BlockErr, AccountErr, SeqnoNotInt, RunErr, UnsupportedVer, SendErr, SendWithInit1, SendWithInit2},
+		V2R2:         {OK, BlockErr, AccountErr, SeqnoNotInt, RunErr, UnsupportedVer, SendErr, SendWithInit1, SendWithInit2, TooMuchMessages},
+		V3R1:         {OK, BlockErr, AccountErr, SeqnoNotInt, RunErr, UnsupportedVer, SendErr, SendWithInit1, SendWithInit2, TooMuchMessages},
+		Lockup:       {OK, BlockErr, AccountErr, SeqnoNotInt, RunErr, UnsupportedVer, SendErr, SendWithInit1, SendWithInit2, TooMuchMessages},
 		V3:           {OK, BlockErr, AccountErr, SeqnoNotInt, RunErr, UnsupportedVer, SendErr, SendWithInit1, SendWithInit2, TooMuchMessages},
 		V4R2:         {OK, BlockErr, AccountErr, SeqnoNotInt, RunErr, UnsupportedVer, SendErr, SendWithInit1, SendWithInit2, TooMuchMessages},
 		HighloadV2R2: {OK, BlockErr, AccountErr, UnsupportedVer, SendErr, SendWithInit1, SendWithInit2, TooMuchMessages},
 	}
 
-	for _, ver := range []Version{V3, V4R2, HighloadV2R2} {
+	for _, ver := range []Version{V1R3, V2R2, V3R1, V3, V4R2, HighloadV2R2, Lockup} {
 		for _, flow := range cases[ver] {
 
 			w, err := FromPrivateKey(m, pkey, ver)
@@ -204,7 +208,15 @@ func TestWallet_Send(t *testing.T) {
 				}
 
 				switch ver {
-				case V3:
+				case V1R3:
+					t.Run("v1 body check", func(t *testing.T) {
+						checkV1V2(t, msg.Body.BeginParse(), w, flow, intMsg, false)
+					})
+				case V2R2:
+					t.Run("v2 body check", func(t *testing.T) {
+						checkV1V2(t, msg.Body.BeginParse(), w, flow, intMsg, true)
+					})
+				case V3, V3R1, Lockup:
 					t.Run("v3 body check", func(t *testing.T) {
 						checkV3(t, msg.Body.BeginParse(), w, flow, intMsg)
 					})
@@ -386,6 +398,40 @@ func checkV3(t *testing.T, p *cell.Slice, w *Wallet, flow int, intMsg *tlb.Inter
 	}
 }
 
+func checkV1V2(t *testing.T, p *cell.Slice, w *Wallet, flow int, intMsg *tlb.InternalMessage, withExpire bool) {
+	sign := p.MustLoadSlice(512)
+
+	if !ed25519.Verify(w.key.Public().(ed25519.PublicKey), p.MustToCell().Hash(), sign) {
+		t.Fatal("sign incorrect")
+	}
+
+	seq := uint64(3)
+	if flow == SendWithInit1 || flow == SendWithInit2 {
+		seq = 0
+	}
+
+	if p.MustLoadUInt(32) != seq {
+		t.Fatal("seqno incorrect")
+	}
+
+	if withExpire && p.MustLoadUInt(32) != uint64(timeNow().Add(60*3*time.Second).UTC().Unix()) {
+		t.Fatal("expire incorrect")
+	}
+
+	if p.MustLoadUInt(8) != uint64(128) {
+		t.Fatal("mode incorrect")
+	}
+
+	intMsgRef, _ := intMsg.ToCell()
+	if !bytes.Equal(p.MustLoadRef().MustToCell().Hash(), intMsgRef.Hash()) {
+		t.Fatal("int msg incorrect")
+	}
+
+	if p.BitsLeft() != 0 || p.RefsNum() != 0 {
+		t.Fatal("body has extra data")
+	}
+}
+
 func checkHighloadV2R2(t *testing.T, p *cell.Slice, w *Wallet, intMsg *tlb.InternalMessage) {
 	sign := p.MustLoadSlice(512)
 
@@ -423,3 +469,14 @@ func checkHighloadV2R2(t *testing.T, p *cell.Slice, w *Wallet, intMsg *tlb.Inter
 		t.Fatal("sign incorrect")
 	}
 }
+
+func TestVersion_Values(t *testing.T) {
+	// numeric values can be stored by users, so they should never change
+	if V3 != 3 || V3R2 != 3 || V4R2 != 42 || HighloadV2R2 != 122 {
+		t.Fatal("version values are changed")
+	}
+
+	if V3.String() != "V3R2" || V3R1.String() != "V3R1" || V1R3.String() != "V1R3" {
+		t.Fatal("wrong version names", V3.String(), V3R1.String(), V1R3.String())
+	}
+}
@@ -60,6 +60,34 @@ func (b *Builder) StoreBigCoins(value *big.Int) error {
 	return nil
 }
 
+func (b *Builder) MustStoreVarUInt(value *big.Int, sz uint) *Builder {
+	err := b.StoreVarUInt(value, sz)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// StoreVarUInt - stores VarUInteger sz, it is length in bytes and value itself
+func (b *Builder) StoreVarUInt(value *big.Int, sz uint) error {
+	ln := uint((value.BitLen() + 7) >> 3)
+	if ln >= sz {
+		return ErrTooBigValue
+	}
+
+	lnBits := uint(big.NewInt(int64(sz - 1)).BitLen())
+	if b.bitsSz+lnBits+(ln*8) >= 1024 {
+		return ErrNotFit1023
+	}
+
+	err := b.StoreUInt(uint64(ln), lnBits)
+	if err != nil {
+		return err
+	}
+
+	return b.StoreBigUInt(value, ln*8)
+}
+
 func (b *Builder) MustStoreUInt(value uint64, sz uint) *Builder {
 	err := b.StoreUInt(value, sz)
 	if err != nil {
@@ -349,7 +377,14 @@ func (b *Builder) StoreBinarySnake(data []byte) error {
 		return c, nil
 	}
 
-	snake, err := f(127 - 4)
+	// first part is stored in the current builder, so we use space which is left in it,
+	// but not more than before, to keep layout of cells built from the empty builder
+	first := int(b.BitsLeft() / 8)
+	if first > 127-4 {
+		first = 127 - 4
+	}
+
+	snake, err := f(first)
 	if err != nil {
 		return err
 	}
@@ -78,6 +78,32 @@ func TestBOCWithCRC(t *testing.T) {
 	}
 }
 
+func TestBOCMultiRoot(t *testing.T) {
+	shared := BeginCell().MustStoreUInt(7, 8).EndCell()
+	roots := []*Cell{
+		BeginCell().MustStoreUInt(1, 8).MustStoreRef(shared).EndCell(),
+		BeginCell().MustStoreUInt(2, 8).EndCell(),
+		BeginCell().MustStoreUInt(3, 8).MustStoreRef(shared).EndCell(),
+	}
+
+	for _, crc := range []bool{false, true} {
+		cells, err := FromBOCMultiRoot(ToBOCMultiRoot(roots, crc))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(cells) != len(roots) {
+			t.Fatal("roots num not same", len(cells))
+		}
+
+		for i := range roots {
+			if !bytes.Equal(cells[i].Hash(), roots[i].Hash()) {
+				t.Fatal("root not same", i)
+			}
+		}
+	}
+}
+
 func TestCell_Hash1(t *testing.T) {
 	emptyHash, _ := new(big.Int).SetString("68134197439415885698044414435951397869210496020759160419881882418413283430343", 10)
 
@@ -9,9 +9,15 @@ import (
 	"math/big"
 )
 
+// ErrPrunedBranch - dictionary is a part of merkle proof and some of its branches are pruned,
+// so not all keys are available, use ToDictPartial to load only available keys
+var ErrPrunedBranch = errors.New("dictionary has pruned branches")
+
 type Dictionary struct {
 	storage map[string]*HashmapKV
 	keySz   uint
+	// number of pruned branches which were skipped by ToDictPartial
+	pruned int
 }
 
 type HashmapKV struct {
@@ -26,13 +32,24 @@ func NewDict(keySz uint) *Dictionary {
 	}
 }
 
+// ToDict - loads dictionary, ErrPrunedBranch is returned if some branches are pruned
 func (c *Slice) ToDict(keySz uint) (*Dictionary, error) {
+	return c.toDict(keySz, false)
+}
+
+// ToDictPartial - loads dictionary from merkle proof, pruned branches are skipped,
+// so only keys which are present in proof are loaded, use PrunedBranches to check if some were skipped
+func (c *Slice) ToDictPartial(keySz uint) (*Dictionary, error) {
+	return c.toDict(keySz, true)
+}
+
+func (c *Slice) toDict(keySz uint, partial bool) (*Dictionary, error) {
 	d := &Dictionary{
 		storage: map[string]*HashmapKV{},
 		keySz:   keySz,
 	}
 
-	err := d.mapInner(keySz, keySz, c, BeginCell())
+	err := d.mapInner(keySz, keySz, c, BeginCell(), partial)
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +116,11 @@ func (d *Dictionary) Get(key *Cell) *Cell {
 	return v.Value
 }
 
+// PrunedBranches - number of pruned branches skipped by ToDictPartial, keys under them are not available
+func (d *Dictionary) PrunedBranches() int {
+	return d.pruned
+}
+
 func (d *Dictionary) All() []*HashmapKV {
 	all := make([]*HashmapKV, 0, len(d.storage))
 	for _, v := range d.storage {
@@ -108,10 +130,28 @@ func (d *Dictionary) All() []*HashmapKV {
 	return all
 }
 
-func (d *Dictionary) mapInner(keySz, leftKeySz uint, loader *Slice, keyPrefix *Builder) error {
+func (d *Dictionary) mapInner(keySz, leftKeySz uint, loader *Slice, keyPrefix *Builder, partial bool) error {
 	var err error
 	var sz uint
 
+	if loader.special {
+		typ, err := loader.Copy().LoadUInt(8)
+		if err != nil {
+			return fmt.Errorf("failed to load exotic cell type: %w", err)
+		}
+
+		if typ != _PrunedBranchType {
+			return fmt.Errorf("unexpected exotic cell of type %d in dictionary", typ)
+		}
+
+		// pruned branch of merkle proof, its data is not available
+		if !partial {
+			return ErrPrunedBranch
+		}
+		d.pruned++
+		return nil
+	}
+
 	sz, keyPrefix, err = loadLabel(leftKeySz, loader, keyPrefix)
 	if err != nil {
 		return err
@@ -126,7 +166,7 @@ func (d *Dictionary) mapInner(keySz, leftKeySz uint, loader *Slice, keyPrefix *B
 		if err != nil {
 			return nil
 		}
-		err = d.mapInner(keySz, leftKeySz-(1+sz), left, keyPrefix.Copy().MustStoreUInt(0, 1))
+		err = d.mapInner(keySz, leftKeySz-(1+sz), left, keyPrefix.Copy().MustStoreUInt(0, 1), partial)
 		if err != nil {
 			return err
 		}
@@ -136,7 +176,7 @@ func (d *Dictionary) mapInner(keySz, leftKeySz uint, loader *Slice, keyPrefix *B
 		if err != nil {
 			return err
 		}
-		err = d.mapInner(keySz, leftKeySz-(1+sz), right, keyPrefix.Copy().MustStoreUInt(1, 1))
+		err = d.mapInner(keySz, leftKeySz-(1+sz), right, keyPrefix.Copy().MustStoreUInt(1, 1), partial)
 		if err != nil {
 			return err
 		}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
+	"math/big"
 	"testing"
 
 	"github.com/xssnick/tonutils-go/address"
@@ -145,3 +147,52 @@ func TestLoadCell_LoadDictEdgeCase(t *testing.T) {
 		}
 	}
 }
+
+func TestDictionary_PrunedBranch(t *testing.T) {
+	d := NewDict(32)
+	for i := int64(0); i < 16; i++ {
+		if err := d.SetIntKey(big.NewInt(i), BeginCell().MustStoreUInt(uint64(i), 16).EndCell()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root, err := d.ToCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keys with first bit 1 are in the right branch of the root, it is pruned
+	rootSlice := root.BeginParse()
+	rootSlice.MustLoadRef()
+	right := rootSlice.MustLoadRef().MustToCell()
+	proof, err := CreateProof(root, func(c *Cell) bool {
+		return !bytes.Equal(c.Hash(), right.Hash())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree := proof.BeginParse().MustLoadRef()
+
+	if _, err = tree.Copy().ToDict(32); !errors.Is(err, ErrPrunedBranch) {
+		t.Fatal("pruned branch should not be skipped silently", err)
+	}
+
+	partial, err := tree.ToDictPartial(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if partial.PrunedBranches() != 1 {
+		t.Fatal("pruned branch should be counted", partial.PrunedBranches())
+	}
+
+	full, err := root.BeginParse().ToDict(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if full.PrunedBranches() != 0 || len(full.All()) != 16 || len(partial.All()) != 8 {
+		t.Fatal("wrong number of keys", len(full.All()), len(partial.All()))
+	}
+}
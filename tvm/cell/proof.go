@@ -0,0 +1,156 @@
+package cell
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	_PrunedBranchType = 1
+	_MerkleProofType  = 3
+)
+
+var ErrProofHashMismatch = errors.New("proof hash not matches")
+
+// CreateProof - creates merkle proof cell of root, subtrees for which keep returns false are replaced with pruned branches.
+// Root is always kept.
+func CreateProof(root *Cell, keep func(c *Cell) bool) (*Cell, error) {
+	tree, err := prune(root, keep, true)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, depth, err := root.proofHash()
+	if err != nil {
+		return nil, err
+	}
+
+	data := append([]byte{_MerkleProofType}, hash...)
+	data = append(data, byte(depth>>8), byte(depth))
+
+	return &Cell{
+		special: true,
+		level:   tree.level >> 1,
+		bitsSz:  uint(len(data) * 8),
+		data:    data,
+		refs:    []*Cell{tree},
+	}, nil
+}
+
+func prune(c *Cell, keep func(c *Cell) bool, isRoot bool) (*Cell, error) {
+	if !isRoot && !keep(c) {
+		hash, depth, err := c.proofHash()
+		if err != nil {
+			return nil, err
+		}
+
+		data := append([]byte{_PrunedBranchType, 1}, hash...)
+		data = append(data, byte(depth>>8), byte(depth))
+
+		return &Cell{
+			special: true,
+			level:   1,
+			bitsSz:  uint(len(data) * 8),
+			data:    data,
+		}, nil
+	}
+
+	if c.special {
+		return nil, errors.New("special cells are not supported in proof tree")
+	}
+
+	res := &Cell{
+		bitsSz: c.bitsSz,
+		data:   append([]byte{}, c.data...),
+		refs:   make([]*Cell, len(c.refs)),
+	}
+
+	for i, ref := range c.refs {
+		r, err := prune(ref, keep, false)
+		if err != nil {
+			return nil, err
+		}
+		res.refs[i] = r
+		res.level |= r.level
+	}
+	return res, nil
+}
+
+// CheckProof - checks that proof is merkle proof cell of tree with the given root hash
+func CheckProof(proof *Cell, hash []byte) error {
+	_, err := UnwrapProof(proof, hash)
+	return err
+}
+
+// UnwrapProof - checks merkle proof cell and returns tree inside it, some of its branches can be pruned
+func UnwrapProof(proof *Cell, hash []byte) (*Cell, error) {
+	if !proof.special || len(proof.refs) != 1 || proof.bitsSz != 280 || proof.data[0] != _MerkleProofType {
+		return nil, errors.New("not a merkle proof cell")
+	}
+
+	tree := proof.refs[0]
+	treeHash, treeDepth, err := tree.proofHash()
+	if err != nil {
+		return nil, fmt.Errorf("failed to calc proof tree hash: %w", err)
+	}
+
+	if !bytes.Equal(proof.data[1:33], treeHash) || binary.BigEndian.Uint16(proof.data[33:]) != treeDepth {
+		return nil, errors.New("merkle proof cell is not matches its tree")
+	}
+
+	if !bytes.Equal(treeHash, hash) {
+		return nil, ErrProofHashMismatch
+	}
+	return tree, nil
+}
+
+// proofHash - calculates hash and depth of original cell, pruned branches are replaced with hashes they store
+func (c *Cell) proofHash() ([]byte, uint16, error) {
+	if c.special {
+		if len(c.data) == 0 || c.data[0] != _PrunedBranchType {
+			return nil, 0, errors.New("only pruned branch special cells are supported in proof tree")
+		}
+
+		levels := bits.OnesCount8(c.level)
+		if levels == 0 || c.bitsSz != uint(16+levels*(256+16)) || len(c.refs) != 0 {
+			return nil, 0, errors.New("invalid pruned branch cell")
+		}
+
+		// first stored hash and depth are for level 0
+		depthOffset := 2 + levels*32
+		return c.data[2:34], binary.BigEndian.Uint16(c.data[depthOffset:]), nil
+	}
+
+	var depth uint16
+	hashes := make([][]byte, len(c.refs))
+	depths := make([]uint16, len(c.refs))
+	for i, ref := range c.refs {
+		h, d, err := ref.proofHash()
+		if err != nil {
+			return nil, 0, err
+		}
+		hashes[i], depths[i] = h, d
+
+		if d+1 > depth {
+			depth = d + 1
+		}
+	}
+
+	// descriptors of hash at level 0 have zero level
+	payload := (&Cell{bitsSz: c.bitsSz, data: c.data}).serialize(0, false)
+
+	hash := sha256.New()
+	hash.Write([]byte{byte(len(c.refs))})
+	hash.Write(payload[1:])
+	for _, d := range depths {
+		hash.Write([]byte{byte(d >> 8), byte(d)})
+	}
+	for _, h := range hashes {
+		hash.Write(h)
+	}
+	return hash.Sum(nil), depth, nil
+}
@@ -23,8 +23,13 @@ func (c *Cell) ToBOC() []byte {
 }
 
 func (c *Cell) ToBOCWithFlags(withCRC bool) []byte {
+	return ToBOCMultiRoot([]*Cell{c}, withCRC)
+}
+
+// ToBOCMultiRoot - serializes several root cells into one bag of cells, order of roots is kept
+func ToBOCMultiRoot(roots []*Cell, withCRC bool) []byte {
 	// recursively go through cells, build hash index and store unique in slice
-	orderCells := flattenIndex([]*Cell{c})
+	orderCells := flattenIndex(roots)
 
 	// bytes needed to store num of cells
 	cellSizeBits := math.Log2(float64(len(orderCells)) + 1)
@@ -57,10 +62,10 @@ func (c *Cell) ToBOCWithFlags(withCRC bool) []byte {
 	data = append(data, sizeBytes)
 
 	// cells num
-	data = append(data, dynamicIntBytes(uint64(calcCells(c)), uint(cellSizeBytes))...)
+	data = append(data, dynamicIntBytes(uint64(len(orderCells)), uint(cellSizeBytes))...)
 
-	// roots num (only 1 supported for now)
-	data = append(data, dynamicIntBytes(1, uint(cellSizeBytes))...)
+	// roots num
+	data = append(data, dynamicIntBytes(uint64(len(roots)), uint(cellSizeBytes))...)
 
 	// complete BOCs = 0
 	data = append(data, dynamicIntBytes(0, uint(cellSizeBytes))...)
@@ -68,8 +73,10 @@ func (c *Cell) ToBOCWithFlags(withCRC bool) []byte {
 	// len of data
 	data = append(data, dynamicIntBytes(uint64(len(payload)), uint(sizeBytes))...)
 
-	// root should have index 0
-	data = append(data, dynamicIntBytes(0, uint(cellSizeBytes))...)
+	// indexes of roots, first root has index 0
+	for _, root := range roots {
+		data = append(data, dynamicIntBytes(uint64(root.index), uint(cellSizeBytes))...)
+	}
 	data = append(data, payload...)
 
 	if withCRC {
@@ -82,22 +89,6 @@ func (c *Cell) ToBOCWithFlags(withCRC bool) []byte {
 	return data
 }
 
-func calcCells(cell *Cell) int {
-	m := map[string]*Cell{}
-	// calc unique cells
-	uniqCells(m, cell)
-
-	return len(m)
-}
-
-func uniqCells(m map[string]*Cell, cell *Cell) {
-	m[hex.EncodeToString(cell.Hash())] = cell
-
-	for _, ref := range cell.refs {
-		uniqCells(m, ref)
-	}
-}
-
 func flattenIndex(roots []*Cell) []*Cell {
 	var indexed []*Cell
 	var offset int
@@ -128,3 +128,22 @@ func TestSlice_Snake(t *testing.T) {
 		t.Fatal("str not eq", str, ldStr)
 	}
 }
+
+func TestSlice_SnakeAfterData(t *testing.T) {
+	data := make([]byte, 500)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	v := BeginCell().MustStoreSlice(make([]byte, 52), 416).MustStoreBinarySnake(data).EndCell().BeginParse()
+	v.MustLoadSlice(416)
+
+	ldData, err := v.LoadBinarySnake()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, ldData) {
+		t.Fatal("data not eq")
+	}
+}